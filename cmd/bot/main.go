@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"github.com/ellavs/tg-bot-golang/internal/cache"
 	"github.com/ellavs/tg-bot-golang/internal/helpers/kafka"
 	"github.com/ellavs/tg-bot-golang/internal/metrics"
+	"github.com/ellavs/tg-bot-golang/internal/ratelimit"
+	"github.com/ellavs/tg-bot-golang/internal/tasks/digestscheduler"
 	"github.com/ellavs/tg-bot-golang/internal/tasks/reportserver"
 	"github.com/ellavs/tg-bot-golang/internal/tracing"
+	"github.com/ellavs/tg-bot-golang/migrations"
+	"github.com/jmoiron/sqlx"
+	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/ellavs/tg-bot-golang/internal/clients/cbr"
 	"github.com/ellavs/tg-bot-golang/internal/clients/tg"
@@ -18,8 +27,11 @@ import (
 	"github.com/ellavs/tg-bot-golang/internal/helpers/net_http"
 	"github.com/ellavs/tg-bot-golang/internal/logger"
 	"github.com/ellavs/tg-bot-golang/internal/model/db"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/migrator"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/sqlite"
 	rates "github.com/ellavs/tg-bot-golang/internal/model/exchangerates"
 	"github.com/ellavs/tg-bot-golang/internal/model/messages"
+	"github.com/ellavs/tg-bot-golang/internal/model/seed"
 	uploader "github.com/ellavs/tg-bot-golang/internal/tasks/exchangeuploader"
 )
 
@@ -30,10 +42,43 @@ var (
 	currenciesUpdatePeriod      = 30 * time.Minute                     // Периодичность обновления курсов валют (раз в 30 минут).
 	currenciesUpdateCachePeriod = 30 * time.Minute                     // Периодичность кэширования курсов валют из базы данных (раз в 30 минут).
 	connectionStringDB          = ""                                   // Строка подключения к базе данных.
+	autoMigrate                 = false                                // Применение миграций базы данных при запуске.
 	kafkaTopic                  = "tgbot"                              // Наименование топика Kafka.
 	brokersList                 = []string{"localhost:9092"}           // Список адресов брокеров сообщений (адрес Kafka).
+	defaultTimezone             = "Europe/Moscow"                      // Часовой пояс пользователей по умолчанию.
+	digestHour                  = 9                                    // Час отправки регулярных отчетов.
+	digestCheckPeriod           = 5 * time.Minute                      // Периодичность проверки подписок на регулярные отчеты (раз в 5 минут).
+	adminIDs                    = []int64{}                            // Телеграм-идентификаторы администраторов бота.
+	accessMode                  = messages.AccessOpen                  // Режим доступа к боту.
+	allowedIDs                  = []int64{}                            // Телеграм-идентификаторы пользователей, которым доступ разрешен всегда.
+	rateLimits                  = map[string]ratelimit.Limit{          // Ограничения частоты запросов пользователя по классам команд.
+		ratelimit.ClassDefault: {PerMinute: 60, Burst: 20},
+		ratelimit.ClassReport:  {PerMinute: 6, Burst: 3},
+		ratelimit.ClassInline:  {PerMinute: 30, Burst: 10},
+	}
+)
+
+// Параметры базы данных по умолчанию (могут быть изменены через config)
+var (
+	// Параметры пула соединений и запросов к базе данных Postgres.
+	dbOptions = dbutils.Options{
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+	dbTxAttempts         = 3                // Количество попыток выполнения транзакции при временных ошибках базы данных.
+	dbHealthCheckPeriod  = 15 * time.Second // Периодичность проверки доступности базы данных.
+	dbHealthCheckTimeout = 3 * time.Second  // Ограничение времени проверки доступности базы данных.
 )
 
+// userDataStorage Хранилище информации о пользователях (для бота и планировщика регулярных отчетов).
+type userDataStorage interface {
+	messages.UserDataStorage
+	digestscheduler.SubscriptionStorage
+	RebuildMonthlyRollups(ctx context.Context) (int64, error)
+}
+
 func main() {
 
 	logger.Info("Старт приложения")
@@ -47,9 +92,62 @@ func main() {
 
 	// Изменение параметров по умолчанию из заданной конфигурации.
 	setConfigSettings(config.GetConfig())
+	dbutils.SetTxRetryPolicy(dbutils.RetryPolicy{Attempts: dbTxAttempts})
+
+	// Команда применения миграций базы данных: bot migrate up|down|status.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(ctx, os.Args[2:])
+		return
+	}
+
+	// Инициализация хранилищ (подключение к базе данных SQLite или Postgres по строке подключения).
+	var userStorage userDataStorage
+	var exchangeRatesStorage rates.RatesDataStorage
+	var dbconn *sqlx.DB
+	if sqlite.IsConnString(connectionStringDB) {
+		dbconn, err = sqlite.NewDBConnect(connectionStringDB, defaultTimezone)
+		if err != nil {
+			logger.Fatal("Ошибка подключения к базе данных:", "err", err)
+		}
+		// БД информации пользователей.
+		userStorage = sqlite.NewUserStorage(dbconn, mainCurrency, 0, defaultTimezone)
+		// БД курсов валют.
+		exchangeRatesStorage = sqlite.NewExchangeRatesStorage(dbconn, currenciesName)
+	} else {
+		dbconn, err = dbutils.NewDBConnect(connectionStringDB, dbOptions)
+		if err != nil {
+			logger.Fatal("Ошибка подключения к базе данных:", "err", err)
+		}
+		// Применение миграций (если включено) и проверка версии схемы БД.
+		dbMigrator, err := migrator.New(dbconn, migrations.FS)
+		if err != nil {
+			logger.Fatal("Ошибка чтения миграций базы данных:", "err", err)
+		}
+		db.AddMigrationHooks(dbMigrator, defaultTimezone)
+		if err := dbMigrator.Prepare(ctx, autoMigrate); err != nil {
+			logger.Fatal("Ошибка проверки схемы базы данных:", "err", err)
+		}
+		// БД информации пользователей.
+		userStorage = db.NewUserStorage(dbconn, mainCurrency, 0, defaultTimezone)
+		// БД курсов валют.
+		exchangeRatesStorage = db.NewExchangeRatesStorage(dbconn, currenciesName)
+	}
+
+	// Команда заполнения базы данных демонстрационными данными: bot seed [-users N] [-categories N] [-months N] [-seed N].
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		runSeedCommand(ctx, userStorage, exchangeRatesStorage, os.Args[2:])
+		return
+	}
+
+	// Команда пересчета помесячных итогов расходов: bot rollups rebuild.
+	if len(os.Args) > 1 && os.Args[1] == "rollups" {
+		runRollupsCommand(ctx, userStorage, os.Args[2:])
+		return
+	}
 
-	// Оборачивание в Middleware функции обработки сообщения для метрик и трейсинга.
+	// Оборачивание в Middleware функции обработки сообщения для ограничения частоты запросов, метрик и трейсинга.
 	tgProcessingFuncHandler := tg.HandlerFunc(tg.ProcessingMessages)
+	tgProcessingFuncHandler = ratelimit.Middleware(ratelimit.NewLimiter(rateLimits))(tgProcessingFuncHandler)
 	tgProcessingFuncHandler = metrics.MetricsMiddleware(tgProcessingFuncHandler)
 	tgProcessingFuncHandler = tracing.TracingMiddleware(tgProcessingFuncHandler)
 
@@ -59,15 +157,11 @@ func main() {
 		logger.Fatal("Ошибка инициализации ТГ-клиента:", "err", err)
 	}
 
-	// Инициализация хранилищ (подключение к базе данных).
-	dbconn, err := dbutils.NewDBConnect(connectionStringDB)
+	// Проверка часового пояса по умолчанию.
+	defaultLocation, err := time.LoadLocation(defaultTimezone)
 	if err != nil {
-		logger.Fatal("Ошибка подключения к базе данных:", "err", err)
+		logger.Fatal("Ошибка загрузки часового пояса по умолчанию:", "err", err)
 	}
-	// БД информации пользователей.
-	userStorage := db.NewUserStorage(dbconn, mainCurrency, 0)
-	// БД курсов валют.
-	exchangeRatesStorage := db.NewExchangeRatesStorage(dbconn, currenciesName)
 
 	// Инициализация клиента загрузки курсов валют из внешнего источника.
 	ctx, cancel := signal.NotifyContext(ctx,
@@ -79,6 +173,11 @@ func main() {
 	httpClient := net_http.New[cbr.ExchangeRatesJson]()
 	cbrClient := cbr.New(ctx, httpClient)
 
+	// Запуск периодической проверки доступности базы данных (метрики tg_db_up и пула соединений go_sql_*).
+	// Результат проверки доступен на сервере метрик по адресу /ready.
+	dbProbe := dbutils.StartHealthProbe(ctx, dbconn, "tgbot", dbHealthCheckPeriod, dbHealthCheckTimeout)
+	http.Handle("/ready", dbProbe)
+
 	// Инициализация локального экземпляра класса для работы с курсами валют.
 	exchangeRates := rates.New(ctx, cbrClient, currenciesName, mainCurrency, exchangeRatesStorage)
 
@@ -93,6 +192,15 @@ func main() {
 
 	// Инициализация основной модели.
 	msgModel := messages.New(ctx, tgClient, userStorage, exchangeRates, cacheLRU, kafkaProducer)
+	msgModel.SetAdmins(adminIDs)
+	if err := msgModel.SetAccess(accessMode, allowedIDs); err != nil {
+		logger.Fatal("Ошибка настройки доступа к боту:", "err", err)
+	}
+
+	// Установка меню бота по реестру команд.
+	if err := tgClient.SetBotCommands(msgModel.BotCommands()); err != nil {
+		logger.Error("Ошибка установки меню бота:", "err", err)
+	}
 
 	// Запуск периодическое обновление курсов валют.
 	uploader.ExchangeRatesUpdater(ctx, exchangeRates, currenciesUpdatePeriod)
@@ -100,6 +208,9 @@ func main() {
 	// Запуск периодическое обновление локального кэша курсов валют из БД.
 	uploader.ExchangeRatesFromStorageLoader(ctx, exchangeRates, currenciesUpdateCachePeriod)
 
+	// Запуск периодической отправки запросов на регулярные отчеты по подпискам.
+	digestscheduler.DigestScheduler(ctx, userStorage, kafkaProducer, digestscheduler.Schedule{Location: defaultLocation, Hour: digestHour}, digestCheckPeriod)
+
 	// Запуск сервера для получения отчетов пользователя.
 	reportserver.StartReportServer(msgModel)
 
@@ -126,10 +237,116 @@ func setConfigSettings(config config.Config) {
 	if config.ConnectionStringDB != "" {
 		connectionStringDB = config.ConnectionStringDB
 	}
+	if config.AutoMigrate {
+		autoMigrate = true
+	}
+	if config.DB.MaxOpenConns > 0 {
+		dbOptions.MaxOpenConns = config.DB.MaxOpenConns
+	}
+	if config.DB.MaxIdleConns > 0 {
+		dbOptions.MaxIdleConns = config.DB.MaxIdleConns
+	}
+	if config.DB.ConnMaxLifetime > 0 {
+		dbOptions.ConnMaxLifetime = time.Duration(config.DB.ConnMaxLifetime) * time.Minute
+	}
+	if config.DB.ConnMaxIdleTime > 0 {
+		dbOptions.ConnMaxIdleTime = time.Duration(config.DB.ConnMaxIdleTime) * time.Minute
+	}
+	if config.DB.StatementTimeout > 0 {
+		dbOptions.StatementTimeout = time.Duration(config.DB.StatementTimeout) * time.Second
+	}
+	if config.DB.LogLevel != "" {
+		dbOptions.LogLevel = config.DB.LogLevel
+	}
+	if config.DB.TxAttempts > 0 {
+		dbTxAttempts = config.DB.TxAttempts
+	}
+	if config.DB.HealthCheckPeriod > 0 {
+		dbHealthCheckPeriod = time.Duration(config.DB.HealthCheckPeriod) * time.Second
+	}
+	if config.DB.HealthCheckTimeout > 0 {
+		dbHealthCheckTimeout = time.Duration(config.DB.HealthCheckTimeout) * time.Second
+	}
 	if config.KafkaTopic != "" {
 		kafkaTopic = config.KafkaTopic
 	}
 	if len(config.BrokersList) > 0 {
 		brokersList = config.BrokersList
 	}
+	if config.DefaultTimezone != "" {
+		defaultTimezone = config.DefaultTimezone
+	}
+	if config.DigestHour > 0 {
+		digestHour = config.DigestHour
+	}
+	if config.DigestCheckPeriod > 0 {
+		digestCheckPeriod = time.Duration(config.DigestCheckPeriod) * time.Minute
+	}
+	if len(config.AdminIDs) > 0 {
+		adminIDs = config.AdminIDs
+	}
+	if config.AccessMode != "" {
+		accessMode = config.AccessMode
+	}
+	if len(config.AllowedIDs) > 0 {
+		allowedIDs = config.AllowedIDs
+	}
+	for class, limit := range config.RateLimits {
+		rateLimits[class] = ratelimit.Limit(limit)
+	}
+}
+
+// runMigrateCommand Выполнение команды migrate (up, down, status) для базы данных из конфигурации.
+func runMigrateCommand(ctx context.Context, args []string) {
+	if len(args) != 1 {
+		logger.Fatal("Использование: bot migrate up|down|status")
+	}
+	if sqlite.IsConnString(connectionStringDB) {
+		logger.Info("Схема базы данных SQLite применяется автоматически при подключении, миграции не требуются.")
+		return
+	}
+	dbconn, err := dbutils.NewDBConnect(connectionStringDB, dbOptions)
+	if err != nil {
+		logger.Fatal("Ошибка подключения к базе данных:", "err", err)
+	}
+	defer dbconn.Close()
+	dbMigrator, err := migrator.New(dbconn, migrations.FS)
+	if err != nil {
+		logger.Fatal("Ошибка чтения миграций базы данных:", "err", err)
+	}
+	db.AddMigrationHooks(dbMigrator, defaultTimezone)
+	if err := dbMigrator.Run(ctx, args[0], os.Stdout); err != nil {
+		logger.Fatal("Ошибка выполнения миграций базы данных:", "err", err)
+	}
+}
+
+// runSeedCommand Заполнение базы данных демонстрационными данными (для демонстраций и нагрузочного тестирования).
+func runSeedCommand(ctx context.Context, userStorage userDataStorage, exchangeRatesStorage rates.RatesDataStorage, args []string) {
+	opts := seed.Options{Now: time.Now(), MainCurrency: mainCurrency, Currencies: currenciesName}
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.IntVar(&opts.Users, "users", 10, "количество пользователей")
+	flags.IntVar(&opts.Categories, "categories", 6, fmt.Sprintf("количество категорий у пользователя (не больше %v)", seed.MaxCategories()))
+	flags.IntVar(&opts.Months, "months", 12, "количество месяцев истории расходов")
+	flags.Int64Var(&opts.Seed, "seed", 1, "начальное значение генератора случайных чисел (одинаковое значение - одинаковые данные)")
+	_ = flags.Parse(args)
+
+	stats, err := seed.Run(ctx, userStorage, exchangeRatesStorage, opts)
+	if err != nil {
+		logger.Fatal("Ошибка добавления демонстрационных данных:", "err", err)
+	}
+	logger.Info("Демонстрационные данные добавлены",
+		"users", stats.Users, "categories", stats.Categories, "records", stats.Records, "rateDays", stats.RateDays)
+}
+
+// runRollupsCommand Пересчет помесячных итогов расходов по записям о расходах (например, после изменения
+// часового пояса по умолчанию в конфигурации).
+func runRollupsCommand(ctx context.Context, userStorage userDataStorage, args []string) {
+	if len(args) != 1 || args[0] != "rebuild" {
+		logger.Fatal("Использование: bot rollups rebuild")
+	}
+	count, err := userStorage.RebuildMonthlyRollups(ctx)
+	if err != nil {
+		logger.Fatal("Ошибка пересчета помесячных итогов:", "err", err)
+	}
+	logger.Info("Помесячные итоги пересчитаны", "rows", count)
 }
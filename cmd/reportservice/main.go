@@ -8,9 +8,13 @@ import (
 	"github.com/ellavs/tg-bot-golang/internal/config"
 	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
 	"github.com/ellavs/tg-bot-golang/internal/helpers/kafka"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
 	"github.com/ellavs/tg-bot-golang/internal/logger"
 	"github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 	"github.com/ellavs/tg-bot-golang/internal/model/db"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/migrator"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/sqlite"
+	"github.com/ellavs/tg-bot-golang/migrations"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"log"
@@ -18,16 +22,34 @@ import (
 	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 )
 
 // Параметры по умолчанию (могут быть изменены через config)
 var (
 	mainCurrency       = "RUB"                      // Основная валюта для хранения данных.
 	connectionStringDB = ""                         // Строка подключения к базе данных.
+	autoMigrate        = false                      // Применение миграций базы данных при запуске.
 	kafkaTopic         = "tgbot"                    // Наименование топика Kafka.
 	brokersList        = []string{"localhost:9092"} // Список адресов брокеров сообщений (адрес Kafka).
+	defaultTimezone    = "Europe/Moscow"            // Часовой пояс пользователей по умолчанию.
 )
 
+// Параметры пула соединений и запросов к базе данных Postgres (могут быть изменены через config).
+var dbOptions = dbutils.Options{
+	MaxOpenConns:    10,
+	MaxIdleConns:    5,
+	ConnMaxLifetime: 30 * time.Minute,
+	ConnMaxIdleTime: 5 * time.Minute,
+}
+
+// reportDataStorage Хранилище данных пользователей, необходимых для построения отчетов.
+type reportDataStorage interface {
+	GetUserTimezone(ctx context.Context, userID int64) (string, error)
+	GetUserDataRecord(ctx context.Context, userID int64, period time.Time) ([]bottypes.UserDataReportRecord, error)
+	GetUserMonthDataRecord(ctx context.Context, userID int64, month time.Time) ([]bottypes.UserDataReportRecord, error)
+}
+
 func main() {
 
 	logger.Info("[Report service] Старт приложения")
@@ -47,13 +69,30 @@ func main() {
 	// Изменение параметров по умолчанию из заданной конфигурации.
 	setConfigSettings(config.GetConfig())
 
-	// Инициализация хранилищ (подключение к базе данных).
-	dbconn, err := dbutils.NewDBConnect(connectionStringDB)
-	if err != nil {
-		logger.Fatal("[Report service] Ошибка подключения к базе данных:", "err", err)
+	// Инициализация хранилищ (подключение к базе данных SQLite или Postgres по строке подключения).
+	var userStorage reportDataStorage
+	if sqlite.IsConnString(connectionStringDB) {
+		dbconn, err := sqlite.NewDBConnect(connectionStringDB, defaultTimezone)
+		if err != nil {
+			logger.Fatal("[Report service] Ошибка подключения к базе данных:", "err", err)
+		}
+		userStorage = sqlite.NewUserStorage(dbconn, mainCurrency, 0, defaultTimezone)
+	} else {
+		dbconn, err := dbutils.NewDBConnect(connectionStringDB, dbOptions)
+		if err != nil {
+			logger.Fatal("[Report service] Ошибка подключения к базе данных:", "err", err)
+		}
+		// Применение миграций (если включено) и проверка версии схемы БД.
+		dbMigrator, err := migrator.New(dbconn, migrations.FS)
+		if err != nil {
+			logger.Fatal("[Report service] Ошибка чтения миграций базы данных:", "err", err)
+		}
+		db.AddMigrationHooks(dbMigrator, defaultTimezone)
+		if err := dbMigrator.Prepare(ctx, autoMigrate); err != nil {
+			logger.Fatal("[Report service] Ошибка проверки схемы базы данных:", "err", err)
+		}
+		userStorage = db.NewUserStorage(dbconn, mainCurrency, 0, defaultTimezone)
 	}
-	// БД информации пользователей.
-	userStorage := db.NewUserStorage(dbconn, mainCurrency, 0)
 
 	// Инициализация кафки для получения сообщений из очереди.
 	kafkaConsumer, err := kafka.NewConsumer(ctx, brokersList, kafkaTopic)
@@ -83,36 +122,72 @@ func setConfigSettings(config config.Config) {
 	if config.ConnectionStringDB != "" {
 		connectionStringDB = config.ConnectionStringDB
 	}
+	if config.AutoMigrate {
+		autoMigrate = true
+	}
+	if config.DB.MaxOpenConns > 0 {
+		dbOptions.MaxOpenConns = config.DB.MaxOpenConns
+	}
+	if config.DB.MaxIdleConns > 0 {
+		dbOptions.MaxIdleConns = config.DB.MaxIdleConns
+	}
+	if config.DB.ConnMaxLifetime > 0 {
+		dbOptions.ConnMaxLifetime = time.Duration(config.DB.ConnMaxLifetime) * time.Minute
+	}
+	if config.DB.ConnMaxIdleTime > 0 {
+		dbOptions.ConnMaxIdleTime = time.Duration(config.DB.ConnMaxIdleTime) * time.Minute
+	}
+	if config.DB.StatementTimeout > 0 {
+		dbOptions.StatementTimeout = time.Duration(config.DB.StatementTimeout) * time.Second
+	}
+	if config.DB.LogLevel != "" {
+		dbOptions.LogLevel = config.DB.LogLevel
+	}
+	if config.DB.TxAttempts > 0 {
+		dbutils.SetTxRetryPolicy(dbutils.RetryPolicy{Attempts: config.DB.TxAttempts})
+	}
+	if config.DefaultTimezone != "" {
+		defaultTimezone = config.DefaultTimezone
+	}
 }
 
 // getKafkaMessage Получение запроса на построение отчета пользователя из кафки.
-func getKafkaMessage(ctx context.Context, userStorage *db.UserStorage, key string, value string) error {
+func getKafkaMessage(ctx context.Context, userStorage reportDataStorage, key string, value string) error {
 	if key == "" || value == "" {
 		logger.Error("[Report service] Сообщение кафка содержит пустой ключ или значение.")
 		return nil
 	}
-	userID, err := strconv.Atoi(key)
+	userID, err := strconv.ParseInt(key, 10, 64)
 	if err != nil {
 		logger.Error("[Report service] Сообщение кафка содержит некорректный ключ.", "err", err)
 		return nil
 	}
-	// Получение данных для отчета из БД.
-	periodDate := time.Now()
+	// Получение данных для отчета из БД (начало периода - в часовом поясе пользователя).
+	timezone, err := userStorage.GetUserTimezone(ctx, userID)
+	if err != nil {
+		logger.Error("[Report service] Ошибка получения часового пояса.", "err", err)
+	}
+	periodDate := time.Now().In(timeutils.LoadLocation(timezone, time.Local))
+	var dt []bottypes.UserDataReportRecord
 	switch value {
+	case bottypes.ReportPreviousMonth:
+		// Прошлый календарный месяц: [начало прошлого месяца, начало текущего месяца).
+		dt, err = userStorage.GetUserMonthDataRecord(ctx, userID, timeutils.BeginOfMonth(periodDate).AddDate(0, -1, 0))
 	case "w":
-		periodDate = periodDate.AddDate(0, 0, -7)
+		dt, err = userStorage.GetUserDataRecord(ctx, userID, periodDate.AddDate(0, 0, -7))
 	case "m":
-		periodDate = periodDate.AddDate(0, -1, 0)
+		dt, err = userStorage.GetUserDataRecord(ctx, userID, periodDate.AddDate(0, -1, 0))
 	case "y":
-		periodDate = periodDate.AddDate(-1, 0, 0)
+		dt, err = userStorage.GetUserDataRecord(ctx, userID, periodDate.AddDate(-1, 0, 0))
+	default:
+		dt, err = userStorage.GetUserDataRecord(ctx, userID, periodDate)
 	}
-	dt, err := userStorage.GetUserDataRecord(ctx, int64(userID), periodDate)
 	if err != nil {
 		logger.Error("[Report service] Ошибка получения отчета.", "err", err)
 		return err
 	}
 	// Вызов бота для отправки отчета пользователю.
-	err = sendReportToBot(dt, int64(userID), value)
+	err = sendReportToBot(dt, userID, value)
 	if err != nil {
 		logger.Error("[Report service] Ошибка отправки отчета.", "err", err)
 		return err
@@ -134,7 +209,7 @@ func sendReportToBot(dt []bottypes.UserDataReportRecord, userID int64, reportKey
 	// Подготовка данных для отправки по gRPC.
 	items := make([]*api.ReportItem, len(dt))
 	for ind, r := range dt {
-		items[ind] = &api.ReportItem{Category: r.Category, Sum: float32(r.Sum)}
+		items[ind] = &api.ReportItem{Category: r.Category, Sum: r.Sum}
 	}
 
 	// Отправка данных.
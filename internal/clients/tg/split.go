@@ -0,0 +1,180 @@
+package tg
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// maxMessageLength Максимальная длина текста сообщения в телеграме (в кодовых единицах UTF-16).
+const maxMessageLength = 4096
+
+// splitMessage Разбиение длинного текста на части не длиннее limit кодовых единиц UTF-16 (так длину считает телеграм).
+// Текст разбивается по строкам, поэтому строки таблиц отчетов не разрываются. Если граница части приходится
+// внутрь HTML-тегов (моноширинного блока <pre>, <b>, <code> и т.д.), незакрытые теги закрываются в конце части
+// и открываются заново в начале следующей. Строки длиннее limit разбиваются по символам, но не внутри HTML-тега
+// или мнемоники (&lt;).
+func splitMessage(text string, limit int) []string {
+	if textLen(text) <= limit {
+		return []string{text}
+	}
+	var chunks []string
+	var chunk strings.Builder
+	chunkLen := 0
+	// Незакрытые теги (открывающие теги целиком, с атрибутами) и длина их повторного открытия в начале части.
+	var openTags []string
+	prefixLen := 0
+
+	flush := func() {
+		if chunkLen == prefixLen {
+			return
+		}
+		chunks = append(chunks, strings.TrimSuffix(chunk.String(), "\n")+closingTags(openTags))
+		chunk.Reset()
+		for _, tag := range openTags {
+			chunk.WriteString(tag)
+		}
+		chunkLen = textLen(chunk.String())
+		prefixLen = chunkLen
+	}
+	// fits Проверка, что часть с добавленным текстом s и закрытием тегов не длиннее limit.
+	fits := func(s string) bool {
+		return chunkLen+textLen(s)+textLen(closingTags(trackTags(openTags, s))) <= limit
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if !fits(line) {
+			flush()
+		}
+		// Слишком длинная строка разбивается по символам.
+		for line != "" && !fits(line) {
+			runes := []rune(line)
+			size := limit - chunkLen - textLen(closingTags(openTags))
+			cut := cutIndex(runes, size)
+			// Теги, открытые в отрезанной части, тоже закрываются в конце части.
+			for size > 0 && !fits(string(runes[:cut])) {
+				size--
+				cut = cutIndex(runes, size)
+			}
+			part := string(runes[:cut])
+			chunk.WriteString(part)
+			chunkLen += textLen(part)
+			openTags = trackTags(openTags, part)
+			line = string(runes[cut:])
+			flush()
+		}
+		chunk.WriteString(line)
+		chunkLen += textLen(line)
+		openTags = trackTags(openTags, line)
+	}
+	openTags = nil
+	flush()
+	return chunks
+}
+
+// trackTags Незакрытые теги после текста s (openTags - незакрытые теги перед ним).
+// Открывающий тег добавляется в конец списка, закрывающий удаляет последний открытый тег с тем же именем.
+func trackTags(openTags []string, s string) []string {
+	res := openTags
+	for {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			return res
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			return res
+		}
+		tag := s[start : start+end+1]
+		s = s[start+end+1:]
+		if !strings.HasPrefix(tag, "</") {
+			res = append(res[:len(res):len(res)], tag)
+			continue
+		}
+		name := tagName(tag)
+		for i := len(res) - 1; i >= 0; i-- {
+			if tagName(res[i]) == name {
+				res = res[:i:i]
+				break
+			}
+		}
+	}
+}
+
+// tagName Имя HTML-тега (<a href="...">, </a> - a).
+func tagName(tag string) string {
+	name := strings.TrimLeft(strings.Trim(tag, "<>"), "/")
+	if ind := strings.IndexAny(name, " \t\n"); ind >= 0 {
+		name = name[:ind]
+	}
+	return name
+}
+
+// closingTags Закрытие незакрытых тегов (в обратном порядке).
+func closingTags(openTags []string) string {
+	var res strings.Builder
+	for i := len(openTags) - 1; i >= 0; i-- {
+		res.WriteString("</" + tagName(openTags[i]) + ">")
+	}
+	return res.String()
+}
+
+// textLen Длина текста в кодовых единицах UTF-16.
+func textLen(text string) int {
+	n := 0
+	for _, r := range text {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// cutIndex Количество символов строки, которые помещаются в size кодовых единиц UTF-16.
+// Если на границе остается незакрытый HTML-тег или мнемоника, граница переносится перед их началом.
+// Открывающий тег в конце части переносится в следующую часть.
+func cutIndex(runes []rune, size int) int {
+	cut, cutLen := 0, 0
+	for cut < len(runes) && cutLen+utf16.RuneLen(runes[cut]) <= size {
+		cutLen += utf16.RuneLen(runes[cut])
+		cut++
+	}
+	for i := cut - 1; i >= 0; i-- {
+		switch runes[i] {
+		case '>':
+			// Открывающий тег, заканчивающийся на границе, переносится целиком.
+			start := i
+			for start > 0 && runes[start] != '<' {
+				start--
+			}
+			if i == cut-1 && start > 0 && runes[start+1] != '/' {
+				return start
+			}
+			return max(cut, 1)
+		case ';':
+			return max(cut, 1)
+		case '<', '&':
+			if i > 0 {
+				return i
+			}
+			// Тег или мнемоника в начале строки переносятся в часть целиком, чтобы разбиение завершилось.
+			return tokenEnd(runes)
+		}
+	}
+	if cut == 0 && len(runes) > 0 && (runes[0] == '<' || runes[0] == '&') {
+		return tokenEnd(runes)
+	}
+	// Хотя бы один символ переносится в часть, чтобы разбиение завершилось.
+	return max(cut, 1)
+}
+
+// tokenEnd Количество символов тега или мнемоники в начале строки.
+func tokenEnd(runes []rune) int {
+	end := '>'
+	if runes[0] == '&' {
+		end = ';'
+	}
+	for i, r := range runes {
+		if r == end {
+			return i + 1
+		}
+	}
+	return len(runes)
+}
@@ -0,0 +1,106 @@
+package tg
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitMessage_ShouldReturnShortTextAsIs(t *testing.T) {
+	assert.Equal(t, []string{"<code>100.00 | Кино</code>\n"}, splitMessage("<code>100.00 | Кино</code>\n", maxMessageLength))
+}
+
+func Test_splitMessage_ShouldSplitByLines(t *testing.T) {
+	line := "<code>  100.00 | Категория</code>"
+	text := strings.Repeat(line+"\n", 300)
+
+	chunks := splitMessage(text, maxMessageLength)
+
+	assert.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, textLen(chunk), maxMessageLength)
+		// Каждая строка таблицы остается целой.
+		for _, l := range strings.Split(chunk, "\n") {
+			assert.Equal(t, line, l)
+		}
+	}
+	assert.Equal(t, strings.TrimSuffix(text, "\n"), strings.Join(chunks, "\n"))
+}
+
+func Test_splitMessage_ShouldReopenCodeBlock(t *testing.T) {
+	text := "Отчет:\n<pre>" + strings.Repeat("строка\n", 4) + "строка</pre>"
+
+	chunks := splitMessage(text, 30)
+
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, textLen(chunk), 30)
+		assertTagsBalanced(t, chunk)
+	}
+	assert.Equal(t, []string{
+		"Отчет:\n<pre>строка</pre>",
+		"<pre>строка\nстрока</pre>",
+		"<pre>строка\nстрока</pre>",
+	}, chunks)
+}
+
+func Test_splitMessage_ShouldSplitLongLine(t *testing.T) {
+	text := strings.Repeat("я", 25)
+
+	chunks := splitMessage(text, 10)
+
+	assert.Equal(t, []string{strings.Repeat("я", 10), strings.Repeat("я", 10), strings.Repeat("я", 5)}, chunks)
+}
+
+func Test_splitMessage_ShouldNotCutEntityOrTag(t *testing.T) {
+	text := "ааааааа&lt;ббб<b>жирный</b>"
+
+	chunks := splitMessage(text, 16)
+
+	for _, chunk := range chunks {
+		assertTagsBalanced(t, chunk)
+	}
+	assert.Equal(t, []string{"ааааааа&lt;ббб", "<b>жирный</b>"}, chunks)
+}
+
+func Test_splitMessage_ShouldReopenTags(t *testing.T) {
+	text := "<b>жирный <i>курсив</i> и <a href=\"https://t.me\">ссылка</a></b>"
+
+	chunks := splitMessage(text, 40)
+
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, textLen(chunk), 40)
+		assertTagsBalanced(t, chunk)
+	}
+	assert.Equal(t, []string{
+		"<b>жирный <i>курсив</i> и </b>",
+		"<b><a href=\"https://t.me\">ссылка</a></b>",
+	}, chunks)
+}
+
+func Test_splitMessage_ShouldCountUTF16Units(t *testing.T) {
+	// Эмодзи занимает две кодовые единицы UTF-16.
+	text := strings.Repeat("💰", 6)
+
+	chunks := splitMessage(text, 10)
+
+	assert.Equal(t, []string{"💰💰💰💰💰", "💰"}, chunks)
+}
+
+// assertTagsBalanced Проверка, что все HTML-теги части закрыты в порядке открытия.
+func assertTagsBalanced(t *testing.T, chunk string) {
+	t.Helper()
+	var open []string
+	for _, tag := range regexp.MustCompile(`<[^>]*>`).FindAllString(chunk, -1) {
+		if !strings.HasPrefix(tag, "</") {
+			open = append(open, tagName(tag))
+			continue
+		}
+		if assert.NotEmpty(t, open, chunk) {
+			assert.Equal(t, open[len(open)-1], tagName(tag), chunk)
+			open = open[:len(open)-1]
+		}
+	}
+	assert.Empty(t, open, chunk)
+}
@@ -1,9 +1,13 @@
 package tg
 
 import (
+	"context"
 	"fmt"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/qrcode"
 	"github.com/ellavs/tg-bot-golang/internal/logger"
+	"net/http"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/pkg/errors"
@@ -11,6 +15,12 @@ import (
 	"github.com/ellavs/tg-bot-golang/internal/model/messages"
 )
 
+// photoDownloadTimeout Время ожидания загрузки фотографии с серверов телеграма.
+const photoDownloadTimeout = 10 * time.Second
+
+// inlineCacheTime Время кэширования ответа на инлайн-запрос на серверах телеграма (в секундах).
+const inlineCacheTime = 10
+
 type HandlerFunc func(tgUpdate tgbotapi.Update, c *Client, msgModel *messages.Model)
 
 func (f HandlerFunc) RunFunc(tgUpdate tgbotapi.Update, c *Client, msgModel *messages.Model) {
@@ -38,9 +48,20 @@ func New(tokenGetter TokenGetter, handlerProcessingFunc HandlerFunc) (*Client, e
 	}, nil
 }
 
+// SendMessage Отправка сообщения (длинный текст отправляется несколькими сообщениями).
 func (c *Client) SendMessage(text string, userID int64) error {
+	for _, chunk := range splitMessage(text, maxMessageLength) {
+		if err := c.sendChunk(chunk, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendChunk Отправка одного сообщения, не превышающего ограничение длины.
+func (c *Client) sendChunk(text string, userID int64) error {
 	msg := tgbotapi.NewMessage(userID, text)
-	msg.ParseMode = "markdown"
+	msg.ParseMode = tgbotapi.ModeHTML
 	_, err := c.client.Send(msg)
 	if err != nil {
 		return errors.Wrap(err, "Ошибка отправки сообщения client.Send")
@@ -68,11 +89,18 @@ func ProcessingMessages(tgUpdate tgbotapi.Update, c *Client, msgModel *messages.
 	if tgUpdate.Message != nil {
 		// Пользователь написал текстовое сообщение.
 		logger.Info(fmt.Sprintf("[%s][%v] %s", tgUpdate.Message.From.UserName, tgUpdate.Message.From.ID, tgUpdate.Message.Text))
+		text := tgUpdate.Message.Text
+		isPhoto := len(tgUpdate.Message.Photo) > 0
+		if isPhoto {
+			// Пользователь отправил фотографию (распознавание QR-кода чека).
+			text = decodePhotoQR(c, tgUpdate.Message.Photo)
+		}
 		err := msgModel.IncomingMessage(messages.Message{
-			Text:            tgUpdate.Message.Text,
+			Text:            text,
 			UserID:          tgUpdate.Message.From.ID,
 			UserName:        tgUpdate.Message.From.UserName,
 			UserDisplayName: strings.TrimSpace(tgUpdate.Message.From.FirstName + " " + tgUpdate.Message.From.LastName),
+			IsPhoto:         isPhoto,
 		})
 		if err != nil {
 			logger.Error("error processing message:", "err", err)
@@ -80,8 +108,7 @@ func ProcessingMessages(tgUpdate tgbotapi.Update, c *Client, msgModel *messages.
 	} else if tgUpdate.CallbackQuery != nil {
 		// Пользователь нажал кнопку.
 		logger.Info(fmt.Sprintf("[%s][%v] Callback: %s", tgUpdate.CallbackQuery.From.UserName, tgUpdate.CallbackQuery.From.ID, tgUpdate.CallbackQuery.Data))
-		callback := tgbotapi.NewCallback(tgUpdate.CallbackQuery.ID, tgUpdate.CallbackQuery.Data)
-		if _, err := c.client.Request(callback); err != nil {
+		if err := c.AnswerCallback(tgUpdate.CallbackQuery.ID, tgUpdate.CallbackQuery.Data); err != nil {
 			logger.Error("Ошибка Request callback:", "err", err)
 		}
 		if err := deleteInlineButtons(c, tgUpdate.CallbackQuery.From.ID, tgUpdate.CallbackQuery.Message.MessageID, tgUpdate.CallbackQuery.Message.Text); err != nil {
@@ -98,12 +125,40 @@ func ProcessingMessages(tgUpdate tgbotapi.Update, c *Client, msgModel *messages.
 		if err != nil {
 			logger.Error("error processing message from callback:", "err", err)
 		}
+	} else if tgUpdate.InlineQuery != nil {
+		// Пользователь набрал инлайн-запрос к боту в каком-либо чате.
+		logger.Info(fmt.Sprintf("[%s][%v] Inline: %s", tgUpdate.InlineQuery.From.UserName, tgUpdate.InlineQuery.From.ID, tgUpdate.InlineQuery.Query))
+		err := msgModel.IncomingInlineQuery(messages.InlineQuery{
+			ID:       tgUpdate.InlineQuery.ID,
+			Query:    tgUpdate.InlineQuery.Query,
+			UserID:   tgUpdate.InlineQuery.From.ID,
+			UserName: tgUpdate.InlineQuery.From.UserName,
+		})
+		if err != nil {
+			logger.Error("error processing inline query:", "err", err)
+		}
 	}
 }
 
+// AnswerCallback Ответ на нажатие кнопки (телеграм перестает показывать ожидание на кнопке,
+// текст text может быть показан пользователю во всплывающем уведомлении).
+func (c *Client) AnswerCallback(callbackID string, text string) error {
+	if _, err := c.client.Request(tgbotapi.NewCallback(callbackID, text)); err != nil {
+		return errors.Wrap(err, "Ошибка ответа на нажатие кнопки")
+	}
+	return nil
+}
+
 // ShowInlineButtons Отображение кнопок меню под сообщением с ответом.
-// Их нажатие ожидает коллбек-ответ.
+// Их нажатие ожидает коллбек-ответ. Длинный текст отправляется несколькими сообщениями, кнопки - под последним.
 func (c *Client) ShowInlineButtons(text string, buttons []types.TgRowButtons, userID int64) error {
+	chunks := splitMessage(text, maxMessageLength)
+	for _, chunk := range chunks[:len(chunks)-1] {
+		if err := c.sendChunk(chunk, userID); err != nil {
+			return err
+		}
+	}
+	text = chunks[len(chunks)-1]
 	keyboard := make([][]tgbotapi.InlineKeyboardButton, len(buttons))
 	for i := 0; i < len(buttons); i++ {
 		tgRowButtons := buttons[i]
@@ -116,7 +171,7 @@ func (c *Client) ShowInlineButtons(text string, buttons []types.TgRowButtons, us
 	var numericKeyboard = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 	msg := tgbotapi.NewMessage(userID, text)
 	msg.ReplyMarkup = numericKeyboard
-	msg.ParseMode = "markdown"
+	msg.ParseMode = tgbotapi.ModeHTML
 	_, err := c.client.Send(msg)
 	if err != nil {
 		logger.Error("Ошибка отправки сообщения", "err", err)
@@ -125,6 +180,81 @@ func (c *Client) ShowInlineButtons(text string, buttons []types.TgRowButtons, us
 	return nil
 }
 
+// AnswerInlineQuery Ответ на инлайн-запрос списком результатов.
+// Ответ персональный: статистика каждого пользователя кэшируется отдельно.
+func (c *Client) AnswerInlineQuery(queryID string, results []types.TgInlineResult) error {
+	tgResults := make([]interface{}, len(results))
+	for ind, res := range results {
+		article := tgbotapi.NewInlineQueryResultArticleHTML(res.ID, res.Title, res.Text)
+		article.Description = res.Description
+		tgResults[ind] = article
+	}
+	inlineConfig := tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       tgResults,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	}
+	if _, err := c.client.Request(inlineConfig); err != nil {
+		logger.Error("Ошибка ответа на инлайн-запрос", "err", err)
+		return errors.Wrap(err, "client.Request answerInlineQuery")
+	}
+	return nil
+}
+
+// SendDocument Отправка файла пользователю.
+func (c *Client) SendDocument(fileName string, data []byte, caption string, userID int64) error {
+	doc := tgbotapi.NewDocument(userID, tgbotapi.FileBytes{Name: fileName, Bytes: data})
+	doc.Caption = caption
+	if _, err := c.client.Send(doc); err != nil {
+		return errors.Wrap(err, "Ошибка отправки файла client.Send")
+	}
+	return nil
+}
+
+// SetBotCommands Установка списка команд в меню бота.
+func (c *Client) SetBotCommands(commands []types.TgBotCommand) error {
+	tgCommands := make([]tgbotapi.BotCommand, len(commands))
+	for ind, cmd := range commands {
+		tgCommands[ind] = tgbotapi.BotCommand{Command: cmd.Command, Description: cmd.Description}
+	}
+	if _, err := c.client.Request(tgbotapi.NewSetMyCommands(tgCommands...)); err != nil {
+		logger.Error("Ошибка установки команд бота", "err", err)
+		return errors.Wrap(err, "client.Request setMyCommands")
+	}
+	return nil
+}
+
+// decodePhotoQR Загрузка фотографии и распознавание QR-кода на ней.
+// В случае ошибки возвращается пустая строка.
+func decodePhotoQR(c *Client, photo []tgbotapi.PhotoSize) string {
+	// Последний размер фотографии - самый большой.
+	fileURL, err := c.client.GetFileDirectURL(photo[len(photo)-1].FileID)
+	if err != nil {
+		logger.Error("Ошибка получения ссылки на фотографию", "err", err)
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), photoDownloadTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		logger.Error("Ошибка загрузки фотографии", "err", err)
+		return ""
+	}
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		logger.Error("Ошибка загрузки фотографии", "err", err)
+		return ""
+	}
+	defer res.Body.Close()
+	text, err := qrcode.Decode(res.Body)
+	if err != nil {
+		logger.Info("QR-код на фотографии не распознан", "err", err)
+		return ""
+	}
+	return text
+}
+
 func deleteInlineButtons(c *Client, userID int64, msgID int, sourceText string) error {
 	msg := tgbotapi.NewEditMessageText(userID, msgID, sourceText)
 	_, err := c.client.Send(msg)
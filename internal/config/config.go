@@ -11,14 +11,42 @@ import (
 const configFile = "data/config.yaml"
 
 type Config struct {
-	Token                       string   `yaml:"token"`                       // Токен бота в телеграме.
-	MainCurrency                string   `yaml:"mainCurrency"`                // Основная валюта, в которой хранятся данные.
-	CurrenciesName              []string `yaml:"CurrenciesName"`              // Список используемых валют.
-	CurrenciesUpdatePeriod      int64    `yaml:"CurrenciesUpdatePeriod"`      // Периодичность обновления курсов валют (в минутах).
-	CurrenciesUpdateCachePeriod int64    `yaml:"CurrenciesUpdateCachePeriod"` // Периодичность кэширования курсов валют из базы данных (в минутах).
-	ConnectionStringDB          string   `yaml:"ConnectionStringDB"`          // Строка подключения в базе данных.
-	KafkaTopic                  string   `yaml:"KafkaTopic"`                  // Наименование топика Kafka.
-	BrokersList                 []string `yaml:"BrokersList"`                 // Список адресов брокеров сообщений (адрес Kafka).
+	Token                       string               `yaml:"token"`                       // Токен бота в телеграме.
+	MainCurrency                string               `yaml:"mainCurrency"`                // Основная валюта, в которой хранятся данные.
+	CurrenciesName              []string             `yaml:"CurrenciesName"`              // Список используемых валют.
+	CurrenciesUpdatePeriod      int64                `yaml:"CurrenciesUpdatePeriod"`      // Периодичность обновления курсов валют (в минутах).
+	CurrenciesUpdateCachePeriod int64                `yaml:"CurrenciesUpdateCachePeriod"` // Периодичность кэширования курсов валют из базы данных (в минутах).
+	ConnectionStringDB          string               `yaml:"ConnectionStringDB"`          // Строка подключения в базе данных (Postgres или "sqlite://путь/к/файлу.db").
+	AutoMigrate                 bool                 `yaml:"AutoMigrate"`                 // Применение миграций базы данных Postgres при запуске.
+	DB                          DBConfig             `yaml:"DB"`                          // Параметры подключения к базе данных.
+	KafkaTopic                  string               `yaml:"KafkaTopic"`                  // Наименование топика Kafka.
+	BrokersList                 []string             `yaml:"BrokersList"`                 // Список адресов брокеров сообщений (адрес Kafka).
+	DefaultTimezone             string               `yaml:"DefaultTimezone"`             // Часовой пояс пользователей по умолчанию (и расписания регулярных отчетов).
+	DigestHour                  int                  `yaml:"DigestHour"`                  // Час отправки регулярных отчетов.
+	DigestCheckPeriod           int64                `yaml:"DigestCheckPeriod"`           // Периодичность проверки подписок на регулярные отчеты (в минутах).
+	AdminIDs                    []int64              `yaml:"AdminIDs"`                    // Телеграм-идентификаторы администраторов бота.
+	AccessMode                  string               `yaml:"AccessMode"`                  // Режим доступа к боту (open, allowlist, invite).
+	AllowedIDs                  []int64              `yaml:"AllowedIDs"`                  // Телеграм-идентификаторы пользователей, которым доступ разрешен всегда.
+	RateLimits                  map[string]RateLimit `yaml:"RateLimits"`                  // Ограничения частоты запросов пользователя по классам команд (default, report, inline).
+}
+
+// RateLimit Ограничение частоты запросов пользователя.
+type RateLimit struct {
+	PerMinute float64 `yaml:"PerMinute"` // Запросов в минуту (0 - без ограничений).
+	Burst     int     `yaml:"Burst"`     // Запросов подряд.
+}
+
+// DBConfig Параметры пула соединений, запросов и проверки доступности базы данных.
+type DBConfig struct {
+	MaxOpenConns       int    `yaml:"MaxOpenConns"`       // Максимальное количество открытых соединений.
+	MaxIdleConns       int    `yaml:"MaxIdleConns"`       // Максимальное количество простаивающих соединений.
+	ConnMaxLifetime    int64  `yaml:"ConnMaxLifetime"`    // Максимальное время жизни соединения (в минутах).
+	ConnMaxIdleTime    int64  `yaml:"ConnMaxIdleTime"`    // Максимальное время простоя соединения (в минутах).
+	StatementTimeout   int64  `yaml:"StatementTimeout"`   // Ограничение времени выполнения запроса (в секундах, только Postgres).
+	LogLevel           string `yaml:"LogLevel"`           // Уровень логирования запросов (trace, debug, info, warn, error, none; только Postgres).
+	TxAttempts         int    `yaml:"TxAttempts"`         // Количество попыток выполнения транзакции при временных ошибках (1 - без повторов).
+	HealthCheckPeriod  int64  `yaml:"HealthCheckPeriod"`  // Периодичность проверки доступности базы данных (в секундах).
+	HealthCheckTimeout int64  `yaml:"HealthCheckTimeout"` // Ограничение времени проверки доступности базы данных (в секундах).
 }
 
 type Service struct {
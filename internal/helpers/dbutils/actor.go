@@ -0,0 +1,21 @@
+package dbutils
+
+// Пользователь, совершающий изменение данных (для журнала изменений).
+
+import "context"
+
+// actorKey Ключ контекста для ТГ-идентификатора пользователя, совершающего изменение.
+type actorKey struct{}
+
+// ContextWithActor Контекст запроса пользователя бота: изменения данных в этом контексте
+// записываются в журнал изменений от имени пользователя actorTgID.
+func ContextWithActor(ctx context.Context, actorTgID int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorTgID)
+}
+
+// ActorFromContext Получение ТГ-идентификатора пользователя, совершающего изменение.
+// Для изменений вне запросов пользователей (начальные данные, команды запуска) пользователь не задан.
+func ActorFromContext(ctx context.Context) (int64, bool) {
+	actorTgID, ok := ctx.Value(actorKey{}).(int64)
+	return actorTgID, ok
+}
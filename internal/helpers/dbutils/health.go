@@ -0,0 +1,109 @@
+package dbutils
+
+// Проверка доступности базы данных и метрики пула соединений.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+)
+
+// Метрики.
+var (
+	DBUp = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "tg",
+		Subsystem: "db",
+		Name:      "up", // Готовность базы данных (1 - последняя проверка успешна, 0 - нет).
+	})
+	PingDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "tg",
+		Subsystem: "db",
+		Name:      "ping_duration_seconds", // Время проверки доступности базы данных.
+		Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	})
+	TxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tg",
+		Subsystem: "db",
+		Name:      "tx_retries_total", // Количество повторов транзакций по причинам (serialization, deadlock, connection).
+	}, []string{"reason"})
+)
+
+// HealthProbe Фоновая проверка доступности базы данных.
+type HealthProbe struct {
+	db      *sqlx.DB
+	timeout time.Duration
+	ready   atomic.Bool
+}
+
+// StartHealthProbe Запуск периодической (с периодом period) проверки доступности базы данных
+// и публикация метрик пула соединений (go_sql_*, с меткой db_name). Проверка прекращается при отмене контекста.
+func StartHealthProbe(ctx context.Context, db *sqlx.DB, dbName string, period time.Duration, timeout time.Duration) *HealthProbe {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db.DB, dbName))
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		logger.Error("Ошибка регистрации метрик пула соединений", "err", err)
+	}
+
+	probe := &HealthProbe{db: db, timeout: timeout}
+	// До первой проверки база считается доступной (в лог попадет ошибка первой же проверки).
+	probe.ready.Store(true)
+	probe.check(ctx)
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				probe.check(ctx)
+			}
+		}
+	}()
+	return probe
+}
+
+// Ready Результат последней проверки доступности базы данных.
+func (probe *HealthProbe) Ready() bool {
+	return probe.ready.Load()
+}
+
+// ServeHTTP Проверка готовности (readiness) по результату последней проверки доступности базы данных:
+// 200 - база доступна, 503 - нет.
+func (probe *HealthProbe) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if !probe.Ready() {
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// check Проверка доступности базы данных (с записью результата в метрики и в лог при его изменении).
+func (probe *HealthProbe) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, probe.timeout)
+	defer cancel()
+	startTime := time.Now()
+	err := probe.db.PingContext(ctx)
+	PingDuration.Observe(time.Since(startTime).Seconds())
+
+	ready := err == nil
+	if ready {
+		DBUp.Set(1)
+	} else {
+		DBUp.Set(0)
+	}
+	if probe.ready.Swap(ready) != ready {
+		if ready {
+			logger.Info("База данных доступна")
+		} else {
+			logger.Error("База данных недоступна", "err", err)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -37,21 +39,55 @@ func (pl *pgxLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, da
 	}
 }
 
-// NewDBConnect Инициализация подключения к базе данных по заданным параметрам.
-func NewDBConnect(connString string) (*sqlx.DB, error) {
+// Options Параметры пула соединений и запросов к базе данных (нулевые значения - значения по умолчанию database/sql и pgx).
+type Options struct {
+	MaxOpenConns     int           // Максимальное количество открытых соединений (0 - без ограничений).
+	MaxIdleConns     int           // Максимальное количество простаивающих соединений (0 - по умолчанию, 2).
+	ConnMaxLifetime  time.Duration // Максимальное время жизни соединения (0 - без ограничений).
+	ConnMaxIdleTime  time.Duration // Максимальное время простоя соединения (0 - без ограничений).
+	StatementTimeout time.Duration // Ограничение времени выполнения запроса на сервере (statement_timeout, 0 - без ограничений).
+	LogLevel         string        // Уровень логирования pgx (trace, debug, info, warn, error, none; по умолчанию debug).
+}
+
+// newConnConfig Параметры подключения pgx по строке подключения и заданным параметрам.
+func newConnConfig(connString string, opts Options) (*pgx.ConnConfig, error) {
 	connConfig, err := pgx.ParseConfig(connString)
 	if err != nil {
-		logger.Error("Ошибка парсинга строки подключения", "err", err)
 		return nil, err
 	}
 	connConfig.RuntimeParams["application_name"] = "tg-bot"
+	if opts.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10)
+	}
 	connConfig.Logger = &pgxLogger{}
 	connConfig.LogLevel = pgx.LogLevelDebug
+	if opts.LogLevel != "" {
+		connConfig.LogLevel, err = pgx.LogLevelFromString(opts.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return connConfig, nil
+}
+
+// NewDBConnect Инициализация подключения к базе данных по заданным параметрам.
+func NewDBConnect(connString string, opts Options) (*sqlx.DB, error) {
+	connConfig, err := newConnConfig(connString, opts)
+	if err != nil {
+		logger.Error("Ошибка парсинга строки подключения", "err", err)
+		return nil, err
+	}
 	connStr := stdlib.RegisterConnConfig(connConfig)
 	dbh, err := sqlx.Connect("pgx", connStr)
 	if err != nil {
 		logger.Error("Ошибка соединения с БД", "err", err)
 		return nil, fmt.Errorf("Ошибка: prepare db connection: %w", err)
 	}
+	dbh.SetMaxOpenConns(opts.MaxOpenConns)
+	if opts.MaxIdleConns > 0 {
+		dbh.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	dbh.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	dbh.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 	return dbh, nil
 }
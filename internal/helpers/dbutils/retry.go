@@ -0,0 +1,90 @@
+package dbutils
+
+// Повтор транзакций при временных ошибках базы данных (Postgres).
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+)
+
+// RetryPolicy Параметры повтора транзакций.
+type RetryPolicy struct {
+	Attempts  int           // Количество попыток (1 - без повторов).
+	BaseDelay time.Duration // Пауза перед первым повтором (удваивается с каждым повтором).
+	MaxDelay  time.Duration // Максимальная пауза между попытками.
+}
+
+// txRetryPolicy Параметры повтора транзакций в RunTx.
+var txRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+// SetTxRetryPolicy Изменение параметров повтора транзакций (при запуске приложения, до выполнения запросов).
+// Незаданные (нулевые) параметры остаются по умолчанию.
+func SetTxRetryPolicy(policy RetryPolicy) {
+	if policy.Attempts > 0 {
+		txRetryPolicy.Attempts = policy.Attempts
+	}
+	if policy.BaseDelay > 0 {
+		txRetryPolicy.BaseDelay = policy.BaseDelay
+	}
+	if policy.MaxDelay > 0 {
+		txRetryPolicy.MaxDelay = policy.MaxDelay
+	}
+}
+
+// delay Пауза перед повтором после попытки attempt (экспоненциальная, со случайной добавкой до половины паузы,
+// чтобы конфликтующие транзакции не повторялись одновременно).
+func (policy RetryPolicy) delay(attempt int) time.Duration {
+	d := policy.MaxDelay
+	if attempt < 30 {
+		d = min(policy.BaseDelay<<(attempt-1), policy.MaxDelay)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d + rand.N(d/2+1)
+}
+
+// Причины повтора транзакции (значения метки reason метрики TxRetries).
+const (
+	retrySerialization = "serialization"
+	retryDeadlock      = "deadlock"
+	retryConnection    = "connection"
+)
+
+// txRetryReason Причина, по которой транзакцию с ошибкой err можно повторить ("" - повторять нельзя).
+// Конфликт сериализации и взаимная блокировка откатывают транзакцию на сервере, поэтому повторяются всегда.
+// Отказ соединения до фиксации транзакции (committing = false) повторяется, а при фиксации - только если
+// запрос гарантированно не был отправлен серверу (иначе транзакция могла быть зафиксирована).
+func txRetryReason(err error, committing bool) string {
+	if err == nil {
+		return ""
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "40001":
+			return retrySerialization
+		case pgErr.Code == "40P01":
+			return retryDeadlock
+		case !committing && (strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01"):
+			// connection_exception или admin_shutdown.
+			return retryConnection
+		}
+		return ""
+	}
+	if pgconn.SafeToRetry(err) || errors.Is(err, driver.ErrBadConn) {
+		return retryConnection
+	}
+	var netErr net.Error
+	if !committing && (errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return retryConnection
+	}
+	return ""
+}
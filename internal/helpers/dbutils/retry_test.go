@@ -0,0 +1,104 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func Test_txRetryReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		committing bool
+		want       string
+	}{
+		{name: "Без ошибки", err: nil, want: ""},
+		{name: "Конфликт сериализации", err: &pgconn.PgError{Code: "40001"}, want: retrySerialization},
+		{name: "Конфликт сериализации при фиксации", err: &pgconn.PgError{Code: "40001"}, committing: true, want: retrySerialization},
+		{name: "Взаимная блокировка в обернутой ошибке", err: fmt.Errorf("run query: %w", &pgconn.PgError{Code: "40P01"}), want: retryDeadlock},
+		{name: "Отказ соединения", err: &pgconn.PgError{Code: "08006"}, want: retryConnection},
+		{name: "Отказ соединения при фиксации", err: io.ErrUnexpectedEOF, committing: true, want: ""},
+		{name: "Обрыв соединения во время запроса", err: io.ErrUnexpectedEOF, want: retryConnection},
+		{name: "Неисправное соединение", err: driver.ErrBadConn, committing: true, want: retryConnection},
+		{name: "Нарушение ограничения", err: &pgconn.PgError{Code: "23505"}, want: ""},
+		{name: "Прочие ошибки", err: errors.New("over limit"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, txRetryReason(tt.err, tt.committing))
+		})
+	}
+}
+
+func Test_RunTx_ShouldRetryTransientErrors(t *testing.T) {
+	defaultPolicy := txRetryPolicy
+	t.Cleanup(func() { txRetryPolicy = defaultPolicy })
+	SetTxRetryPolicy(RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	f := func(tx *sqlx.Tx) error {
+		_, err := Exec(ctx, tx, "UPDATE users SET limits = $1;", 100)
+		return err
+	}
+
+	// Первая попытка - конфликт сериализации, вторая - успешна.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WithArgs(100).WillReturnError(&pgconn.PgError{Code: "40001"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WithArgs(100).WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, RunTx(ctx, db, f))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// После исчерпания попыток возвращается последняя ошибка.
+	for range 2 {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users").WithArgs(100).WillReturnError(&pgconn.PgError{Code: "40P01"})
+		mock.ExpectRollback()
+	}
+	err = RunTx(ctx, db, f)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "40P01", pgErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Прочие ошибки не повторяются.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WithArgs(100).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, RunTx(ctx, db, f), assert.AnError)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_newConnConfig(t *testing.T) {
+	connString := "host=localhost port=5432 dbname=tgbot user=tgbot password=pass sslmode=disable"
+	cfg, err := newConnConfig(connString, Options{StatementTimeout: 5 * time.Second, LogLevel: "warn"})
+	require.NoError(t, err)
+	assert.Equal(t, "5000", cfg.RuntimeParams["statement_timeout"])
+	assert.Equal(t, "tg-bot", cfg.RuntimeParams["application_name"])
+	assert.Equal(t, "warn", cfg.LogLevel.String())
+
+	// По умолчанию ограничения времени запроса нет, уровень логирования - debug.
+	cfg, err = newConnConfig(connString, Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.RuntimeParams, "statement_timeout")
+	assert.Equal(t, "debug", cfg.LogLevel.String())
+
+	_, err = newConnConfig(connString, Options{LogLevel: "verbose"})
+	assert.Error(t, err)
+}
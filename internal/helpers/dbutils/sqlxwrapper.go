@@ -7,6 +7,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/multierr"
 )
@@ -70,6 +74,26 @@ func GetMap(ctx context.Context, db sqlx.QueryerContext, query string, args ...a
 	return ret, nil
 }
 
+// ValuesPlaceholders Формирование списка параметров для многострочной вставки (VALUES):
+// ValuesPlaceholders(2, 3) = "($1, $2, $3), ($4, $5, $6)".
+func ValuesPlaceholders(rows int, columns int) string {
+	var res strings.Builder
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			res.WriteString(", ")
+		}
+		res.WriteString("(")
+		for col := 1; col <= columns; col++ {
+			if col > 1 {
+				res.WriteString(", ")
+			}
+			res.WriteString("$" + strconv.Itoa(row*columns+col))
+		}
+		res.WriteString(")")
+	}
+	return res.String()
+}
+
 // TxFunc Описание типа вложенной функции для выполнения в транзакции.
 type TxFunc func(tx *sqlx.Tx) error
 
@@ -82,7 +106,28 @@ type TxRunner interface {
 //
 // Запуск транзакции (в случае ошибки выполнения вложенной функции вызовет откат транзакции).
 // Вложенная функция (f TxFunc) должна возвращать ошибку в случае присутствия условий, требущих откат транзакции.
-func RunTx(ctx context.Context, db TxRunner, f TxFunc) (err error) {
+// При конфликте сериализации, взаимной блокировке или отказе соединения транзакция повторяется целиком
+// (с паузами по txRetryPolicy), поэтому вложенная функция не должна накапливать результаты предыдущих попыток.
+func RunTx(ctx context.Context, db TxRunner, f TxFunc) error {
+	policy := txRetryPolicy
+	for attempt := 1; ; attempt++ {
+		committing, err := runTxOnce(ctx, db, f)
+		reason := txRetryReason(err, committing)
+		if reason == "" || attempt >= policy.Attempts || ctx.Err() != nil {
+			return err
+		}
+		TxRetries.WithLabelValues(reason).Inc()
+		logger.Warn("Повтор транзакции", "reason", reason, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.delay(attempt)):
+		}
+	}
+}
+
+// runTxOnce Одна попытка выполнения транзакции. Возвращает признак ошибки при фиксации транзакции (committing).
+func runTxOnce(ctx context.Context, db TxRunner, f TxFunc) (committing bool, err error) {
 	var tx *sqlx.Tx
 
 	opts := &sql.TxOptions{
@@ -91,7 +136,7 @@ func RunTx(ctx context.Context, db TxRunner, f TxFunc) (err error) {
 	// Запуск транзакции.
 	tx, err = db.BeginTxx(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
+		return false, fmt.Errorf("begin transaction: %w", err)
 	}
 	// Откат или коммит транзакции при завершении функции.
 	defer func() {
@@ -100,9 +145,10 @@ func RunTx(ctx context.Context, db TxRunner, f TxFunc) (err error) {
 			err = multierr.Combine(err, tx.Rollback())
 		} else {
 			// Коммит транзакции.
+			committing = true
 			err = tx.Commit()
 		}
 	}()
 	// Выполнение вложенной функции и возврат результата.
-	return f(tx)
+	return false, f(tx)
 }
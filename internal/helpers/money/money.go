@@ -0,0 +1,269 @@
+// Package money Хелпер для точных операций с денежными суммами и курсами валют.
+// Суммы хранятся в целых минорных единицах (копейках, центах), курсы - в десятичных дробях
+// с фиксированным количеством знаков. Округление при конвертации - банковское (к четному).
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MinorDigits Количество знаков минорных единиц (для всех используемых валют - сотые доли).
+const MinorDigits = 2
+
+// RateScale Количество знаков после запятой в курсе валюты.
+const RateScale = 12
+
+// Money Денежная сумма в валюте.
+type Money struct {
+	Amount   int64  // Сумма в минорных единицах (копейках, центах).
+	Currency string // Код валюты (RUB, USD...).
+}
+
+// New Денежная сумма в минорных единицах указанной валюты.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse Разбор суммы в основных единицах валюты (например, "1500.50" или "1500,5").
+func Parse(s string, currency string) (Money, error) {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, currency), nil
+}
+
+// String Сумма с кодом валюты (например, "1500.50 RUB").
+func (m Money) String() string {
+	return FormatAmount(m.Amount) + " " + m.Currency
+}
+
+// Convert Конвертация суммы в другую валюту по курсу (количество единиц валюты за единицу исходной валюты).
+func (m Money) Convert(rate Rate, currency string) (Money, error) {
+	amount, err := rate.Multiply(m.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, currency), nil
+}
+
+// ParseAmount Разбор суммы в основных единицах валюты в минорные единицы без потери точности.
+// Допускается точка или запятая в качестве разделителя и не более MinorDigits знаков после него.
+func ParseAmount(s string) (int64, error) {
+	sign, intPart, fracPart, err := splitDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(fracPart) > MinorDigits {
+		return 0, errors.Errorf("Сумма %q содержит больше %v знаков после запятой.", s, MinorDigits)
+	}
+	amount, err := strconv.ParseInt(sign+intPart+fracPart+strings.Repeat("0", MinorDigits-len(fracPart)), 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("Сумма %q слишком большая.", s)
+	}
+	return amount, nil
+}
+
+// FormatAmount Форматирование суммы в минорных единицах в основные единицы (например, 150050 - "1500.50").
+func FormatAmount(amount int64) string {
+	sign := ""
+	abs := uint64(amount)
+	if amount < 0 {
+		sign, abs = "-", -abs
+	}
+	return fmt.Sprintf("%v%d.%0*d", sign, abs/pow10(MinorDigits), MinorDigits, abs%pow10(MinorDigits))
+}
+
+// DivCeil Деление суммы на части с округлением вверх до минорной единицы (например, ежемесячный взнос).
+func DivCeil(amount int64, parts int64) int64 {
+	if parts <= 0 {
+		return amount
+	}
+	q := amount / parts
+	if amount%parts > 0 {
+		q++
+	}
+	return q
+}
+
+// Rate Курс валюты: десятичная дробь с RateScale знаками после запятой.
+// Курс сравнивается через ==, нулевое значение - курс не задан.
+type Rate struct {
+	units int64 // Курс в единицах 10^-RateScale.
+}
+
+// ParseRate Разбор курса валюты из десятичной записи (например, "0.015858969" или "1.5e-2").
+// Знаки после RateScale округляются по банковскому правилу.
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || strings.Contains(s, "/") {
+		return Rate{}, errors.Errorf("Некорректный курс валюты %q.", s)
+	}
+	if r.Sign() < 0 {
+		return Rate{}, errors.Errorf("Отрицательный курс валюты %q.", s)
+	}
+	num := new(big.Int).Mul(r.Num(), big.NewInt(int64(pow10(RateScale))))
+	units := roundHalfEven(num, r.Denom())
+	if !units.IsInt64() {
+		return Rate{}, errors.Errorf("Слишком большой курс валюты %q.", s)
+	}
+	return Rate{units: units.Int64()}, nil
+}
+
+// MustParseRate Разбор курса валюты с паникой при ошибке (для констант и тестов).
+func MustParseRate(s string) Rate {
+	rate, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+// IsZero Проверка, что курс не задан.
+func (rate Rate) IsZero() bool {
+	return rate.units == 0
+}
+
+// String Десятичная запись курса без лишних нулей (например, "0.015858969").
+func (rate Rate) String() string {
+	s := strconv.FormatInt(rate.units, 10)
+	if len(s) <= RateScale {
+		s = strings.Repeat("0", RateScale-len(s)+1) + s
+	}
+	intPart, fracPart := s[:len(s)-RateScale], strings.TrimRight(s[len(s)-RateScale:], "0")
+	if fracPart == "" {
+		return intPart
+	}
+	return intPart + "." + fracPart
+}
+
+// Float64 Приближенное значение курса (только для отображения и метрик).
+func (rate Rate) Float64() float64 {
+	f, _ := strconv.ParseFloat(rate.String(), 64)
+	return f
+}
+
+// Multiply Умножение суммы в минорных единицах на курс с банковским округлением.
+func (rate Rate) Multiply(amount int64) (int64, error) {
+	num := new(big.Int).Mul(big.NewInt(amount), big.NewInt(rate.units))
+	return toInt64(roundHalfEven(num, big.NewInt(int64(pow10(RateScale)))))
+}
+
+// Divide Деление суммы в минорных единицах на курс с банковским округлением.
+func (rate Rate) Divide(amount int64) (int64, error) {
+	if rate.IsZero() {
+		return 0, errors.New("Курс валюты не задан.")
+	}
+	num := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(pow10(RateScale))))
+	return toInt64(roundHalfEven(num, big.NewInt(rate.units)))
+}
+
+// MarshalJSON Запись курса в JSON числом без потери точности.
+func (rate Rate) MarshalJSON() ([]byte, error) {
+	return []byte(rate.String()), nil
+}
+
+// UnmarshalJSON Чтение курса из числа JSON (или строки) без промежуточного float64.
+func (rate *Rate) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseRate(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*rate = parsed
+	return nil
+}
+
+// Value Запись курса в базу данных десятичной строкой (для колонок numeric/text).
+func (rate Rate) Value() (driver.Value, error) {
+	return rate.String(), nil
+}
+
+// Scan Чтение курса из базы данных.
+func (rate *Rate) Scan(src any) error {
+	var s string
+	switch value := src.(type) {
+	case string:
+		s = value
+	case []byte:
+		s = string(value)
+	case int64:
+		s = strconv.FormatInt(value, 10)
+	case float64:
+		s = strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return errors.Errorf("Неподдерживаемый тип курса валюты %T.", src)
+	}
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*rate = parsed
+	return nil
+}
+
+// splitDecimal Разбор десятичной записи на знак, целую и дробную части (только цифры).
+func splitDecimal(s string) (sign string, intPart string, fracPart string, err error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, hasSeparator := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+	if intPart == "" && (!hasSeparator || fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return "", "", "", errors.Errorf("Некорректная сумма %q.", s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	return sign, intPart, fracPart, nil
+}
+
+// isDigits Проверка, что строка состоит только из цифр.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// roundHalfEven Деление с банковским округлением (половина округляется к четному).
+func roundHalfEven(num *big.Int, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// Сравнение удвоенного остатка с делителем (по модулю).
+	cmp := new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(new(big.Int).Abs(den))
+	if cmp > 0 || cmp == 0 && q.Bit(0) == 1 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// toInt64 Приведение результата конвертации к int64 с проверкой переполнения.
+func toInt64(value *big.Int) (int64, error) {
+	if !value.IsInt64() {
+		return 0, errors.New("Переполнение суммы при конвертации.")
+	}
+	return value.Int64(), nil
+}
+
+// pow10 Степень десяти.
+func pow10(n int) uint64 {
+	result := uint64(1)
+	for ; n > 0; n-- {
+		result *= 10
+	}
+	return result
+}
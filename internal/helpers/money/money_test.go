@@ -0,0 +1,144 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseAmount_ShouldBeExact(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"1500", 150000},
+		{"1500.5", 150050},
+		{"1500,05", 150005},
+		{"0.29", 29}, // float64: 0.29 * 100 = 28.999999999999996
+		{"1.15", 115},
+		{"4.35", 435},
+		{".5", 50},
+		{"-12.34", -1234},
+		{"170000.01", 17000001}, // float32 теряет копейки.
+		{"92233720368547758.07", math.MaxInt64},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+}
+
+func Test_ParseAmount_ShouldReturnError_WhenInvalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "1.234", "1e3", "1 500", "92233720368547758.08", ".", "-", "1.2.3"} {
+		_, err := ParseAmount(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_FormatAmount(t *testing.T) {
+	assert.Equal(t, "1500.50", FormatAmount(150050))
+	assert.Equal(t, "0.05", FormatAmount(5))
+	assert.Equal(t, "-0.05", FormatAmount(-5))
+	assert.Equal(t, "0.00", FormatAmount(0))
+	assert.Equal(t, "92233720368547758.07", FormatAmount(math.MaxInt64))
+	assert.Equal(t, "-92233720368547758.08", FormatAmount(math.MinInt64))
+	assert.Equal(t, "1500.50 USD", New(150050, "USD").String())
+}
+
+func Test_DivCeil(t *testing.T) {
+	assert.Equal(t, int64(334), DivCeil(1000, 3))
+	assert.Equal(t, int64(500), DivCeil(1000, 2))
+	assert.Equal(t, int64(1000), DivCeil(1000, 0))
+}
+
+func Test_ParseRate_ShouldRoundHalfEvenToScale(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0.015858969", "0.015858969"},
+		{"1", "1"},
+		{"95.4", "95.4"},
+		{"1.5e-2", "0.015"},
+		{"0.0000000000005", "0"},              // Половина - к четному (вниз).
+		{"0.0000000000015", "0.000000000002"}, // Половина - к четному (вверх).
+		{"0.00000000000051", "0.000000000001"},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, rate.String(), tt.input)
+	}
+	for _, input := range []string{"", "abc", "-1", "1/3", "10000000"} {
+		_, err := ParseRate(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_Rate_Multiply_ShouldUseBankersRounding(t *testing.T) {
+	rate := MustParseRate("0.5")
+	tests := []struct {
+		amount int64
+		want   int64
+	}{
+		{1, 0},   // 0.5 -> 0
+		{3, 2},   // 1.5 -> 2
+		{5, 2},   // 2.5 -> 2
+		{7, 4},   // 3.5 -> 4
+		{-5, -2}, // -2.5 -> -2
+		{-7, -4}, // -3.5 -> -4
+	}
+	for _, tt := range tests {
+		got, err := rate.Multiply(tt.amount)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.amount)
+	}
+
+	// 100 000 000.00 руб. по курсу 0.015858969 = 1 585 896.90 долл.
+	got, err := MustParseRate("0.015858969").Multiply(10000000000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(158589690), got)
+
+	_, err = MustParseRate("1000").Multiply(math.MaxInt64)
+	assert.Error(t, err)
+}
+
+func Test_Rate_Divide_ShouldRoundTrip(t *testing.T) {
+	rate := MustParseRate("0.015858969")
+	for _, amount := range []int64{1, 99, 100, 15000, 17000001, 1234567891} {
+		converted, err := rate.Multiply(amount)
+		require.NoError(t, err)
+		back, err := rate.Divide(converted)
+		require.NoError(t, err)
+		// Обратная конвертация отличается не больше, чем на сумму, соответствующую минорной единице другой валюты.
+		assert.InDelta(t, amount, back, 1/rate.Float64()/2+1, amount)
+	}
+	got, err := MustParseRate("0.02").Divide(3000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150000), got)
+
+	_, err = Rate{}.Divide(100)
+	assert.Error(t, err)
+}
+
+func Test_Rate_JSONAndScan(t *testing.T) {
+	var rates map[string]Rate
+	require.NoError(t, json.Unmarshal([]byte(`{"USD": 0.015858969, "EUR": "0.0160078"}`), &rates))
+	assert.Equal(t, map[string]Rate{"USD": MustParseRate("0.015858969"), "EUR": MustParseRate("0.0160078")}, rates)
+	data, err := json.Marshal(rates)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"USD": 0.015858969, "EUR": 0.0160078}`, string(data))
+
+	var rate Rate
+	for _, src := range []any{"0.0160078", []byte("0.0160078"), 0.0160078} {
+		require.NoError(t, rate.Scan(src))
+		assert.Equal(t, MustParseRate("0.0160078"), rate)
+	}
+	value, err := rate.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0.0160078", value)
+}
@@ -0,0 +1,34 @@
+// Package qrcode Хелпер для распознавания QR-кодов на изображениях (без внешних сервисов).
+package qrcode
+
+import (
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/pkg/errors"
+)
+
+// Decode Распознавание QR-кода на изображении (jpeg или png) и получение закодированной строки.
+func Decode(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", errors.Wrap(err, "Ошибка чтения изображения")
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", errors.Wrap(err, "Ошибка подготовки изображения")
+	}
+	// Режим TRY_HARDER для фотографий, сделанных под углом или с бликами.
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	res, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return "", errors.Wrap(err, "QR-код не распознан")
+	}
+	return res.GetText(), nil
+}
@@ -0,0 +1,31 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Decode_ShouldReturnEncodedText(t *testing.T) {
+	text := "t=20240101T1230&s=1234.00&fn=9289000100408074&i=12345&fp=3522207165&n=1"
+	// Формирование изображения с QR-кодом.
+	matrix, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 300, 300, nil)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, matrix))
+
+	res, err := Decode(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, text, res)
+}
+
+func Test_Decode_ShouldReturnError_WhenNotImage(t *testing.T) {
+	_, err := Decode(bytes.NewBufferString("not an image"))
+
+	assert.Error(t, err)
+}
@@ -3,13 +3,13 @@ package timeutils
 
 import "time"
 
-// BeginOfMonth Функция возвращает момент начала месяца указанной даты.
+// BeginOfMonth Функция возвращает момент начала месяца указанной даты (в часовом поясе даты).
 // Например, при t = "16.10.2022 15:22:30" функция вернет дату "01.10.2022 00:00:00"
 func BeginOfMonth(t time.Time) time.Time {
-	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
 }
 
-// BeginOfNextMonth Функция возвращает момент начала следующего месяца указанной даты.
+// BeginOfNextMonth Функция возвращает момент начала следующего месяца указанной даты (в часовом поясе даты).
 // Например, при t = "16.10.2022 15:22:30" функция вернет дату "01.11.2022 00:00:00"
 func BeginOfNextMonth(t time.Time) time.Time {
 	m := t.Month() + 1
@@ -18,5 +18,27 @@ func BeginOfNextMonth(t time.Time) time.Time {
 		m = 1
 		y++
 	}
-	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// BeginOfFullMonth Функция возвращает момент начала первого полного месяца, начинающегося не раньше указанной даты (в часовом поясе даты).
+// Например, при t = "16.10.2022 15:22:30" функция вернет дату "01.11.2022 00:00:00", при t = "01.10.2022 00:00:00" - ту же дату.
+func BeginOfFullMonth(t time.Time) time.Time {
+	if begin := BeginOfMonth(t); begin.Equal(t) {
+		return begin
+	}
+	return BeginOfNextMonth(t)
+}
+
+// LoadLocation Функция возвращает часовой пояс по названию (например, "Asia/Vladivostok").
+// Если часовой пояс не найден, возвращается часовой пояс по умолчанию (defaultLocation).
+func LoadLocation(name string, defaultLocation *time.Location) *time.Location {
+	if name == "" {
+		return defaultLocation
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return defaultLocation
+	}
+	return loc
 }
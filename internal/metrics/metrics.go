@@ -7,7 +7,6 @@ import (
 	"github.com/ellavs/tg-bot-golang/internal/logger"
 	"github.com/ellavs/tg-bot-golang/internal/model/messages"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -46,10 +45,7 @@ var (
 	)
 )
 
-var labels []string
-
 func init() {
-	labels = []string{"start", "cat", "curr", "report", "add_tbl", "add_cat", "add_rec", "choice_currency", "set_limit"}
 	// Для просмотра значений метрик по адресу http://127.0.0.1:8080/
 	http.Handle("/", promhttp.Handler())
 	logger.Info("Старт сервиса метрик.")
@@ -76,8 +72,7 @@ func MetricsMiddleware(next tg.HandlerFunc) tg.HandlerFunc {
 		// Сохранение метрик продолжительности обработки.
 		SummaryResponseTime.Observe(duration.Seconds())
 
-		// Определение команды для сохранения в метрике.
-		cmd := "none"
+		// Определение команды для сохранения в метрике (по реестру команд бота).
 		msg := ""
 		if tgUpdate.Message == nil {
 			if tgUpdate.CallbackQuery != nil {
@@ -86,13 +81,9 @@ func MetricsMiddleware(next tg.HandlerFunc) tg.HandlerFunc {
 		} else {
 			msg = tgUpdate.Message.Text
 		}
-		if msg != "" {
-			for _, lbl := range labels {
-				if strings.Contains(msg, "/"+lbl) {
-					cmd = lbl
-					break
-				}
-			}
+		cmd := msgModel.CommandLabel(msg)
+		if tgUpdate.InlineQuery != nil {
+			cmd = "inline"
 		}
 		HistogramResponseTime.
 			WithLabelValues(cmd).
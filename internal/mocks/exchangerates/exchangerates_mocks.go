@@ -9,8 +9,8 @@ import (
 	reflect "reflect"
 	time "time"
 
-	gomock "github.com/golang/mock/gomock"
 	bottypes "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	gomock "github.com/golang/mock/gomock"
 )
 
 // MockCbrClient is a mock of CbrClient interface.
@@ -9,8 +9,9 @@ import (
 	reflect "reflect"
 	time "time"
 
-	gomock "github.com/golang/mock/gomock"
+	money "github.com/ellavs/tg-bot-golang/internal/helpers/money"
 	bottypes "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	gomock "github.com/golang/mock/gomock"
 )
 
 // MockMessageSender is a mock of MessageSender interface.
@@ -36,6 +37,34 @@ func (m *MockMessageSender) EXPECT() *MockMessageSenderMockRecorder {
 	return m.recorder
 }
 
+// AnswerInlineQuery mocks base method.
+func (m *MockMessageSender) AnswerInlineQuery(queryID string, results []bottypes.TgInlineResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnswerInlineQuery", queryID, results)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnswerInlineQuery indicates an expected call of AnswerInlineQuery.
+func (mr *MockMessageSenderMockRecorder) AnswerInlineQuery(queryID, results interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnswerInlineQuery", reflect.TypeOf((*MockMessageSender)(nil).AnswerInlineQuery), queryID, results)
+}
+
+// SendDocument mocks base method.
+func (m *MockMessageSender) SendDocument(fileName string, data []byte, caption string, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendDocument", fileName, data, caption, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendDocument indicates an expected call of SendDocument.
+func (mr *MockMessageSenderMockRecorder) SendDocument(fileName, data, caption, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendDocument", reflect.TypeOf((*MockMessageSender)(nil).SendDocument), fileName, data, caption, userID)
+}
+
 // SendMessage mocks base method.
 func (m *MockMessageSender) SendMessage(text string, userID int64) error {
 	m.ctrl.T.Helper()
@@ -87,6 +116,111 @@ func (m *MockUserDataStorage) EXPECT() *MockUserDataStorageMockRecorder {
 	return m.recorder
 }
 
+// AddGoalContribution mocks base method.
+func (m *MockUserDataStorage) AddGoalContribution(ctx context.Context, userID, goalID, sum int64) (bottypes.Goal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGoalContribution", ctx, userID, goalID, sum)
+	ret0, _ := ret[0].(bottypes.Goal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGoalContribution indicates an expected call of AddGoalContribution.
+func (mr *MockUserDataStorageMockRecorder) AddGoalContribution(ctx, userID, goalID, sum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGoalContribution", reflect.TypeOf((*MockUserDataStorage)(nil).AddGoalContribution), ctx, userID, goalID, sum)
+}
+
+// CheckIfReceiptExist mocks base method.
+func (m *MockUserDataStorage) CheckIfReceiptExist(ctx context.Context, userID int64, receipt bottypes.Receipt) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckIfReceiptExist", ctx, userID, receipt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckIfReceiptExist indicates an expected call of CheckIfReceiptExist.
+func (mr *MockUserDataStorageMockRecorder) CheckIfReceiptExist(ctx, userID, receipt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIfReceiptExist", reflect.TypeOf((*MockUserDataStorage)(nil).CheckIfReceiptExist), ctx, userID, receipt)
+}
+
+// DeleteGoal mocks base method.
+func (m *MockUserDataStorage) DeleteGoal(ctx context.Context, userID, goalID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGoal", ctx, userID, goalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGoal indicates an expected call of DeleteGoal.
+func (mr *MockUserDataStorageMockRecorder) DeleteGoal(ctx, userID, goalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGoal", reflect.TypeOf((*MockUserDataStorage)(nil).DeleteGoal), ctx, userID, goalID)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserDataStorage) DeleteUser(ctx context.Context, userID int64) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserDataStorageMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserDataStorage)(nil).DeleteUser), ctx, userID)
+}
+
+// GetAdminStats mocks base method.
+func (m *MockUserDataStorage) GetAdminStats(ctx context.Context, days int) (bottypes.AdminStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdminStats", ctx, days)
+	ret0, _ := ret[0].(bottypes.AdminStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdminStats indicates an expected call of GetAdminStats.
+func (mr *MockUserDataStorageMockRecorder) GetAdminStats(ctx, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdminStats", reflect.TypeOf((*MockUserDataStorage)(nil).GetAdminStats), ctx, days)
+}
+
+// GetUserAccess mocks base method.
+func (m *MockUserDataStorage) GetUserAccess(ctx context.Context, userID int64) (bottypes.UserAccess, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAccess", ctx, userID)
+	ret0, _ := ret[0].(bottypes.UserAccess)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAccess indicates an expected call of GetUserAccess.
+func (mr *MockUserDataStorageMockRecorder) GetUserAccess(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccess", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserAccess), ctx, userID)
+}
+
+// GetUserAudit mocks base method.
+func (m *MockUserDataStorage) GetUserAudit(ctx context.Context, userID int64, limit int) ([]bottypes.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAudit", ctx, userID, limit)
+	ret0, _ := ret[0].([]bottypes.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAudit indicates an expected call of GetUserAudit.
+func (mr *MockUserDataStorageMockRecorder) GetUserAudit(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAudit", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserAudit), ctx, userID, limit)
+}
+
 // GetUserCategory mocks base method.
 func (m *MockUserDataStorage) GetUserCategory(ctx context.Context, userID int64) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +266,51 @@ func (mr *MockUserDataStorageMockRecorder) GetUserDataRecord(ctx, userID, period
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDataRecord", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserDataRecord), ctx, userID, period)
 }
 
+// GetUserExport mocks base method.
+func (m *MockUserDataStorage) GetUserExport(ctx context.Context, userID int64) (bottypes.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserExport", ctx, userID)
+	ret0, _ := ret[0].(bottypes.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserExport indicates an expected call of GetUserExport.
+func (mr *MockUserDataStorageMockRecorder) GetUserExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserExport", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserExport), ctx, userID)
+}
+
+// GetUserGoals mocks base method.
+func (m *MockUserDataStorage) GetUserGoals(ctx context.Context, userID int64) ([]bottypes.Goal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserGoals", ctx, userID)
+	ret0, _ := ret[0].([]bottypes.Goal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserGoals indicates an expected call of GetUserGoals.
+func (mr *MockUserDataStorageMockRecorder) GetUserGoals(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserGoals", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserGoals), ctx, userID)
+}
+
+// GetUserInfo mocks base method.
+func (m *MockUserDataStorage) GetUserInfo(ctx context.Context, userID int64) (bottypes.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInfo", ctx, userID)
+	ret0, _ := ret[0].(bottypes.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInfo indicates an expected call of GetUserInfo.
+func (mr *MockUserDataStorageMockRecorder) GetUserInfo(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserInfo), ctx, userID)
+}
+
 // GetUserLimit mocks base method.
 func (m *MockUserDataStorage) GetUserLimit(ctx context.Context, userID int64) (int64, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +326,65 @@ func (mr *MockUserDataStorageMockRecorder) GetUserLimit(ctx, userID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimit", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserLimit), ctx, userID)
 }
 
+// GetUserSubscriptions mocks base method.
+func (m *MockUserDataStorage) GetUserSubscriptions(ctx context.Context, userID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSubscriptions", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSubscriptions indicates an expected call of GetUserSubscriptions.
+func (mr *MockUserDataStorageMockRecorder) GetUserSubscriptions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSubscriptions", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserSubscriptions), ctx, userID)
+}
+
+// GetUserTimezone mocks base method.
+func (m *MockUserDataStorage) GetUserTimezone(ctx context.Context, userID int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTimezone", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTimezone indicates an expected call of GetUserTimezone.
+func (mr *MockUserDataStorageMockRecorder) GetUserTimezone(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimezone", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserTimezone), ctx, userID)
+}
+
+// GetUserTrash mocks base method.
+func (m *MockUserDataStorage) GetUserTrash(ctx context.Context, userID int64, since time.Time) ([]bottypes.TrashItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTrash", ctx, userID, since)
+	ret0, _ := ret[0].([]bottypes.TrashItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTrash indicates an expected call of GetUserTrash.
+func (mr *MockUserDataStorageMockRecorder) GetUserTrash(ctx, userID, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTrash", reflect.TypeOf((*MockUserDataStorage)(nil).GetUserTrash), ctx, userID, since)
+}
+
+// InsertAdminAudit mocks base method.
+func (m *MockUserDataStorage) InsertAdminAudit(ctx context.Context, adminID int64, command, args string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertAdminAudit", ctx, adminID, command, args)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertAdminAudit indicates an expected call of InsertAdminAudit.
+func (mr *MockUserDataStorageMockRecorder) InsertAdminAudit(ctx, adminID, command, args interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAdminAudit", reflect.TypeOf((*MockUserDataStorage)(nil).InsertAdminAudit), ctx, adminID, command, args)
+}
+
 // InsertCategory mocks base method.
 func (m *MockUserDataStorage) InsertCategory(ctx context.Context, userID int64, catName, userName string) error {
 	m.ctrl.T.Helper()
@@ -161,6 +399,34 @@ func (mr *MockUserDataStorageMockRecorder) InsertCategory(ctx, userID, catName,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertCategory", reflect.TypeOf((*MockUserDataStorage)(nil).InsertCategory), ctx, userID, catName, userName)
 }
 
+// InsertGoal mocks base method.
+func (m *MockUserDataStorage) InsertGoal(ctx context.Context, userID int64, goal bottypes.Goal, userName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertGoal", ctx, userID, goal, userName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertGoal indicates an expected call of InsertGoal.
+func (mr *MockUserDataStorageMockRecorder) InsertGoal(ctx, userID, goal, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertGoal", reflect.TypeOf((*MockUserDataStorage)(nil).InsertGoal), ctx, userID, goal, userName)
+}
+
+// InsertInviteCode mocks base method.
+func (m *MockUserDataStorage) InsertInviteCode(ctx context.Context, code string, adminID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertInviteCode", ctx, code, adminID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertInviteCode indicates an expected call of InsertInviteCode.
+func (mr *MockUserDataStorageMockRecorder) InsertInviteCode(ctx, code, adminID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertInviteCode", reflect.TypeOf((*MockUserDataStorage)(nil).InsertInviteCode), ctx, code, adminID)
+}
+
 // InsertUserDataRecord mocks base method.
 func (m *MockUserDataStorage) InsertUserDataRecord(ctx context.Context, userID int64, rec bottypes.UserDataRecord, userName string, limitPeriod time.Time) (bool, error) {
 	m.ctrl.T.Helper()
@@ -176,6 +442,80 @@ func (mr *MockUserDataStorageMockRecorder) InsertUserDataRecord(ctx, userID, rec
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserDataRecord", reflect.TypeOf((*MockUserDataStorage)(nil).InsertUserDataRecord), ctx, userID, rec, userName, limitPeriod)
 }
 
+// InsertUserDataRecords mocks base method.
+func (m *MockUserDataStorage) InsertUserDataRecords(ctx context.Context, userID int64, recs []bottypes.UserDataRecord, userName, mode string) (bottypes.UserDataImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertUserDataRecords", ctx, userID, recs, userName, mode)
+	ret0, _ := ret[0].(bottypes.UserDataImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertUserDataRecords indicates an expected call of InsertUserDataRecords.
+func (mr *MockUserDataStorageMockRecorder) InsertUserDataRecords(ctx, userID, recs, userName, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserDataRecords", reflect.TypeOf((*MockUserDataStorage)(nil).InsertUserDataRecords), ctx, userID, recs, userName, mode)
+}
+
+// RedeemInviteCode mocks base method.
+func (m *MockUserDataStorage) RedeemInviteCode(ctx context.Context, code string, userID int64, userName string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemInviteCode", ctx, code, userID, userName)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemInviteCode indicates an expected call of RedeemInviteCode.
+func (mr *MockUserDataStorageMockRecorder) RedeemInviteCode(ctx, code, userID, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemInviteCode", reflect.TypeOf((*MockUserDataStorage)(nil).RedeemInviteCode), ctx, code, userID, userName)
+}
+
+// RestoreTrashItem mocks base method.
+func (m *MockUserDataStorage) RestoreTrashItem(ctx context.Context, userID int64, kind string, id int64, since time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrashItem", ctx, userID, kind, id, since)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTrashItem indicates an expected call of RestoreTrashItem.
+func (mr *MockUserDataStorageMockRecorder) RestoreTrashItem(ctx, userID, kind, id, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrashItem", reflect.TypeOf((*MockUserDataStorage)(nil).RestoreTrashItem), ctx, userID, kind, id, since)
+}
+
+// SearchUserDataRecords mocks base method.
+func (m *MockUserDataStorage) SearchUserDataRecords(ctx context.Context, userID int64, filter bottypes.UserDataSearchFilter, limit, offset int) ([]bottypes.UserDataRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUserDataRecords", ctx, userID, filter, limit, offset)
+	ret0, _ := ret[0].([]bottypes.UserDataRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUserDataRecords indicates an expected call of SearchUserDataRecords.
+func (mr *MockUserDataStorageMockRecorder) SearchUserDataRecords(ctx, userID, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUserDataRecords", reflect.TypeOf((*MockUserDataStorage)(nil).SearchUserDataRecords), ctx, userID, filter, limit, offset)
+}
+
+// SetUserBlocked mocks base method.
+func (m *MockUserDataStorage) SetUserBlocked(ctx context.Context, userID int64, blocked bool, adminID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserBlocked", ctx, userID, blocked, adminID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserBlocked indicates an expected call of SetUserBlocked.
+func (mr *MockUserDataStorageMockRecorder) SetUserBlocked(ctx, userID, blocked, adminID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserBlocked", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserBlocked), ctx, userID, blocked, adminID)
+}
+
 // SetUserCurrency mocks base method.
 func (m *MockUserDataStorage) SetUserCurrency(ctx context.Context, userID int64, currencyName, userName string) error {
 	m.ctrl.T.Helper()
@@ -190,6 +530,21 @@ func (mr *MockUserDataStorageMockRecorder) SetUserCurrency(ctx, userID, currency
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserCurrency", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserCurrency), ctx, userID, currencyName, userName)
 }
 
+// SetUserLastSeen mocks base method.
+func (m *MockUserDataStorage) SetUserLastSeen(ctx context.Context, userID int64, seenAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLastSeen", ctx, userID, seenAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserLastSeen indicates an expected call of SetUserLastSeen.
+func (mr *MockUserDataStorageMockRecorder) SetUserLastSeen(ctx, userID, seenAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLastSeen", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserLastSeen), ctx, userID, seenAt)
+}
+
 // SetUserLimit mocks base method.
 func (m *MockUserDataStorage) SetUserLimit(ctx context.Context, userID, limits int64, userName string) error {
 	m.ctrl.T.Helper()
@@ -204,6 +559,49 @@ func (mr *MockUserDataStorageMockRecorder) SetUserLimit(ctx, userID, limits, use
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimit", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserLimit), ctx, userID, limits, userName)
 }
 
+// SetUserSubscription mocks base method.
+func (m *MockUserDataStorage) SetUserSubscription(ctx context.Context, userID int64, kind string, enabled bool, userName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserSubscription", ctx, userID, kind, enabled, userName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserSubscription indicates an expected call of SetUserSubscription.
+func (mr *MockUserDataStorageMockRecorder) SetUserSubscription(ctx, userID, kind, enabled, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserSubscription", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserSubscription), ctx, userID, kind, enabled, userName)
+}
+
+// SetUserTimezone mocks base method.
+func (m *MockUserDataStorage) SetUserTimezone(ctx context.Context, userID int64, timezone, userName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTimezone", ctx, userID, timezone, userName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTimezone indicates an expected call of SetUserTimezone.
+func (mr *MockUserDataStorageMockRecorder) SetUserTimezone(ctx, userID, timezone, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTimezone", reflect.TypeOf((*MockUserDataStorage)(nil).SetUserTimezone), ctx, userID, timezone, userName)
+}
+
+// UndoLastUserAction mocks base method.
+func (m *MockUserDataStorage) UndoLastUserAction(ctx context.Context, userID int64, since time.Time) (bottypes.UserAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndoLastUserAction", ctx, userID, since)
+	ret0, _ := ret[0].(bottypes.UserAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UndoLastUserAction indicates an expected call of UndoLastUserAction.
+func (mr *MockUserDataStorageMockRecorder) UndoLastUserAction(ctx, userID, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndoLastUserAction", reflect.TypeOf((*MockUserDataStorage)(nil).UndoLastUserAction), ctx, userID, since)
+}
+
 // MockExchangeRates is a mock of ExchangeRates interface.
 type MockExchangeRates struct {
 	ctrl     *gomock.Controller
@@ -272,10 +670,10 @@ func (mr *MockExchangeRatesMockRecorder) GetCurrenciesList() *gomock.Call {
 }
 
 // GetExchangeRate mocks base method.
-func (m *MockExchangeRates) GetExchangeRate(currencyName string) (float64, error) {
+func (m *MockExchangeRates) GetExchangeRate(currencyName string) (money.Rate, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetExchangeRate", currencyName)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(money.Rate)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -299,3 +697,131 @@ func (mr *MockExchangeRatesMockRecorder) GetMainCurrency() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMainCurrency", reflect.TypeOf((*MockExchangeRates)(nil).GetMainCurrency))
 }
+
+// UpdateExchangeRates mocks base method.
+func (m *MockExchangeRates) UpdateExchangeRates() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExchangeRates")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExchangeRates indicates an expected call of UpdateExchangeRates.
+func (mr *MockExchangeRatesMockRecorder) UpdateExchangeRates() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExchangeRates", reflect.TypeOf((*MockExchangeRates)(nil).UpdateExchangeRates))
+}
+
+// MockLRUCache is a mock of LRUCache interface.
+type MockLRUCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockLRUCacheMockRecorder
+}
+
+// MockLRUCacheMockRecorder is the mock recorder for MockLRUCache.
+type MockLRUCacheMockRecorder struct {
+	mock *MockLRUCache
+}
+
+// NewMockLRUCache creates a new mock instance.
+func NewMockLRUCache(ctrl *gomock.Controller) *MockLRUCache {
+	mock := &MockLRUCache{ctrl: ctrl}
+	mock.recorder = &MockLRUCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLRUCache) EXPECT() *MockLRUCacheMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockLRUCache) Add(key string, value any) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Add", key, value)
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockLRUCacheMockRecorder) Add(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockLRUCache)(nil).Add), key, value)
+}
+
+// Get mocks base method.
+func (m *MockLRUCache) Get(key string) any {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].(any)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLRUCacheMockRecorder) Get(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLRUCache)(nil).Get), key)
+}
+
+// Remove mocks base method.
+func (m *MockLRUCache) Remove(key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Remove", key)
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockLRUCacheMockRecorder) Remove(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockLRUCache)(nil).Remove), key)
+}
+
+// MockkafkaProducer is a mock of kafkaProducer interface.
+type MockkafkaProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockkafkaProducerMockRecorder
+}
+
+// MockkafkaProducerMockRecorder is the mock recorder for MockkafkaProducer.
+type MockkafkaProducerMockRecorder struct {
+	mock *MockkafkaProducer
+}
+
+// NewMockkafkaProducer creates a new mock instance.
+func NewMockkafkaProducer(ctrl *gomock.Controller) *MockkafkaProducer {
+	mock := &MockkafkaProducer{ctrl: ctrl}
+	mock.recorder = &MockkafkaProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockkafkaProducer) EXPECT() *MockkafkaProducerMockRecorder {
+	return m.recorder
+}
+
+// GetTopic mocks base method.
+func (m *MockkafkaProducer) GetTopic() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTopic")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetTopic indicates an expected call of GetTopic.
+func (mr *MockkafkaProducerMockRecorder) GetTopic() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopic", reflect.TypeOf((*MockkafkaProducer)(nil).GetTopic))
+}
+
+// SendMessage mocks base method.
+func (m *MockkafkaProducer) SendMessage(key, value string) (int32, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessage", key, value)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SendMessage indicates an expected call of SendMessage.
+func (mr *MockkafkaProducerMockRecorder) SendMessage(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockkafkaProducer)(nil).SendMessage), key, value)
+}
@@ -2,6 +2,9 @@ package bottypes
 
 import (
 	"time"
+
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
 )
 
 type Empty struct{}
@@ -15,12 +18,235 @@ type UserDataRecord struct {
 	Category string
 	Sum      int64
 	Period   time.Time
+	Note     string   // Комментарий к записи.
+	Receipt  *Receipt // Реквизиты кассового чека (если запись добавлена по чеку).
+}
+
+// Режимы пакетного добавления записей о расходах.
+const (
+	ImportAllOrNothing = "all"  // Если хотя бы одна запись отклонена, не добавляется ничего.
+	ImportBestEffort   = "best" // Добавляются все записи, кроме отклоненных.
+)
+
+// Тип для результата пакетного добавления записей о расходах.
+type UserDataImportResult struct {
+	Inserted  int64 // Количество добавленных записей.
+	OverLimit []int // Номера записей пакета (с 0), отклоненных из-за превышения бюджета.
+}
+
+// SplitRecordsByLimit Проверка бюджета для пакета записей о расходах (0 - бюджет не установлен).
+// Записи проверяются по порядку: расходы за месяц записи (monthSum - уже сохраненные расходы за месяц,
+// начинающийся с month) вместе с принятыми ранее записями пакета не должны превышать бюджет.
+// Возвращает принятые записи и номера отклоненных записей.
+func SplitRecordsByLimit(recs []UserDataRecord, limits int64, monthSum func(month time.Time) (int64, error)) ([]UserDataRecord, []int, error) {
+	if limits == 0 {
+		return recs, nil, nil
+	}
+	var accepted []UserDataRecord
+	var overLimit []int
+	sums := map[time.Time]int64{}
+	for ind, rec := range recs {
+		month := timeutils.BeginOfMonth(rec.Period)
+		sum, ok := sums[month]
+		if !ok {
+			var err error
+			if sum, err = monthSum(month); err != nil {
+				return nil, nil, err
+			}
+		}
+		if sum+rec.Sum > limits {
+			overLimit = append(overLimit, ind)
+		} else {
+			accepted = append(accepted, rec)
+			sum += rec.Sum
+		}
+		sums[month] = sum
+	}
+	return accepted, overLimit, nil
+}
+
+// Тип для условий поиска записей о расходах (нулевые значения - без ограничения).
+type UserDataSearchFilter struct {
+	Text       string    // Подстрока категории или комментария.
+	SumFrom    int64     // Минимальная сумма (в копейках базовой валюты).
+	SumTo      int64     // Максимальная сумма (в копейках базовой валюты).
+	PeriodFrom time.Time // Начало периода (включительно).
+	PeriodTo   time.Time // Конец периода (не включительно).
+}
+
+// Тип для реквизитов кассового чека (из QR-кода).
+type Receipt struct {
+	Period time.Time // Дата и время покупки (t).
+	Sum    int64     // Сумма чека в копейках (s).
+	FN     string    // Номер фискального накопителя (fn).
+	FD     string    // Номер фискального документа (i).
+	FP     string    // Фискальный признак документа (fp).
 }
 
 // Тип для записей отчета.
 type UserDataReportRecord struct {
-	Category string  // Категория.
-	Sum      float64 // Сумма расходов по категории.
+	Category string // Категория.
+	Sum      int64  // Сумма расходов по категории (в минорных единицах валюты).
+}
+
+// Типы действий пользователя, которые можно отменить.
+const (
+	UserActionRecord   = "record"   // Добавление записи о расходах.
+	UserActionCategory = "category" // Добавление категории.
+	UserActionLimit    = "limit"    // Изменение бюджета.
+	UserActionCurrency = "currency" // Изменение валюты.
+)
+
+// Тип для записи журнала действий пользователя.
+type UserAction struct {
+	Action    string    // Тип действия (UserActionRecord, UserActionCategory...).
+	ObjectID  int64     // Идентификатор добавленной записи или категории.
+	PrevValue string    // Предыдущее значение бюджета или валюты.
+	CreatedAt time.Time // Время действия.
+}
+
+// Тип для удаленной записи о расходах или категории в корзине.
+type TrashItem struct {
+	Kind      string    // Тип объекта (UserActionRecord - запись о расходах, UserActionCategory - категория).
+	ID        int64     // Идентификатор записи или категории.
+	Category  string    // Название категории (для записи - категории записи).
+	Sum       int64     // Сумма записи (для категории - сумма записей, удаленных вместе с ней) в копейках базовой валюты.
+	Records   int64     // Для категории - количество записей, удаленных вместе с ней.
+	Period    time.Time // Дата расхода (для записи).
+	Note      string    // Комментарий к записи.
+	DeletedAt time.Time // Время удаления.
+}
+
+// Типы объектов журнала изменений данных пользователя.
+const (
+	AuditEntityRecord   = "record"   // Запись о расходах.
+	AuditEntityCategory = "category" // Категория.
+	AuditEntitySettings = "settings" // Настройки пользователя (валюта, бюджет, часовой пояс).
+)
+
+// Операции журнала изменений данных пользователя.
+const (
+	AuditOpInsert  = "insert"  // Добавление.
+	AuditOpUpdate  = "update"  // Изменение.
+	AuditOpDelete  = "delete"  // Удаление (в том числе перенос в корзину).
+	AuditOpRestore = "restore" // Восстановление из корзины.
+)
+
+// Тип для записи журнала изменений данных пользователя.
+type AuditRecord struct {
+	ActorTgID int64          // ТГ-идентификатор пользователя, совершившего изменение (0 - неизвестен).
+	Entity    string         // Тип объекта (AuditEntityRecord, AuditEntityCategory, AuditEntitySettings).
+	ObjectID  int64          // Идентификатор записи, категории или пользователя (для настроек).
+	Operation string         // Операция (AuditOpInsert, AuditOpUpdate...).
+	OldValue  map[string]any // Значения до изменения (nil при добавлении).
+	NewValue  map[string]any // Значения после изменения (nil при удалении без корзины).
+	CreatedAt time.Time      // Время изменения.
+}
+
+// Тип для цели накоплений.
+type Goal struct {
+	ID       int64
+	Name     string    // Название цели.
+	Currency string    // Валюта цели.
+	Target   int64     // Целевая сумма (в копейках валюты цели).
+	Saved    int64     // Накопленная сумма (в копейках валюты цели).
+	Deadline time.Time // Срок достижения цели.
+}
+
+// Виды подписок на регулярные отчеты.
+const (
+	SubscriptionWeekly  = "w" // Еженедельный отчет (по понедельникам), ключ отчета - как у /report_w.
+	SubscriptionMonthly = "m" // Ежемесячный отчет (1-го числа), ключ отчета - ReportPreviousMonth.
+)
+
+// ReportPreviousMonth Ключ отчета за прошлый календарный месяц в часовом поясе пользователя (ежемесячный регулярный отчет).
+const ReportPreviousMonth = "pm"
+
+// Тип для подписки пользователя на регулярный отчет.
+type Subscription struct {
+	ID         int64
+	UserID     int64     // Идентификатор пользователя в телеграме.
+	Kind       string    // Вид подписки (SubscriptionWeekly, SubscriptionMonthly).
+	LastSentAt time.Time // Время последней отправки отчета.
+	Timezone   string    // Часовой пояс пользователя.
+}
+
+// Тип для выгрузки всех данных пользователя (суммы - в копейках указанной валюты).
+type UserExport struct {
+	ExportedAt    time.Time          `json:"exported_at"`
+	BaseCurrency  string             `json:"base_currency"` // Валюта хранения сумм записей и бюджета.
+	Profile       UserExportProfile  `json:"profile"`
+	Categories    []string           `json:"categories"`
+	Records       []UserExportRecord `json:"records"`
+	Goals         []UserExportGoal   `json:"goals"`
+	Subscriptions []string           `json:"subscriptions"` // Виды подписок на регулярные отчеты.
+}
+
+// Тип для профиля и настроек пользователя в выгрузке.
+type UserExportProfile struct {
+	TgID     int64  `json:"tg_id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"` // Выбранная валюта.
+	Limits   int64  `json:"limits"`   // Бюджет на месяц (в базовой валюте, 0 - не установлен).
+	Timezone string `json:"timezone"` // Часовой пояс (пустая строка - по умолчанию).
+}
+
+// Тип для записи о расходах в выгрузке.
+type UserExportRecord struct {
+	Category  string    `json:"category"`
+	Sum       int64     `json:"sum"` // В базовой валюте.
+	Period    time.Time `json:"period"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Тип для цели накоплений в выгрузке.
+type UserExportGoal struct {
+	Name     string    `json:"name"`
+	Currency string    `json:"currency"`
+	Target   int64     `json:"target"` // В валюте цели.
+	Saved    int64     `json:"saved"`  // В валюте цели.
+	Deadline time.Time `json:"deadline"`
+}
+
+// Тип для сведений о доступе пользователя к боту.
+type UserAccess struct {
+	Exists  bool // Пользователь зарегистрирован (есть в базе данных).
+	Blocked bool // Пользователь заблокирован администратором.
+}
+
+// Тип для статистики использования бота (для администраторов).
+type AdminStats struct {
+	UsersTotal    int64           // Всего пользователей.
+	DAU           int64           // Пользователей, вносивших расходы за последние сутки.
+	MAU           int64           // Пользователей, вносивших расходы за последние 30 дней.
+	RecordsPerDay []DayCount      // Количество введенных записей по дням.
+	TopCurrencies []CurrencyCount // Самые популярные валюты пользователей.
+}
+
+// Тип для количества за день.
+type DayCount struct {
+	Day   time.Time
+	Count int64
+}
+
+// Тип для количества пользователей валюты.
+type CurrencyCount struct {
+	Currency string
+	Users    int64
+}
+
+// Тип для сведений о пользователе (для администраторов).
+type UserInfo struct {
+	TgID         int64
+	Name         string
+	Currency     string
+	Limits       int64     // Бюджет (в копейках базовой валюты).
+	Timezone     string    // Часовой пояс (пустая строка - по умолчанию).
+	Categories   int64     // Количество категорий.
+	Records      int64     // Количество записей о расходах.
+	TotalSum     int64     // Сумма всех расходов (в копейках базовой валюты).
+	LastRecordAt time.Time // Время ввода последней записи (нулевое, если записей нет).
 }
 
 // Типы для описания состава кнопок телеграм сообщения.
@@ -33,5 +259,19 @@ type TgInlineButton struct {
 // Строка с кнопками сообщения.
 type TgRowButtons []TgInlineButton
 
-// Тип для хранения курса валюты в формате "USD" = 0.01659657
-type ExchangeRate map[string]float64
+// Команда меню бота.
+type TgBotCommand struct {
+	Command     string // Команда без "/".
+	Description string // Описание команды.
+}
+
+// Результат инлайн-запроса.
+type TgInlineResult struct {
+	ID          string // Уникальный идентификатор результата.
+	Title       string // Заголовок результата в списке.
+	Description string // Краткое описание результата в списке.
+	Text        string // Текст сообщения, отправляемого в чат при выборе результата.
+}
+
+// Тип для хранения курса валюты в формате "USD" = 0.01659657 (количество единиц валюты за единицу основной валюты).
+type ExchangeRate map[string]money.Rate
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func Test_UserStorage_RedeemInviteCode(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "Код действителен - пользователь добавляется",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE invitecodes SET used_by = $2, used_at = now()")).
+					WithArgs("a1b2c3", 15236).WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs(15236, "test user name", "RUB", 10000).WillReturnResult(sqlxmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "Код уже использован - пользователь не добавляется",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE invitecodes SET used_by = $2, used_at = now()")).
+					WithArgs("a1b2c3", 15236).WillReturnResult(sqlxmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: false,
+		},
+		{
+			name: "Повтор транзакции - результат первой попытки не сохраняется",
+			mock: func() {
+				// Первая попытка использует код, но прерывается конфликтом сериализации,
+				// при повторе код уже использован другим пользователем.
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE invitecodes SET used_by = $2, used_at = now()")).
+					WithArgs("a1b2c3", 15236).WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs(15236, "test user name", "RUB", 10000).WillReturnError(&pgconn.PgError{Code: "40001"})
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE invitecodes SET used_by = $2, used_at = now()")).
+					WithArgs("a1b2c3", 15236).WillReturnResult(sqlxmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.RedeemInviteCode(ctx, "a1b2c3", 15236, "test user name")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Не совпало ожидание ошибки: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Не совпал результат: got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Не выполнены ожидания: %v", err)
+			}
+		})
+	}
+}
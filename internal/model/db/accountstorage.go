@@ -0,0 +1,162 @@
+package db
+
+// Выгрузка всех данных пользователя и удаление аккаунта по запросу пользователя.
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// UserExportProfileDB Тип, принимающий профиль пользователя.
+type UserExportProfileDB struct {
+	TgID     int64  `db:"tg_id"`
+	Name     string `db:"name"`
+	Currency string `db:"currency"`
+	Limits   int64  `db:"limits"`
+	Timezone string `db:"timezone"`
+}
+
+// UserExportRecordDB Тип, принимающий запись о расходах для выгрузки.
+type UserExportRecordDB struct {
+	Category  string    `db:"name"`
+	Sum       int64     `db:"sum"`
+	Period    time.Time `db:"period"`
+	Note      string    `db:"note"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// GetUserExport Получение всех данных пользователя для выгрузки.
+// Если пользователь не найден, возвращается выгрузка с пустым профилем (TgID == 0).
+func (storage *UserStorage) GetUserExport(ctx context.Context, userID int64) (types.UserExport, error) {
+	const sqlProfile = `
+		SELECT tg_id, name, currency, limits, timezone
+		FROM users
+		WHERE tg_id = $1;`
+
+	const sqlRecords = `
+		SELECT c.name, r.sum, r.period, r.note, r.created_at
+		FROM usermoneytransactions AS r
+			INNER JOIN usercategories AS c
+				ON r.category_id = c.id
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.deleted_at IS NULL
+		ORDER BY r.period, r.id;`
+
+	export := types.UserExport{
+		ExportedAt:   time.Now(),
+		BaseCurrency: storage.defaultCurrency,
+	}
+
+	var profiles []UserExportProfileDB
+	if err := dbutils.Select(ctx, storage.db, &profiles, sqlProfile, userID); err != nil {
+		return export, errors.Wrap(err, "Get user export error")
+	}
+	if len(profiles) == 0 {
+		return export, nil
+	}
+	export.Profile = types.UserExportProfile(profiles[0])
+
+	var records []UserExportRecordDB
+	if err := dbutils.Select(ctx, storage.db, &records, sqlRecords, userID); err != nil {
+		return export, errors.Wrap(err, "Get user export error")
+	}
+	export.Records = make([]types.UserExportRecord, len(records))
+	for ind, rec := range records {
+		export.Records[ind] = types.UserExportRecord(rec)
+	}
+
+	categories, err := storage.GetUserCategory(ctx, userID)
+	if err != nil {
+		return export, errors.Wrap(err, "Get user export error")
+	}
+	export.Categories = categories
+
+	goals, err := storage.GetUserGoals(ctx, userID)
+	if err != nil {
+		return export, errors.Wrap(err, "Get user export error")
+	}
+	export.Goals = make([]types.UserExportGoal, len(goals))
+	for ind, goal := range goals {
+		export.Goals[ind] = types.UserExportGoal{
+			Name:     goal.Name,
+			Currency: goal.Currency,
+			Target:   goal.Target,
+			Saved:    goal.Saved,
+			Deadline: goal.Deadline,
+		}
+	}
+
+	subscriptions, err := storage.GetUserSubscriptions(ctx, userID)
+	if err != nil {
+		return export, errors.Wrap(err, "Get user export error")
+	}
+	export.Subscriptions = subscriptions
+
+	return export, nil
+}
+
+// deletedUserDB Тип, принимающий идентификатор и количество записей удаляемого пользователя.
+type deletedUserDB struct {
+	ID      int64 `db:"id"`
+	Records int64 `db:"records"`
+}
+
+// DeleteUser Удаление пользователя и всех его данных (каскадно, вместе с корзиной и журналом изменений)
+// с записью в журнал удалений (в транзакции).
+// Возвращает количество удаленных записей о расходах и false, если пользователь не найден.
+func (storage *UserStorage) DeleteUser(ctx context.Context, userID int64) (int64, bool, error) {
+	const sqlCount = `
+		SELECT u.id, COUNT(r.id) AS records
+		FROM users AS u
+			LEFT JOIN usermoneytransactions AS r
+				ON r.user_id = u.id AND r.deleted_at IS NULL
+		WHERE u.tg_id = $1
+		GROUP BY u.id;`
+
+	const sqlDelete = `DELETE FROM users WHERE id = $1;`
+
+	// Журнал изменений не связан с пользователем внешним ключом и очищается отдельно
+	// (после удаления пользователя, в том числе от записей о каскадном удалении его данных).
+	const sqlDeleteAudit = `DELETE FROM useraudit WHERE user_id = $1;`
+
+	const sqlLog = `
+		INSERT INTO userdeletions (tg_id, records)
+			VALUES ($1, $2);`
+
+	// Итоги удаления - по последней попытке транзакции (RunTx повторяет ее при временных ошибках).
+	var records int64
+	var isDeleted bool
+	err := dbutils.RunTx(ctx, storage.db,
+		func(tx *sqlx.Tx) error {
+			records, isDeleted = 0, false
+			var users []deletedUserDB
+			if err := dbutils.Select(ctx, tx, &users, sqlCount, userID); err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				return nil
+			}
+			// Связанные данные удаляются каскадно.
+			if _, err := dbutils.Exec(ctx, tx, sqlDelete, users[0].ID); err != nil {
+				return err
+			}
+			if _, err := dbutils.Exec(ctx, tx, sqlDeleteAudit, users[0].ID); err != nil {
+				return err
+			}
+			if _, err := dbutils.Exec(ctx, tx, sqlLog, userID, users[0].Records); err != nil {
+				return err
+			}
+			records, isDeleted = users[0].Records, true
+			return nil
+		})
+	if err != nil {
+		return 0, false, errors.Wrap(err, "Delete user error")
+	}
+	return records, isDeleted, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func Test_UserStorage_DeleteUser(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT u.id, COUNT").WithArgs(15236).
+		WillReturnRows(sqlxmock.NewRows([]string{"id", "records"}).AddRow(int64(3), int64(7)))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = $1;")).WithArgs(int64(3)).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM useraudit WHERE user_id = $1;")).WithArgs(int64(3)).
+		WillReturnResult(sqlxmock.NewResult(0, 5))
+	mock.ExpectExec("INSERT INTO userdeletions").WithArgs(15236, int64(7)).
+		WillReturnResult(sqlxmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	records, isDeleted, err := s.DeleteUser(ctx, 15236)
+	if err != nil {
+		t.Fatalf("Ошибка удаления пользователя: %v", err)
+	}
+	if !isDeleted || records != 7 {
+		t.Errorf("Не совпал результат: isDeleted = %v, records = %v", isDeleted, records)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Не выполнены ожидания: %v", err)
+	}
+}
+
+func Test_UserStorage_DeleteUser_NotFound(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT u.id, COUNT").WithArgs(15236).
+		WillReturnRows(sqlxmock.NewRows([]string{"id", "records"}))
+	mock.ExpectCommit()
+
+	_, isDeleted, err := s.DeleteUser(ctx, 15236)
+	if err != nil || isDeleted {
+		t.Errorf("Не совпал результат: isDeleted = %v, err = %v", isDeleted, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Не выполнены ожидания: %v", err)
+	}
+}
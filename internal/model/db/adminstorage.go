@@ -0,0 +1,171 @@
+package db
+
+// Статистика использования бота и журнал действий администраторов.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// DayCountDB Тип, принимающий количество записей за день.
+type DayCountDB struct {
+	Day   time.Time `db:"day"`
+	Count int64     `db:"count"`
+}
+
+// CurrencyCountDB Тип, принимающий количество пользователей валюты.
+type CurrencyCountDB struct {
+	Currency string `db:"currency"`
+	Users    int64  `db:"users"`
+}
+
+// UserInfoDB Тип, принимающий сведения о пользователе.
+type UserInfoDB struct {
+	TgID         int64        `db:"tg_id"`
+	Name         string       `db:"name"`
+	Currency     string       `db:"currency"`
+	Limits       int64        `db:"limits"`
+	Timezone     string       `db:"timezone"`
+	Categories   int64        `db:"categories"`
+	Records      int64        `db:"records"`
+	TotalSum     int64        `db:"total_sum"`
+	LastRecordAt sql.NullTime `db:"last_record_at"`
+}
+
+// GetAdminStats Получение статистики использования бота.
+// Записи по дням считаются за указанное количество последних дней (в часовом поясе по умолчанию).
+func (storage *UserStorage) GetAdminStats(ctx context.Context, days int) (types.AdminStats, error) {
+	// Количество пользователей и активных пользователей (обращавшихся к боту).
+	const sqlUsers = `
+		SELECT COUNT(id) AS users_total,
+			   COUNT(id) FILTER (WHERE last_seen_at >= now() - interval '1 day') AS dau,
+			   COUNT(id) FILTER (WHERE last_seen_at >= now() - interval '30 days') AS mau
+		FROM users;`
+
+	res, err := dbutils.GetMap(ctx, storage.db, sqlUsers)
+	if err != nil {
+		return types.AdminStats{}, errors.Wrap(err, "Get admin stats error")
+	}
+	// Приведение результата запроса к нужному типу.
+	usersTotal, ok1 := res["users_total"].(int64)
+	dau, ok2 := res["dau"].(int64)
+	mau, ok3 := res["mau"].(int64)
+	if !ok1 || !ok2 || !ok3 {
+		return types.AdminStats{}, errors.New("Ошибка приведения типа результата запроса.")
+	}
+
+	// Количество введенных записей по дням.
+	const sqlRecords = `
+		SELECT date_trunc('day', created_at AT TIME ZONE $1) AS day, COUNT(id) AS count
+		FROM usermoneytransactions
+		WHERE created_at >= $2
+		GROUP BY day
+		ORDER BY day;`
+
+	loc := timeutils.LoadLocation(storage.defaultTimezone, time.Local)
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, loc)
+
+	var dayCounts []DayCountDB
+	if err := dbutils.Select(ctx, storage.db, &dayCounts, sqlRecords, storage.defaultTimezone, since); err != nil {
+		return types.AdminStats{}, errors.Wrap(err, "Get admin stats error")
+	}
+
+	// Самые популярные валюты пользователей.
+	const sqlCurrencies = `
+		SELECT currency, COUNT(id) AS users
+		FROM users
+		GROUP BY currency
+		ORDER BY users DESC, currency
+		LIMIT 5;`
+
+	var currencies []CurrencyCountDB
+	if err := dbutils.Select(ctx, storage.db, &currencies, sqlCurrencies); err != nil {
+		return types.AdminStats{}, errors.Wrap(err, "Get admin stats error")
+	}
+
+	stats := types.AdminStats{
+		UsersTotal:    usersTotal,
+		DAU:           dau,
+		MAU:           mau,
+		RecordsPerDay: make([]types.DayCount, len(dayCounts)),
+		TopCurrencies: make([]types.CurrencyCount, len(currencies)),
+	}
+	for ind, rec := range dayCounts {
+		stats.RecordsPerDay[ind] = types.DayCount(rec)
+	}
+	for ind, rec := range currencies {
+		stats.TopCurrencies[ind] = types.CurrencyCount(rec)
+	}
+	return stats, nil
+}
+
+// SetUserLastSeen Сохранение времени последнего обращения пользователя к боту (для статистики активности).
+// Незарегистрированные пользователи не добавляются (возвращается false).
+func (storage *UserStorage) SetUserLastSeen(ctx context.Context, userID int64, seenAt time.Time) (bool, error) {
+	const sqlString = `UPDATE users SET last_seen_at = $2 WHERE tg_id = $1;`
+
+	res, err := dbutils.Exec(ctx, storage.db, sqlString, userID, seenAt)
+	if err != nil {
+		return false, errors.Wrap(err, "Set user last seen error")
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Set user last seen error")
+	}
+	return updated > 0, nil
+}
+
+// GetUserInfo Получение сведений о пользователе по ТГ-идентификатору.
+// Если пользователь не найден, возвращается пустая структура (TgID == 0).
+func (storage *UserStorage) GetUserInfo(ctx context.Context, userID int64) (types.UserInfo, error) {
+	const sqlString = `
+		SELECT u.tg_id, u.name, u.currency, u.limits, u.timezone,
+			   (SELECT COUNT(c.id) FROM usercategories AS c WHERE c.user_id = u.id AND c.deleted_at IS NULL) AS categories,
+			   COUNT(r.id) AS records,
+			   COALESCE(SUM(r.sum), 0) AS total_sum,
+			   MAX(r.created_at) AS last_record_at
+		FROM users AS u
+			LEFT JOIN usermoneytransactions AS r
+				ON r.user_id = u.id AND r.deleted_at IS NULL
+		WHERE u.tg_id = $1
+		GROUP BY u.id;`
+
+	var recs []UserInfoDB
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID); err != nil {
+		return types.UserInfo{}, errors.Wrap(err, "Get user info error")
+	}
+	if len(recs) == 0 {
+		return types.UserInfo{}, nil
+	}
+	rec := recs[0]
+	return types.UserInfo{
+		TgID:         rec.TgID,
+		Name:         rec.Name,
+		Currency:     rec.Currency,
+		Limits:       rec.Limits,
+		Timezone:     rec.Timezone,
+		Categories:   rec.Categories,
+		Records:      rec.Records,
+		TotalSum:     rec.TotalSum,
+		LastRecordAt: rec.LastRecordAt.Time,
+	}, nil
+}
+
+// InsertAdminAudit Запись действия администратора в журнал.
+func (storage *UserStorage) InsertAdminAudit(ctx context.Context, adminID int64, command string, args string) error {
+	const sqlString = `
+		INSERT INTO adminaudit (admin_tg_id, command, args)
+			VALUES ($1, $2, $3);`
+
+	if _, err := dbutils.Exec(ctx, storage.db, sqlString, adminID, command, args); err != nil {
+		return errors.Wrap(err, "Insert admin audit error")
+	}
+	return nil
+}
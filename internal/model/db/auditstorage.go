@@ -0,0 +1,111 @@
+package db
+
+// Журнал изменений записей о расходах, категорий и настроек пользователя.
+// Журнал заполняется триггерами базы данных (см. миграцию 20221031120000_add_soft_delete_and_useraudit.sql).
+// Пользователь, совершивший изменение, передается триггерам через настройку транзакции app.actor_tg_id
+// (см. миграцию 20221103120000_useraudit_actor_setting.sql).
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// AuditRecordDB Тип, принимающий запись журнала изменений.
+type AuditRecordDB struct {
+	ActorTgID sql.NullInt64  `db:"actor_tg_id"`
+	Entity    string         `db:"entity"`
+	ObjectID  int64          `db:"object_id"`
+	Operation string         `db:"operation"`
+	OldValue  sql.NullString `db:"old_value"`
+	NewValue  sql.NullString `db:"new_value"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// sqlSetActor Передача триггерам журнала изменений пользователя, совершающего изменение (до конца транзакции).
+const sqlSetActor = `SELECT set_config('app.actor_tg_id', $1, true);`
+
+// runTx Запуск транзакции, изменения в которой записываются в журнал от имени пользователя из контекста
+// (dbutils.ContextWithActor). Без пользователя в контексте поле actor_tg_id журнала остается пустым.
+func (storage *UserStorage) runTx(ctx context.Context, f dbutils.TxFunc) error {
+	return dbutils.RunTx(ctx, storage.db,
+		func(tx *sqlx.Tx) error {
+			if actorTgID, ok := dbutils.ActorFromContext(ctx); ok {
+				if _, err := dbutils.Exec(ctx, tx, sqlSetActor, strconv.FormatInt(actorTgID, 10)); err != nil {
+					return err
+				}
+			}
+			return f(tx)
+		})
+}
+
+// exec Выполнение запроса, изменяющего данные из журнала изменений. Если в контексте задан пользователь,
+// запрос выполняется в транзакции (см. runTx), иначе - без транзакции.
+func (storage *UserStorage) exec(ctx context.Context, query string, args ...any) error {
+	if _, ok := dbutils.ActorFromContext(ctx); !ok {
+		_, err := dbutils.Exec(ctx, storage.db, query, args...)
+		return err
+	}
+	return storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			_, err := dbutils.Exec(ctx, tx, query, args...)
+			return err
+		})
+}
+
+// GetUserAudit Получение последних изменений данных пользователя (новые - первыми).
+func (storage *UserStorage) GetUserAudit(ctx context.Context, userID int64, limit int) ([]types.AuditRecord, error) {
+	const sqlString = `
+		SELECT a.actor_tg_id, a.entity, a.object_id, a.operation, a.old_value, a.new_value, a.created_at
+		FROM useraudit AS a
+			INNER JOIN users AS u
+				ON a.user_id = u.id
+		WHERE u.tg_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $2;`
+
+	var recs []AuditRecordDB
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, limit); err != nil {
+		return nil, errors.Wrap(err, "Get user audit error")
+	}
+	result := make([]types.AuditRecord, len(recs))
+	for ind, rec := range recs {
+		oldValue, err := parseAuditValue(rec.OldValue)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := parseAuditValue(rec.NewValue)
+		if err != nil {
+			return nil, err
+		}
+		result[ind] = types.AuditRecord{
+			ActorTgID: rec.ActorTgID.Int64,
+			Entity:    rec.Entity,
+			ObjectID:  rec.ObjectID,
+			Operation: rec.Operation,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			CreatedAt: rec.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+// parseAuditValue Разбор значений из журнала изменений (JSON-объект, null - значений нет).
+func parseAuditValue(value sql.NullString) (map[string]any, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	var result map[string]any
+	if err := json.Unmarshal([]byte(value.String), &result); err != nil {
+		return nil, errors.Wrap(err, "Parse audit value error")
+	}
+	return result, nil
+}
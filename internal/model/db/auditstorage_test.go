@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+)
+
+func Test_UserStorage_InsertUser_ShouldSetAuditActor(t *testing.T) {
+	ctx := dbutils.ContextWithActor(context.Background(), 15236)
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT set_config('app.actor_tg_id', $1, true);")).
+		WithArgs("15236").
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(15236, "test user name", "RUB", 10000).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, s.InsertUser(ctx, 15236, "test user name"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_UserStorage_InsertUser_ShouldNotSetAuditActor_WithoutUser(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(15236, "test user name", "RUB", 10000).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+
+	require.NoError(t, s.InsertUser(context.Background(), 15236, "test user name"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/storagetest"
+	rates "github.com/ellavs/tg-bot-golang/internal/model/exchangerates"
+)
+
+// newTestDB Подключение к тестовой базе данных Postgres (строка подключения - в TEST_DB_CONN)
+// с очисткой всех таблиц. Все данные в тестовой базе удаляются.
+func newTestDB(t testing.TB) *sqlx.DB {
+	connString := os.Getenv("TEST_DB_CONN")
+	if connString == "" {
+		t.Skip("TEST_DB_CONN не задана, тесты с базой данных Postgres пропущены")
+	}
+	db, err := dbutils.NewDBConnect(connString, dbutils.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`TRUNCATE users, usercategories, usermoneytransactions, exchangerates, useractions, userreceipts,
+		usergoals, usersubscriptions, adminaudit, invitecodes, blockedusers, userdeletions, useraudit, usermonthlyrollups RESTART IDENTITY CASCADE;`)
+	require.NoError(t, err)
+	return db
+}
+
+func Test_UserStorage_Contract(t *testing.T) {
+	storagetest.RunUserStorageTests(t, func(t *testing.T) storagetest.UserStorage {
+		return NewUserStorage(newTestDB(t), storagetest.DefaultCurrency, storagetest.DefaultLimits, storagetest.DefaultTimezone)
+	})
+}
+
+func Test_ExchangeRatesStorage_Contract(t *testing.T) {
+	storagetest.RunRatesStorageTests(t, func(t *testing.T) rates.RatesDataStorage {
+		return NewExchangeRatesStorage(newTestDB(t), storagetest.Currencies)
+	})
+}
@@ -0,0 +1,100 @@
+package db
+
+// Работа с хранилищем целей накоплений пользователей.
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// GoalDB Тип, принимающий структуру таблицы целей накоплений.
+type GoalDB struct {
+	ID       int64     `db:"id"`
+	Name     string    `db:"name"`
+	Currency string    `db:"currency"`
+	Target   int64     `db:"target"`
+	Saved    int64     `db:"saved"`
+	Deadline time.Time `db:"deadline"`
+}
+
+// InsertGoal Добавление цели накоплений пользователя.
+func (storage *UserStorage) InsertGoal(ctx context.Context, userID int64, goal types.Goal, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+	// Запрос на добавление данных.
+	const sqlString = `
+		INSERT INTO usergoals (user_id, name, currency, target, deadline)
+			(SELECT id, $2, $3, $4, $5 FROM users WHERE users.tg_id = $1);`
+
+	// Выполнение запроса на добавление данных.
+	if _, err := dbutils.Exec(ctx, storage.db, sqlString, userID, goal.Name, goal.Currency, goal.Target, goal.Deadline); err != nil {
+		return errors.Wrap(err, "Insert goal error")
+	}
+	return nil
+}
+
+// GetUserGoals Получение списка целей накоплений пользователя.
+func (storage *UserStorage) GetUserGoals(ctx context.Context, userID int64) ([]types.Goal, error) {
+	// Отбор целей по пользователю (в порядке срока достижения).
+	const sqlString = `
+		SELECT g.id, g.name, g.currency, g.target, g.saved, g.deadline
+		FROM usergoals AS g
+			INNER JOIN users AS u
+				ON g.user_id = u.id
+		WHERE u.tg_id = $1
+		ORDER BY g.deadline, g.id;`
+
+	var recs []GoalDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID); err != nil {
+		return nil, errors.Wrap(err, "Get user goals error")
+	}
+	result := make([]types.Goal, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.Goal(rec)
+	}
+	return result, nil
+}
+
+// AddGoalContribution Пополнение цели накоплений (сумма в копейках валюты цели).
+// Возвращает цель с обновленной накопленной суммой.
+func (storage *UserStorage) AddGoalContribution(ctx context.Context, userID int64, goalID int64, sum int64) (types.Goal, error) {
+	// Запрос на обновление данных (только цели указанного пользователя).
+	const sqlString = `
+		UPDATE usergoals AS g SET saved = g.saved + $3
+		FROM users AS u
+		WHERE g.user_id = u.id AND u.tg_id = $1 AND g.id = $2
+		RETURNING g.id, g.name, g.currency, g.target, g.saved, g.deadline;`
+
+	var recs []GoalDB
+	// Выполнение запроса на обновление с получением обновленной записи.
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, goalID, sum); err != nil {
+		return types.Goal{}, errors.Wrap(err, "Add goal contribution error")
+	}
+	if len(recs) == 0 {
+		return types.Goal{}, errors.New("Цель не найдена.")
+	}
+	return types.Goal(recs[0]), nil
+}
+
+// DeleteGoal Удаление цели накоплений пользователя.
+func (storage *UserStorage) DeleteGoal(ctx context.Context, userID int64, goalID int64) error {
+	// Запрос на удаление данных (только цели указанного пользователя).
+	const sqlString = `
+		DELETE FROM usergoals AS g
+		USING users AS u
+		WHERE g.user_id = u.id AND u.tg_id = $1 AND g.id = $2;`
+
+	// Выполнение запроса на удаление данных.
+	if _, err := dbutils.Exec(ctx, storage.db, sqlString, userID, goalID); err != nil {
+		return errors.Wrap(err, "Delete goal error")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_UserStorage_InsertUserDataRecords(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	march := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	recs := []types.UserDataRecord{
+		{Category: "Кино", Sum: 60000, Period: march},
+		{Category: "такси", Sum: 50000, Period: march},
+		{Category: "Такси", Sum: 30000, Period: march, Note: "вокзал"},
+	}
+
+	// Проверка пользователя и бюджета по месяцам записей (расходы месяца запрашиваются из итогов один раз).
+	expectLimitCheck := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(id) AS countusers FROM users WHERE tg_id = $1;")).
+			WithArgs(15236).
+			WillReturnRows(sqlxmock.NewRows([]string{"countusers"}).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, limits FROM users WHERE tg_id = $1 FOR UPDATE;")).
+			WithArgs(15236).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "limits"}).AddRow(7, 100000))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM usermonthlyrollups WHERE user_id = $1 AND month = $2;")).
+			WithArgs(7, "2024-03-01").
+			WillReturnRows(sqlxmock.NewRows([]string{"sum"}).AddRow(10000))
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		mock    func()
+		want    types.UserDataImportResult
+		wantErr bool
+	}{
+		{
+			name: "Все или ничего: таблица с превышением бюджета не сохраняется",
+			mode: types.ImportAllOrNothing,
+			mock: func() {
+				expectLimitCheck()
+				mock.ExpectCommit()
+			},
+			want: types.UserDataImportResult{OverLimit: []int{1}},
+		},
+		{
+			name: "Строки без ошибок: категории и записи добавляются многострочными запросами",
+			mode: types.ImportBestEffort,
+			mock: func() {
+				expectLimitCheck()
+				mock.ExpectExec(regexp.QuoteMeta("VALUES ($1, $2), ($3, $4)")).
+					WithArgs(7, "Кино", 7, "Такси").
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM usercategories WHERE user_id = $1 AND deleted_at IS NULL;")).
+					WithArgs(7).
+					WillReturnRows(sqlxmock.NewRows([]string{"id", "name"}).AddRow(1, "Кино").AddRow(2, "Такси"))
+				mock.ExpectExec(regexp.QuoteMeta("VALUES ($1, $2, $3, $4, $5), ($6, $7, $8, $9, $10)")).
+					WithArgs(7, 1, 60000, march, "", 7, 2, 30000, march, "вокзал", "Europe/Moscow").
+					WillReturnResult(sqlxmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			want: types.UserDataImportResult{Inserted: 2, OverLimit: []int{1}},
+		},
+		{
+			name: "Ошибка вставки откатывает транзакцию",
+			mode: types.ImportBestEffort,
+			mock: func() {
+				expectLimitCheck()
+				mock.ExpectExec(regexp.QuoteMeta("VALUES ($1, $2), ($3, $4)")).
+					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.InsertUserDataRecords(ctx, 15236, recs, "test", tt.mode)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
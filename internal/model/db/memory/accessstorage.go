@@ -0,0 +1,70 @@
+package memory
+
+// Доступ к боту: коды приглашений и блокировка пользователей.
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// inviteCode Код приглашения.
+type inviteCode struct {
+	createdBy int64
+	createdAt time.Time
+	usedBy    int64 // 0 - код еще не использован.
+	usedAt    time.Time
+}
+
+// GetUserAccess Получение сведений о доступе пользователя (зарегистрирован ли и не заблокирован ли).
+func (storage *UserStorage) GetUserAccess(ctx context.Context, userID int64) (types.UserAccess, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	_, exists := storage.users[userID]
+	_, blocked := storage.blocked[userID]
+	return types.UserAccess{Exists: exists, Blocked: blocked}, nil
+}
+
+// InsertInviteCode Сохранение нового кода приглашения.
+func (storage *UserStorage) InsertInviteCode(ctx context.Context, code string, adminID int64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.inviteCodes[code]; ok {
+		return errors.Wrap(errors.New("Код приглашения уже существует."), "Insert invite code error")
+	}
+	storage.inviteCodes[code] = &inviteCode{createdBy: adminID, createdAt: time.Now()}
+	return nil
+}
+
+// RedeemInviteCode Использование кода приглашения и регистрация пользователя.
+// Возвращает false, если код не найден или уже использован.
+func (storage *UserStorage) RedeemInviteCode(ctx context.Context, code string, userID int64, userName string) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	// Код можно использовать только один раз.
+	invite, ok := storage.inviteCodes[code]
+	if !ok || invite.usedBy != 0 {
+		return false, nil
+	}
+	invite.usedBy, invite.usedAt = userID, time.Now()
+	storage.userOrAdd(ctx, userID, userName)
+	return true, nil
+}
+
+// SetUserBlocked Блокировка или разблокировка пользователя администратором.
+func (storage *UserStorage) SetUserBlocked(ctx context.Context, userID int64, blocked bool, adminID int64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if !blocked {
+		delete(storage.blocked, userID)
+	} else if _, ok := storage.blocked[userID]; !ok {
+		storage.blocked[userID] = adminID
+	}
+	return nil
+}
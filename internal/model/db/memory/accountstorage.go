@@ -0,0 +1,92 @@
+package memory
+
+// Выгрузка всех данных пользователя и удаление аккаунта по запросу пользователя.
+
+import (
+	"context"
+	"time"
+
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// userDeletion Запись журнала удалений пользователей.
+type userDeletion struct {
+	tgID      int64
+	records   int64
+	deletedAt time.Time
+}
+
+// GetUserExport Получение всех данных пользователя для выгрузки.
+// Если пользователь не найден, возвращается выгрузка с пустым профилем (TgID == 0).
+func (storage *UserStorage) GetUserExport(ctx context.Context, userID int64) (types.UserExport, error) {
+	export := types.UserExport{
+		ExportedAt:   time.Now(),
+		BaseCurrency: storage.defaultCurrency,
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return export, nil
+	}
+	export.Profile = types.UserExportProfile{
+		TgID:     u.tgID,
+		Name:     u.name,
+		Currency: u.currency,
+		Limits:   u.limits,
+		Timezone: u.timezone,
+	}
+
+	// Записи из корзины не выгружаются.
+	records := filter(u.records, func(rec record) bool { return !rec.isDeleted() })
+	sortBy(records, func(a, b record) bool {
+		if !a.period.Equal(b.period) {
+			return a.period.Before(b.period)
+		}
+		return a.id < b.id
+	})
+	export.Records = make([]types.UserExportRecord, len(records))
+	for ind, rec := range records {
+		export.Records[ind] = types.UserExportRecord{
+			Category:  u.categoryName(rec.categoryID),
+			Sum:       rec.sum,
+			Period:    rec.period,
+			Note:      rec.note,
+			CreatedAt: rec.createdAt,
+		}
+	}
+
+	export.Categories = u.categoryNames()
+	goals := u.sortedGoals()
+	export.Goals = make([]types.UserExportGoal, len(goals))
+	for ind, goal := range goals {
+		export.Goals[ind] = types.UserExportGoal{
+			Name:     goal.Name,
+			Currency: goal.Currency,
+			Target:   goal.Target,
+			Saved:    goal.Saved,
+			Deadline: goal.Deadline,
+		}
+	}
+	export.Subscriptions = u.subscriptionKinds()
+
+	return export, nil
+}
+
+// DeleteUser Удаление пользователя и всех его данных с записью в журнал удалений.
+// Возвращает количество удаленных записей о расходах и false, если пользователь не найден.
+func (storage *UserStorage) DeleteUser(ctx context.Context, userID int64) (int64, bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return 0, false, nil
+	}
+	records := int64(len(filter(u.records, func(rec record) bool { return !rec.isDeleted() })))
+	delete(storage.users, userID)
+	storage.deletions = append(storage.deletions, userDeletion{tgID: userID, records: records, deletedAt: time.Now()})
+	return records, true, nil
+}
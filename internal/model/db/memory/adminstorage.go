@@ -0,0 +1,130 @@
+package memory
+
+// Статистика использования бота и журнал действий администраторов.
+
+import (
+	"context"
+	"time"
+
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// adminAudit Запись журнала действий администраторов.
+type adminAudit struct {
+	adminID   int64
+	command   string
+	args      string
+	createdAt time.Time
+}
+
+// GetAdminStats Получение статистики использования бота.
+// Записи по дням считаются за указанное количество последних дней (в часовом поясе по умолчанию).
+func (storage *UserStorage) GetAdminStats(ctx context.Context, days int) (types.AdminStats, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	now := time.Now()
+	dayAgo, monthAgo := now.AddDate(0, 0, -1), now.AddDate(0, 0, -30)
+	loc := timeutils.LoadLocation(storage.defaultTimezone, time.Local)
+	localNow := now.In(loc)
+	since := time.Date(localNow.Year(), localNow.Month(), localNow.Day()-days+1, 0, 0, 0, 0, loc)
+
+	stats := types.AdminStats{
+		UsersTotal:    int64(len(storage.users)),
+		RecordsPerDay: []types.DayCount{},
+		TopCurrencies: []types.CurrencyCount{},
+	}
+	perDay := map[time.Time]int64{}
+	currencies := map[string]int64{}
+	for _, u := range storage.users {
+		currencies[u.currency]++
+		if !u.lastSeenAt.Before(dayAgo) {
+			stats.DAU++
+		}
+		if !u.lastSeenAt.Before(monthAgo) {
+			stats.MAU++
+		}
+		for _, rec := range u.records {
+			if !rec.createdAt.Before(since) {
+				// День ввода записи (дата в часовом поясе по умолчанию, как в хранилищах в базе данных).
+				local := rec.createdAt.In(loc)
+				perDay[time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)]++
+			}
+		}
+	}
+
+	for day, count := range perDay {
+		stats.RecordsPerDay = append(stats.RecordsPerDay, types.DayCount{Day: day, Count: count})
+	}
+	sortBy(stats.RecordsPerDay, func(a, b types.DayCount) bool { return a.Day.Before(b.Day) })
+
+	// Самые популярные валюты пользователей.
+	for currency, users := range currencies {
+		stats.TopCurrencies = append(stats.TopCurrencies, types.CurrencyCount{Currency: currency, Users: users})
+	}
+	sortBy(stats.TopCurrencies, func(a, b types.CurrencyCount) bool {
+		if a.Users != b.Users {
+			return a.Users > b.Users
+		}
+		return a.Currency < b.Currency
+	})
+	if len(stats.TopCurrencies) > 5 {
+		stats.TopCurrencies = stats.TopCurrencies[:5]
+	}
+	return stats, nil
+}
+
+// SetUserLastSeen Сохранение времени последнего обращения пользователя к боту (для статистики активности).
+// Незарегистрированные пользователи не добавляются (возвращается false).
+func (storage *UserStorage) SetUserLastSeen(ctx context.Context, userID int64, seenAt time.Time) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if ok {
+		u.lastSeenAt = seenAt
+	}
+	return ok, nil
+}
+
+// GetUserInfo Получение сведений о пользователе по ТГ-идентификатору.
+// Если пользователь не найден, возвращается пустая структура (TgID == 0).
+func (storage *UserStorage) GetUserInfo(ctx context.Context, userID int64) (types.UserInfo, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return types.UserInfo{}, nil
+	}
+	info := types.UserInfo{
+		TgID:       u.tgID,
+		Name:       u.name,
+		Currency:   u.currency,
+		Limits:     u.limits,
+		Timezone:   u.timezone,
+		Categories: int64(len(u.categoryNames())),
+	}
+	// Записи и категории из корзины не учитываются.
+	for _, rec := range u.records {
+		if rec.isDeleted() {
+			continue
+		}
+		info.Records++
+		info.TotalSum += rec.sum
+		if rec.createdAt.After(info.LastRecordAt) {
+			info.LastRecordAt = rec.createdAt
+		}
+	}
+	return info, nil
+}
+
+// InsertAdminAudit Запись действия администратора в журнал.
+func (storage *UserStorage) InsertAdminAudit(ctx context.Context, adminID int64, command string, args string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	storage.adminAudit = append(storage.adminAudit, adminAudit{adminID: adminID, command: command, args: args, createdAt: time.Now()})
+	return nil
+}
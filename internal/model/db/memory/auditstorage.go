@@ -0,0 +1,122 @@
+package memory
+
+// Журнал изменений записей о расходах, категорий и настроек пользователя.
+
+import (
+	"context"
+	"time"
+
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// GetUserAudit Получение последних изменений данных пользователя (новые - первыми).
+func (storage *UserStorage) GetUserAudit(ctx context.Context, userID int64, limit int) ([]types.AuditRecord, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	result := []types.AuditRecord{}
+	u, ok := storage.users[userID]
+	if !ok {
+		return result, nil
+	}
+	for ind := len(u.audit) - 1; ind >= 0 && len(result) < limit; ind-- {
+		result = append(result, u.audit[ind])
+	}
+	return result, nil
+}
+
+// setActor Установка пользователя, от имени которого изменения записываются в журнал (вызывается под блокировкой).
+// Без пользователя в контексте (начальные данные, команды запуска) пользователь в журнале не указывается.
+func (u *user) setActor(ctx context.Context) {
+	u.actor, _ = dbutils.ActorFromContext(ctx)
+}
+
+// addAudit Запись изменения в журнал (вызывается под блокировкой).
+func (u *user) addAudit(entity string, objectID int64, operation string, oldValue, newValue map[string]any) {
+	u.audit = append(u.audit, types.AuditRecord{
+		ActorTgID: u.actor,
+		Entity:    entity,
+		ObjectID:  objectID,
+		Operation: operation,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: time.Now(),
+	})
+}
+
+// updateSettings Изменение настроек пользователя с записью в журнал, если значения изменились.
+func (u *user) updateSettings(update func()) {
+	oldValue := u.settingsValue()
+	update()
+	if newValue := u.settingsValue(); !equalValues(oldValue, newValue) {
+		u.addAudit(types.AuditEntitySettings, u.id, types.AuditOpUpdate, oldValue, newValue)
+	}
+}
+
+// updateRecord Изменение записи о расходах с записью в журнал.
+func (u *user) updateRecord(rec *record, update func()) {
+	oldValue := rec.auditValue()
+	update()
+	u.addAudit(types.AuditEntityRecord, rec.id, auditOperation(oldValue, rec.auditValue()), oldValue, rec.auditValue())
+}
+
+// updateCategory Изменение категории с записью в журнал.
+func (u *user) updateCategory(cat *category, update func()) {
+	oldValue := cat.auditValue()
+	update()
+	u.addAudit(types.AuditEntityCategory, cat.id, auditOperation(oldValue, cat.auditValue()), oldValue, cat.auditValue())
+}
+
+// settingsValue Значения настроек пользователя для журнала.
+func (u *user) settingsValue() map[string]any {
+	return map[string]any{"currency": u.currency, "limits": u.limits, "timezone": u.timezone}
+}
+
+// auditValue Значения записи о расходах для журнала.
+func (rec record) auditValue() map[string]any {
+	return map[string]any{
+		"category_id": rec.categoryID,
+		"period":      rec.period,
+		"sum":         rec.sum,
+		"note":        rec.note,
+		"deleted_at":  auditTime(rec.deletedAt),
+	}
+}
+
+// auditValue Значения категории для журнала.
+func (cat category) auditValue() map[string]any {
+	return map[string]any{"name": cat.name, "deleted_at": auditTime(cat.deletedAt)}
+}
+
+// auditTime Время удаления для журнала (null - объект не удален).
+func auditTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// auditOperation Операция изменения: перенос в корзину и восстановление из нее отмечаются отдельно.
+func auditOperation(oldValue, newValue map[string]any) string {
+	switch {
+	case oldValue["deleted_at"] == nil && newValue["deleted_at"] != nil:
+		return types.AuditOpDelete
+	case oldValue["deleted_at"] != nil && newValue["deleted_at"] == nil:
+		return types.AuditOpRestore
+	}
+	return types.AuditOpUpdate
+}
+
+// equalValues Сравнение значений для журнала.
+func equalValues(a, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if b[key] != value {
+			return false
+		}
+	}
+	return true
+}
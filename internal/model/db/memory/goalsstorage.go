@@ -0,0 +1,76 @@
+package memory
+
+// Работа с хранилищем целей накоплений.
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// InsertGoal Добавление цели накоплений пользователя.
+func (storage *UserStorage) InsertGoal(ctx context.Context, userID int64, goal types.Goal, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	if goal.Name == "" || goal.Target <= 0 {
+		return errors.Wrap(errors.New("Некорректная цель."), "Insert goal error")
+	}
+	goal.ID = storage.nextID()
+	goal.Saved = 0
+	u.goals = append(u.goals, goal)
+	return nil
+}
+
+// GetUserGoals Получение списка целей накоплений пользователя (по сроку достижения).
+func (storage *UserStorage) GetUserGoals(ctx context.Context, userID int64) ([]types.Goal, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok {
+		return u.sortedGoals(), nil
+	}
+	return nil, nil
+}
+
+// AddGoalContribution Пополнение цели накоплений пользователя.
+// Возвращает цель с новой накопленной суммой.
+func (storage *UserStorage) AddGoalContribution(ctx context.Context, userID int64, goalID int64, sum int64) (types.Goal, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok {
+		for ind := range u.goals {
+			if u.goals[ind].ID == goalID {
+				u.goals[ind].Saved += sum
+				return u.goals[ind], nil
+			}
+		}
+	}
+	return types.Goal{}, errors.New("Цель не найдена.")
+}
+
+// DeleteGoal Удаление цели накоплений пользователя.
+func (storage *UserStorage) DeleteGoal(ctx context.Context, userID int64, goalID int64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok {
+		u.goals = filter(u.goals, func(goal types.Goal) bool { return goal.ID != goalID })
+	}
+	return nil
+}
+
+// sortedGoals Список целей накоплений пользователя (по сроку достижения).
+func (u *user) sortedGoals() []types.Goal {
+	result := append([]types.Goal(nil), u.goals...)
+	sortBy(result, func(a, b types.Goal) bool {
+		if !a.Deadline.Equal(b.Deadline) {
+			return a.Deadline.Before(b.Deadline)
+		}
+		return a.ID < b.ID
+	})
+	return result
+}
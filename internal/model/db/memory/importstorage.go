@@ -0,0 +1,52 @@
+package memory
+
+// Пакетное добавление записей о расходах (загрузка истории расходов таблицей).
+
+import (
+	"context"
+	"time"
+
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// InsertUserDataRecords Пакетное добавление записей о расходах пользователя (с проверкой бюджета по месяцам записей).
+// Категории добавляются, если их еще нет, реквизиты чеков не сохраняются.
+// mode - types.ImportAllOrNothing (при превышении бюджета хотя бы одной записью не добавляется ничего)
+// или types.ImportBestEffort (записи, превышающие бюджет, пропускаются).
+func (storage *UserStorage) InsertUserDataRecords(ctx context.Context, userID int64, recs []types.UserDataRecord, userName string, mode string) (types.UserDataImportResult, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	// Проверка бюджета по месяцам записей.
+	accepted, overLimit, err := types.SplitRecordsByLimit(recs, u.limits, func(month time.Time) (int64, error) {
+		return u.monthSum(month), nil
+	})
+	if err != nil {
+		return types.UserDataImportResult{}, err
+	}
+	result := types.UserDataImportResult{OverLimit: overLimit}
+	if len(accepted) == 0 || (mode == types.ImportAllOrNothing && len(overLimit) > 0) {
+		return result, nil
+	}
+
+	// Сначала добавляются все новые категории, затем записи (как в хранилищах в базе данных).
+	// Загруженные категории и записи не записываются в журнал действий: по одной их отменить нельзя.
+	for _, rec := range accepted {
+		storage.addCategory(u, rec.Category)
+	}
+	for _, rec := range accepted {
+		newRec := record{
+			id:         storage.nextID(),
+			categoryID: u.findCategory(rec.Category).id,
+			sum:        rec.Sum,
+			period:     rec.Period,
+			note:       rec.Note,
+			createdAt:  time.Now(),
+		}
+		u.records = append(u.records, newRec)
+		u.addAudit(types.AuditEntityRecord, newRec.id, types.AuditOpInsert, nil, newRec.auditValue())
+		result.Inserted++
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/storagetest"
+	rates "github.com/ellavs/tg-bot-golang/internal/model/exchangerates"
+)
+
+func Test_UserStorage_Contract(t *testing.T) {
+	storagetest.RunUserStorageTests(t, func(t *testing.T) storagetest.UserStorage {
+		return NewUserStorage(storagetest.DefaultCurrency, storagetest.DefaultLimits, storagetest.DefaultTimezone)
+	})
+}
+
+func Test_ExchangeRatesStorage_Contract(t *testing.T) {
+	storagetest.RunRatesStorageTests(t, func(t *testing.T) rates.RatesDataStorage {
+		return NewExchangeRatesStorage(storagetest.Currencies)
+	})
+}
+
+func Test_UserStorage_InsertUserDataRecord_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := NewUserStorage(storagetest.DefaultCurrency, storagetest.DefaultLimits, storagetest.DefaultTimezone)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	limitPeriod := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.SetUserLimit(ctx, 123, 100000, "test"))
+
+	// Параллельные записи не превышают бюджет в сумме.
+	var wg sync.WaitGroup
+	for ind := 0; ind < 50; ind++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = storage.InsertUserDataRecord(ctx, 123, types.UserDataRecord{Category: "Кино", Sum: 3000, Period: now}, "test", limitPeriod)
+		}()
+	}
+	wg.Wait()
+
+	report, err := storage.GetUserDataRecord(ctx, 123, limitPeriod)
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 99000}}, report)
+}
@@ -0,0 +1,68 @@
+package memory
+
+// Работа с хранилищем курсов валют.
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// ExchangeRatesStorage Тип для хранилища курсов валют.
+type ExchangeRatesStorage struct {
+	mu                  sync.Mutex
+	usedCurrenciesNames []string
+	rates               map[string]map[time.Time]money.Rate // Курсы по валютам и датам.
+}
+
+// NewExchangeRatesStorage Инициализация хранилища курсов валют.
+// usedCurrenciesNames - []string - массив используемых в приложении валют.
+func NewExchangeRatesStorage(usedCurrenciesNames []string) *ExchangeRatesStorage {
+	return &ExchangeRatesStorage{
+		usedCurrenciesNames: usedCurrenciesNames,
+		rates:               map[string]map[time.Time]money.Rate{},
+	}
+}
+
+// InsertExchangeRatesToDate Добавление курсов используемых валют на дату (курсы, уже добавленные на эту дату, не изменяются).
+func (storage *ExchangeRatesStorage) InsertExchangeRatesToDate(ctx context.Context, rates types.ExchangeRate, period time.Time) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	// Даты сравниваются без учета часового пояса.
+	period = period.UTC()
+	for _, cName := range storage.usedCurrenciesNames {
+		rate, ok := rates[cName]
+		if !ok {
+			continue
+		}
+		if storage.rates[cName] == nil {
+			storage.rates[cName] = map[time.Time]money.Rate{}
+		}
+		if _, ok := storage.rates[cName][period]; !ok {
+			storage.rates[cName][period] = rate
+		}
+	}
+	return nil
+}
+
+// GetLastExchangeRates Получение последних курсов используемых валют.
+func (storage *ExchangeRatesStorage) GetLastExchangeRates(ctx context.Context) (types.ExchangeRate, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	exchangeRates := types.ExchangeRate{}
+	for _, cName := range storage.usedCurrenciesNames {
+		var last time.Time
+		for period, rate := range storage.rates[cName] {
+			if period.After(last) {
+				last = period
+				exchangeRates[cName] = rate
+			}
+		}
+	}
+	return exchangeRates, nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+// Поиск записей о расходах пользователя.
+
+import (
+	"context"
+	"strings"
+
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// SearchUserDataRecords Поиск записей о расходах пользователя по условиям (новые записи - первыми).
+// Текст ищется без учета регистра в названии категории и в комментарии.
+func (storage *UserStorage) SearchUserDataRecords(ctx context.Context, userID int64, filter types.UserDataSearchFilter, limit int, offset int) ([]types.UserDataRecord, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return []types.UserDataRecord{}, nil
+	}
+	text := strings.ToLower(filter.Text)
+	var found []record
+	for _, rec := range u.records {
+		switch {
+		case rec.isDeleted():
+		case text != "" && !strings.Contains(strings.ToLower(u.categoryName(rec.categoryID)), text) &&
+			!strings.Contains(strings.ToLower(rec.note), text):
+		case filter.SumFrom > 0 && rec.sum < filter.SumFrom:
+		case filter.SumTo > 0 && rec.sum > filter.SumTo:
+		case !filter.PeriodFrom.IsZero() && rec.period.Before(filter.PeriodFrom):
+		case !filter.PeriodTo.IsZero() && !rec.period.Before(filter.PeriodTo):
+		default:
+			found = append(found, rec)
+		}
+	}
+	sortBy(found, func(a, b record) bool {
+		if !a.period.Equal(b.period) {
+			return a.period.After(b.period)
+		}
+		return a.id > b.id
+	})
+
+	result := []types.UserDataRecord{}
+	for ind := offset; ind < len(found) && ind < offset+limit; ind++ {
+		result = append(result, types.UserDataRecord{
+			UserID:   userID,
+			Category: u.categoryName(found[ind].categoryID),
+			Sum:      found[ind].sum,
+			Period:   found[ind].period,
+			Note:     found[ind].note,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+// Работа с хранилищем подписок на регулярные отчеты.
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// subscription Подписка пользователя на регулярный отчет.
+type subscription struct {
+	id         int64
+	kind       string
+	lastSentAt time.Time
+}
+
+// GetUserSubscriptions Получение видов подписок пользователя на регулярные отчеты.
+func (storage *UserStorage) GetUserSubscriptions(ctx context.Context, userID int64) ([]string, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok {
+		return u.subscriptionKinds(), nil
+	}
+	return nil, nil
+}
+
+// SetUserSubscription Включение или отключение подписки пользователя на регулярный отчет.
+func (storage *UserStorage) SetUserSubscription(ctx context.Context, userID int64, kind string, enabled bool, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	if kind != types.SubscriptionWeekly && kind != types.SubscriptionMonthly {
+		return errors.Wrap(errors.New("Неизвестный вид подписки."), "Set user subscription error")
+	}
+	if !enabled {
+		u.subscriptions = filter(u.subscriptions, func(sub subscription) bool { return sub.kind != kind })
+		return nil
+	}
+	for _, sub := range u.subscriptions {
+		if sub.kind == kind {
+			return nil
+		}
+	}
+	// Время последней отправки - время подписки (отчет за прошедший период не отправляется).
+	u.subscriptions = append(u.subscriptions, subscription{id: storage.nextID(), kind: kind, lastSentAt: time.Now()})
+	return nil
+}
+
+// subscriptionKinds Список видов подписок пользователя (по алфавиту).
+func (u *user) subscriptionKinds() []string {
+	var result []string
+	for _, sub := range u.subscriptions {
+		result = append(result, sub.kind)
+	}
+	sortBy(result, func(a, b string) bool { return a < b })
+	return result
+}
+
+// GetSubscriptions Получение всех подписок на регулярные отчеты (кроме подписок заблокированных пользователей).
+func (storage *UserStorage) GetSubscriptions(ctx context.Context) ([]types.Subscription, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var result []types.Subscription
+	for _, u := range storage.users {
+		if _, ok := storage.blocked[u.tgID]; ok {
+			continue
+		}
+		timezone := u.timezone
+		if timezone == "" {
+			timezone = storage.defaultTimezone
+		}
+		for _, sub := range u.subscriptions {
+			result = append(result, types.Subscription{
+				ID:         sub.id,
+				UserID:     u.tgID,
+				Kind:       sub.kind,
+				LastSentAt: sub.lastSentAt,
+				Timezone:   timezone,
+			})
+		}
+	}
+	sortBy(result, func(a, b types.Subscription) bool { return a.ID < b.ID })
+	return result, nil
+}
+
+// MarkSubscriptionSent Отметка об отправке регулярного отчета по подписке.
+// Возвращает false, если отчет за срок dueAt уже был отправлен.
+func (storage *UserStorage) MarkSubscriptionSent(ctx context.Context, subscriptionID int64, dueAt time.Time, sentAt time.Time) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	for _, u := range storage.users {
+		for ind := range u.subscriptions {
+			sub := &u.subscriptions[ind]
+			if sub.id == subscriptionID && sub.lastSentAt.Before(dueAt) {
+				sub.lastSentAt = sentAt
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
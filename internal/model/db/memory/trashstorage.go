@@ -0,0 +1,125 @@
+package memory
+
+// Корзина: удаленные записи о расходах и категории с возможностью восстановления.
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// GetUserTrash Получение содержимого корзины пользователя: записей и категорий, удаленных не ранее since
+// (новые - первыми). Записи, удаленные вместе с категорией, входят в элемент категории.
+func (storage *UserStorage) GetUserTrash(ctx context.Context, userID int64, since time.Time) ([]types.TrashItem, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	result := []types.TrashItem{}
+	u, ok := storage.users[userID]
+	if !ok {
+		return result, nil
+	}
+	for _, cat := range u.categories {
+		if !cat.isDeleted() || cat.deletedAt.Before(since) {
+			continue
+		}
+		item := types.TrashItem{
+			Kind:      types.UserActionCategory,
+			ID:        cat.id,
+			Category:  cat.name,
+			DeletedAt: cat.deletedAt,
+		}
+		for _, rec := range u.records {
+			if rec.categoryID == cat.id && rec.deletedAt.Equal(cat.deletedAt) {
+				item.Sum += rec.sum
+				item.Records++
+			}
+		}
+		result = append(result, item)
+	}
+	for _, rec := range u.records {
+		if !rec.isDeleted() || rec.deletedAt.Before(since) {
+			continue
+		}
+		if cat := u.category(rec.categoryID); cat.isDeleted() && cat.deletedAt.Equal(rec.deletedAt) {
+			// Запись удалена вместе с категорией.
+			continue
+		}
+		result = append(result, types.TrashItem{
+			Kind:      types.UserActionRecord,
+			ID:        rec.id,
+			Category:  u.categoryName(rec.categoryID),
+			Sum:       rec.sum,
+			Period:    rec.period,
+			Note:      rec.note,
+			DeletedAt: rec.deletedAt,
+		})
+	}
+	sortBy(result, func(a, b types.TrashItem) bool {
+		if !a.DeletedAt.Equal(b.DeletedAt) {
+			return a.DeletedAt.After(b.DeletedAt)
+		}
+		return a.ID > b.ID
+	})
+	return result, nil
+}
+
+// RestoreTrashItem Восстановление записи или категории, удаленной не ранее since.
+// Вместе с записью восстанавливается ее категория, вместе с категорией - записи, удаленные вместе с ней.
+// Возвращает false, если объект не найден в корзине.
+func (storage *UserStorage) RestoreTrashItem(ctx context.Context, userID int64, kind string, id int64, since time.Time) (bool, error) {
+	if kind != types.UserActionRecord && kind != types.UserActionCategory {
+		return false, errors.Wrap(errors.New("Неизвестный тип объекта корзины."), "Restore trash item error")
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return false, nil
+	}
+	u.setActor(ctx)
+	var categoryID int64
+	switch kind {
+	case types.UserActionRecord:
+		for ind := range u.records {
+			if rec := &u.records[ind]; rec.id == id && rec.isDeleted() && !rec.deletedAt.Before(since) {
+				categoryID = rec.categoryID
+				u.updateRecord(rec, func() { rec.deletedAt = time.Time{} })
+			}
+		}
+	case types.UserActionCategory:
+		for ind := range u.categories {
+			if cat := &u.categories[ind]; cat.id == id && cat.isDeleted() && !cat.deletedAt.Before(since) {
+				categoryID = cat.id
+				for recInd := range u.records {
+					if rec := &u.records[recInd]; rec.categoryID == cat.id && rec.deletedAt.Equal(cat.deletedAt) {
+						u.updateRecord(rec, func() { rec.deletedAt = time.Time{} })
+					}
+				}
+			}
+		}
+	}
+	if categoryID == 0 {
+		return false, nil
+	}
+	for ind := range u.categories {
+		if cat := &u.categories[ind]; cat.id == categoryID && cat.isDeleted() {
+			u.updateCategory(cat, func() { cat.deletedAt = time.Time{} })
+		}
+	}
+	return true, nil
+}
+
+// category Категория по идентификатору.
+func (u *user) category(id int64) category {
+	for _, cat := range u.categories {
+		if cat.id == id {
+			return cat
+		}
+	}
+	return category{}
+}
@@ -0,0 +1,550 @@
+// Package memory Хранилища в памяти процесса (для тестов без базы данных и моков).
+// Хранилища повторяют поведение хранилищ в базе данных: проверку бюджета, уникальность категорий
+// без учета регистра, валюту и часовой пояс по умолчанию, журнал действий для отмены,
+// корзину удаленных записей и журнал изменений.
+package memory
+
+// Работа с хранилищем информации о пользователях.
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// UserStorage Тип для хранилища информации о пользователях.
+type UserStorage struct {
+	mu              sync.Mutex
+	defaultCurrency string
+	defaultLimits   int64
+	defaultTimezone string
+	lastID          int64                  // Последний выданный идентификатор (общий для всех объектов хранилища).
+	users           map[int64]*user        // Пользователи по ТГ-идентификатору.
+	inviteCodes     map[string]*inviteCode // Коды приглашений.
+	blocked         map[int64]int64        // Заблокированные пользователи (ТГ-идентификатор - кем заблокирован).
+	adminAudit      []adminAudit           // Журнал действий администраторов.
+	deletions       []userDeletion         // Журнал удалений пользователей.
+}
+
+// user Пользователь и все его данные.
+type user struct {
+	id            int64
+	tgID          int64
+	name          string
+	currency      string
+	limits        int64
+	timezone      string    // Пустая строка - часовой пояс по умолчанию.
+	lastSeenAt    time.Time // Время последнего обращения к боту.
+	categories    []category
+	records       []record
+	receipts      []receipt
+	actions       []userAction
+	goals         []types.Goal
+	subscriptions []subscription
+	audit         []types.AuditRecord // Журнал изменений (как триггеры в базе данных).
+	actor         int64               // ТГ-идентификатор пользователя, совершающего текущее изменение (0 - не задан).
+}
+
+// category Категория расходов пользователя.
+type category struct {
+	id        int64
+	name      string
+	deletedAt time.Time // Время удаления (нулевое - категория не удалена).
+}
+
+// record Запись о расходах пользователя.
+type record struct {
+	id         int64
+	categoryID int64
+	sum        int64
+	period     time.Time
+	note       string
+	createdAt  time.Time
+	deletedAt  time.Time // Время удаления (нулевое - запись не удалена).
+}
+
+// receipt Реквизиты кассового чека, по которому добавлена запись.
+type receipt struct {
+	recordID   int64
+	fn, fd, fp string
+}
+
+// userAction Запись журнала действий пользователя.
+type userAction struct {
+	id        int64
+	action    string
+	objectID  int64
+	prevValue string
+	createdAt time.Time
+}
+
+// NewUserStorage Инициализация хранилища информации о пользователях.
+// defaultCurrency - string - валюта по умолчанию.
+// defaultLimits - int64 - бюджет по умолчанию.
+// defaultTimezone - string - часовой пояс по умолчанию (для пользователей, не выбравших часовой пояс).
+func NewUserStorage(defaultCurrency string, defaultLimits int64, defaultTimezone string) *UserStorage {
+	return &UserStorage{
+		defaultCurrency: defaultCurrency,
+		defaultLimits:   defaultLimits,
+		defaultTimezone: defaultTimezone,
+		users:           map[int64]*user{},
+		inviteCodes:     map[string]*inviteCode{},
+		blocked:         map[int64]int64{},
+	}
+}
+
+// nextID Получение нового идентификатора (вызывается под блокировкой).
+func (storage *UserStorage) nextID() int64 {
+	storage.lastID++
+	return storage.lastID
+}
+
+// userOrAdd Получение пользователя с добавлением, если не существует (вызывается под блокировкой).
+// Изменения данных пользователя записываются в журнал от имени пользователя из контекста.
+func (storage *UserStorage) userOrAdd(ctx context.Context, userID int64, userName string) *user {
+	u, ok := storage.users[userID]
+	if !ok {
+		u = &user{
+			id:       storage.nextID(),
+			tgID:     userID,
+			name:     userName,
+			currency: storage.defaultCurrency,
+			limits:   storage.defaultLimits,
+		}
+		storage.users[userID] = u
+	}
+	u.setActor(ctx)
+	if !ok {
+		u.addAudit(types.AuditEntitySettings, u.id, types.AuditOpInsert, nil, u.settingsValue())
+	}
+	return u
+}
+
+// InsertUser Добавление пользователя в хранилище.
+func (storage *UserStorage) InsertUser(ctx context.Context, userID int64, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.userOrAdd(ctx, userID, userName)
+	return nil
+}
+
+// CheckIfUserExist Проверка существования пользователя в хранилище.
+func (storage *UserStorage) CheckIfUserExist(ctx context.Context, userID int64) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	_, ok := storage.users[userID]
+	return ok, nil
+}
+
+// InsertUserDataRecord Добавление записи о расходах пользователя (с проверкой превышения лимита).
+func (storage *UserStorage) InsertUserDataRecord(ctx context.Context, userID int64, rec types.UserDataRecord, userName string, limitPeriod time.Time) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	// Проверка, что не превышен лимит расходов.
+	if u.isOverLimit(limitPeriod) {
+		return true, nil
+	}
+	// Чек действующей записи повторно не добавляется (как уникальный индекс в базе данных).
+	if rec.Receipt != nil && u.hasReceipt(*rec.Receipt) {
+		return false, errors.New("Чек уже добавлен.")
+	}
+
+	// Состояние для отката при превышении лимита после добавления записи (как откат транзакции).
+	categories, records, receipts, actions, audit := len(u.categories), len(u.records), len(u.receipts), len(u.actions), len(u.audit)
+	var restored *category
+	var restoredAt time.Time
+	if cat := u.findCategory(rec.Category); cat != nil && cat.isDeleted() {
+		restored, restoredAt = cat, cat.deletedAt
+	}
+
+	// Добавление категории, если ее еще нет (удаленная категория восстанавливается из корзины).
+	cat, _ := storage.addCategory(u, rec.Category)
+	newRec := record{
+		id:         storage.nextID(),
+		categoryID: cat.id,
+		sum:        rec.Sum,
+		period:     rec.Period,
+		note:       rec.Note,
+		createdAt:  time.Now(),
+	}
+	u.records = append(u.records, newRec)
+	u.addAudit(types.AuditEntityRecord, newRec.id, types.AuditOpInsert, nil, newRec.auditValue())
+	// Запись в журнал действий для возможности отмены.
+	storage.addUserAction(u, types.UserActionRecord, newRec.id, "")
+	// Чек записи из корзины переходит к новой записи.
+	released, releasedFrom := -1, int64(0)
+	if rec.Receipt != nil {
+		for ind, r := range u.receipts {
+			if r.fn == rec.Receipt.FN && r.fd == rec.Receipt.FD && r.fp == rec.Receipt.FP {
+				released, releasedFrom = ind, r.recordID
+				u.receipts[ind].recordID = newRec.id
+			}
+		}
+		if released < 0 {
+			u.receipts = append(u.receipts, receipt{recordID: newRec.id, fn: rec.Receipt.FN, fd: rec.Receipt.FD, fp: rec.Receipt.FP})
+		}
+	}
+
+	if u.isOverLimit(limitPeriod) {
+		u.categories, u.records, u.receipts, u.actions, u.audit = u.categories[:categories], u.records[:records], u.receipts[:receipts], u.actions[:actions], u.audit[:audit]
+		if restored != nil {
+			restored.deletedAt = restoredAt
+		}
+		if released >= 0 {
+			u.receipts[released].recordID = releasedFrom
+		}
+		return true, errors.New("Превышение лимита.")
+	}
+	return false, nil
+}
+
+// GetUserDataRecord Получение информации о расходах по категориям за период.
+func (storage *UserStorage) GetUserDataRecord(ctx context.Context, userID int64, period time.Time) ([]types.UserDataReportRecord, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	result := []types.UserDataReportRecord{}
+	u, ok := storage.users[userID]
+	if !ok {
+		return result, nil
+	}
+	sums := map[string]int64{}
+	for _, rec := range u.records {
+		if !rec.isDeleted() && !rec.period.Before(period) {
+			sums[u.categoryName(rec.categoryID)] += rec.sum
+		}
+	}
+	for name, sum := range sums {
+		result = append(result, types.UserDataReportRecord{Category: name, Sum: sum})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Category < result[j].Category })
+	return result, nil
+}
+
+// GetUserMonthDataRecord Получение информации о расходах по категориям за календарный месяц
+// (month - начало месяца в часовом поясе пользователя).
+func (storage *UserStorage) GetUserMonthDataRecord(ctx context.Context, userID int64, month time.Time) ([]types.UserDataReportRecord, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	result := []types.UserDataReportRecord{}
+	u, ok := storage.users[userID]
+	if !ok {
+		return result, nil
+	}
+	nextMonth := timeutils.BeginOfNextMonth(month)
+	sums := map[string]int64{}
+	for _, rec := range u.records {
+		if !rec.isDeleted() && !rec.period.Before(month) && rec.period.Before(nextMonth) {
+			sums[u.categoryName(rec.categoryID)] += rec.sum
+		}
+	}
+	for name, sum := range sums {
+		result = append(result, types.UserDataReportRecord{Category: name, Sum: sum})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Category < result[j].Category })
+	return result, nil
+}
+
+// InsertCategory Добавление категории пользователя.
+func (storage *UserStorage) InsertCategory(ctx context.Context, userID int64, catName string, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	// Обрезка до 30 символов для удобства дальнейших отчетов.
+	if runes := []rune(catName); len(runes) > 30 {
+		catName = string(runes[:30])
+	}
+	// Удаленная ранее категория с тем же названием восстанавливается из корзины (без удаленных записей).
+	if cat, isAdded := storage.addCategory(u, catName); isAdded {
+		storage.addUserAction(u, types.UserActionCategory, cat.id, "")
+	}
+	return nil
+}
+
+// GetUserCategory Получение списка категорий пользователя.
+func (storage *UserStorage) GetUserCategory(ctx context.Context, userID int64) ([]string, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok {
+		return u.categoryNames(), nil
+	}
+	return nil, nil
+}
+
+// GetUserCurrency Получение выбранной валюты пользователя.
+func (storage *UserStorage) GetUserCurrency(ctx context.Context, userID int64) (string, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return "", errors.Wrap(errUserNotFound, "Get user currency error")
+	}
+	return u.currency, nil
+}
+
+// SetUserCurrency Сохранение выбранной валюты пользователя.
+func (storage *UserStorage) SetUserCurrency(ctx context.Context, userID int64, currencyName string, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	// Запись предыдущего значения в журнал действий.
+	storage.addUserAction(u, types.UserActionCurrency, 0, u.currency)
+	u.updateSettings(func() { u.currency = currencyName })
+	return nil
+}
+
+// GetUserTimezone Получение часового пояса пользователя.
+// Если пользователь не выбирал часовой пояс, возвращается часовой пояс по умолчанию.
+func (storage *UserStorage) GetUserTimezone(ctx context.Context, userID int64) (string, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if u, ok := storage.users[userID]; ok && u.timezone != "" {
+		return u.timezone, nil
+	}
+	return storage.defaultTimezone, nil
+}
+
+// SetUserTimezone Сохранение выбранного часового пояса пользователя.
+func (storage *UserStorage) SetUserTimezone(ctx context.Context, userID int64, timezone string, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+	u.updateSettings(func() { u.timezone = timezone })
+	return nil
+}
+
+// GetUserLimit Получение бюджета пользователя.
+func (storage *UserStorage) GetUserLimit(ctx context.Context, userID int64) (int64, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return 0, errors.Wrap(errUserNotFound, "Get user limits error")
+	}
+	return u.limits, nil
+}
+
+// SetUserLimit Сохранение бюджета пользователя.
+func (storage *UserStorage) SetUserLimit(ctx context.Context, userID int64, limits int64, userName string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	u := storage.userOrAdd(ctx, userID, userName)
+
+	// Запись предыдущего значения в журнал действий.
+	storage.addUserAction(u, types.UserActionLimit, 0, strconv.FormatInt(u.limits, 10))
+	u.updateSettings(func() { u.limits = limits })
+	return nil
+}
+
+// CheckIfReceiptExist Проверка, что записи по кассовому чеку уже добавлялись пользователем.
+func (storage *UserStorage) CheckIfReceiptExist(ctx context.Context, userID int64, rec types.Receipt) (bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok {
+		return false, nil
+	}
+	return u.hasReceipt(rec), nil
+}
+
+// UndoLastUserAction Отмена последнего действия пользователя, совершенного не ранее указанного момента.
+// Если отменять нечего, возвращается пустое действие (Action == "").
+func (storage *UserStorage) UndoLastUserAction(ctx context.Context, userID int64, since time.Time) (types.UserAction, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	u, ok := storage.users[userID]
+	if !ok || len(u.actions) == 0 {
+		return types.UserAction{}, nil
+	}
+	u.setActor(ctx)
+	// Действия добавляются в журнал по порядку, последнее действие - в конце.
+	last := u.actions[len(u.actions)-1]
+	if last.createdAt.Before(since) {
+		return types.UserAction{}, nil
+	}
+
+	// Записи и категории переносятся в корзину (записи категории - вместе с ней, с тем же временем удаления).
+	deletedAt := time.Now()
+	switch last.action {
+	case types.UserActionRecord:
+		u.deleteRecords(func(rec record) bool { return rec.id == last.objectID }, deletedAt)
+	case types.UserActionCategory:
+		for ind := range u.categories {
+			if cat := &u.categories[ind]; cat.id == last.objectID && !cat.isDeleted() {
+				u.updateCategory(cat, func() { cat.deletedAt = deletedAt })
+				u.deleteRecords(func(rec record) bool { return rec.categoryID == last.objectID }, deletedAt)
+			}
+		}
+	case types.UserActionLimit:
+		limits, err := strconv.ParseInt(last.prevValue, 10, 64)
+		if err != nil {
+			return types.UserAction{}, errors.Wrap(err, "Undo user action error")
+		}
+		u.updateSettings(func() { u.limits = limits })
+	case types.UserActionCurrency:
+		u.updateSettings(func() { u.currency = last.prevValue })
+	default:
+		return types.UserAction{}, errors.Wrap(errors.New("Неизвестный тип действия."), "Undo user action error")
+	}
+	u.actions = u.actions[:len(u.actions)-1]
+
+	return types.UserAction{
+		Action:    last.action,
+		ObjectID:  last.objectID,
+		PrevValue: last.prevValue,
+		CreatedAt: last.createdAt,
+	}, nil
+}
+
+// errUserNotFound Ошибка получения настроек незарегистрированного пользователя (как отсутствие строки в базе данных).
+var errUserNotFound = errors.New("Пользователь не найден.")
+
+// addUserAction Запись действия пользователя в журнал для возможности отмены (вызывается под блокировкой).
+func (storage *UserStorage) addUserAction(u *user, action string, objectID int64, prevValue string) {
+	u.actions = append(u.actions, userAction{
+		id:        storage.nextID(),
+		action:    action,
+		objectID:  objectID,
+		prevValue: prevValue,
+		createdAt: time.Now(),
+	})
+}
+
+// addCategory Добавление категории пользователя, если ее еще нет (вызывается под блокировкой).
+// Удаленная категория с тем же названием восстанавливается из корзины.
+// Возвращает категорию и false, если категория уже существовала и не была удалена.
+func (storage *UserStorage) addCategory(u *user, name string) (category, bool) {
+	if cat := u.findCategory(name); cat != nil {
+		if !cat.isDeleted() {
+			return *cat, false
+		}
+		u.updateCategory(cat, func() { cat.deletedAt = time.Time{} })
+		return *cat, true
+	}
+	cat := category{id: storage.nextID(), name: name}
+	u.categories = append(u.categories, cat)
+	u.addAudit(types.AuditEntityCategory, cat.id, types.AuditOpInsert, nil, cat.auditValue())
+	return cat, true
+}
+
+// findCategory Поиск категории пользователя без учета регистра (в том числе удаленной).
+func (u *user) findCategory(name string) *category {
+	for ind := range u.categories {
+		if strings.EqualFold(u.categories[ind].name, name) {
+			return &u.categories[ind]
+		}
+	}
+	return nil
+}
+
+// categoryNames Список названий категорий пользователя (по алфавиту, без удаленных).
+func (u *user) categoryNames() []string {
+	var result []string
+	for _, cat := range u.categories {
+		if !cat.isDeleted() {
+			result = append(result, cat.name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// categoryName Название категории по идентификатору.
+func (u *user) categoryName(id int64) string {
+	for _, cat := range u.categories {
+		if cat.id == id {
+			return cat.name
+		}
+	}
+	return ""
+}
+
+// isOverLimit Проверка, что расходы пользователя за месяц превзошли бюджет (0 - бюджет не установлен).
+func (u *user) isOverLimit(period time.Time) bool {
+	return u.limits != 0 && u.monthSum(period) > u.limits
+}
+
+// monthSum Расходы пользователя за месяц, начинающийся с month.
+func (u *user) monthSum(month time.Time) int64 {
+	nextMonth := timeutils.BeginOfNextMonth(month)
+	var total int64
+	for _, rec := range u.records {
+		if !rec.isDeleted() && !rec.period.Before(month) && rec.period.Before(nextMonth) {
+			total += rec.sum
+		}
+	}
+	return total
+}
+
+// deleteRecords Перенос записей о расходах в корзину (записи, уже находящиеся в корзине, не изменяются).
+func (u *user) deleteRecords(isDeleted func(rec record) bool, deletedAt time.Time) {
+	for ind := range u.records {
+		if rec := &u.records[ind]; !rec.isDeleted() && isDeleted(*rec) {
+			u.updateRecord(rec, func() { rec.deletedAt = deletedAt })
+		}
+	}
+}
+
+// hasReceipt Проверка, что чек добавлен к действующей записи (чеки записей в корзине не учитываются).
+func (u *user) hasReceipt(rec types.Receipt) bool {
+	for _, r := range u.receipts {
+		if r.fn == rec.FN && r.fd == rec.FD && r.fp == rec.FP && !u.isRecordDeleted(r.recordID) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRecordDeleted Проверка, что запись о расходах находится в корзине.
+func (u *user) isRecordDeleted(id int64) bool {
+	for _, rec := range u.records {
+		if rec.id == id {
+			return rec.isDeleted()
+		}
+	}
+	return false
+}
+
+// isDeleted Проверка, что запись находится в корзине.
+func (rec record) isDeleted() bool {
+	return !rec.deletedAt.IsZero()
+}
+
+// isDeleted Проверка, что категория находится в корзине.
+func (cat category) isDeleted() bool {
+	return !cat.deletedAt.IsZero()
+}
+
+// filter Отбор элементов среза, удовлетворяющих условию (в новый срез).
+func filter[T any](items []T, keep func(item T) bool) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if keep(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// sortBy Сортировка среза по условию.
+func sortBy[T any](items []T, less func(a, b T) bool) {
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/migrator"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/storagetest"
+	"github.com/ellavs/tg-bot-golang/migrations"
+)
+
+// Миграции применяются к пустой схеме migrationtest тестовой базы (расширения - из схемы public),
+// после чего в таблицах хранятся ТГ-идентификаторы больше 2^31.
+func Test_Migrations_Up_ShouldStoreLargeTgIDs(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	// Схема задается для сеанса, поэтому все запросы выполняются на одном соединении.
+	db.SetMaxOpenConns(1)
+	_, err := db.Exec("DROP SCHEMA IF EXISTS migrationtest CASCADE; CREATE SCHEMA migrationtest; SET search_path TO migrationtest, public;")
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.Exec("DROP SCHEMA IF EXISTS migrationtest CASCADE;") })
+
+	m, err := migrator.New(db, migrations.FS)
+	require.NoError(t, err)
+	AddMigrationHooks(m, storagetest.DefaultTimezone)
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.CheckVersion(ctx))
+
+	s := NewUserStorage(db, storagetest.DefaultCurrency, 0, storagetest.DefaultTimezone)
+	const largeUserID = int64(5_000_000_123)
+	period := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	_, err = s.InsertUserDataRecord(ctx, largeUserID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: period}, "test", time.Time{})
+	require.NoError(t, err)
+
+	isExist, err := s.CheckIfUserExist(ctx, largeUserID)
+	require.NoError(t, err)
+	assert.True(t, isExist)
+	report, err := s.GetUserDataRecord(ctx, largeUserID, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 1000}}, report)
+	var tgID int64
+	require.NoError(t, db.Get(&tgID, "SELECT tg_id FROM migrationtest.users;"))
+	assert.Equal(t, largeUserID, tgID)
+}
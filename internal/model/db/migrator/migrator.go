@@ -0,0 +1,363 @@
+// Package migrator Применение встроенных в приложение миграций базы данных PostgreSQL.
+// Версии примененных миграций хранятся в таблице goose_db_version (совместимо с утилитой goose),
+// одновременный запуск миграций из нескольких экземпляров приложения исключается рекомендательной блокировкой.
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+)
+
+// advisoryLockKey Ключ рекомендательной блокировки на время применения миграций.
+const advisoryLockKey int64 = 5_871_104_221
+
+// versionTable Таблица версий примененных миграций (как у goose).
+const versionTable = "goose_db_version"
+
+// Migrator Тип для применения миграций.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	after      map[int64]AfterFunc // Функции, выполняемые при применении миграций (по версиям).
+}
+
+// AfterFunc Функция, выполняемая при применении миграции после ее запросов (например, заполнение данных,
+// зависящее от конфигурации приложения). Выполняется на схеме базы данных версии миграции.
+type AfterFunc func(ctx context.Context, ex sqlx.ExecerContext) error
+
+// MigrationStatus Состояние миграции в базе данных.
+type MigrationStatus struct {
+	Version   int64     // Версия миграции.
+	Name      string    // Имя файла миграции (пустое для миграций, неизвестных приложению).
+	Applied   bool      // Признак применения миграции.
+	AppliedAt time.Time // Время применения миграции.
+}
+
+// appliedVersion Запись таблицы версий.
+type appliedVersion struct {
+	Version   int64     `db:"version_id"`
+	IsApplied bool      `db:"is_applied"`
+	AppliedAt time.Time `db:"tstamp"`
+}
+
+// New Инициализация применения миграций.
+// db - *sqlx.DB - ссылка на подключение к БД.
+// fsys - fs.FS - каталог с файлами миграций в формате goose (см. пакет migrations).
+func New(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, after: map[int64]AfterFunc{}}, nil
+}
+
+// After Регистрация функции f, выполняемой при применении миграции версии version (в той же транзакции,
+// поэтому при ошибке функции миграция не применяется). При откате миграции функция не выполняется.
+func (m *Migrator) After(version int64, f AfterFunc) {
+	m.after[version] = f
+}
+
+// Prepare Подготовка базы данных при запуске приложения: применение новых миграций (если autoMigrate)
+// и проверка совместимости схемы базы данных с приложением.
+func (m *Migrator) Prepare(ctx context.Context, autoMigrate bool) error {
+	if autoMigrate {
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	}
+	return m.CheckVersion(ctx)
+}
+
+// Run Выполнение команды migrate: up - применение новых миграций, down - откат последней миграции,
+// status - вывод состояния миграций.
+func (m *Migrator) Run(ctx context.Context, command string, out io.Writer) error {
+	switch command {
+	case "up":
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := m.Down(ctx); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return errors.Errorf("Неизвестная команда migrate %q (допустимы up, down, status).", command)
+	}
+	return m.printStatus(ctx, out)
+}
+
+// Up Применение всех еще не примененных миграций (каждая миграция - в отдельной транзакции).
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := getAppliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := m.apply(ctx, conn, migration, migration.Up, func(ex sqlx.ExecerContext) error {
+				if after, ok := m.after[migration.Version]; ok {
+					if err := after(ctx, ex); err != nil {
+						return err
+					}
+				}
+				_, err := dbutils.Exec(ctx, ex, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES ($1, true);", migration.Version)
+				return err
+			})
+			if err != nil {
+				return errors.Wrapf(err, "Apply migration %v error", migration.Name)
+			}
+			logger.Info("Применена миграция БД", "file", migration.Name)
+		}
+		return nil
+	})
+}
+
+// Down Откат последней примененной миграции.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := getAppliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		current := currentVersion(applied)
+		if current == 0 {
+			return errors.New("Нет примененных миграций.")
+		}
+		migration, ok := m.find(current)
+		if !ok {
+			return errors.Errorf("Миграция версии %v неизвестна приложению.", current)
+		}
+		err = m.apply(ctx, conn, migration, migration.Down, func(ex sqlx.ExecerContext) error {
+			_, err := dbutils.Exec(ctx, ex, "DELETE FROM "+versionTable+" WHERE version_id = $1;", migration.Version)
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Rollback migration %v error", migration.Name)
+		}
+		logger.Info("Отменена миграция БД", "file", migration.Name)
+		return nil
+	})
+}
+
+// Status Состояние известных приложению миграций и миграций, примененных в базе данных.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildStatus(m.migrations, applied), nil
+}
+
+// CheckVersion Проверка совместимости схемы базы данных с приложением:
+// все миграции приложения применены и нет примененных миграций, неизвестных приложению.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	return checkVersion(m.migrations, applied)
+}
+
+// apply Выполнение запросов миграции и изменение таблицы версий (в транзакции, если это допускает миграция).
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, migration Migration, statements []string, setVersion func(ex sqlx.ExecerContext) error) error {
+	run := func(ex sqlx.ExecerContext) error {
+		for _, statement := range statements {
+			if _, err := dbutils.Exec(ctx, ex, statement); err != nil {
+				return err
+			}
+		}
+		return setVersion(ex)
+	}
+	if migration.NoTransaction {
+		return run(conn)
+	}
+	return dbutils.RunTx(ctx, conn, func(tx *sqlx.Tx) error {
+		return run(tx)
+	})
+}
+
+// find Поиск миграции по версии.
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock Выполнение функции на отдельном соединении под рекомендательной блокировкой
+// (другие экземпляры приложения ждут окончания применения миграций).
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sqlx.Conn) error) (err error) {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Get db connection error")
+	}
+	defer conn.Close()
+
+	if _, err := dbutils.Exec(ctx, conn, "SELECT pg_advisory_lock($1);", advisoryLockKey); err != nil {
+		return errors.Wrap(err, "Lock migrations error")
+	}
+	defer func() {
+		// Блокировка снимается без отмены контекста, чтобы не оставить ее на соединении.
+		if _, unlockErr := dbutils.Exec(context.Background(), conn, "SELECT pg_advisory_unlock($1);", advisoryLockKey); unlockErr != nil && err == nil {
+			err = errors.Wrap(unlockErr, "Unlock migrations error")
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+// appliedVersions Получение примененных версий (без блокировки, для проверки и вывода состояния).
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]appliedVersion, error) {
+	var exists bool
+	if err := sqlx.GetContext(ctx, m.db, &exists, "SELECT to_regclass($1) IS NOT NULL;", versionTable); err != nil {
+		return nil, errors.Wrap(err, "Check migrations table error")
+	}
+	if !exists {
+		return map[int64]appliedVersion{}, nil
+	}
+	return getAppliedVersions(ctx, m.db)
+}
+
+// printStatus Вывод состояния миграций.
+func (m *Migrator) printStatus(ctx context.Context, out io.Writer) error {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Применена\tМиграция")
+	for _, s := range buildStatus(m.migrations, applied) {
+		appliedAt := "нет"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%v (неизвестна приложению)", s.Version)
+		}
+		fmt.Fprintf(w, "%v\t%v\n", appliedAt, name)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "Print migrations status error")
+	}
+	if err := checkVersion(m.migrations, applied); err != nil {
+		fmt.Fprintln(out, err.Error())
+	}
+	return nil
+}
+
+// ensureVersionTable Создание таблицы версий (как при первом запуске goose).
+func ensureVersionTable(ctx context.Context, conn *sqlx.Conn) error {
+	const sqlString = `
+		CREATE TABLE IF NOT EXISTS ` + versionTable + ` (
+			id         serial primary key,
+			version_id bigint  not null,
+			is_applied boolean not null,
+			tstamp     timestamp default now()
+		);
+		INSERT INTO ` + versionTable + ` (version_id, is_applied)
+			SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM ` + versionTable + `);`
+	if _, err := dbutils.Exec(ctx, conn, sqlString); err != nil {
+		return errors.Wrap(err, "Create migrations table error")
+	}
+	return nil
+}
+
+// getAppliedVersions Получение примененных версий: учитывается последняя запись по каждой версии
+// (старые версии goose при откате добавляли запись с is_applied = false).
+func getAppliedVersions(ctx context.Context, db sqlx.QueryerContext) (map[int64]appliedVersion, error) {
+	const sqlString = `
+		SELECT version_id, is_applied, coalesce(tstamp, now()) AS tstamp
+		FROM ` + versionTable + `
+		WHERE version_id > 0
+		ORDER BY id;`
+
+	var rows []appliedVersion
+	if err := dbutils.Select(ctx, db, &rows, sqlString); err != nil {
+		return nil, errors.Wrap(err, "Get applied migrations error")
+	}
+	applied := make(map[int64]appliedVersion, len(rows))
+	for _, row := range rows {
+		if row.IsApplied {
+			applied[row.Version] = row
+		} else {
+			delete(applied, row.Version)
+		}
+	}
+	return applied, nil
+}
+
+// currentVersion Версия последней примененной миграции (0, если миграции не применялись).
+func currentVersion(applied map[int64]appliedVersion) int64 {
+	var current int64
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current
+}
+
+// buildStatus Состояние миграций приложения и примененных миграций, неизвестных приложению.
+func buildStatus(migrations []Migration, applied map[int64]appliedVersion) []MigrationStatus {
+	status := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[int64]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		row, ok := applied[migration.Version]
+		status = append(status, MigrationStatus{Version: migration.Version, Name: migration.Name, Applied: ok, AppliedAt: row.AppliedAt})
+	}
+	for version, row := range applied {
+		if !known[version] {
+			status = append(status, MigrationStatus{Version: version, Applied: true, AppliedAt: row.AppliedAt})
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status
+}
+
+// checkVersion Проверка, что примененные миграции совпадают с миграциями приложения.
+func checkVersion(migrations []Migration, applied map[int64]appliedVersion) error {
+	known := make(map[int64]bool, len(migrations))
+	var pending []string
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration.Name)
+		}
+	}
+	var unknown []int64
+	for version := range applied {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+	current := currentVersion(applied)
+	if len(unknown) > 0 {
+		return errors.Errorf("Схема базы данных (версия %v) новее приложения: неизвестные приложению миграции %v. Обновите приложение.", current, unknown)
+	}
+	if len(pending) > 0 {
+		return errors.Errorf("Схема базы данных (версия %v) не соответствует приложению: не применены миграции %v. Выполните команду \"migrate up\" или включите AutoMigrate.", current, pending)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+// Миграции для тестов: первая уже применена в базе данных, вторая - нет.
+var testMigrations = fstest.MapFS{
+	"1_init.sql": {Data: []byte("-- +goose Up\ncreate table t1 (id integer);\n-- +goose Down\ndrop table t1;")},
+	"2_add.sql":  {Data: []byte("-- +goose Up\nalter table t1 add column name text;\n-- +goose Down\nalter table t1 drop column name;")},
+}
+
+func Test_Migrator_Up_ShouldApplyPendingMigrationsUnderLock(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(advisoryLockKey).WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS goose_db_version").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}).AddRow(1, true, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("alter table t1 add column name text").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO goose_db_version").WithArgs(2).WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(advisoryLockKey).WillReturnResult(sqlxmock.NewResult(0, 0))
+
+	require.NoError(t, m.Up(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_Migrator_Up_ShouldRunAfterFuncInMigrationTransaction(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+	m.After(1, func(ctx context.Context, ex sqlx.ExecerContext) error {
+		t.Error("функция уже примененной миграции не должна выполняться")
+		return nil
+	})
+	m.After(2, func(ctx context.Context, ex sqlx.ExecerContext) error {
+		_, err := ex.ExecContext(ctx, "update t1 set name = $1", "default")
+		return err
+	})
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(advisoryLockKey).WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS goose_db_version").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}).AddRow(1, true, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("alter table t1 add column name text").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("update t1 set name").WithArgs("default").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(advisoryLockKey).WillReturnResult(sqlxmock.NewResult(0, 0))
+
+	// Ошибка функции отменяет миграцию.
+	require.ErrorIs(t, m.Up(context.Background()), assert.AnError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_Migrator_Down_ShouldRollbackLastMigration(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS goose_db_version").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}).
+			AddRow(1, true, time.Now()).AddRow(2, true, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("alter table t1 drop column name").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM goose_db_version").WithArgs(2).WillReturnResult(sqlxmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlxmock.NewResult(0, 0))
+
+	require.NoError(t, m.Down(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_Migrator_Up_ShouldRollbackFailedMigrationAndUnlock(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS goose_db_version").WillReturnResult(sqlxmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("create table t1").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlxmock.NewResult(0, 0))
+
+	assert.ErrorContains(t, m.Up(context.Background()), "1_init.sql")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_Migrator_Run_ShouldPrintStatus(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}).
+			AddRow(1, true, time.Date(2022, 10, 11, 17, 42, 32, 0, time.UTC)))
+
+	var out bytes.Buffer
+	require.NoError(t, m.Run(context.Background(), "status", &out))
+	assert.Contains(t, out.String(), "2022-10-11 17:42:32  1_init.sql")
+	assert.Contains(t, out.String(), "нет                  2_add.sql")
+	assert.Contains(t, out.String(), "не применены миграции [2_add.sql]")
+
+	assert.Error(t, m.Run(context.Background(), "redo", &out))
+}
+
+func Test_checkVersion(t *testing.T) {
+	list, err := Parse(testMigrations)
+	require.NoError(t, err)
+
+	applied := map[int64]appliedVersion{1: {Version: 1, IsApplied: true}}
+	assert.ErrorContains(t, checkVersion(list, applied), "не применены миграции [2_add.sql]")
+
+	applied[2] = appliedVersion{Version: 2, IsApplied: true}
+	assert.NoError(t, checkVersion(list, applied))
+
+	// База данных обновлена более новой версией приложения.
+	applied[3] = appliedVersion{Version: 3, IsApplied: true}
+	assert.ErrorContains(t, checkVersion(list, applied), "новее приложения")
+	status := buildStatus(list, applied)
+	require.Len(t, status, 3)
+	assert.Equal(t, MigrationStatus{Version: 3, Applied: true}, status[2])
+}
+
+func Test_getAppliedVersions_ShouldUseLastRowOfVersion(t *testing.T) {
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Старые версии goose при откате добавляли запись с is_applied = false.
+	now := time.Now()
+	mock.ExpectQuery("SELECT version_id, is_applied").
+		WillReturnRows(sqlxmock.NewRows([]string{"version_id", "is_applied", "tstamp"}).
+			AddRow(1, true, now).AddRow(2, true, now).AddRow(2, false, now))
+	applied, err := getAppliedVersions(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]appliedVersion{1: {Version: 1, IsApplied: true, AppliedAt: now}}, applied)
+	assert.Equal(t, int64(1), currentVersion(applied))
+}
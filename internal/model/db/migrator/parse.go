@@ -0,0 +1,163 @@
+package migrator
+
+// Разбор файлов миграций в формате goose.
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Аннотации goose в файлах миграций.
+const (
+	annotationUp             = "-- +goose Up"
+	annotationDown           = "-- +goose Down"
+	annotationStatementBegin = "-- +goose StatementBegin"
+	annotationStatementEnd   = "-- +goose StatementEnd"
+	annotationNoTransaction  = "-- +goose NO TRANSACTION"
+)
+
+// Имя файла миграции: версия и описание, например, "20221011174232_init_user_table.sql".
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// Migration Миграция базы данных.
+type Migration struct {
+	Version       int64    // Версия (числовой префикс имени файла).
+	Name          string   // Имя файла.
+	Up            []string // Запросы применения миграции.
+	Down          []string // Запросы отката миграции.
+	NoTransaction bool     // Признак выполнения запросов вне транзакции.
+}
+
+// Parse Чтение миграций из файлов *.sql каталога (в порядке возрастания версий).
+func Parse(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "Read migrations error")
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	versions := make(map[int64]string, len(files))
+	for _, file := range files {
+		matches := fileNameRegexp.FindStringSubmatch(path.Base(file))
+		if len(matches) < 3 {
+			return nil, errors.Errorf("Некорректное имя файла миграции %v.", file)
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, errors.Errorf("Некорректная версия миграции %v.", file)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, errors.Errorf("Повторяющаяся версия миграции %v (%v и %v).", version, other, file)
+		}
+		versions[version] = file
+
+		script, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Read migration %v error", file)
+		}
+		migration, err := parseScript(string(script))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Parse migration %v error", file)
+		}
+		migration.Version = version
+		migration.Name = path.Base(file)
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseScript Разбор текста миграции на запросы разделов Up и Down.
+// Запрос заканчивается строкой с ";" в конце, либо аннотацией StatementEnd (для запросов с ";" внутри).
+func parseScript(script string) (Migration, error) {
+	var migration Migration
+	var section *[]string
+	var statement strings.Builder
+	inBlock, hasUp := false, false
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, annotationUp), strings.HasPrefix(trimmed, annotationDown):
+			if inBlock || strings.TrimSpace(statement.String()) != "" {
+				return Migration{}, errors.New("Незавершенный запрос перед началом раздела.")
+			}
+			statement.Reset()
+			if strings.HasPrefix(trimmed, annotationUp) {
+				section, hasUp = &migration.Up, true
+			} else {
+				section = &migration.Down
+			}
+			continue
+		case strings.HasPrefix(trimmed, annotationNoTransaction):
+			migration.NoTransaction = true
+			continue
+		}
+		if section == nil {
+			// Строки до первого раздела (комментарии к миграции) игнорируются.
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, annotationStatementBegin):
+			if inBlock {
+				return Migration{}, errors.New("Вложенная аннотация StatementBegin.")
+			}
+			inBlock = true
+		case strings.HasPrefix(trimmed, annotationStatementEnd):
+			if !inBlock {
+				return Migration{}, errors.New("Аннотация StatementEnd без StatementBegin.")
+			}
+			inBlock = false
+			*section = appendStatement(*section, statement.String())
+			statement.Reset()
+		case inBlock:
+			statement.WriteString(line + "\n")
+		case trimmed == "" && statement.Len() == 0, strings.HasPrefix(trimmed, "--"):
+			// Комментарии и пустые строки между запросами пропускаются.
+		default:
+			statement.WriteString(line + "\n")
+			if endsWithSemicolon(trimmed) {
+				*section = appendStatement(*section, statement.String())
+				statement.Reset()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, errors.Wrap(err, "Read migration error")
+	}
+	if inBlock || strings.TrimSpace(statement.String()) != "" {
+		return Migration{}, errors.New("Незавершенный запрос в конце файла.")
+	}
+	if !hasUp {
+		return Migration{}, errors.New("Отсутствует раздел \"-- +goose Up\".")
+	}
+	return migration, nil
+}
+
+// endsWithSemicolon Проверка окончания запроса в строке (без учета комментария в конце строки).
+func endsWithSemicolon(line string) bool {
+	if ind := strings.Index(line, "--"); ind >= 0 {
+		line = strings.TrimSpace(line[:ind])
+	}
+	return strings.HasSuffix(line, ";")
+}
+
+// appendStatement Добавление непустого запроса в список.
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
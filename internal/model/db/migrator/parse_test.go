@@ -0,0 +1,89 @@
+package migrator
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ellavs/tg-bot-golang/migrations"
+)
+
+func Test_parseScript_ShouldSplitStatements(t *testing.T) {
+	script := `-- Комментарий к миграции.
+-- +goose Up
+create table t1 (id integer); -- таблица
+create index t1_id
+    on t1 (id);
+
+-- +goose StatementBegin
+create function f() returns integer as $$
+begin
+    return 1;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop function f;
+drop table t1;
+-- +goose StatementEnd
+`
+	migration, err := parseScript(script)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create table t1 (id integer); -- таблица",
+		"create index t1_id\n    on t1 (id);",
+		"create function f() returns integer as $$\nbegin\n    return 1;\nend;\n$$ language plpgsql;",
+	}, migration.Up)
+	assert.Equal(t, []string{"drop function f;\ndrop table t1;"}, migration.Down)
+	assert.False(t, migration.NoTransaction)
+}
+
+func Test_parseScript_ShouldReturnError_WhenInvalid(t *testing.T) {
+	for _, script := range []string{
+		"create table t1 (id integer);",
+		"-- +goose Up\ncreate table t1 (id integer)",
+		"-- +goose Up\n-- +goose StatementBegin\ncreate table t1 (id integer);",
+		"-- +goose Up\n-- +goose StatementEnd",
+	} {
+		_, err := parseScript(script)
+		assert.Error(t, err, script)
+	}
+}
+
+func Test_Parse_ShouldSortByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2_second.sql": {Data: []byte("-- +goose NO TRANSACTION\n-- +goose Up\nselect 2;")},
+		"1_first.sql":  {Data: []byte("-- +goose Up\nselect 1;\n-- +goose Down\nselect -1;")},
+		"readme.txt":   {Data: []byte("не миграция")},
+	}
+	list, err := Parse(fsys)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, Migration{Version: 1, Name: "1_first.sql", Up: []string{"select 1;"}, Down: []string{"select -1;"}}, list[0])
+	assert.Equal(t, Migration{Version: 2, Name: "2_second.sql", Up: []string{"select 2;"}, NoTransaction: true}, list[1])
+
+	_, err = Parse(fstest.MapFS{"init.sql": {Data: []byte("-- +goose Up\nselect 1;")}})
+	assert.Error(t, err)
+	_, err = Parse(fstest.MapFS{
+		"1_a.sql":  {Data: []byte("-- +goose Up\nselect 1;")},
+		"01_b.sql": {Data: []byte("-- +goose Up\nselect 1;")},
+	})
+	assert.Error(t, err)
+}
+
+func Test_parseScript_ShouldAllowEmptySections(t *testing.T) {
+	// Миграция без запросов сохраняет версию в истории (например, после переноса данных в другое место).
+	migration, err := parseScript("-- Данные перенесены.\n-- +goose Up\n-- +goose Down\n")
+	require.NoError(t, err)
+	assert.Empty(t, migration.Up)
+	assert.Empty(t, migration.Down)
+}
+
+func Test_Parse_ShouldReadEmbeddedMigrations(t *testing.T) {
+	list, err := Parse(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+}
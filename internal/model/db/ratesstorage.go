@@ -8,6 +8,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
 	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 )
 
@@ -19,8 +20,8 @@ type ExchangeRatesStorage struct {
 
 // RateDB Тип, принимающий структуру таблицы курсов валют.
 type RateDB struct {
-	Name string  `db:"currency"`
-	Rate float64 `db:"rate"`
+	Name string     `db:"currency"`
+	Rate money.Rate `db:"rate"`
 }
 
 // NewExchangeRatesStorage Инициализация хранилища курсов валют.
@@ -36,19 +37,20 @@ func NewExchangeRatesStorage(db *sqlx.DB, usedCurrenciesNames []string) *Exchang
 // InsertExchangeRatesToDate Добавление курсов валют в базу данных
 func (storage *ExchangeRatesStorage) InsertExchangeRatesToDate(ctx context.Context, rates types.ExchangeRate, period time.Time) error {
 	// Преобразование map в слайсы по используемым валютам для удобства вставки в БД.
-	ratesNames := make([]string, len(storage.usedCurrenciesNames)-1)
-	ratesValues := make([]float64, len(storage.usedCurrenciesNames)-1)
-	for ind, cName := range storage.usedCurrenciesNames {
+	// Курсы передаются десятичными строками, чтобы не терять точность на float.
+	ratesNames := make([]string, 0, len(storage.usedCurrenciesNames))
+	ratesValues := make([]string, 0, len(storage.usedCurrenciesNames))
+	for _, cName := range storage.usedCurrenciesNames {
 		if rate, ok := rates[cName]; ok {
-			ratesNames[ind] = cName
-			ratesValues[ind] = rate
+			ratesNames = append(ratesNames, cName)
+			ratesValues = append(ratesValues, rate.String())
 		}
 	}
 
 	// Запрос на добавление данных.
 	const sqlString = `
 		INSERT INTO exchangerates (currency, rate, period)
-			SELECT *, $1 FROM unnest($2::text[], $3::float[])
+			SELECT *, $1 FROM unnest($2::text[], $3::numeric[])
 			 ON CONFLICT (currency, period) DO NOTHING`
 
 	// Выполнение запроса на добавление данных.
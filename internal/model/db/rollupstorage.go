@@ -0,0 +1,96 @@
+package db
+
+// Помесячные итоги расходов пользователей по категориям (для быстрых отчетов и проверки бюджета).
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/migrator"
+)
+
+// Итоги хранятся в таблице usermonthlyrollups по месяцам в часовом поясе пользователя и изменяются в тех же
+// запросах, что и записи о расходах: добавление, удаление в корзину и восстановление из нее.
+// При изменении часового пояса итоги пользователя пересчитываются.
+
+// Знак изменения итогов на суммы записей.
+const (
+	rollupAdd    = ""  // Добавление записей (или восстановление из корзины).
+	rollupRemove = "-" // Удаление записей в корзину.
+)
+
+// sqlRollupMonth Месяц записи о расходах (r.period) в часовом поясе пользователя (u.timezone или часовой пояс по умолчанию).
+// Приведение типа - через CAST, так как запрос может использоваться с именованными параметрами.
+func sqlRollupMonth(defaultTimezoneParam string) string {
+	return `CAST(date_trunc('month', r.period AT TIME ZONE COALESCE(NULLIF(u.timezone, ''), ` + defaultTimezoneParam + `)) AS date)`
+}
+
+// sqlRollupDelta Подзапрос (часть WITH), изменяющий итоги на суммы записей из подзапроса source
+// (с полями user_id, category_id, period, sum). sign - rollupAdd или rollupRemove.
+func sqlRollupDelta(source string, sign string, defaultTimezoneParam string) string {
+	return `INSERT INTO usermonthlyrollups AS m (user_id, category_id, month, sum, count)
+			SELECT r.user_id, r.category_id, ` + sqlRollupMonth(defaultTimezoneParam) + `, ` + sign + `SUM(r.sum), ` + sign + `COUNT(*)
+			FROM ` + source + ` AS r
+				INNER JOIN users AS u
+					ON r.user_id = u.id
+			GROUP BY 1, 2, 3
+		ON CONFLICT (user_id, month, category_id) DO UPDATE
+			SET sum = m.sum + excluded.sum, count = m.count + excluded.count`
+}
+
+// rollupsMigrationVersion Версия миграции, добавляющей помесячные итоги (20221101120000_init_usermonthlyrollups_table.sql).
+const rollupsMigrationVersion = 20221101120000
+
+// AddMigrationHooks Регистрация заполнения данных, зависящего от конфигурации приложения, при применении миграций:
+// миграция заполняет помесячные итоги с часовым поясом Europe/Moscow, поэтому в той же транзакции они пересчитываются
+// с часовым поясом по умолчанию defaultTimezone.
+func AddMigrationHooks(m *migrator.Migrator, defaultTimezone string) {
+	m.After(rollupsMigrationVersion, func(ctx context.Context, ex sqlx.ExecerContext) error {
+		_, err := rebuildMonthlyRollupsTx(ctx, ex, 0, defaultTimezone)
+		return err
+	})
+}
+
+// RebuildMonthlyRollups Пересчет помесячных итогов расходов всех пользователей по записям о расходах
+// (например, после изменения часового пояса по умолчанию). Возвращает количество строк итогов.
+func (storage *UserStorage) RebuildMonthlyRollups(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbutils.RunTx(ctx, storage.db,
+		func(tx *sqlx.Tx) error {
+			var err error
+			count, err = rebuildMonthlyRollupsTx(ctx, tx, 0, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return 0, errors.Wrap(err, "Rebuild monthly rollups error")
+	}
+	return count, nil
+}
+
+// rebuildMonthlyRollupsTx Пересчет помесячных итогов пользователя с ТГ-идентификатором userID
+// (0 - всех пользователей), выполняемый внутри транзакции (tx).
+func rebuildMonthlyRollupsTx(ctx context.Context, tx sqlx.ExecerContext, userID int64, defaultTimezone string) (int64, error) {
+	const sqlDelete = `
+		DELETE FROM usermonthlyrollups AS m
+		USING users AS u
+		WHERE m.user_id = u.id AND ($1::bigint = 0 OR u.tg_id = $1);`
+	sqlInsert := `
+		INSERT INTO usermonthlyrollups (user_id, category_id, month, sum, count)
+			SELECT r.user_id, r.category_id, ` + sqlRollupMonth("$2") + `, SUM(r.sum), COUNT(*)
+			FROM usermoneytransactions AS r
+				INNER JOIN users AS u
+					ON r.user_id = u.id
+			WHERE ($1::bigint = 0 OR u.tg_id = $1) AND r.deleted_at IS NULL
+			GROUP BY 1, 2, 3;`
+
+	if _, err := dbutils.Exec(ctx, tx, sqlDelete, userID); err != nil {
+		return 0, err
+	}
+	res, err := dbutils.Exec(ctx, tx, sqlInsert, userID, defaultTimezone)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/storagetest"
+)
+
+func Test_UserStorage_GetUserDataRecord_ShouldUseRollupsForFullMonths(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	require.NoError(t, err)
+	// 10 марта 20:00 UTC - 11 марта по Владивостоку, полные месяцы - с 1 апреля по Владивостоку.
+	period := time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC)
+	fullMonths := time.Date(2024, 4, 1, 0, 0, 0, 0, vladivostok)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(timezone), '') AS timezone FROM users WHERE tg_id = $1;")).
+		WithArgs(15236).
+		WillReturnRows(sqlxmock.NewRows([]string{"timezone"}).AddRow("Asia/Vladivostok"))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND m.month >= $3 AND m.count > 0")).
+		WithArgs(15236, period, "2024-04-01", fullMonths).
+		WillReturnRows(sqlxmock.NewRows([]string{"name", "sum"}).AddRow("Кино", 1000).AddRow("Такси", 2500))
+
+	got, err := s.GetUserDataRecord(ctx, 15236, period)
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 1000}, {Category: "Такси", Sum: 2500}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_checkIfUserOverLimit_ShouldReadMonthRollup(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN usermonthlyrollups AS m ON m.user_id = u.id AND m.month = $2")).
+		WithArgs(15236, "2024-03-01").
+		WillReturnRows(sqlxmock.NewRows([]string{"overlimit"}).AddRow(true))
+
+	got, err := checkIfUserOverLimit(ctx, db, 15236, time.Date(2024, 3, 1, 0, 0, 0, 0, moscow))
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_UserStorage_RebuildMonthlyRollups(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM usermonthlyrollups AS m USING users AS u")).
+		WithArgs(0).
+		WillReturnResult(sqlxmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO usermonthlyrollups (user_id, category_id, month, sum, count)")).
+		WithArgs(0, "Europe/Moscow").
+		WillReturnResult(sqlxmock.NewResult(0, 5))
+	mock.ExpectCommit()
+
+	got, err := s.RebuildMonthlyRollups(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// benchmarkRecords Количество записей о расходах пользователя в бенчмарках.
+const benchmarkRecords = 1_000_000
+
+// newBenchmarkStorage Хранилище с benchmarkRecords записями о расходах одного пользователя
+// (6 категорий, записи равномерно распределены по 5 годам до 2025 года) и пересчитанными итогами.
+func newBenchmarkStorage(b *testing.B) (*UserStorage, int64) {
+	ctx := context.Background()
+	db := newTestDB(b)
+	s := NewUserStorage(db, storagetest.DefaultCurrency, 0, storagetest.DefaultTimezone)
+	const userID = 123
+	for i := 1; i <= 6; i++ {
+		require.NoError(b, s.InsertCategory(ctx, userID, fmt.Sprintf("Категория %v", i), "bench"))
+	}
+	// Записи добавляются одним запросом (через InsertUserDataRecord заполнение заняло бы слишком много времени).
+	_, err := db.Exec(`
+		INSERT INTO usermoneytransactions (user_id, category_id, period, sum)
+			SELECT u.id, c.id, timestamptz '2020-01-01 00:00:00+00' + x * interval '157 seconds', 100 + x % 10000
+			FROM generate_series(0, $1 - 1) AS x
+					 INNER JOIN users AS u
+								ON u.tg_id = $2
+					 INNER JOIN usercategories AS c
+								ON c.user_id = u.id AND c.name = 'Категория ' || (x % 6 + 1);`, benchmarkRecords, userID)
+	require.NoError(b, err)
+	_, err = s.RebuildMonthlyRollups(ctx)
+	require.NoError(b, err)
+	_, err = db.Exec("ANALYZE usermoneytransactions, usermonthlyrollups;")
+	require.NoError(b, err)
+	return s, userID
+}
+
+// Сравнение отчета за год и проверки бюджета за месяц по помесячным итогам
+// с суммированием записей о расходах.
+func BenchmarkMonthlyRollups(b *testing.B) {
+	ctx := context.Background()
+	s, userID := newBenchmarkStorage(b)
+	period := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	limitPeriod := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(b, s.SetUserLimit(ctx, userID, 1<<40, "bench"))
+
+	b.Run("ReportRollups", func(b *testing.B) {
+		for b.Loop() {
+			_, err := s.GetUserDataRecord(ctx, userID, period)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("ReportRawRecords", func(b *testing.B) {
+		// Суммирование записей о расходах без итогов (как до появления таблицы usermonthlyrollups).
+		const sqlString = `
+			SELECT c.name, SUM(r.sum) AS sum
+			FROM usermoneytransactions AS r
+					 INNER JOIN usercategories AS c
+								ON r.category_id = c.id
+					 INNER JOIN users AS u
+								ON r.user_id = u.id
+			WHERE u.tg_id = $1 AND r.period >= $2 AND r.deleted_at IS NULL
+			GROUP BY c.name
+			ORDER BY c.name;`
+		for b.Loop() {
+			var recs []UserDataReportRecordDB
+			require.NoError(b, s.db.SelectContext(ctx, &recs, sqlString, userID, period))
+		}
+	})
+
+	b.Run("LimitCheckRollups", func(b *testing.B) {
+		for b.Loop() {
+			_, err := checkIfUserOverLimit(ctx, s.db, userID, limitPeriod)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("LimitCheckRawRecords", func(b *testing.B) {
+		// Проверка бюджета по записям о расходах без итогов (как до появления таблицы usermonthlyrollups).
+		const sqlString = `
+			SELECT SUM(r.sum) > u.limits AND NOT u.limits = 0 AS overlimit
+			FROM usermoneytransactions AS r
+				INNER JOIN users AS u
+					ON r.user_id = u.id
+			WHERE u.tg_id = $1 AND r.period >= $2 AND r.period < $3 AND r.deleted_at IS NULL
+			GROUP BY u.limits;`
+		for b.Loop() {
+			_, err := dbutils.GetMap(ctx, s.db, sqlString, userID, limitPeriod, timeutils.BeginOfNextMonth(limitPeriod))
+			require.NoError(b, err)
+		}
+	})
+}
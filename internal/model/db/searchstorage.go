@@ -0,0 +1,80 @@
+package db
+
+// Поиск записей о расходах пользователей.
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// UserDataSearchRecordDB Тип, принимающий структуру найденной записи о расходах.
+type UserDataSearchRecordDB struct {
+	Category string    `db:"name"`
+	Sum      int64     `db:"sum"`
+	Period   time.Time `db:"period"`
+	Note     string    `db:"note"`
+}
+
+// likeEscaper Экранирование спецсимволов шаблона LIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUserDataRecords Поиск записей о расходах пользователя (новые записи - первыми).
+// Условия добавляются в запрос только заданные, чтобы использовались индексы
+// по периоду, сумме и комментарию.
+func (storage *UserStorage) SearchUserDataRecords(ctx context.Context, userID int64, filter types.UserDataSearchFilter, limit int, offset int) ([]types.UserDataRecord, error) {
+	var sqlString strings.Builder
+	sqlString.WriteString(`
+		SELECT c.name, r.sum, r.period, r.note
+		FROM usermoneytransactions AS r
+				 INNER JOIN usercategories AS c
+							ON r.category_id = c.id
+				 INNER JOIN users AS u
+							ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.deleted_at IS NULL`)
+	args := []any{userID}
+	// Добавление условия с очередным параметром запроса.
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		sqlString.WriteString(" AND " + strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if filter.Text != "" {
+		addCondition("(c.name ILIKE ? OR r.note ILIKE ?)", "%"+likeEscaper.Replace(filter.Text)+"%")
+	}
+	if filter.SumFrom > 0 {
+		addCondition("r.sum >= ?", filter.SumFrom)
+	}
+	if filter.SumTo > 0 {
+		addCondition("r.sum <= ?", filter.SumTo)
+	}
+	if !filter.PeriodFrom.IsZero() {
+		addCondition("r.period >= ?", filter.PeriodFrom)
+	}
+	if !filter.PeriodTo.IsZero() {
+		addCondition("r.period < ?", filter.PeriodTo)
+	}
+	args = append(args, limit, offset)
+	sqlString.WriteString(" ORDER BY r.period DESC, r.id DESC LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args)) + ";")
+
+	var recs []UserDataSearchRecordDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString.String(), args...); err != nil {
+		return nil, errors.Wrap(err, "Search user data records error")
+	}
+	result := make([]types.UserDataRecord, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.UserDataRecord{
+			UserID:   userID,
+			Category: rec.Category,
+			Sum:      rec.Sum,
+			Period:   rec.Period,
+			Note:     rec.Note,
+		}
+	}
+	return result, nil
+}
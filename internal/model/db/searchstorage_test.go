@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_UserStorage_SearchUserDataRecords(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	period := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  types.UserDataSearchFilter
+		mock    func()
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "Только заданные условия",
+			filter: types.UserDataSearchFilter{Text: "вет_", SumFrom: 500000},
+			mock: func() {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND r.deleted_at IS NULL AND (c.name ILIKE $2 OR r.note ILIKE $2) AND r.sum >= $3 ORDER BY r.period DESC, r.id DESC LIMIT $4 OFFSET $5;")).
+					WithArgs(15236, `%вет\_%`, 500000, 11, 20).
+					WillReturnRows(sqlxmock.NewRows([]string{"name", "sum", "period", "note"}).AddRow("Ветеринар", 650000, period, "прививка"))
+			},
+			want: 1,
+		},
+		{
+			name:   "Без условий",
+			filter: types.UserDataSearchFilter{},
+			mock: func() {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND r.deleted_at IS NULL ORDER BY r.period DESC, r.id DESC LIMIT $2 OFFSET $3;")).
+					WithArgs(15236, 11, 20).
+					WillReturnRows(sqlxmock.NewRows([]string{"name", "sum", "period", "note"}))
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.SearchUserDataRecords(ctx, 15236, tt.filter, 11, 20)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Не совпало ожидание ошибки: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.want {
+				t.Errorf("Не совпало количество записей: got = %v, want %v", len(got), tt.want)
+			}
+		})
+	}
+}
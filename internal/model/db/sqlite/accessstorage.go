@@ -0,0 +1,94 @@
+package sqlite
+
+// Доступ к боту: коды приглашений и блокировка пользователей.
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// GetUserAccess Получение сведений о доступе пользователя (зарегистрирован ли и не заблокирован ли).
+func (storage *UserStorage) GetUserAccess(ctx context.Context, userID int64) (types.UserAccess, error) {
+	const sqlString = `
+		SELECT EXISTS (SELECT 1 FROM users WHERE tg_id = $1) AS user_exists,
+			   EXISTS (SELECT 1 FROM blockedusers WHERE tg_id = $1) AS blocked;`
+
+	res, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return types.UserAccess{}, errors.Wrap(err, "Get user access error")
+	}
+	// Приведение результата запроса к нужному типу (логические значения в SQLite - целые числа).
+	exists, ok1 := res["user_exists"].(int64)
+	blocked, ok2 := res["blocked"].(int64)
+	if !ok1 || !ok2 {
+		return types.UserAccess{}, errors.New("Ошибка приведения типа результата запроса.")
+	}
+	return types.UserAccess{Exists: exists != 0, Blocked: blocked != 0}, nil
+}
+
+// InsertInviteCode Сохранение нового кода приглашения.
+func (storage *UserStorage) InsertInviteCode(ctx context.Context, code string, adminID int64) error {
+	const sqlString = `
+		INSERT INTO invitecodes (code, created_by)
+			VALUES ($1, $2);`
+
+	if _, err := dbutils.Exec(ctx, storage.db, sqlString, code, adminID); err != nil {
+		return errors.Wrap(err, "Insert invite code error")
+	}
+	return nil
+}
+
+// RedeemInviteCode Использование кода приглашения и регистрация пользователя (в транзакции).
+// Возвращает false, если код не найден или уже использован.
+func (storage *UserStorage) RedeemInviteCode(ctx context.Context, code string, userID int64, userName string) (bool, error) {
+	// Код можно использовать только один раз.
+	const sqlString = `
+		UPDATE invitecodes SET used_by = $2, used_at = $3
+		WHERE code = $1 AND used_by IS NULL;`
+
+	// Результат сбрасывается в начале каждой попытки: транзакция может быть повторена.
+	var isRedeemed bool
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			isRedeemed = false
+			res, err := dbutils.Exec(ctx, tx, sqlString, code, userID, utc(time.Now()))
+			if err != nil {
+				return err
+			}
+			cnt, err := res.RowsAffected()
+			if err != nil || cnt == 0 {
+				return err
+			}
+			isRedeemed = true
+			return insertUser(ctx, tx, userID, userName, storage.defaultCurrency, storage.defaultLimits)
+		})
+	if err != nil {
+		return false, errors.Wrap(err, "Redeem invite code error")
+	}
+	return isRedeemed, nil
+}
+
+// SetUserBlocked Блокировка или разблокировка пользователя администратором.
+func (storage *UserStorage) SetUserBlocked(ctx context.Context, userID int64, blocked bool, adminID int64) error {
+	const sqlInsert = `
+		INSERT INTO blockedusers (tg_id, blocked_by)
+			VALUES ($1, $2)
+			ON CONFLICT (tg_id) DO NOTHING;`
+	const sqlDelete = `DELETE FROM blockedusers WHERE tg_id = $1;`
+
+	var err error
+	if blocked {
+		_, err = dbutils.Exec(ctx, storage.db, sqlInsert, userID, adminID)
+	} else {
+		_, err = dbutils.Exec(ctx, storage.db, sqlDelete, userID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Set user blocked error")
+	}
+	return nil
+}
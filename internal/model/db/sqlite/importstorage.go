@@ -0,0 +1,156 @@
+package sqlite
+
+// Пакетное добавление записей о расходах (загрузка истории расходов таблицей).
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// importBatchSize Количество записей в одном запросе многострочной вставки.
+const importBatchSize = 500
+
+// importUserDB Тип, принимающий пользователя для пакетного добавления записей.
+type importUserDB struct {
+	ID     int64 `db:"id"`
+	Limits int64 `db:"limits"`
+}
+
+// importCategoryDB Тип, принимающий категорию пользователя.
+type importCategoryDB struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+// InsertUserDataRecords Пакетное добавление записей о расходах пользователя в одной транзакции
+// (с проверкой бюджета по месяцам записей). Категории добавляются, если их еще нет, реквизиты чеков не сохраняются.
+// mode - types.ImportAllOrNothing (при превышении бюджета хотя бы одной записью не добавляется ничего)
+// или types.ImportBestEffort (записи, превышающие бюджет, пропускаются).
+func (storage *UserStorage) InsertUserDataRecords(ctx context.Context, userID int64, recs []types.UserDataRecord, userName string, mode string) (types.UserDataImportResult, error) {
+	// Проверка существования пользователя в БД.
+	if _, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName); err != nil {
+		return types.UserDataImportResult{}, err
+	}
+
+	// Транзакции SQLite блокируют базу на запись при начале (_txlock=immediate), поэтому бюджет
+	// не может быть превышен параллельным добавлением записей.
+	var result types.UserDataImportResult
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			var err error
+			result, err = insertUserDataRecordsTx(ctx, tx, userID, recs, mode, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return types.UserDataImportResult{}, errors.Wrap(err, "Insert user data records error")
+	}
+	return result, nil
+}
+
+// insertUserDataRecordsTx Функция пакетного добавления расходов, выполняемая внутри транзакции (tx).
+func insertUserDataRecordsTx(ctx context.Context, tx *sqlx.Tx, userID int64, recs []types.UserDataRecord, mode string, defaultTimezone string) (types.UserDataImportResult, error) {
+	const sqlUser = `SELECT id, limits FROM users WHERE tg_id = $1;`
+
+	// Расходы пользователя за месяц (по помесячным итогам).
+	const sqlMonthSum = `
+		SELECT COALESCE(SUM(sum), 0)
+		FROM usermonthlyrollups
+		WHERE user_id = $1 AND month = $2;`
+
+	// Категории пользователя (после добавления новых).
+	const sqlCategories = `SELECT id, name FROM usercategories WHERE user_id = $1 AND deleted_at IS NULL;`
+
+	var users []importUserDB
+	if err := dbutils.Select(ctx, tx, &users, sqlUser, userID); err != nil {
+		return types.UserDataImportResult{}, err
+	}
+	if len(users) == 0 {
+		return types.UserDataImportResult{}, errors.New("Пользователь не найден.")
+	}
+	user := users[0]
+
+	// Проверка бюджета по месяцам записей.
+	accepted, overLimit, err := types.SplitRecordsByLimit(recs, user.Limits, func(month time.Time) (int64, error) {
+		var sums []int64
+		if err := dbutils.Select(ctx, tx, &sums, sqlMonthSum, user.ID, month.Format(time.DateOnly)); err != nil {
+			return 0, err
+		}
+		return sums[0], nil
+	})
+	if err != nil {
+		return types.UserDataImportResult{}, err
+	}
+	result := types.UserDataImportResult{OverLimit: overLimit}
+	if len(accepted) == 0 || (mode == types.ImportAllOrNothing && len(overLimit) > 0) {
+		return result, nil
+	}
+
+	// Добавление всех новых категорий одним запросом (удаленные категории восстанавливаются из корзины).
+	// Загруженные категории и записи не записываются в журнал действий: по одной их отменить нельзя.
+	names := importCategoryNames(accepted)
+	sqlInsertCategories := `
+		INSERT INTO usercategories (user_id, name)
+			VALUES ` + dbutils.ValuesPlaceholders(len(names), 2) + `
+		ON CONFLICT (user_id, utf8_lower(name)) DO UPDATE SET deleted_at = NULL
+			WHERE deleted_at IS NOT NULL;`
+	args := make([]any, 0, len(names)*2)
+	for _, name := range names {
+		args = append(args, user.ID, name)
+	}
+	if _, err := dbutils.Exec(ctx, tx, sqlInsertCategories, args...); err != nil {
+		return types.UserDataImportResult{}, err
+	}
+	var categories []importCategoryDB
+	if err := dbutils.Select(ctx, tx, &categories, sqlCategories, user.ID); err != nil {
+		return types.UserDataImportResult{}, err
+	}
+	categoryIDs := map[string]int64{}
+	for _, cat := range categories {
+		categoryIDs[strings.ToLower(cat.Name)] = cat.ID
+	}
+
+	// Добавление записей многострочными запросами (с изменением помесячных итогов).
+	for start := 0; start < len(accepted); start += importBatchSize {
+		batch := accepted[start:min(start+importBatchSize, len(accepted))]
+		sqlInsertRecords := `
+			INSERT INTO usermoneytransactions (user_id, category_id, sum, period, note)
+				VALUES ` + dbutils.ValuesPlaceholders(len(batch), 5) + `
+			RETURNING id, user_id;`
+		args := make([]any, 0, len(batch)*5)
+		for _, rec := range batch {
+			args = append(args, user.ID, categoryIDs[strings.ToLower(rec.Category)], rec.Sum, utc(rec.Period), rec.Note)
+		}
+		var inserted []insertedDB
+		if err := dbutils.Select(ctx, tx, &inserted, sqlInsertRecords, args...); err != nil {
+			return types.UserDataImportResult{}, err
+		}
+		ids := make([]int64, len(inserted))
+		for ind, obj := range inserted {
+			ids[ind] = obj.ID
+		}
+		if err := changeMonthlyRollups(ctx, tx, ids, rollupAdd, defaultTimezone); err != nil {
+			return types.UserDataImportResult{}, err
+		}
+		result.Inserted += int64(len(inserted))
+	}
+	return result, nil
+}
+
+// importCategoryNames Названия категорий пакета записей без повторов (без учета регистра, по порядку первого появления).
+func importCategoryNames(recs []types.UserDataRecord) []string {
+	var names []string
+	isAdded := map[string]bool{}
+	for _, rec := range recs {
+		if key := strings.ToLower(rec.Category); !isAdded[key] {
+			isAdded[key] = true
+			names = append(names, rec.Category)
+		}
+	}
+	return names
+}
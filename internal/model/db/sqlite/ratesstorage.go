@@ -0,0 +1,96 @@
+package sqlite
+
+// Работа с хранилищем курсов валют.
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// ExchangeRatesStorage Тип для хранилища курсов валют.
+type ExchangeRatesStorage struct {
+	db                  *sqlx.DB
+	usedCurrenciesNames []string
+}
+
+// RateDB Тип, принимающий структуру таблицы курсов валют.
+type RateDB struct {
+	Name string     `db:"currency"`
+	Rate money.Rate `db:"rate"`
+}
+
+// NewExchangeRatesStorage Инициализация хранилища курсов валют.
+// db - *sqlx.DB - ссылка на подключение к БД (см. NewDBConnect).
+// usedCurrenciesNames - []string - массив используемых в приложении валют.
+func NewExchangeRatesStorage(db *sqlx.DB, usedCurrenciesNames []string) *ExchangeRatesStorage {
+	return &ExchangeRatesStorage{
+		db:                  db,
+		usedCurrenciesNames: usedCurrenciesNames,
+	}
+}
+
+// InsertExchangeRatesToDate Добавление курсов используемых валют в базу данных (в транзакции).
+func (storage *ExchangeRatesStorage) InsertExchangeRatesToDate(ctx context.Context, rates types.ExchangeRate, period time.Time) error {
+	// Запрос на добавление данных (в SQLite нет массивов, курсы добавляются по одному).
+	const sqlString = `
+		INSERT INTO exchangerates (currency, rate, period)
+			VALUES ($1, $2, $3)
+			 ON CONFLICT (currency, period) DO NOTHING;`
+
+	err := dbutils.RunTx(ctx, storage.db,
+		func(tx *sqlx.Tx) error {
+			for _, cName := range storage.usedCurrenciesNames {
+				rate, ok := rates[cName]
+				if !ok {
+					continue
+				}
+				if _, err := dbutils.Exec(ctx, tx, sqlString, cName, rate, utc(period)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return errors.Wrap(err, "Insert exchange rates error")
+	}
+	return nil
+}
+
+// GetLastExchangeRates Получение последних курсов используемых валют из базы данных.
+func (storage *ExchangeRatesStorage) GetLastExchangeRates(ctx context.Context) (types.ExchangeRate, error) {
+	// Отбор последних курсов всех валют (используемые валюты отбираются после запроса).
+	const sqlString = `
+		SELECT rt.currency, rt.rate
+		FROM exchangerates AS rt
+         INNER JOIN (SELECT currency,
+                            MAX(period) AS maxperiod
+                     FROM exchangerates
+                     GROUP BY currency)
+                    AS rtMaxPeriod
+                    ON rt.period = rtMaxPeriod.maxperiod
+                        AND rt.currency = rtMaxPeriod.currency;`
+
+	var rates []RateDB
+	// Выполнение запроса на выборку данных (запись в переменную rates).
+	if err := dbutils.Select(ctx, storage.db, &rates, sqlString); err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(storage.usedCurrenciesNames))
+	for _, cName := range storage.usedCurrenciesNames {
+		used[cName] = true
+	}
+	exchangeRates := types.ExchangeRate{}
+	for _, rate := range rates {
+		if used[rate.Name] {
+			exchangeRates[rate.Name] = rate.Rate
+		}
+	}
+	return exchangeRates, nil
+}
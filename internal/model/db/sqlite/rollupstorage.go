@@ -0,0 +1,88 @@
+package sqlite
+
+// Помесячные итоги расходов пользователей по категориям (для быстрых отчетов и проверки бюджета).
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+)
+
+// Итоги хранятся в таблице usermonthlyrollups по месяцам в часовом поясе пользователя и изменяются в тех же
+// транзакциях, что и записи о расходах: добавление, удаление в корзину и восстановление из нее.
+// При изменении часового пояса итоги пользователя пересчитываются.
+
+// Знак изменения итогов на суммы записей.
+const (
+	rollupAdd    = int64(1)  // Добавление записей (или восстановление из корзины).
+	rollupRemove = int64(-1) // Удаление записей в корзину.
+)
+
+// changeMonthlyRollups Изменение помесячных итогов на суммы записей о расходах с идентификаторами ids
+// (внутри транзакции). sign - rollupAdd или rollupRemove.
+func changeMonthlyRollups(ctx context.Context, tx sqlx.ExecerContext, ids []int64, sign int64, defaultTimezone string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	const sqlString = `
+		INSERT INTO usermonthlyrollups AS m (user_id, category_id, month, sum, count)
+			SELECT r.user_id, r.category_id, local_month(r.period, COALESCE(NULLIF(u.timezone, ''), $1)), $2 * SUM(r.sum), $2 * COUNT(*)
+			FROM usermoneytransactions AS r
+				INNER JOIN users AS u
+					ON r.user_id = u.id
+			WHERE r.id IN (SELECT value FROM json_each($3))
+			GROUP BY 1, 2, 3
+		ON CONFLICT (user_id, month, category_id) DO UPDATE
+			SET sum = m.sum + excluded.sum, count = m.count + excluded.count;`
+
+	idsJSON, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	_, err = dbutils.Exec(ctx, tx, sqlString, defaultTimezone, sign, string(idsJSON))
+	return err
+}
+
+// RebuildMonthlyRollups Пересчет помесячных итогов расходов всех пользователей по записям о расходах
+// (например, после изменения часового пояса по умолчанию). Возвращает количество строк итогов.
+func (storage *UserStorage) RebuildMonthlyRollups(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbutils.RunTx(ctx, storage.db,
+		func(tx *sqlx.Tx) error {
+			var err error
+			count, err = rebuildMonthlyRollupsTx(ctx, tx, 0, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return 0, errors.Wrap(err, "Rebuild monthly rollups error")
+	}
+	return count, nil
+}
+
+// rebuildMonthlyRollupsTx Пересчет помесячных итогов пользователя с ТГ-идентификатором userID
+// (0 - всех пользователей), выполняемый внутри транзакции (tx).
+func rebuildMonthlyRollupsTx(ctx context.Context, tx sqlx.ExecerContext, userID int64, defaultTimezone string) (int64, error) {
+	const sqlDelete = `
+		DELETE FROM usermonthlyrollups
+		WHERE user_id IN (SELECT id FROM users WHERE $1 = 0 OR tg_id = $1);`
+	const sqlInsert = `
+		INSERT INTO usermonthlyrollups (user_id, category_id, month, sum, count)
+			SELECT r.user_id, r.category_id, local_month(r.period, COALESCE(NULLIF(u.timezone, ''), $2)), SUM(r.sum), COUNT(*)
+			FROM usermoneytransactions AS r
+				INNER JOIN users AS u
+					ON r.user_id = u.id
+			WHERE ($1 = 0 OR u.tg_id = $1) AND r.deleted_at IS NULL
+			GROUP BY 1, 2, 3;`
+
+	if _, err := dbutils.Exec(ctx, tx, sqlDelete, userID); err != nil {
+		return 0, err
+	}
+	res, err := dbutils.Exec(ctx, tx, sqlInsert, userID, defaultTimezone)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
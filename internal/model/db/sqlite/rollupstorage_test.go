@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/storagetest"
+)
+
+func Test_UserStorage_RebuildMonthlyRollups(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := NewUserStorage(db, storagetest.DefaultCurrency, 0, storagetest.DefaultTimezone)
+
+	period := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, rec := range []types.UserDataRecord{
+		{Category: "Кино", Sum: 1000, Period: period},
+		{Category: "Такси", Sum: 2000, Period: period},
+		{Category: "Такси", Sum: 500, Period: period.AddDate(0, 1, 0)},
+	} {
+		_, err := s.InsertUserDataRecord(ctx, 123, rec, "test", time.Time{})
+		require.NoError(t, err)
+	}
+	report, err := s.GetUserDataRecord(ctx, 123, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	// Итоги, испорченные вне приложения, восстанавливаются по записям о расходах.
+	_, err = db.Exec("UPDATE usermonthlyrollups SET sum = 0;")
+	require.NoError(t, err)
+	count, err := s.RebuildMonthlyRollups(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	got, err := s.GetUserDataRecord(ctx, 123, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, report, got)
+}
+
+// benchmarkRecords Количество записей о расходах пользователя в бенчмарках.
+const benchmarkRecords = 1_000_000
+
+// newBenchmarkStorage Хранилище с benchmarkRecords записями о расходах одного пользователя
+// (6 категорий, записи равномерно распределены по 5 годам до 2025 года) и пересчитанными итогами.
+func newBenchmarkStorage(b *testing.B) (*UserStorage, int64) {
+	ctx := context.Background()
+	db := newTestDB(b)
+	s := NewUserStorage(db, storagetest.DefaultCurrency, 0, storagetest.DefaultTimezone)
+	const userID = 123
+	for i := 1; i <= 6; i++ {
+		require.NoError(b, s.InsertCategory(ctx, userID, fmt.Sprintf("Категория %v", i), "bench"))
+	}
+	// Записи добавляются одним запросом (через InsertUserDataRecord заполнение заняло бы слишком много времени).
+	_, err := db.Exec(`
+		WITH RECURSIVE seq(x) AS (SELECT 0 UNION ALL SELECT x + 1 FROM seq WHERE x < $1 - 1),
+			cats AS (SELECT c.user_id, c.id, row_number() OVER (ORDER BY c.id) - 1 AS rn
+					 FROM usercategories AS c
+							  INNER JOIN users AS u
+										 ON c.user_id = u.id
+					 WHERE u.tg_id = $2)
+		INSERT INTO usermoneytransactions (user_id, category_id, period, sum)
+			SELECT c.user_id, c.id, strftime('%Y-%m-%d %H:%M:%f+00:00', '2020-01-01', '+' || (x * 157) || ' seconds'), 100 + x % 10000
+			FROM seq
+					 INNER JOIN cats AS c
+								ON c.rn = x % 6;`, benchmarkRecords, userID)
+	require.NoError(b, err)
+	_, err = s.RebuildMonthlyRollups(ctx)
+	require.NoError(b, err)
+	return s, userID
+}
+
+// Сравнение отчета за год по помесячным итогам с суммированием записей о расходах
+// и проверки бюджета при добавлении записи.
+func BenchmarkMonthlyRollups(b *testing.B) {
+	ctx := context.Background()
+	s, userID := newBenchmarkStorage(b)
+	period := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	b.Run("ReportRollups", func(b *testing.B) {
+		for b.Loop() {
+			_, err := s.GetUserDataRecord(ctx, userID, period)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("ReportRawRecords", func(b *testing.B) {
+		// Суммирование записей о расходах без итогов (как до появления таблицы usermonthlyrollups).
+		const sqlString = `
+			SELECT c.name, SUM(r.sum) AS sum
+			FROM usermoneytransactions AS r
+					 INNER JOIN users AS u
+								ON r.user_id = u.id
+					 INNER JOIN usercategories AS c
+								ON r.category_id = c.id
+			WHERE u.tg_id = $1 AND r.period >= $2 AND r.deleted_at IS NULL
+			GROUP BY c.name
+			ORDER BY c.name;`
+		for b.Loop() {
+			var recs []UserDataReportRecordDB
+			require.NoError(b, s.db.SelectContext(ctx, &recs, sqlString, userID, utc(period)))
+		}
+	})
+
+	b.Run("InsertWithLimitCheck", func(b *testing.B) {
+		require.NoError(b, s.SetUserLimit(ctx, userID, 1<<40, "bench"))
+		rec := types.UserDataRecord{Category: "Категория 1", Sum: 100, Period: time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)}
+		limitPeriod := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+		for b.Loop() {
+			_, err := s.InsertUserDataRecord(ctx, userID, rec, "bench", limitPeriod)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("Rebuild", func(b *testing.B) {
+		for b.Loop() {
+			_, err := s.RebuildMonthlyRollups(ctx)
+			require.NoError(b, err)
+		}
+	})
+}
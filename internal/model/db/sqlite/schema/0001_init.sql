@@ -0,0 +1,158 @@
+-- Схема базы данных SQLite (соответствует миграциям Postgres в каталоге migrations).
+-- Время хранится в UTC в текстовом формате "YYYY-MM-DD HH:MM:SS.SSS+00:00",
+-- регистронезависимое сравнение строк выполняется функцией utf8_lower (регистрируется приложением).
+
+create table if not exists users
+(
+    id       integer primary key,
+    tg_id    integer not null
+        constraint users_tg_id_unique unique,
+    name     text    not null
+        constraint users_name_check
+            check (name <> ''),
+    currency text    not null,
+    limits   integer not null,
+    timezone text    not null default ''
+);
+
+create table if not exists usercategories
+(
+    id      integer primary key,
+    user_id integer not null references users (id) on delete cascade,
+    name    text    not null
+        constraint usercategories_name_check
+            check (name <> '')
+);
+
+create unique index if not exists usercategories_user_id_lower_name
+    on usercategories (user_id, utf8_lower(name));
+
+create table if not exists usermoneytransactions
+(
+    id          integer primary key,
+    user_id     integer  not null references users (id) on delete cascade,
+    category_id integer  not null references usercategories (id) on delete cascade,
+    period      datetime not null,
+    sum         integer  not null, -- (сумма в копейках)
+    note        text     not null default '',
+    created_at  datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create index if not exists usermoneytransactions_user_id_period
+    on usermoneytransactions (user_id, period);
+
+create index if not exists usermoneytransactions_created_at
+    on usermoneytransactions (created_at);
+
+create table if not exists exchangerates
+(
+    id       integer primary key,
+    period   datetime not null,
+    currency text     not null
+        constraint exchangerates_currency_check
+            check (currency <> ''),
+    rate     real     not null
+);
+
+create unique index if not exists exchangerates_currency_period
+    on exchangerates (currency, period);
+
+create table if not exists useractions
+(
+    id         integer primary key,
+    user_id    integer  not null references users (id) on delete cascade,
+    action     text     not null
+        constraint useractions_action_check
+            check (action in ('record', 'category', 'limit', 'currency')),
+    object_id  integer,  -- (идентификатор добавленной записи или категории)
+    prev_value text,     -- (предыдущее значение бюджета или валюты)
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create index if not exists useractions_user_id_created_at
+    on useractions (user_id, created_at);
+
+create table if not exists userreceipts
+(
+    id             integer primary key,
+    user_id        integer not null references users (id) on delete cascade,
+    transaction_id integer not null references usermoneytransactions (id) on delete cascade,
+    fn             text    not null, -- (номер фискального накопителя)
+    fd             text    not null, -- (номер фискального документа)
+    fp             text    not null  -- (фискальный признак документа)
+);
+
+create unique index if not exists userreceipts_user_id_fn_fd_fp
+    on userreceipts (user_id, fn, fd, fp);
+
+create table if not exists usergoals
+(
+    id         integer primary key,
+    user_id    integer  not null references users (id) on delete cascade,
+    name       text     not null
+        constraint usergoals_name_check
+            check (name <> ''),
+    currency   text     not null,
+    target     integer  not null -- (целевая сумма в копейках валюты цели)
+        constraint usergoals_target_check
+            check (target > 0),
+    saved      integer  not null default 0, -- (накопленная сумма в копейках валюты цели)
+    deadline   datetime not null,
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create index if not exists usergoals_user_id
+    on usergoals (user_id);
+
+create table if not exists usersubscriptions
+(
+    id           integer primary key,
+    user_id      integer  not null references users (id) on delete cascade,
+    kind         text     not null -- (w - еженедельный отчет, m - ежемесячный отчет)
+        constraint usersubscriptions_kind_check
+            check (kind in ('w', 'm')),
+    last_sent_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create unique index if not exists usersubscriptions_user_id_kind
+    on usersubscriptions (user_id, kind);
+
+create table if not exists adminaudit
+(
+    id          integer primary key,
+    admin_tg_id integer  not null, -- (ТГ-идентификатор администратора)
+    command     text     not null,
+    args        text     not null default '',
+    created_at  datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create index if not exists adminaudit_created_at
+    on adminaudit (created_at);
+
+create table if not exists invitecodes
+(
+    id         integer primary key,
+    code       text     not null,
+    created_by integer  not null, -- (ТГ-идентификатор администратора)
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    used_by    integer,           -- (ТГ-идентификатор пользователя, использовавшего код)
+    used_at    datetime
+);
+
+create unique index if not exists invitecodes_code
+    on invitecodes (code);
+
+create table if not exists blockedusers
+(
+    tg_id      integer  not null primary key,
+    blocked_by integer  not null, -- (ТГ-идентификатор администратора)
+    created_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create table if not exists userdeletions
+(
+    id         integer primary key,
+    tg_id      integer  not null, -- (ТГ-идентификатор удаленного пользователя)
+    records    integer  not null default 0, -- (количество удаленных записей о расходах)
+    deleted_at datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
@@ -0,0 +1,19 @@
+-- Курсы валют хранятся десятичными строками (в SQLite нет точного десятичного типа, real теряет знаки).
+create table exchangerates_decimal
+(
+    id       integer primary key,
+    period   datetime not null,
+    currency text     not null
+        constraint exchangerates_currency_check
+            check (currency <> ''),
+    rate     text     not null
+);
+
+insert into exchangerates_decimal (id, period, currency, rate)
+    select id, period, currency, cast(rate as text) from exchangerates;
+
+drop table exchangerates;
+alter table exchangerates_decimal rename to exchangerates;
+
+create unique index if not exists exchangerates_currency_period
+    on exchangerates (currency, period);
@@ -0,0 +1,150 @@
+-- Мягкое удаление записей о расходах и категорий (корзина) и журнал изменений (соответствует миграции
+-- 20221031120000_add_soft_delete_and_useraudit.sql). Значения в журнале хранятся в формате JSON.
+alter table usercategories
+    add column deleted_at datetime; -- (время удаления категории, null - не удалена)
+alter table usermoneytransactions
+    add column deleted_at datetime; -- (время удаления записи, null - не удалена)
+
+create index if not exists usermoneytransactions_user_id_deleted_at
+    on usermoneytransactions (user_id, deleted_at)
+    where deleted_at is not null;
+
+-- Записи о расходах не удаляются каскадно вместе с категорией (внешний ключ в SQLite нельзя изменить
+-- без пересоздания таблицы): категорию с записями можно удалить только вместе с пользователем.
+create trigger if not exists usercategories_keep_records
+    before delete
+    on usercategories
+    when exists (select 1 from users where id = old.user_id)
+        and exists (select 1 from usermoneytransactions where category_id = old.id)
+begin
+    select raise(abort, 'usercategories: category has records');
+end;
+
+create table if not exists useraudit
+(
+    id          integer primary key,
+    user_id     integer  not null, -- (владелец измененных данных; без внешнего ключа, чтобы журнал не изменялся каскадно)
+    actor_tg_id integer,           -- (ТГ-идентификатор пользователя, совершившего изменение)
+    entity      text     not null
+        constraint useraudit_entity_check
+            check (entity in ('record', 'category', 'settings')),
+    object_id   integer  not null, -- (идентификатор записи, категории или пользователя)
+    operation   text     not null
+        constraint useraudit_operation_check
+            check (operation in ('insert', 'update', 'delete', 'restore')),
+    old_value   text,              -- (значения до изменения)
+    new_value   text,              -- (значения после изменения)
+    created_at  datetime not null default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+create index if not exists useraudit_user_id_created_at
+    on useraudit (user_id, created_at);
+
+create trigger if not exists usermoneytransactions_audit_insert
+    after insert
+    on usermoneytransactions
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.user_id, (select tg_id from users where id = new.user_id), 'record', new.id, 'insert',
+            json_object('category_id', new.category_id, 'period', new.period, 'sum', new.sum,
+                        'note', new.note, 'deleted_at', new.deleted_at));
+end;
+
+create trigger if not exists usermoneytransactions_audit_update
+    after update
+    on usermoneytransactions
+    when old.category_id is not new.category_id or old.period is not new.period or old.sum is not new.sum
+        or old.note is not new.note or old.deleted_at is not new.deleted_at
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.user_id, (select tg_id from users where id = new.user_id), 'record', new.id,
+            case
+                when old.deleted_at is null and new.deleted_at is not null then 'delete'
+                when old.deleted_at is not null and new.deleted_at is null then 'restore'
+                else 'update'
+                end,
+            json_object('category_id', old.category_id, 'period', old.period, 'sum', old.sum,
+                        'note', old.note, 'deleted_at', old.deleted_at),
+            json_object('category_id', new.category_id, 'period', new.period, 'sum', new.sum,
+                        'note', new.note, 'deleted_at', new.deleted_at));
+end;
+
+create trigger if not exists usermoneytransactions_audit_delete
+    after delete
+    on usermoneytransactions
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value)
+    values (old.user_id, (select tg_id from users where id = old.user_id), 'record', old.id, 'delete',
+            json_object('category_id', old.category_id, 'period', old.period, 'sum', old.sum,
+                        'note', old.note, 'deleted_at', old.deleted_at));
+end;
+
+create trigger if not exists usercategories_audit_insert
+    after insert
+    on usercategories
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.user_id, (select tg_id from users where id = new.user_id), 'category', new.id, 'insert',
+            json_object('name', new.name, 'deleted_at', new.deleted_at));
+end;
+
+create trigger if not exists usercategories_audit_update
+    after update
+    on usercategories
+    when old.name is not new.name or old.deleted_at is not new.deleted_at
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.user_id, (select tg_id from users where id = new.user_id), 'category', new.id,
+            case
+                when old.deleted_at is null and new.deleted_at is not null then 'delete'
+                when old.deleted_at is not null and new.deleted_at is null then 'restore'
+                else 'update'
+                end,
+            json_object('name', old.name, 'deleted_at', old.deleted_at),
+            json_object('name', new.name, 'deleted_at', new.deleted_at));
+end;
+
+create trigger if not exists usercategories_audit_delete
+    after delete
+    on usercategories
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value)
+    values (old.user_id, (select tg_id from users where id = old.user_id), 'category', old.id, 'delete',
+            json_object('name', old.name, 'deleted_at', old.deleted_at));
+end;
+
+create trigger if not exists users_audit_insert
+    after insert
+    on users
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.id, new.tg_id, 'settings', new.id, 'insert',
+            json_object('currency', new.currency, 'limits', new.limits, 'timezone', new.timezone));
+end;
+
+create trigger if not exists users_audit_update
+    after update
+    on users
+    when old.currency is not new.currency or old.limits is not new.limits or old.timezone is not new.timezone
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.id, new.tg_id, 'settings', new.id, 'update',
+            json_object('currency', old.currency, 'limits', old.limits, 'timezone', old.timezone),
+            json_object('currency', new.currency, 'limits', new.limits, 'timezone', new.timezone));
+end;
+
+-- Журнал не изменяется, записи удаляются только вместе с данными удаленного пользователя.
+create trigger if not exists useraudit_protect_update
+    before update
+    on useraudit
+begin
+    select raise(abort, 'useraudit is append-only');
+end;
+
+create trigger if not exists useraudit_protect_delete
+    before delete
+    on useraudit
+    when exists (select 1 from users where id = old.user_id)
+begin
+    select raise(abort, 'useraudit is append-only');
+end;
@@ -0,0 +1,21 @@
+-- Помесячные итоги расходов пользователей по категориям (без удаленных записей).
+-- Месяц - первый день месяца в часовом поясе пользователя в формате "YYYY-MM-DD" (вычисляется функцией local_month).
+create table if not exists usermonthlyrollups
+(
+    user_id     integer not null references users (id) on delete cascade,
+    category_id integer not null references usercategories (id) on delete cascade,
+    month       text    not null,
+    sum         integer not null, -- (сумма в копейках)
+    count       integer not null, -- (количество записей)
+    primary key (user_id, month, category_id)
+) without rowid;
+
+-- Заполнение итогов по уже добавленным записям (для пользователей, не выбравших часовой пояс, - Europe/Moscow;
+-- после применения схемы итоги в той же транзакции пересчитываются с часовым поясом по умолчанию из конфигурации).
+insert into usermonthlyrollups (user_id, category_id, month, sum, count)
+    select r.user_id, r.category_id, local_month(r.period, coalesce(nullif(u.timezone, ''), 'Europe/Moscow')), sum(r.sum), count(*)
+    from usermoneytransactions as r
+             inner join users as u
+                        on r.user_id = u.id
+    where r.deleted_at is null
+    group by 1, 2, 3;
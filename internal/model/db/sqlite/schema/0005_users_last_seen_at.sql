@@ -0,0 +1,11 @@
+-- Время последнего обращения пользователя к боту для статистики активности DAU/MAU
+-- (соответствует миграции 20221102120000_add_users_last_seen_at.sql).
+alter table users
+    add column last_seen_at datetime;
+
+-- Начальное значение - время ввода последней записи о расходах (ранее активность считалась по записям).
+update users
+set last_seen_at = (select max(r.created_at) from usermoneytransactions as r where r.user_id = users.id);
+
+create index if not exists users_last_seen_at
+    on users (last_seen_at);
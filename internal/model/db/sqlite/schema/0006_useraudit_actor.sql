@@ -0,0 +1,112 @@
+-- Пользователь, совершивший изменение, передается триггерам журнала изменений через таблицу auditactor
+-- (соответствует миграции 20221103120000_useraudit_actor_setting.sql). Строка добавляется хранилищем в начале
+-- транзакции и удаляется перед ее фиксацией; для изменений без пользователя (начальные данные, команды запуска)
+-- таблица пуста и поле actor_tg_id остается пустым. Временные таблицы в триггерах недоступны,
+-- поэтому таблица обычная.
+create table if not exists auditactor
+(
+    id    integer primary key check (id = 1),
+    tg_id integer not null -- (ТГ-идентификатор пользователя, совершающего изменение)
+);
+
+drop trigger if exists usermoneytransactions_audit_insert;
+drop trigger if exists usermoneytransactions_audit_update;
+drop trigger if exists usermoneytransactions_audit_delete;
+drop trigger if exists usercategories_audit_insert;
+drop trigger if exists usercategories_audit_update;
+drop trigger if exists usercategories_audit_delete;
+drop trigger if exists users_audit_insert;
+drop trigger if exists users_audit_update;
+
+create trigger usermoneytransactions_audit_insert
+    after insert
+    on usermoneytransactions
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.user_id, (select tg_id from auditactor), 'record', new.id, 'insert',
+            json_object('category_id', new.category_id, 'period', new.period, 'sum', new.sum,
+                        'note', new.note, 'deleted_at', new.deleted_at));
+end;
+
+create trigger usermoneytransactions_audit_update
+    after update
+    on usermoneytransactions
+    when old.category_id is not new.category_id or old.period is not new.period or old.sum is not new.sum
+        or old.note is not new.note or old.deleted_at is not new.deleted_at
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.user_id, (select tg_id from auditactor), 'record', new.id,
+            case
+                when old.deleted_at is null and new.deleted_at is not null then 'delete'
+                when old.deleted_at is not null and new.deleted_at is null then 'restore'
+                else 'update'
+                end,
+            json_object('category_id', old.category_id, 'period', old.period, 'sum', old.sum,
+                        'note', old.note, 'deleted_at', old.deleted_at),
+            json_object('category_id', new.category_id, 'period', new.period, 'sum', new.sum,
+                        'note', new.note, 'deleted_at', new.deleted_at));
+end;
+
+create trigger usermoneytransactions_audit_delete
+    after delete
+    on usermoneytransactions
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value)
+    values (old.user_id, (select tg_id from auditactor), 'record', old.id, 'delete',
+            json_object('category_id', old.category_id, 'period', old.period, 'sum', old.sum,
+                        'note', old.note, 'deleted_at', old.deleted_at));
+end;
+
+create trigger usercategories_audit_insert
+    after insert
+    on usercategories
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.user_id, (select tg_id from auditactor), 'category', new.id, 'insert',
+            json_object('name', new.name, 'deleted_at', new.deleted_at));
+end;
+
+create trigger usercategories_audit_update
+    after update
+    on usercategories
+    when old.name is not new.name or old.deleted_at is not new.deleted_at
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.user_id, (select tg_id from auditactor), 'category', new.id,
+            case
+                when old.deleted_at is null and new.deleted_at is not null then 'delete'
+                when old.deleted_at is not null and new.deleted_at is null then 'restore'
+                else 'update'
+                end,
+            json_object('name', old.name, 'deleted_at', old.deleted_at),
+            json_object('name', new.name, 'deleted_at', new.deleted_at));
+end;
+
+create trigger usercategories_audit_delete
+    after delete
+    on usercategories
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value)
+    values (old.user_id, (select tg_id from auditactor), 'category', old.id, 'delete',
+            json_object('name', old.name, 'deleted_at', old.deleted_at));
+end;
+
+create trigger users_audit_insert
+    after insert
+    on users
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, new_value)
+    values (new.id, (select tg_id from auditactor), 'settings', new.id, 'insert',
+            json_object('currency', new.currency, 'limits', new.limits, 'timezone', new.timezone));
+end;
+
+create trigger users_audit_update
+    after update
+    on users
+    when old.currency is not new.currency or old.limits is not new.limits or old.timezone is not new.timezone
+begin
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (new.id, (select tg_id from auditactor), 'settings', new.id, 'update',
+            json_object('currency', old.currency, 'limits', old.limits, 'timezone', old.timezone),
+            json_object('currency', new.currency, 'limits', new.limits, 'timezone', new.timezone));
+end;
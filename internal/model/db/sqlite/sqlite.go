@@ -0,0 +1,187 @@
+// Package sqlite Работа с хранилищами на базе SQLite (для небольших установок и локальной разработки).
+package sqlite
+
+// Подключение к базе данных SQLite и создание схемы.
+
+import (
+	"context"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	"modernc.org/sqlite"
+)
+
+// connPrefix Префикс строки подключения к базе данных SQLite (например, "sqlite://data/bot.db").
+const connPrefix = "sqlite://"
+
+// connParams Параметры подключения: внешние ключи (для каскадного удаления), ожидание блокировки,
+// запись времени в формате, пригодном для сравнения строк, и блокировка базы в начале транзакции.
+var connParams = []string{"_pragma=foreign_keys(1)", "_pragma=busy_timeout(5000)", "_time_format=sqlite", "_txlock=immediate"}
+
+// timeLayout Формат времени в базе данных (время хранится в UTC).
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// Файлы схемы базы данных (применяются по порядку, номер последнего примененного хранится в user_version).
+//
+//go:embed schema/*.sql
+var schemaFS embed.FS
+
+func init() {
+	// Регистронезависимое сравнение строк (встроенная функция lower в SQLite учитывает только латиницу).
+	sqlite.MustRegisterDeterministicScalarFunction("utf8_lower", 1,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			switch value := args[0].(type) {
+			case string:
+				return strings.ToLower(value), nil
+			case []byte:
+				return strings.ToLower(string(value)), nil
+			default:
+				return value, nil
+			}
+		})
+	// Месяц времени из базы данных в часовом поясе ("YYYY-MM-DD", первый день месяца) - для помесячных итогов расходов.
+	sqlite.MustRegisterDeterministicScalarFunction("local_month", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			var t time.Time
+			switch value := args[0].(type) {
+			case string:
+				var err error
+				if t, err = parseTime(value); err != nil {
+					return nil, err
+				}
+			case time.Time:
+				t = value
+			default:
+				return nil, errors.New("Неверный тип времени.")
+			}
+			timezone, _ := args[1].(string)
+			loc, err := loadLocation(timezone)
+			if err != nil {
+				return nil, err
+			}
+			return timeutils.BeginOfMonth(t.In(loc)).Format(time.DateOnly), nil
+		})
+}
+
+// locations Загруженные часовые пояса (функция local_month вызывается для каждой записи о расходах).
+var locations sync.Map
+
+// loadLocation Загрузка часового пояса по названию с кэшированием.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "Load location error")
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// IsConnString Проверка, что строка подключения относится к базе данных SQLite.
+func IsConnString(connString string) bool {
+	return strings.HasPrefix(connString, connPrefix)
+}
+
+// NewDBConnect Инициализация подключения к базе данных SQLite с созданием или обновлением схемы.
+// connString - строка подключения вида "sqlite://путь/к/файлу.db" (или "sqlite://:memory:").
+// defaultTimezone - часовой пояс пользователей по умолчанию (для заполнения помесячных итогов при обновлении схемы).
+func NewDBConnect(connString string, defaultTimezone string) (*sqlx.DB, error) {
+	dsn := strings.TrimPrefix(connString, connPrefix)
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	// Создание каталога файла базы данных (например, data при первом запуске).
+	if path, _, _ := strings.Cut(dsn, "?"); path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			logger.Error("Ошибка создания каталога БД", "err", err)
+			return nil, fmt.Errorf("Ошибка: prepare db connection: %w", err)
+		}
+	}
+	dbh, err := sqlx.Connect("sqlite", "file:"+dsn+separator+strings.Join(connParams, "&"))
+	if err != nil {
+		logger.Error("Ошибка соединения с БД", "err", err)
+		return nil, fmt.Errorf("Ошибка: prepare db connection: %w", err)
+	}
+	// SQLite допускает одну пишущую транзакцию, база в памяти существует в рамках одного соединения.
+	dbh.SetMaxOpenConns(1)
+	if err := migrate(context.Background(), dbh, defaultTimezone); err != nil {
+		dbh.Close()
+		logger.Error("Ошибка создания схемы БД", "err", err)
+		return nil, err
+	}
+	return dbh, nil
+}
+
+// rollupsSchemaFile Файл схемы, добавляющий помесячные итоги (заполняет их с часовым поясом Europe/Moscow).
+const rollupsSchemaFile = "schema/0004_usermonthlyrollups.sql"
+
+// migrate Применение еще не примененных файлов схемы базы данных.
+// После добавления помесячных итогов они пересчитываются в той же транзакции с часовым поясом по умолчанию defaultTimezone.
+func migrate(ctx context.Context, db *sqlx.DB, defaultTimezone string) error {
+	files, err := fs.Glob(schemaFS, "schema/*.sql")
+	if err != nil {
+		return errors.Wrap(err, "Read schema error")
+	}
+	sort.Strings(files)
+
+	var version int
+	if err := db.GetContext(ctx, &version, "PRAGMA user_version;"); err != nil {
+		return errors.Wrap(err, "Get schema version error")
+	}
+	for ind := version; ind < len(files); ind++ {
+		script, err := schemaFS.ReadFile(files[ind])
+		if err != nil {
+			return errors.Wrap(err, "Read schema error")
+		}
+		err = dbutils.RunTx(ctx, db, func(tx *sqlx.Tx) error {
+			if _, err := dbutils.Exec(ctx, tx, string(script)); err != nil {
+				return err
+			}
+			if files[ind] == rollupsSchemaFile {
+				if _, err := rebuildMonthlyRollupsTx(ctx, tx, 0, defaultTimezone); err != nil {
+					return err
+				}
+			}
+			_, err := dbutils.Exec(ctx, tx, fmt.Sprintf("PRAGMA user_version = %d;", ind+1))
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Apply schema %v error", files[ind])
+		}
+		logger.Info("Применена схема БД", "file", files[ind])
+	}
+	return nil
+}
+
+// utc Приведение времени к UTC (время в базе сравнивается как строки, поэтому хранится в одном часовом поясе).
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// parseTime Разбор времени, полученного из базы данных в виде строки (результаты агрегатных функций).
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Parse time error")
+	}
+	return t, nil
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+// Работа с хранилищем подписок пользователей на регулярные отчеты.
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// SubscriptionDB Тип, принимающий структуру таблицы подписок.
+type SubscriptionDB struct {
+	ID         int64     `db:"id"`
+	UserID     int64     `db:"tg_id"`
+	Kind       string    `db:"kind"`
+	LastSentAt time.Time `db:"last_sent_at"`
+	Timezone   string    `db:"timezone"`
+}
+
+// GetUserSubscriptions Получение списка видов подписок пользователя.
+func (storage *UserStorage) GetUserSubscriptions(ctx context.Context, userID int64) ([]string, error) {
+	// Отбор подписок по пользователю.
+	const sqlString = `
+		SELECT s.kind
+		FROM usersubscriptions AS s
+			INNER JOIN users AS u
+				ON s.user_id = u.id
+		WHERE u.tg_id = $1
+		ORDER BY s.kind;`
+
+	var recs []string
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID); err != nil {
+		return nil, errors.Wrap(err, "Get user subscriptions error")
+	}
+	return recs, nil
+}
+
+// SetUserSubscription Включение или отключение подписки пользователя.
+func (storage *UserStorage) SetUserSubscription(ctx context.Context, userID int64, kind string, enabled bool, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+
+	// Запрос на добавление подписки (время последней отправки - текущее,
+	// чтобы первый отчет был отправлен в ближайший срок по расписанию, а не сразу).
+	const sqlInsert = `
+		INSERT INTO usersubscriptions (user_id, kind)
+			SELECT id, $2 FROM users WHERE users.tg_id = $1
+		ON CONFLICT (user_id, kind) DO NOTHING;`
+	// Запрос на удаление подписки.
+	const sqlDelete = `
+		DELETE FROM usersubscriptions
+		WHERE kind = $2 AND user_id = (SELECT id FROM users WHERE tg_id = $1);`
+
+	sqlString := sqlDelete
+	if enabled {
+		sqlString = sqlInsert
+	}
+	if _, err := dbutils.Exec(ctx, storage.db, sqlString, userID, kind); err != nil {
+		return errors.Wrap(err, "Set user subscription error")
+	}
+	return nil
+}
+
+// GetSubscriptions Получение всех подписок пользователей (для планировщика отчетов).
+func (storage *UserStorage) GetSubscriptions(ctx context.Context) ([]types.Subscription, error) {
+	const sqlString = `
+		SELECT s.id, u.tg_id, s.kind, s.last_sent_at, COALESCE(NULLIF(u.timezone, ''), $1) AS timezone
+		FROM usersubscriptions AS s
+			INNER JOIN users AS u
+				ON s.user_id = u.id
+		WHERE NOT EXISTS (SELECT 1 FROM blockedusers AS b WHERE b.tg_id = u.tg_id)
+		ORDER BY s.id;`
+
+	var recs []SubscriptionDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, storage.defaultTimezone); err != nil {
+		return nil, errors.Wrap(err, "Get subscriptions error")
+	}
+	result := make([]types.Subscription, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.Subscription(rec)
+		result[ind].LastSentAt = rec.LastSentAt.Local()
+	}
+	return result, nil
+}
+
+// MarkSubscriptionSent Отметка об отправке отчета по подписке.
+// Возвращает false, если отчет за указанный срок уже был отправлен (например, другим экземпляром бота).
+func (storage *UserStorage) MarkSubscriptionSent(ctx context.Context, subscriptionID int64, dueAt time.Time, sentAt time.Time) (bool, error) {
+	// Запрос на обновление только неотправленной подписки.
+	const sqlString = `
+		UPDATE usersubscriptions SET last_sent_at = $3
+		WHERE id = $1 AND last_sent_at < $2;`
+
+	res, err := dbutils.Exec(ctx, storage.db, sqlString, subscriptionID, utc(dueAt), utc(sentAt))
+	if err != nil {
+		return false, errors.Wrap(err, "Mark subscription sent error")
+	}
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Mark subscription sent error")
+	}
+	return cnt > 0, nil
+}
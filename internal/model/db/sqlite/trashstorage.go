@@ -0,0 +1,175 @@
+package sqlite
+
+// Корзина: удаленные записи о расходах и категории с возможностью восстановления.
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// TrashRecordDB Тип, принимающий удаленную запись о расходах.
+type TrashRecordDB struct {
+	ID        int64     `db:"id"`
+	Category  string    `db:"name"`
+	Sum       int64     `db:"sum"`
+	Period    time.Time `db:"period"`
+	Note      string    `db:"note"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// TrashCategoryDB Тип, принимающий удаленную категорию.
+type TrashCategoryDB struct {
+	ID        int64     `db:"id"`
+	Category  string    `db:"name"`
+	Sum       int64     `db:"sum"`
+	Records   int64     `db:"records"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// trashObjectDB Тип, принимающий восстанавливаемый объект.
+type trashObjectDB struct {
+	ID         int64  `db:"id"`
+	CategoryID int64  `db:"category_id"`
+	DeletedAt  string `db:"deleted_at"`
+}
+
+// GetUserTrash Получение содержимого корзины пользователя: записей и категорий, удаленных не ранее since
+// (новые - первыми). Записи, удаленные вместе с категорией, входят в элемент категории.
+func (storage *UserStorage) GetUserTrash(ctx context.Context, userID int64, since time.Time) ([]types.TrashItem, error) {
+	const sqlRecords = `
+		SELECT r.id, c.name, r.sum, r.period, r.note, r.deleted_at
+		FROM usermoneytransactions AS r
+			INNER JOIN usercategories AS c
+				ON r.category_id = c.id
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.deleted_at >= $2
+		  AND (c.deleted_at IS NULL OR c.deleted_at <> r.deleted_at);`
+
+	const sqlCategories = `
+		SELECT c.id, c.name, COALESCE(SUM(r.sum), 0) AS sum, COUNT(r.id) AS records, c.deleted_at
+		FROM usercategories AS c
+			INNER JOIN users AS u
+				ON c.user_id = u.id
+			LEFT JOIN usermoneytransactions AS r
+				ON r.category_id = c.id AND r.deleted_at = c.deleted_at
+		WHERE u.tg_id = $1 AND c.deleted_at >= $2
+		GROUP BY c.id;`
+
+	var records []TrashRecordDB
+	if err := dbutils.Select(ctx, storage.db, &records, sqlRecords, userID, utc(since)); err != nil {
+		return nil, errors.Wrap(err, "Get user trash error")
+	}
+	var categories []TrashCategoryDB
+	if err := dbutils.Select(ctx, storage.db, &categories, sqlCategories, userID, utc(since)); err != nil {
+		return nil, errors.Wrap(err, "Get user trash error")
+	}
+
+	result := make([]types.TrashItem, 0, len(records)+len(categories))
+	for _, rec := range records {
+		result = append(result, types.TrashItem{
+			Kind:      types.UserActionRecord,
+			ID:        rec.ID,
+			Category:  rec.Category,
+			Sum:       rec.Sum,
+			Period:    rec.Period.Local(),
+			Note:      rec.Note,
+			DeletedAt: rec.DeletedAt.Local(),
+		})
+	}
+	for _, cat := range categories {
+		result = append(result, types.TrashItem{
+			Kind:      types.UserActionCategory,
+			ID:        cat.ID,
+			Category:  cat.Category,
+			Sum:       cat.Sum,
+			Records:   cat.Records,
+			DeletedAt: cat.DeletedAt.Local(),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(result[j].DeletedAt) {
+			return result[i].DeletedAt.After(result[j].DeletedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+// RestoreTrashItem Восстановление записи или категории, удаленной не ранее since (в транзакции).
+// Вместе с записью восстанавливается ее категория, вместе с категорией - записи, удаленные вместе с ней.
+// Возвращает false, если объект не найден в корзине.
+func (storage *UserStorage) RestoreTrashItem(ctx context.Context, userID int64, kind string, id int64, since time.Time) (bool, error) {
+	// Запросы выборки объекта из корзины (время удаления - строкой для точного сравнения).
+	const sqlSelectRecord = `
+		SELECT r.id, r.category_id, CAST(r.deleted_at AS TEXT) AS deleted_at
+		FROM usermoneytransactions AS r
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.id = $2 AND r.deleted_at >= $3;`
+
+	const sqlSelectCategory = `
+		SELECT c.id, c.id AS category_id, CAST(c.deleted_at AS TEXT) AS deleted_at
+		FROM usercategories AS c
+			INNER JOIN users AS u
+				ON c.user_id = u.id
+		WHERE u.tg_id = $1 AND c.id = $2 AND c.deleted_at >= $3;`
+
+	// Запросы восстановления записей (суммы восстановленных записей добавляются в помесячные итоги).
+	const sqlRestoreRecord = `UPDATE usermoneytransactions SET deleted_at = NULL WHERE id = $1 RETURNING id;`
+	const sqlRestoreRecords = `UPDATE usermoneytransactions SET deleted_at = NULL WHERE category_id = $1 AND deleted_at = $2 RETURNING id;`
+	const sqlRestoreCategory = `UPDATE usercategories SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL;`
+
+	var sqlSelect string
+	switch kind {
+	case types.UserActionRecord:
+		sqlSelect = sqlSelectRecord
+	case types.UserActionCategory:
+		sqlSelect = sqlSelectCategory
+	default:
+		return false, errors.New("Неизвестный тип объекта корзины.")
+	}
+
+	// Транзакции SQLite блокируют базу на запись при начале (_txlock=immediate), поэтому параллельное восстановление невозможно.
+	var isRestored bool
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			isRestored = false
+			var recs []trashObjectDB
+			if err := dbutils.Select(ctx, tx, &recs, sqlSelect, userID, id, utc(since)); err != nil {
+				return err
+			}
+			if len(recs) == 0 {
+				return nil
+			}
+			obj := recs[0]
+			var restoredIDs []int64
+			if kind == types.UserActionRecord {
+				if err := dbutils.Select(ctx, tx, &restoredIDs, sqlRestoreRecord, obj.ID); err != nil {
+					return err
+				}
+			} else {
+				if err := dbutils.Select(ctx, tx, &restoredIDs, sqlRestoreRecords, obj.CategoryID, obj.DeletedAt); err != nil {
+					return err
+				}
+			}
+			if err := changeMonthlyRollups(ctx, tx, restoredIDs, rollupAdd, storage.defaultTimezone); err != nil {
+				return err
+			}
+			if _, err := dbutils.Exec(ctx, tx, sqlRestoreCategory, obj.CategoryID); err != nil {
+				return err
+			}
+			isRestored = true
+			return nil
+		})
+	if err != nil {
+		return false, errors.Wrap(err, "Restore trash item error")
+	}
+	return isRestored, nil
+}
@@ -0,0 +1,646 @@
+package sqlite
+
+// Работа с хранилищем информации о пользователях.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// UserDataReportRecordDB - Тип, принимающий структуру записей о расходах.
+type UserDataReportRecordDB struct {
+	Category string `db:"name"`
+	Sum      int64  `db:"sum"`
+}
+
+// UserStorage - Тип для хранилища информации о пользователях.
+type UserStorage struct {
+	db              *sqlx.DB
+	defaultCurrency string
+	defaultLimits   int64
+	defaultTimezone string
+}
+
+// NewUserStorage - Инициализация хранилища информации о пользователях.
+// db - *sqlx.DB - ссылка на подключение к БД (см. NewDBConnect).
+// defaultCurrency - string - валюта по умолчанию.
+// defaultLimits - int64 - бюджет по умолчанию.
+// defaultTimezone - string - часовой пояс по умолчанию (для пользователей, не выбравших часовой пояс).
+func NewUserStorage(db *sqlx.DB, defaultCurrency string, defaultLimits int64, defaultTimezone string) *UserStorage {
+	return &UserStorage{
+		db:              db,
+		defaultCurrency: defaultCurrency,
+		defaultLimits:   defaultLimits,
+		defaultTimezone: defaultTimezone,
+	}
+}
+
+// InsertUser Добавление пользователя в базу данных.
+func (storage *UserStorage) InsertUser(ctx context.Context, userID int64, userName string) error {
+	return storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			return insertUser(ctx, tx, userID, userName, storage.defaultCurrency, storage.defaultLimits)
+		})
+}
+
+// Добавление пользователя внутри транзакции.
+func insertUser(ctx context.Context, db sqlx.ExecerContext, userID int64, userName string, currency string, limits int64) error {
+	// Запрос на добавление данных.
+	const sqlString = `
+		INSERT INTO users (tg_id, name, currency, limits)
+			VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (tg_id) DO NOTHING;`
+
+	// Выполнение запроса на добавление данных.
+	if _, err := dbutils.Exec(ctx, db, sqlString, userID, userName, currency, limits); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckIfUserExist Проверка существования пользователя в базе данных.
+func (storage *UserStorage) CheckIfUserExist(ctx context.Context, userID int64) (bool, error) {
+	// Запрос на выборку пользователя.
+	const sqlString = `SELECT COUNT(id) AS countusers FROM users WHERE tg_id = $1;`
+
+	// Выполнение запроса на получение данных.
+	cnt, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return false, err
+	}
+	// Приведение результата запроса к нужному типу.
+	countusers, ok := cnt["countusers"].(int64)
+	if !ok {
+		return false, errors.New("Ошибка приведения типа результата запроса.")
+	}
+	return countusers > 0, nil
+}
+
+// CheckIfUserExistAndAdd Проверка существования пользователя в базе данных добавление, если не существует.
+func (storage *UserStorage) CheckIfUserExistAndAdd(ctx context.Context, userID int64, userName string) (bool, error) {
+	exist, err := storage.CheckIfUserExist(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !exist {
+		// Добавление пользователя в базу, если не существует.
+		err := storage.InsertUser(ctx, userID, userName)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// InsertUserDataRecord Добавление записи о расходах пользователя (в транзакции с проверкой превышения лимита).
+func (storage *UserStorage) InsertUserDataRecord(ctx context.Context, userID int64, rec types.UserDataRecord, userName string, limitPeriod time.Time) (bool, error) {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return false, err
+	}
+
+	// Запуск транзакции (при превышении лимита после добавления записи транзакция откатывается).
+	var isOverLimit bool
+	err = storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			isOverLimit, err = insertUserDataRecordTx(ctx, tx, userID, rec, limitPeriod, storage.defaultTimezone)
+			return err
+		})
+
+	// Функция возвращает признак isOverLimit:
+	// - true - запись не добавлена из-за превышения лимита,
+	// - false - запись добавлена (при err == nil).
+	return isOverLimit, err
+}
+
+// GetUserDataRecord Получение информации о расходах по категориям за период.
+// Полные месяцы периода (в часовом поясе пользователя) суммируются по помесячным итогам,
+// начало периода до первого полного месяца - по записям о расходах.
+func (storage *UserStorage) GetUserDataRecord(ctx context.Context, userID int64, period time.Time) ([]types.UserDataReportRecord, error) {
+	// Отбор итогов и записей по пользователю за указанный период с группировкой по категориям.
+	const sqlString = `
+		SELECT c.name, SUM(t.sum) AS sum
+		FROM (SELECT m.category_id, m.sum
+			  FROM usermonthlyrollups AS m
+					   INNER JOIN users AS u
+								  ON m.user_id = u.id
+			  WHERE u.tg_id = $1 AND m.month >= $3 AND m.count > 0
+			  UNION ALL
+			  SELECT r.category_id, r.sum
+			  FROM usermoneytransactions AS r
+					   INNER JOIN users AS u
+								  ON r.user_id = u.id
+			  WHERE u.tg_id = $1 AND r.period >= $2 AND r.period < $4 AND r.deleted_at IS NULL) AS t
+				 INNER JOIN usercategories AS c
+							ON t.category_id = c.id
+		GROUP BY c.name
+		ORDER BY c.name;`
+
+	// Начало первого полного месяца периода в часовом поясе пользователя.
+	timezone, err := storage.GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Get user data record error")
+	}
+	fullMonths := timeutils.BeginOfFullMonth(period.In(timeutils.LoadLocation(timezone, time.UTC)))
+
+	var recs []UserDataReportRecordDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, utc(period), fullMonths.Format(time.DateOnly), utc(fullMonths)); err != nil {
+		return nil, errors.Wrap(err, "Get user data record error")
+	}
+
+	result := make([]types.UserDataReportRecord, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.UserDataReportRecord{
+			Category: rec.Category,
+			Sum:      rec.Sum,
+		}
+	}
+	return result, nil
+}
+
+// GetUserMonthDataRecord Получение информации о расходах по категориям за календарный месяц
+// (month - начало месяца в часовом поясе пользователя) по помесячным итогам.
+func (storage *UserStorage) GetUserMonthDataRecord(ctx context.Context, userID int64, month time.Time) ([]types.UserDataReportRecord, error) {
+	// Отбор итогов пользователя за месяц с группировкой по категориям.
+	const sqlString = `
+		SELECT c.name, SUM(m.sum) AS sum
+		FROM usermonthlyrollups AS m
+				 INNER JOIN users AS u
+							ON m.user_id = u.id
+				 INNER JOIN usercategories AS c
+							ON m.category_id = c.id
+		WHERE u.tg_id = $1 AND m.month = $2 AND m.count > 0
+		GROUP BY c.name
+		ORDER BY c.name;`
+
+	var recs []UserDataReportRecordDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, month.Format(time.DateOnly)); err != nil {
+		return nil, errors.Wrap(err, "Get user month data record error")
+	}
+
+	result := make([]types.UserDataReportRecord, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.UserDataReportRecord{
+			Category: rec.Category,
+			Sum:      rec.Sum,
+		}
+	}
+	return result, nil
+}
+
+// InsertCategory Добавление категории пользователя.
+func (storage *UserStorage) InsertCategory(ctx context.Context, userID int64, catName string, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+	// Обрезка до 30 символов для удобства дальнейших отчетов.
+	if runes := []rune(catName); len(runes) > 30 {
+		catName = string(runes[:30])
+	}
+	// Запрос на добавление данных.
+	// Удаленная ранее категория с тем же названием восстанавливается из корзины (без удаленных записей).
+	const sqlInsert = `
+		INSERT INTO usercategories (user_id, name)
+			SELECT id, $1 FROM users WHERE users.tg_id = $2
+		ON CONFLICT (user_id, utf8_lower(name)) DO UPDATE SET deleted_at = NULL
+			WHERE deleted_at IS NOT NULL
+		RETURNING id, user_id;`
+
+	// Добавление категории с записью в журнал действий (если категория добавлена).
+	err = storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			var recs []insertedDB
+			if err := dbutils.Select(ctx, tx, &recs, sqlInsert, catName, userID); err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				if err := insertUserAction(ctx, tx, rec.UserID, types.UserActionCategory, rec.ID, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return errors.Wrap(err, "Insert category error")
+	}
+	return nil
+}
+
+// GetUserCategory Получение списка категорий пользователя.
+func (storage *UserStorage) GetUserCategory(ctx context.Context, userID int64) ([]string, error) {
+	// Отбор категорий по пользователю.
+	const sqlString = `
+		SELECT c.name
+		FROM usercategories AS c
+			INNER JOIN users AS u
+				ON c.user_id = u.id
+		WHERE u.tg_id = $1 AND c.deleted_at IS NULL
+		GROUP BY c.name
+		ORDER BY c.name;`
+
+	var recs []string
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID); err != nil {
+		return nil, errors.Wrap(err, "Get user category error")
+	}
+	return recs, nil
+}
+
+// GetUserCurrency Получение выбранной валюты пользователя.
+func (storage *UserStorage) GetUserCurrency(ctx context.Context, userID int64) (string, error) {
+	// Получение  валюты по пользователю.
+	const sqlString = `SELECT currency FROM users WHERE tg_id = $1;`
+
+	// Выполнение запроса на выборку данных (запись результата запроса в map).
+	result, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return "", errors.Wrap(err, "Get user currency error")
+	}
+	// Приведение результата запроса к нужному типу.
+	currency, ok := result["currency"].(string)
+	if !ok {
+		return "", errors.New("Ошибка приведения типа результата запроса.")
+	}
+	return currency, nil
+}
+
+// SetUserCurrency Сохранение выбранной валюты пользователя.
+func (storage *UserStorage) SetUserCurrency(ctx context.Context, userID int64, currencyName string, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+	// Обновление данных с записью предыдущего значения в журнал действий.
+	const sqlPrev = `SELECT id, currency AS prev_value FROM users WHERE tg_id = $1;`
+	const sqlUpdate = `UPDATE users SET currency = $1 WHERE id = $2;`
+
+	err = storage.updateUserSetting(ctx, userID, types.UserActionCurrency, sqlPrev, sqlUpdate, currencyName)
+	if err != nil {
+		return errors.Wrap(err, "Set user currency error")
+	}
+	return nil
+}
+
+// GetUserTimezone Получение часового пояса пользователя.
+// Если пользователь не выбирал часовой пояс, возвращается часовой пояс по умолчанию.
+func (storage *UserStorage) GetUserTimezone(ctx context.Context, userID int64) (string, error) {
+	// Получение часового пояса по пользователю (пустая строка, если пользователя нет).
+	const sqlString = `
+		SELECT COALESCE(MAX(timezone), '') AS timezone FROM users WHERE tg_id = $1;`
+
+	// Выполнение запроса на выборку данных (запись результата запроса в map).
+	result, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return "", errors.Wrap(err, "Get user timezone error")
+	}
+	// Приведение результата запроса к нужному типу.
+	timezone, ok := result["timezone"].(string)
+	if !ok {
+		return "", errors.New("Ошибка приведения типа результата запроса.")
+	}
+	if timezone == "" {
+		return storage.defaultTimezone, nil
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone Сохранение выбранного часового пояса пользователя.
+func (storage *UserStorage) SetUserTimezone(ctx context.Context, userID int64, timezone string, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+	// Запрос на обновление данных.
+	const sqlString = `UPDATE users SET timezone = $1 WHERE tg_id = $2;`
+
+	// Изменение часового пояса с пересчетом помесячных итогов пользователя (границы месяцев сдвигаются).
+	err = storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			if _, err := dbutils.Exec(ctx, tx, sqlString, timezone, userID); err != nil {
+				return err
+			}
+			_, err := rebuildMonthlyRollupsTx(ctx, tx, userID, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return errors.Wrap(err, "Set user timezone error")
+	}
+	return nil
+}
+
+// GetUserLimit Получение бюджета пользователя.
+func (storage *UserStorage) GetUserLimit(ctx context.Context, userID int64) (int64, error) {
+	// Получение бюджета по пользователю.
+	const sqlString = `SELECT limits FROM users WHERE tg_id = $1;`
+
+	// Выполнение запроса на выборку данных (запись результата запроса в map).
+	result, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Get user limits error")
+	}
+	// Приведение результата запроса к нужному типу.
+	limits, ok := result["limits"].(int64)
+	if !ok {
+		return 0, errors.New("Ошибка приведения типа результата запроса.")
+	}
+	return limits, nil
+}
+
+// SetUserLimit Сохранение бюджета пользователя.
+func (storage *UserStorage) SetUserLimit(ctx context.Context, userID int64, limits int64, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
+		return err
+	}
+	// Обновление данных с записью предыдущего значения в журнал действий.
+	const sqlPrev = `SELECT id, CAST(limits AS TEXT) AS prev_value FROM users WHERE tg_id = $1;`
+	const sqlUpdate = `UPDATE users SET limits = $1 WHERE id = $2;`
+
+	err = storage.updateUserSetting(ctx, userID, types.UserActionLimit, sqlPrev, sqlUpdate, limits)
+	if err != nil {
+		return errors.Wrap(err, "Set user limits error")
+	}
+	return nil
+}
+
+// CheckIfReceiptExist Проверка, что записи по кассовому чеку уже добавлялись пользователем.
+func (storage *UserStorage) CheckIfReceiptExist(ctx context.Context, userID int64, receipt types.Receipt) (bool, error) {
+	// Запрос на поиск чека по реквизитам.
+	const sqlString = `
+		SELECT COUNT(r.id) AS counter
+		FROM userreceipts AS r
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+			INNER JOIN usermoneytransactions AS t
+				ON r.transaction_id = t.id
+		WHERE u.tg_id = $1 AND r.fn = $2 AND r.fd = $3 AND r.fp = $4 AND t.deleted_at IS NULL;`
+
+	// Выполнение запроса на получение данных.
+	cnt, err := dbutils.GetMap(ctx, storage.db, sqlString, userID, receipt.FN, receipt.FD, receipt.FP)
+	if err != nil {
+		return false, errors.Wrap(err, "Check receipt error")
+	}
+	// Приведение результата запроса к нужному типу.
+	counter, ok := cnt["counter"].(int64)
+	if !ok {
+		return false, errors.New("Ошибка приведения типа результата запроса.")
+	}
+	return counter > 0, nil
+}
+
+// UndoLastUserAction Отмена последнего действия пользователя, совершенного не ранее указанного момента.
+// Если отменять нечего, возвращается пустое действие (Action == "").
+func (storage *UserStorage) UndoLastUserAction(ctx context.Context, userID int64, since time.Time) (types.UserAction, error) {
+	var action types.UserAction
+	// Запуск транзакции: отмена действия и удаление его из журнала.
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			var err error
+			action, err = undoLastUserActionTx(ctx, tx, userID, since, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return types.UserAction{}, errors.Wrap(err, "Undo user action error")
+	}
+	return action, nil
+}
+
+// insertedDB Тип, принимающий идентификаторы добавленной строки.
+type insertedDB struct {
+	ID     int64 `db:"id"`
+	UserID int64 `db:"user_id"`
+}
+
+// prevValueDB Тип, принимающий предыдущее значение настройки пользователя.
+type prevValueDB struct {
+	ID        int64  `db:"id"`
+	PrevValue string `db:"prev_value"`
+}
+
+// insertUserAction Запись действия пользователя в журнал для возможности отмены (внутри транзакции).
+func insertUserAction(ctx context.Context, tx sqlx.ExecerContext, userID int64, action string, objectID any, prevValue any) error {
+	const sqlString = `
+		INSERT INTO useractions (user_id, action, object_id, prev_value)
+			VALUES ($1, $2, $3, $4);`
+
+	_, err := dbutils.Exec(ctx, tx, sqlString, userID, action, objectID, prevValue)
+	return err
+}
+
+// updateUserSetting Изменение настройки пользователя с записью предыдущего значения в журнал действий (в транзакции).
+// sqlPrev - запрос предыдущего значения (id, prev_value) по ТГ-идентификатору,
+// sqlUpdate - запрос изменения значения ($1 - новое значение, $2 - id пользователя).
+func (storage *UserStorage) updateUserSetting(ctx context.Context, userID int64, action string, sqlPrev string, sqlUpdate string, value any) error {
+	return storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			var recs []prevValueDB
+			if err := dbutils.Select(ctx, tx, &recs, sqlPrev, userID); err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				if _, err := dbutils.Exec(ctx, tx, sqlUpdate, value, rec.ID); err != nil {
+					return err
+				}
+				if err := insertUserAction(ctx, tx, rec.ID, action, nil, rec.PrevValue); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// checkIfUserOverLimit Проверка, что расходы пользователя за месяц, начинающийся с period (в часовом поясе пользователя),
+// не превзошли лимит (можно вызывать внутри транзакции). Расходы за месяц берутся из помесячных итогов.
+func checkIfUserOverLimit(ctx context.Context, db sqlx.QueryerContext, userID int64, period time.Time) (bool, error) {
+	// Запрос суммы расходов за месяц и бюджета пользователя.
+	const sqlString = `
+		SELECT COALESCE(SUM(m.sum), 0) AS total, u.limits
+		FROM users AS u
+			LEFT JOIN usermonthlyrollups AS m
+				ON m.user_id = u.id AND m.month = $2
+		WHERE u.tg_id = $1
+		GROUP BY u.id;`
+
+	var recs []struct {
+		Total  int64 `db:"total"`
+		Limits int64 `db:"limits"`
+	}
+	if err := dbutils.Select(ctx, db, &recs, sqlString, userID, period.Format(time.DateOnly)); err != nil {
+		return false, err
+	}
+	for _, rec := range recs {
+		if rec.Limits != 0 && rec.Total > rec.Limits {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// insertUserDataRecordTx Функция добавления расхода, выполняемая внутри транзакции (tx).
+func insertUserDataRecordTx(ctx context.Context, tx *sqlx.Tx, userID int64, rec types.UserDataRecord, limitPeriod time.Time, defaultTimezone string) (bool, error) {
+	// Добавление категории, если ее еще нет (удаленная категория восстанавливается из корзины).
+	const sqlCategory = `
+		INSERT INTO usercategories (user_id, name)
+			SELECT id, $1 FROM users WHERE users.tg_id = $2
+		ON CONFLICT (user_id, utf8_lower(name)) DO UPDATE SET deleted_at = NULL
+			WHERE deleted_at IS NOT NULL;`
+
+	// Добавление записи о расходах.
+	const sqlRecord = `
+		INSERT INTO usermoneytransactions (user_id, category_id, sum, period, note)
+			SELECT u.id, c.id, $3, $4, $5
+			FROM usercategories AS c
+				INNER JOIN users AS u ON c.user_id = u.id
+			WHERE u.tg_id = $1 AND utf8_lower(c.name) = utf8_lower($2)
+		RETURNING id, user_id;`
+
+	// Чек записи из корзины переходит к новой записи (восстановленная из корзины запись остается без чека),
+	// чек действующей записи повторно не добавляется (уникальный индекс).
+	const sqlReleaseReceipt = `
+		DELETE FROM userreceipts
+		WHERE user_id = $1 AND fn = $2 AND fd = $3 AND fp = $4
+			AND transaction_id IN (SELECT id FROM usermoneytransactions WHERE deleted_at IS NOT NULL);`
+
+	// Сохранение реквизитов чека для защиты от повторного ввода.
+	const sqlReceipt = `
+		INSERT INTO userreceipts (user_id, transaction_id, fn, fd, fp)
+			VALUES ($1, $2, $3, $4, $5);`
+
+	if _, err := dbutils.Exec(ctx, tx, sqlCategory, rec.Category, userID); err != nil {
+		return false, err
+	}
+	var recs []insertedDB
+	if err := dbutils.Select(ctx, tx, &recs, sqlRecord, userID, rec.Category, rec.Sum, utc(rec.Period), rec.Note); err != nil {
+		return false, err
+	}
+	for _, inserted := range recs {
+		// Запись в журнал действий для возможности отмены.
+		if err := insertUserAction(ctx, tx, inserted.UserID, types.UserActionRecord, inserted.ID, nil); err != nil {
+			return false, err
+		}
+		if err := changeMonthlyRollups(ctx, tx, []int64{inserted.ID}, rollupAdd, defaultTimezone); err != nil {
+			return false, err
+		}
+		if rec.Receipt != nil {
+			if _, err := dbutils.Exec(ctx, tx, sqlReleaseReceipt, inserted.UserID, rec.Receipt.FN, rec.Receipt.FD, rec.Receipt.FP); err != nil {
+				return false, err
+			}
+			if _, err := dbutils.Exec(ctx, tx, sqlReceipt, inserted.UserID, inserted.ID, rec.Receipt.FN, rec.Receipt.FD, rec.Receipt.FP); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// Проверка превышения (для отката транзакции в случае превышения).
+	isOverLimit, err := checkIfUserOverLimit(ctx, tx, userID, limitPeriod)
+	if err != nil {
+		return false, err
+	}
+	if isOverLimit {
+		// Признак превышения лимита (вызовет откат транзакции)
+		return true, errors.New("Превышение лимита.")
+	}
+	return false, nil
+}
+
+// userActionDB Тип, принимающий структуру записи журнала действий пользователя.
+type userActionDB struct {
+	ID        int64          `db:"id"`
+	UserID    int64          `db:"user_id"`
+	Action    string         `db:"action"`
+	ObjectID  sql.NullInt64  `db:"object_id"`
+	PrevValue sql.NullString `db:"prev_value"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// undoLastUserActionTx Функция отмены последнего действия пользователя, выполняемая внутри транзакции (tx).
+// Транзакции SQLite блокируют базу на запись при начале (_txlock=immediate), поэтому параллельная отмена невозможна.
+func undoLastUserActionTx(ctx context.Context, tx sqlx.ExtContext, userID int64, since time.Time, defaultTimezone string) (types.UserAction, error) {
+	// Запрос на выборку последнего действия.
+	const sqlSelect = `
+		SELECT a.id, a.user_id, a.action, a.object_id, a.prev_value, a.created_at
+		FROM useractions AS a
+			INNER JOIN users AS u
+				ON a.user_id = u.id
+		WHERE u.tg_id = $1 AND a.created_at >= $2
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT 1;`
+
+	var recs []userActionDB
+	if err := dbutils.Select(ctx, tx, &recs, sqlSelect, userID, utc(since)); err != nil {
+		return types.UserAction{}, err
+	}
+	if len(recs) == 0 {
+		// Действий для отмены нет.
+		return types.UserAction{}, nil
+	}
+	rec := recs[0]
+
+	// Запросы на отмену действия в зависимости от его типа.
+	// Записи и категории переносятся в корзину (записи категории - вместе с ней, с тем же временем удаления),
+	// суммы удаленных записей вычитаются из помесячных итогов.
+	const sqlDeleteRecord = `
+		UPDATE usermoneytransactions SET deleted_at = $3
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id;`
+	const sqlDeleteCategoryRecords = `
+		UPDATE usermoneytransactions SET deleted_at = $3
+		WHERE category_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id;`
+	const sqlDeleteCategory = `
+		UPDATE usercategories SET deleted_at = $3
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;`
+
+	deletedAt := utc(time.Now())
+	var deletedIDs []int64
+	var err error
+	switch rec.Action {
+	case types.UserActionRecord:
+		err = dbutils.Select(ctx, tx, &deletedIDs, sqlDeleteRecord, rec.ObjectID.Int64, rec.UserID, deletedAt)
+	case types.UserActionCategory:
+		err = dbutils.Select(ctx, tx, &deletedIDs, sqlDeleteCategoryRecords, rec.ObjectID.Int64, rec.UserID, deletedAt)
+		if err == nil {
+			_, err = dbutils.Exec(ctx, tx, sqlDeleteCategory, rec.ObjectID.Int64, rec.UserID, deletedAt)
+		}
+	case types.UserActionLimit:
+		_, err = dbutils.Exec(ctx, tx, `UPDATE users SET limits = CAST($1 AS INTEGER) WHERE id = $2;`, rec.PrevValue.String, rec.UserID)
+	case types.UserActionCurrency:
+		_, err = dbutils.Exec(ctx, tx, `UPDATE users SET currency = $1 WHERE id = $2;`, rec.PrevValue.String, rec.UserID)
+	default:
+		return types.UserAction{}, errors.New("Неизвестный тип действия.")
+	}
+	if err == nil {
+		err = changeMonthlyRollups(ctx, tx, deletedIDs, rollupRemove, defaultTimezone)
+	}
+	if err != nil {
+		return types.UserAction{}, err
+	}
+
+	// Удаление отмененного действия из журнала.
+	const sqlDelete = `DELETE FROM useractions WHERE id = $1;`
+	if _, err := dbutils.Exec(ctx, tx, sqlDelete, rec.ID); err != nil {
+		return types.UserAction{}, err
+	}
+
+	return types.UserAction{
+		Action:    rec.Action,
+		ObjectID:  rec.ObjectID.Int64,
+		PrevValue: rec.PrevValue.String,
+		CreatedAt: rec.CreatedAt.Local(),
+	}, nil
+}
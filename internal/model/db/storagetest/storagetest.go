@@ -0,0 +1,989 @@
+// Package storagetest Общий набор тестов (контракт) для реализаций хранилищ бота.
+// Каждая реализация хранилища (Postgres, SQLite) должна проходить одни и те же тесты.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	rates "github.com/ellavs/tg-bot-golang/internal/model/exchangerates"
+	"github.com/ellavs/tg-bot-golang/internal/model/messages"
+	"github.com/ellavs/tg-bot-golang/internal/tasks/digestscheduler"
+)
+
+// Параметры, с которыми должны создаваться проверяемые хранилища.
+const (
+	DefaultCurrency = "RUB"           // Валюта по умолчанию.
+	DefaultLimits   = int64(0)        // Бюджет по умолчанию.
+	DefaultTimezone = "Europe/Moscow" // Часовой пояс по умолчанию.
+)
+
+// Currencies Используемые валюты, с которыми должно создаваться хранилище курсов валют.
+var Currencies = []string{"USD", "CNY", "EUR", "RUB"}
+
+// UserStorage Хранилище информации о пользователях (для бота и планировщика регулярных отчетов).
+type UserStorage interface {
+	messages.UserDataStorage
+	digestscheduler.SubscriptionStorage
+	GetUserMonthDataRecord(ctx context.Context, userID int64, month time.Time) ([]types.UserDataReportRecord, error)
+}
+
+// Идентификаторы тестовых пользователей.
+const (
+	userID      = int64(123)
+	otherUserID = int64(456)
+	adminID     = int64(789)
+	userName    = "test"
+
+	// ТГ-идентификаторы за пределами 32-битного диапазона (идентификаторы групп отрицательные).
+	largeUserID  = int64(5_000_000_123)
+	largeGroupID = int64(-1_001_234_567_890)
+)
+
+// RunUserStorageTests Запуск набора тестов хранилища информации о пользователях.
+// newStorage должна возвращать хранилище с пустой базой данных и параметрами по умолчанию из этого пакета.
+func RunUserStorageTests(t *testing.T, newStorage func(t *testing.T) UserStorage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage UserStorage)
+	}{
+		{"SettingsDefaults", testSettingsDefaults},
+		{"CategoriesCaseInsensitive", testCategoriesCaseInsensitive},
+		{"RecordsReport", testRecordsReport},
+		{"RecordsLimit", testRecordsLimit},
+		{"BatchImport", testBatchImport},
+		{"MonthlyTotals", testMonthlyTotals},
+		{"UndoLastAction", testUndoLastAction},
+		{"Receipts", testReceipts},
+		{"Goals", testGoals},
+		{"Subscriptions", testSubscriptions},
+		{"Search", testSearch},
+		{"Access", testAccess},
+		{"AdminInfo", testAdminInfo},
+		{"ExportAndDelete", testExportAndDelete},
+		{"LargeTgIDs", testLargeTgIDs},
+		{"TrashAndRestore", testTrashAndRestore},
+		{"ReceiptReentry", testReceiptReentry},
+		{"Audit", testAudit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// RunRatesStorageTests Запуск набора тестов хранилища курсов валют.
+// newStorage должна возвращать хранилище с пустой базой данных и валютами Currencies.
+func RunRatesStorageTests(t *testing.T, newStorage func(t *testing.T) rates.RatesDataStorage) {
+	ctx := context.Background()
+	storage := newStorage(t)
+
+	last, err := storage.GetLastExchangeRates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, last)
+
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.InsertExchangeRatesToDate(ctx, rateList("0.011", "0.078", "0.01"), day))
+	// Курс хранится без потери знаков (19 значащих цифр не помещаются в float64).
+	require.NoError(t, storage.InsertExchangeRatesToDate(ctx, rateList("0.012", "1234567.123456789012", "0.02"), day.AddDate(0, 0, 1)))
+	// Повторная загрузка курсов на ту же дату не изменяет курсы.
+	require.NoError(t, storage.InsertExchangeRatesToDate(ctx, rateList("0.5", "0.5", "0.5"), day.AddDate(0, 0, 1)))
+
+	last, err = storage.GetLastExchangeRates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rateList("0.012", "1234567.123456789012", "0.02"), last)
+}
+
+// rateList Курсы USD, CNY и EUR из десятичной записи.
+func rateList(usd string, cny string, eur string) types.ExchangeRate {
+	return types.ExchangeRate{"USD": money.MustParseRate(usd), "CNY": money.MustParseRate(cny), "EUR": money.MustParseRate(eur)}
+}
+
+// Валюта, бюджет и часовой пояс по умолчанию, изменение настроек.
+func testSettingsDefaults(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+
+	timezone, err := storage.GetUserTimezone(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimezone, timezone)
+
+	// Пользователь добавляется при первом изменении настроек.
+	require.NoError(t, storage.SetUserTimezone(ctx, userID, "Asia/Vladivostok", userName))
+	currency, err := storage.GetUserCurrency(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, currency)
+	limits, err := storage.GetUserLimit(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLimits, limits)
+	timezone, err = storage.GetUserTimezone(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Vladivostok", timezone)
+
+	require.NoError(t, storage.SetUserCurrency(ctx, userID, "USD", userName))
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 500000, userName))
+	currency, err = storage.GetUserCurrency(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", currency)
+	limits, err = storage.GetUserLimit(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500000), limits)
+
+	// Настройки другого пользователя не изменились.
+	timezone, err = storage.GetUserTimezone(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimezone, timezone)
+}
+
+// Категории уникальны без учета регистра (в том числе для кириллицы).
+func testCategoriesCaseInsensitive(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Кафе", userName))
+	require.NoError(t, storage.InsertCategory(ctx, userID, "кафе", userName))
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Такси", userName))
+	require.NoError(t, storage.InsertCategory(ctx, otherUserID, "Кафе", userName))
+
+	categories, err := storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кафе", "Такси"}, categories)
+
+	// Запись в категорию в другом регистре относится к существующей категории.
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "КАФЕ", Sum: 1000, Period: time.Now()}, userName, beginOfMonth(time.Now()))
+	require.NoError(t, err)
+	categories, err = storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кафе", "Такси"}, categories)
+}
+
+// Отчет по категориям за период.
+func testRecordsReport(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, loc)
+	limitPeriod := beginOfMonth(now)
+
+	for _, rec := range []types.UserDataRecord{
+		{Category: "Такси", Sum: 30000, Period: now},
+		{Category: "Кино", Sum: 10000, Period: now.Add(-time.Hour), Note: "премьера"},
+		{Category: "Кино", Sum: 5000, Period: now.AddDate(0, 0, -3)},
+		{Category: "Кино", Sum: 7000, Period: now.AddDate(0, -2, 0)},
+	} {
+		isOverLimit, err := storage.InsertUserDataRecord(ctx, userID, rec, userName, limitPeriod)
+		require.NoError(t, err)
+		assert.False(t, isOverLimit)
+	}
+	_, err := storage.InsertUserDataRecord(ctx, otherUserID, types.UserDataRecord{Category: "Кино", Sum: 100, Period: now}, userName, limitPeriod)
+	require.NoError(t, err)
+
+	// Начало периода включается в отчет (время в другом часовом поясе).
+	report, err := storage.GetUserDataRecord(ctx, userID, now.AddDate(0, 0, -3).UTC())
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 15000}, {Category: "Такси", Sum: 30000}}, report)
+
+	report, err = storage.GetUserDataRecord(ctx, userID, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, report)
+}
+
+// Запись, превышающая бюджет на месяц, не добавляется.
+func testRecordsLimit(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	// Бюджет проверяется за месяц в часовом поясе пользователя.
+	loc, err := time.LoadLocation(DefaultTimezone)
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, loc)
+	limitPeriod := beginOfMonth(now)
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 100000, userName))
+
+	isOverLimit, err := storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 60000, Period: now}, userName, limitPeriod)
+	require.NoError(t, err)
+	assert.False(t, isOverLimit)
+
+	// Запись в новой категории откатывается вместе с категорией.
+	isOverLimit, _ = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Такси", Sum: 50000, Period: now}, userName, limitPeriod)
+	assert.True(t, isOverLimit)
+
+	// Расходы прошлого месяца в бюджете текущего месяца не учитываются.
+	isOverLimit, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 90000, Period: limitPeriod.Add(-time.Second)}, userName, beginOfMonth(limitPeriod.Add(-time.Second)))
+	require.NoError(t, err)
+	assert.False(t, isOverLimit)
+	isOverLimit, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 40000, Period: now}, userName, limitPeriod)
+	require.NoError(t, err)
+	assert.False(t, isOverLimit)
+
+	report, err := storage.GetUserDataRecord(ctx, userID, limitPeriod)
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 100000}}, report)
+	categories, err := storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кино"}, categories)
+}
+
+// Пакетная загрузка записей в режимах "все или ничего" и "только строки без ошибок".
+func testBatchImport(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	march := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 100000, userName))
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Кино", userName))
+	recs := []types.UserDataRecord{
+		{Category: "кино", Sum: 60000, Period: march},
+		{Category: "Такси", Sum: 50000, Period: march},
+		{Category: "Такси", Sum: 30000, Period: march},
+		{Category: "такси", Sum: 90000, Period: april},
+	}
+
+	// Строка, превышающая бюджет, отменяет загрузку всей таблицы.
+	result, err := storage.InsertUserDataRecords(ctx, userID, recs, userName, types.ImportAllOrNothing)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserDataImportResult{OverLimit: []int{1}}, result)
+	categories, err := storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кино"}, categories)
+
+	// Строки, превышающие бюджет, пропускаются, бюджет проверяется по месяцам записей.
+	importedAt := time.Now()
+	result, err = storage.InsertUserDataRecords(ctx, userID, recs, userName, types.ImportBestEffort)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserDataImportResult{Inserted: 3, OverLimit: []int{1}}, result)
+	report, err := storage.GetUserDataRecord(ctx, userID, beginOfMonth(march))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 60000}, {Category: "Такси", Sum: 120000}}, report)
+	categories, err = storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Кино", "Такси"}, categories)
+
+	// Загруженные категории и записи не записываются в журнал действий (по одной не отменяются).
+	action, err := storage.UndoLastUserAction(ctx, userID, importedAt)
+	require.NoError(t, err)
+	assert.Empty(t, action.Action)
+	report, err = storage.GetUserDataRecord(ctx, userID, beginOfMonth(march))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 60000}, {Category: "Такси", Sum: 120000}}, report)
+
+	// Без бюджета загружаются все записи.
+	result, err = storage.InsertUserDataRecords(ctx, otherUserID, recs, userName, types.ImportAllOrNothing)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserDataImportResult{Inserted: 4}, result)
+}
+
+// Отчеты и бюджет по месяцам в часовом поясе пользователя (после добавления, удаления и восстановления записей).
+func testMonthlyTotals(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Minute)
+	moscow, err := time.LoadLocation(DefaultTimezone)
+	require.NoError(t, err)
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	require.NoError(t, err)
+	report := func(period time.Time) []types.UserDataReportRecord {
+		report, err := storage.GetUserDataRecord(ctx, userID, period)
+		require.NoError(t, err)
+		return report
+	}
+	monthReport := func(month time.Time) []types.UserDataReportRecord {
+		report, err := storage.GetUserMonthDataRecord(ctx, userID, month)
+		require.NoError(t, err)
+		return report
+	}
+	insert := func(category string, sum int64, period time.Time) bool {
+		isOverLimit, err := storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: category, Sum: sum, Period: period}, userName, beginOfMonth(period))
+		if !isOverLimit {
+			require.NoError(t, err)
+		}
+		return isOverLimit
+	}
+
+	// Запись в конце февраля по Москве и в начале марта по Москве (оба момента - 29 февраля по UTC).
+	insert("Кино", 1000, time.Date(2024, 2, 29, 23, 30, 0, 0, moscow))
+	insert("Кино", 2000, time.Date(2024, 3, 1, 0, 30, 0, 0, moscow))
+	insert("Такси", 3000, time.Date(2024, 3, 15, 12, 0, 0, 0, moscow))
+	insert("Кино", 4000, time.Date(2024, 4, 2, 12, 0, 0, 0, moscow))
+
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 6000}, {Category: "Такси", Sum: 3000}}, report(time.Date(2024, 3, 1, 0, 0, 0, 0, moscow)))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 4000}, {Category: "Такси", Sum: 3000}}, report(time.Date(2024, 3, 10, 0, 0, 0, 0, moscow)))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 7000}, {Category: "Такси", Sum: 3000}}, report(time.Date(2024, 2, 29, 23, 0, 0, 0, moscow)))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 7000}, {Category: "Такси", Sum: 3000}}, report(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	// Отчет за календарный месяц (регулярный ежемесячный отчет) - без записей соседних месяцев.
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 2000}, {Category: "Такси", Sum: 3000}}, monthReport(time.Date(2024, 3, 1, 0, 0, 0, 0, moscow)))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 1000}}, monthReport(time.Date(2024, 2, 1, 0, 0, 0, 0, moscow)))
+
+	// Бюджет за март: 5000 уже потрачено.
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 6000, userName))
+	assert.True(t, insert("Такси", 1500, time.Date(2024, 3, 31, 23, 0, 0, 0, moscow)))
+	assert.False(t, insert("Такси", 1000, time.Date(2024, 3, 31, 23, 0, 0, 0, moscow)))
+	assert.True(t, insert("Такси", 100, time.Date(2024, 3, 20, 0, 0, 0, 0, moscow)))
+
+	// Отмененная запись не учитывается в отчете и бюджете, восстановленная - снова учитывается.
+	action, err := storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionRecord, action.Action)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 6000}, {Category: "Такси", Sum: 3000}}, report(time.Date(2024, 3, 1, 0, 0, 0, 0, moscow)))
+	assert.False(t, insert("Такси", 100, time.Date(2024, 3, 20, 0, 0, 0, 0, moscow)))
+	action, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionRecord, action.Action)
+	trash, err := storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	isRestored, err := storage.RestoreTrashItem(ctx, userID, trash[1].Kind, trash[1].ID, since)
+	require.NoError(t, err)
+	assert.True(t, isRestored)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 6000}, {Category: "Такси", Sum: 4000}}, report(time.Date(2024, 3, 1, 0, 0, 0, 0, moscow)))
+
+	// После изменения часового пояса месяцы считаются в новом часовом поясе (запись 29 февраля по Москве - в марте).
+	require.NoError(t, storage.SetUserTimezone(ctx, userID, "Asia/Vladivostok", userName))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 7000}, {Category: "Такси", Sum: 4000}}, report(time.Date(2024, 3, 1, 0, 0, 0, 0, vladivostok)))
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 3000}, {Category: "Такси", Sum: 3000}}, monthReport(time.Date(2024, 3, 1, 0, 0, 0, 0, vladivostok)))
+	assert.True(t, insert("Такси", 100, time.Date(2024, 3, 20, 0, 0, 0, 0, vladivostok)))
+}
+
+// Отмена последних действий пользователя в обратном порядке.
+func testUndoLastAction(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Minute)
+
+	action, err := storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Empty(t, action.Action)
+
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 100000, userName))
+	require.NoError(t, storage.SetUserCurrency(ctx, userID, "USD", userName))
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Кино", userName))
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: time.Now()}, userName, beginOfMonth(time.Now()))
+	require.NoError(t, err)
+
+	action, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionRecord, action.Action)
+	report, err := storage.GetUserDataRecord(ctx, userID, since.AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Empty(t, report)
+
+	action, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionCategory, action.Action)
+	categories, err := storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+
+	action, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionCurrency, action.Action)
+	assert.Equal(t, DefaultCurrency, action.PrevValue)
+	currency, err := storage.GetUserCurrency(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, currency)
+
+	action, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionLimit, action.Action)
+	limits, err := storage.GetUserLimit(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLimits, limits)
+
+	// Действия, совершенные раньше указанного момента, не отменяются.
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 100000, userName))
+	action, err = storage.UndoLastUserAction(ctx, userID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, action.Action)
+}
+
+// Повторный ввод кассового чека.
+func testReceipts(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	receipt := types.Receipt{Period: time.Now(), Sum: 1000, FN: "1", FD: "2", FP: "3"}
+
+	isExist, err := storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.False(t, isExist)
+
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: receipt.Period, Receipt: &receipt}, userName, beginOfMonth(time.Now()))
+	require.NoError(t, err)
+
+	isExist, err = storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.True(t, isExist)
+	isExist, err = storage.CheckIfReceiptExist(ctx, otherUserID, receipt)
+	require.NoError(t, err)
+	assert.False(t, isExist)
+}
+
+// Цели накоплений.
+func testGoals(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	deadline := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, storage.InsertGoal(ctx, userID, types.Goal{Name: "Отпуск", Currency: "RUB", Target: 15000000, Deadline: deadline}, userName))
+	require.NoError(t, storage.InsertGoal(ctx, userID, types.Goal{Name: "Ноутбук", Currency: "USD", Target: 150000, Deadline: deadline.AddDate(0, -1, 0)}, userName))
+
+	goals, err := storage.GetUserGoals(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "Ноутбук", goals[0].Name)
+	assert.Equal(t, "Отпуск", goals[1].Name)
+	assert.True(t, deadline.Equal(goals[1].Deadline), goals[1].Deadline)
+
+	goal, err := storage.AddGoalContribution(ctx, userID, goals[1].ID, 500000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500000), goal.Saved)
+	goal, err = storage.AddGoalContribution(ctx, userID, goals[1].ID, 500000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000000), goal.Saved)
+
+	// Цель другого пользователя не изменяется и не удаляется.
+	_, err = storage.AddGoalContribution(ctx, otherUserID, goals[1].ID, 500000)
+	assert.Error(t, err)
+	require.NoError(t, storage.DeleteGoal(ctx, otherUserID, goals[1].ID))
+
+	require.NoError(t, storage.DeleteGoal(ctx, userID, goals[0].ID))
+	goals, err = storage.GetUserGoals(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+	assert.Equal(t, int64(1000000), goals[0].Saved)
+}
+
+// Подписки на регулярные отчеты.
+func testSubscriptions(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+
+	require.NoError(t, storage.SetUserSubscription(ctx, userID, types.SubscriptionWeekly, true, userName))
+	require.NoError(t, storage.SetUserSubscription(ctx, userID, types.SubscriptionMonthly, true, userName))
+	require.NoError(t, storage.SetUserSubscription(ctx, userID, types.SubscriptionMonthly, true, userName))
+	require.NoError(t, storage.SetUserSubscription(ctx, otherUserID, types.SubscriptionWeekly, true, userName))
+	require.NoError(t, storage.SetUserTimezone(ctx, otherUserID, "Asia/Vladivostok", userName))
+
+	kinds, err := storage.GetUserSubscriptions(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.SubscriptionMonthly, types.SubscriptionWeekly}, kinds)
+
+	require.NoError(t, storage.SetUserSubscription(ctx, userID, types.SubscriptionMonthly, false, userName))
+	kinds, err = storage.GetUserSubscriptions(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.SubscriptionWeekly}, kinds)
+
+	subscriptions, err := storage.GetSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 2)
+	assert.Equal(t, userID, subscriptions[0].UserID)
+	assert.Equal(t, DefaultTimezone, subscriptions[0].Timezone)
+	assert.Equal(t, "Asia/Vladivostok", subscriptions[1].Timezone)
+	assert.WithinDuration(t, time.Now(), subscriptions[0].LastSentAt, time.Minute)
+
+	// Отчет за срок отправляется один раз.
+	dueAt := time.Now().Add(time.Minute)
+	isMarked, err := storage.MarkSubscriptionSent(ctx, subscriptions[0].ID, dueAt, dueAt)
+	require.NoError(t, err)
+	assert.True(t, isMarked)
+	isMarked, err = storage.MarkSubscriptionSent(ctx, subscriptions[0].ID, dueAt, dueAt)
+	require.NoError(t, err)
+	assert.False(t, isMarked)
+
+	// Подписки заблокированных пользователей не обрабатываются.
+	require.NoError(t, storage.SetUserBlocked(ctx, otherUserID, true, adminID))
+	subscriptions, err = storage.GetSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, userID, subscriptions[0].UserID)
+}
+
+// Поиск записей по тексту, сумме и периоду.
+func testSearch(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, rec := range []types.UserDataRecord{
+		{Category: "Кафе_бар", Sum: 150000, Period: now.AddDate(0, 0, -2)},
+		{Category: "Такси", Sum: 30000, Period: now.AddDate(0, 0, -1), Note: "В аэропорт"},
+		{Category: "Кино", Sum: 50000, Period: now, Note: "100% премьера"},
+	} {
+		_, err := storage.InsertUserDataRecord(ctx, userID, rec, userName, beginOfMonth(now))
+		require.NoError(t, err)
+	}
+
+	search := func(filter types.UserDataSearchFilter, limit int, offset int) []string {
+		recs, err := storage.SearchUserDataRecords(ctx, userID, filter, limit, offset)
+		require.NoError(t, err)
+		categories := make([]string, len(recs))
+		for ind, rec := range recs {
+			categories[ind] = rec.Category
+		}
+		return categories
+	}
+
+	// Без учета регистра, по категории и комментарию, спецсимволы шаблона - как обычные символы.
+	assert.Equal(t, []string{"Такси"}, search(types.UserDataSearchFilter{Text: "аэропорт"}, 10, 0))
+	assert.Equal(t, []string{"Кафе_бар"}, search(types.UserDataSearchFilter{Text: "КАФЕ_"}, 10, 0))
+	assert.Equal(t, []string{"Кино"}, search(types.UserDataSearchFilter{Text: "100%"}, 10, 0))
+	assert.Empty(t, search(types.UserDataSearchFilter{Text: "_"}, 10, 1))
+
+	assert.Equal(t, []string{"Кино", "Такси"}, search(types.UserDataSearchFilter{SumTo: 50000}, 10, 0))
+	assert.Equal(t, []string{"Кино", "Кафе_бар"}, search(types.UserDataSearchFilter{SumFrom: 50000}, 10, 0))
+	assert.Equal(t, []string{"Такси", "Кафе_бар"}, search(types.UserDataSearchFilter{PeriodFrom: now.AddDate(0, 0, -2), PeriodTo: now}, 10, 0))
+	assert.Equal(t, []string{"Такси"}, search(types.UserDataSearchFilter{}, 1, 1))
+
+	recs, err := storage.SearchUserDataRecords(ctx, userID, types.UserDataSearchFilter{Text: "кино"}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, userID, recs[0].UserID)
+	assert.Equal(t, int64(50000), recs[0].Sum)
+	assert.Equal(t, "100% премьера", recs[0].Note)
+	assert.True(t, now.Equal(recs[0].Period), recs[0].Period)
+}
+
+// Коды приглашений и блокировка пользователей.
+func testAccess(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+
+	access, err := storage.GetUserAccess(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{}, access)
+
+	require.NoError(t, storage.InsertInviteCode(ctx, "a1b2c3", adminID))
+	isRedeemed, err := storage.RedeemInviteCode(ctx, "a1b2c3", userID, userName)
+	require.NoError(t, err)
+	assert.True(t, isRedeemed)
+	isRedeemed, err = storage.RedeemInviteCode(ctx, "a1b2c3", otherUserID, userName)
+	require.NoError(t, err)
+	assert.False(t, isRedeemed)
+	isRedeemed, err = storage.RedeemInviteCode(ctx, "unknown", otherUserID, userName)
+	require.NoError(t, err)
+	assert.False(t, isRedeemed)
+
+	access, err = storage.GetUserAccess(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Exists: true}, access)
+	access, err = storage.GetUserAccess(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{}, access)
+
+	// Блокировать можно и незарегистрированного пользователя.
+	require.NoError(t, storage.SetUserBlocked(ctx, userID, true, adminID))
+	require.NoError(t, storage.SetUserBlocked(ctx, userID, true, adminID))
+	require.NoError(t, storage.SetUserBlocked(ctx, otherUserID, true, adminID))
+	access, err = storage.GetUserAccess(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Exists: true, Blocked: true}, access)
+	access, err = storage.GetUserAccess(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Blocked: true}, access)
+
+	require.NoError(t, storage.SetUserBlocked(ctx, userID, false, adminID))
+	access, err = storage.GetUserAccess(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Exists: true}, access)
+}
+
+// Статистика и сведения о пользователе для администраторов.
+func testAdminInfo(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Now()
+
+	info, err := storage.GetUserInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserInfo{}, info)
+
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Такси", userName))
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: now}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 2500, Period: now}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	require.NoError(t, storage.SetUserCurrency(ctx, otherUserID, "USD", userName))
+	require.NoError(t, storage.InsertAdminAudit(ctx, adminID, "/admin_user", "123"))
+
+	info, err = storage.GetUserInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, info.TgID)
+	assert.Equal(t, userName, info.Name)
+	assert.Equal(t, DefaultCurrency, info.Currency)
+	assert.Equal(t, int64(2), info.Categories)
+	assert.Equal(t, int64(2), info.Records)
+	assert.Equal(t, int64(3500), info.TotalSum)
+	assert.WithinDuration(t, now, info.LastRecordAt, time.Minute)
+
+	info, err = storage.GetUserInfo(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), info.Records)
+	assert.True(t, info.LastRecordAt.IsZero())
+
+	// Активность считается по обращениям к боту, а не по вводу записей (незарегистрированные пользователи не учитываются).
+	for id, seenAt := range map[int64]time.Time{userID: now.Add(-2 * time.Hour), otherUserID: now.AddDate(0, 0, -10), adminID: now} {
+		isSaved, err := storage.SetUserLastSeen(ctx, id, seenAt)
+		require.NoError(t, err)
+		assert.Equal(t, id != adminID, isSaved)
+	}
+	stats, err := storage.GetAdminStats(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.UsersTotal)
+	assert.Equal(t, int64(1), stats.DAU)
+	assert.Equal(t, int64(2), stats.MAU)
+	require.Len(t, stats.RecordsPerDay, 1)
+	assert.Equal(t, int64(2), stats.RecordsPerDay[0].Count)
+	assert.Equal(t, []types.CurrencyCount{{Currency: DefaultCurrency, Users: 1}, {Currency: "USD", Users: 1}}, stats.TopCurrencies)
+}
+
+// Выгрузка данных и удаление аккаунта пользователя.
+func testExportAndDelete(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	export, err := storage.GetUserExport(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), export.Profile.TgID)
+	assert.Equal(t, DefaultCurrency, export.BaseCurrency)
+
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: now, Note: "премьера"}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Такси", Sum: 2000, Period: now.Add(time.Hour)}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	require.NoError(t, storage.InsertGoal(ctx, userID, types.Goal{Name: "Отпуск", Currency: "RUB", Target: 100, Deadline: now}, userName))
+	require.NoError(t, storage.SetUserSubscription(ctx, userID, types.SubscriptionWeekly, true, userName))
+	_, err = storage.InsertUserDataRecord(ctx, otherUserID, types.UserDataRecord{Category: "Кино", Sum: 500, Period: now}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+
+	export, err = storage.GetUserExport(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserExportProfile{TgID: userID, Name: userName, Currency: DefaultCurrency, Limits: DefaultLimits}, export.Profile)
+	assert.Equal(t, []string{"Кино", "Такси"}, export.Categories)
+	require.Len(t, export.Records, 2)
+	assert.Equal(t, "Кино", export.Records[0].Category)
+	assert.Equal(t, "премьера", export.Records[0].Note)
+	assert.True(t, now.Equal(export.Records[0].Period), export.Records[0].Period)
+	require.Len(t, export.Goals, 1)
+	assert.Equal(t, "Отпуск", export.Goals[0].Name)
+	assert.Equal(t, []string{types.SubscriptionWeekly}, export.Subscriptions)
+
+	records, isDeleted, err := storage.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, isDeleted)
+	assert.Equal(t, int64(2), records)
+	_, isDeleted, err = storage.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, isDeleted)
+
+	// Все данные пользователя удалены, данные других пользователей - нет.
+	access, err := storage.GetUserAccess(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, access.Exists)
+	export, err = storage.GetUserExport(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), export.Profile.TgID)
+	subscriptions, err := storage.GetSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+	report, err := storage.GetUserDataRecord(ctx, otherUserID, beginOfMonth(now))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 500}}, report)
+}
+
+// ТГ-идентификаторы, не помещающиеся в 32 бита, сохраняются и ищутся без искажений.
+func testLargeTgIDs(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// Идентификаторы отличаются только старшими битами от userID.
+	aliasID := userID + 1<<32
+	require.NoError(t, storage.SetUserCurrency(ctx, userID, "USD", userName))
+	for _, id := range []int64{largeUserID, largeGroupID, aliasID} {
+		require.NoError(t, storage.SetUserLimit(ctx, id, 500000, userName))
+		_, err := storage.InsertUserDataRecord(ctx, id, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: now}, userName, beginOfMonth(now))
+		require.NoError(t, err)
+		require.NoError(t, storage.SetUserSubscription(ctx, id, types.SubscriptionWeekly, true, userName))
+	}
+
+	for _, id := range []int64{largeUserID, largeGroupID, aliasID} {
+		currency, err := storage.GetUserCurrency(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultCurrency, currency)
+		limits, err := storage.GetUserLimit(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500000), limits)
+		report, err := storage.GetUserDataRecord(ctx, id, beginOfMonth(now))
+		require.NoError(t, err)
+		assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 1000}}, report)
+		info, err := storage.GetUserInfo(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, id, info.TgID)
+	}
+	currency, err := storage.GetUserCurrency(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", currency)
+
+	subscriptions, err := storage.GetSubscriptions(ctx)
+	require.NoError(t, err)
+	var subscribers []int64
+	for _, sub := range subscriptions {
+		subscribers = append(subscribers, sub.UserID)
+	}
+	assert.ElementsMatch(t, []int64{largeUserID, largeGroupID, aliasID}, subscribers)
+
+	require.NoError(t, storage.InsertInviteCode(ctx, "big123", largeUserID))
+	isRedeemed, err := storage.RedeemInviteCode(ctx, "big123", largeGroupID-1, userName)
+	require.NoError(t, err)
+	assert.True(t, isRedeemed)
+	require.NoError(t, storage.SetUserBlocked(ctx, largeGroupID, true, largeUserID))
+	access, err := storage.GetUserAccess(ctx, largeGroupID)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Exists: true, Blocked: true}, access)
+	access, err = storage.GetUserAccess(ctx, largeGroupID-1)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserAccess{Exists: true}, access)
+
+	export, err := storage.GetUserExport(ctx, largeUserID)
+	require.NoError(t, err)
+	assert.Equal(t, largeUserID, export.Profile.TgID)
+	_, isDeleted, err := storage.DeleteUser(ctx, largeUserID)
+	require.NoError(t, err)
+	assert.True(t, isDeleted)
+	access, err = storage.GetUserAccess(ctx, aliasID)
+	require.NoError(t, err)
+	assert.True(t, access.Exists)
+}
+
+// Отмененные записи и категории попадают в корзину и восстанавливаются из нее.
+func testTrashAndRestore(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-time.Minute)
+
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Такси", userName))
+	receipt := types.Receipt{Period: now, Sum: 2000, FN: "1", FD: "2", FP: "3"}
+	_, err := storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Такси", Sum: 2000, Period: now, Receipt: &receipt}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: now, Note: "премьера"}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+
+	// Отмена записей переносит их в корзину.
+	for ind := 0; ind < 2; ind++ {
+		action, err := storage.UndoLastUserAction(ctx, userID, since)
+		require.NoError(t, err)
+		assert.Equal(t, types.UserActionRecord, action.Action)
+	}
+	report, err := storage.GetUserDataRecord(ctx, userID, beginOfMonth(now))
+	require.NoError(t, err)
+	assert.Empty(t, report)
+	isExist, err := storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.False(t, isExist)
+	found, err := storage.SearchUserDataRecords(ctx, userID, types.UserDataSearchFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	trash, err := storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	taxi, cinema := findTrashItem(t, trash, "Такси"), findTrashItem(t, trash, "Кино")
+	assert.Equal(t, types.UserActionRecord, taxi.Kind)
+	assert.Equal(t, int64(2000), taxi.Sum)
+	assert.Equal(t, "премьера", cinema.Note)
+	assert.True(t, now.Sub(cinema.DeletedAt).Abs() < time.Minute, cinema.DeletedAt)
+
+	// Чужие записи и записи, удаленные раньше указанного момента, не восстанавливаются.
+	isRestored, err := storage.RestoreTrashItem(ctx, otherUserID, types.UserActionRecord, taxi.ID, since)
+	require.NoError(t, err)
+	assert.False(t, isRestored)
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, taxi.ID, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, isRestored)
+	trash, err = storage.GetUserTrash(ctx, userID, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = storage.RestoreTrashItem(ctx, userID, "unknown", taxi.ID, since)
+	assert.Error(t, err)
+
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, taxi.ID, since)
+	require.NoError(t, err)
+	assert.True(t, isRestored)
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, taxi.ID, since)
+	require.NoError(t, err)
+	assert.False(t, isRestored)
+	report, err = storage.GetUserDataRecord(ctx, userID, beginOfMonth(now))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Такси", Sum: 2000}}, report)
+	isExist, err = storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.True(t, isExist)
+
+	// Категория переносится в корзину вместе с записями и восстанавливается вместе с ними.
+	action, err := storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Equal(t, types.UserActionCategory, action.Action)
+	categories, err := storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кино"}, categories)
+	trash, err = storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	taxi = findTrashItem(t, trash, "Такси")
+	assert.Equal(t, types.TrashItem{Kind: types.UserActionCategory, ID: taxi.ID, Category: "Такси", Sum: 2000, Records: 1, DeletedAt: taxi.DeletedAt}, taxi)
+
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionCategory, taxi.ID, since)
+	require.NoError(t, err)
+	assert.True(t, isRestored)
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, cinema.ID, since)
+	require.NoError(t, err)
+	assert.True(t, isRestored)
+	report, err = storage.GetUserDataRecord(ctx, userID, beginOfMonth(now))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 1000}, {Category: "Такси", Sum: 2000}}, report)
+	trash, err = storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// Повторное добавление удаленной категории восстанавливает ее из корзины.
+	require.NoError(t, storage.InsertCategory(ctx, userID, "Спорт", userName))
+	_, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	require.NoError(t, storage.InsertCategory(ctx, userID, "спорт", userName))
+	categories, err = storage.GetUserCategory(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Кино", "Спорт", "Такси"}, categories)
+	trash, err = storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// Удаление пользователя удаляет и содержимое корзины.
+	_, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	records, isDeleted, err := storage.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, isDeleted)
+	assert.Equal(t, int64(2), records)
+	trash, err = storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+// Журнал изменений записей, категорий и настроек.
+func testAudit(t *testing.T, storage UserStorage) {
+	// Изменения записываются в журнал от имени пользователя из контекста.
+	ctx := dbutils.ContextWithActor(context.Background(), userID)
+	now := time.Now()
+
+	audit, err := storage.GetUserAudit(ctx, userID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, audit)
+
+	require.NoError(t, storage.SetUserLimit(ctx, userID, 100000, userName))
+	_, err = storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Кино", Sum: 1000, Period: now}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+	_, err = storage.UndoLastUserAction(ctx, userID, now.Add(-time.Minute))
+	require.NoError(t, err)
+	// Изменение без пользователя в контексте (начальные данные, команды запуска).
+	_, err = storage.InsertUserDataRecord(context.Background(), otherUserID, types.UserDataRecord{Category: "Кино", Sum: 500, Period: now}, userName, beginOfMonth(now))
+	require.NoError(t, err)
+
+	audit, err = storage.GetUserAudit(ctx, userID, 10)
+	require.NoError(t, err)
+	require.Len(t, audit, 5)
+	type change struct{ entity, operation string }
+	var changes []change
+	for _, rec := range audit {
+		changes = append(changes, change{rec.Entity, rec.Operation})
+		assert.Equal(t, userID, rec.ActorTgID)
+		assert.True(t, now.Sub(rec.CreatedAt).Abs() < time.Minute, rec.CreatedAt)
+	}
+	assert.Equal(t, []change{
+		{types.AuditEntityRecord, types.AuditOpDelete},
+		{types.AuditEntityRecord, types.AuditOpInsert},
+		{types.AuditEntityCategory, types.AuditOpInsert},
+		{types.AuditEntitySettings, types.AuditOpUpdate},
+		{types.AuditEntitySettings, types.AuditOpInsert},
+	}, changes)
+
+	// Значения до и после изменения.
+	assert.Nil(t, audit[0].OldValue["deleted_at"])
+	assert.NotNil(t, audit[0].NewValue["deleted_at"])
+	assert.EqualValues(t, 1000, audit[0].OldValue["sum"])
+	assert.Equal(t, audit[0].ObjectID, audit[1].ObjectID)
+	assert.Nil(t, audit[1].OldValue)
+	assert.Equal(t, "Кино", audit[2].NewValue["name"])
+	assert.EqualValues(t, DefaultLimits, audit[3].OldValue["limits"])
+	assert.EqualValues(t, 100000, audit[3].NewValue["limits"])
+	assert.Equal(t, DefaultCurrency, audit[4].NewValue["currency"])
+
+	audit, err = storage.GetUserAudit(ctx, userID, 2)
+	require.NoError(t, err)
+	assert.Len(t, audit, 2)
+
+	// Журнал удаляется только вместе с пользователем.
+	_, _, err = storage.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+	audit, err = storage.GetUserAudit(ctx, userID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, audit)
+	audit, err = storage.GetUserAudit(ctx, otherUserID, 10)
+	require.NoError(t, err)
+	assert.Len(t, audit, 3)
+	for _, rec := range audit {
+		assert.Zero(t, rec.ActorTgID)
+	}
+}
+
+// findTrashItem Поиск элемента корзины по названию категории.
+func findTrashItem(t *testing.T, items []types.TrashItem, category string) types.TrashItem {
+	for _, item := range items {
+		if item.Category == category {
+			return item
+		}
+	}
+	require.Failf(t, "trash item not found", "category %s", category)
+	return types.TrashItem{}
+}
+
+// Повторный ввод кассового чека после отмены записи и после ее восстановления из корзины.
+func testReceiptReentry(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-time.Minute)
+	receipt := types.Receipt{Period: now, Sum: 2000, FN: "1", FD: "2", FP: "3"}
+	insertReceipt := func(sum int64) error {
+		_, err := storage.InsertUserDataRecord(ctx, userID, types.UserDataRecord{Category: "Такси", Sum: sum, Period: now, Receipt: &receipt}, userName, beginOfMonth(now))
+		return err
+	}
+
+	// Чек отмененной записи вводится повторно.
+	require.NoError(t, insertReceipt(2000))
+	_, err := storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	isExist, err := storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.False(t, isExist)
+	require.NoError(t, insertReceipt(2100))
+	isExist, err = storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.True(t, isExist)
+
+	// После восстановления из корзины записи с чеком чек повторно не вводится.
+	_, err = storage.UndoLastUserAction(ctx, userID, since)
+	require.NoError(t, err)
+	trash, err := storage.GetUserTrash(ctx, userID, since)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	assert.Equal(t, int64(2100), trash[0].Sum)
+	isRestored, err := storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, trash[0].ID, since)
+	require.NoError(t, err)
+	require.True(t, isRestored)
+	isExist, err = storage.CheckIfReceiptExist(ctx, userID, receipt)
+	require.NoError(t, err)
+	assert.True(t, isExist)
+	assert.Error(t, insertReceipt(2200))
+
+	// Запись, чек которой введен повторно, восстанавливается из корзины без чека.
+	isRestored, err = storage.RestoreTrashItem(ctx, userID, types.UserActionRecord, trash[1].ID, since)
+	require.NoError(t, err)
+	require.True(t, isRestored)
+	report, err := storage.GetUserDataRecord(ctx, userID, beginOfMonth(now))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Такси", Sum: 4100}}, report)
+}
+
+// beginOfMonth Начало месяца (период проверки бюджета).
+func beginOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
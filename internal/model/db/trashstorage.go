@@ -0,0 +1,171 @@
+package db
+
+// Корзина: удаленные записи о расходах и категории с возможностью восстановления.
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// TrashItemDB Тип, принимающий удаленную запись о расходах или категорию.
+type TrashItemDB struct {
+	ID        int64     `db:"id"`
+	Category  string    `db:"name"`
+	Sum       int64     `db:"sum"`
+	Records   int64     `db:"records"`
+	Period    time.Time `db:"period"`
+	Note      string    `db:"note"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// trashObjectDB Тип, принимающий восстанавливаемый объект.
+type trashObjectDB struct {
+	ID         int64     `db:"id"`
+	CategoryID int64     `db:"category_id"`
+	DeletedAt  time.Time `db:"deleted_at"`
+}
+
+// GetUserTrash Получение содержимого корзины пользователя: записей и категорий, удаленных не ранее since
+// (новые - первыми). Записи, удаленные вместе с категорией, входят в элемент категории.
+func (storage *UserStorage) GetUserTrash(ctx context.Context, userID int64, since time.Time) ([]types.TrashItem, error) {
+	const sqlRecords = `
+		SELECT r.id, c.name, r.sum, 0 AS records, r.period, r.note, r.deleted_at
+		FROM usermoneytransactions AS r
+			INNER JOIN usercategories AS c
+				ON r.category_id = c.id
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.deleted_at >= $2
+		  AND (c.deleted_at IS NULL OR c.deleted_at <> r.deleted_at);`
+
+	const sqlCategories = `
+		SELECT c.id, c.name, COALESCE(SUM(r.sum), 0) AS sum, COUNT(r.id) AS records,
+			   c.deleted_at AS period, '' AS note, c.deleted_at
+		FROM usercategories AS c
+			INNER JOIN users AS u
+				ON c.user_id = u.id
+			LEFT JOIN usermoneytransactions AS r
+				ON r.category_id = c.id AND r.deleted_at = c.deleted_at
+		WHERE u.tg_id = $1 AND c.deleted_at >= $2
+		GROUP BY c.id;`
+
+	var result []types.TrashItem
+	for _, kind := range []string{types.UserActionRecord, types.UserActionCategory} {
+		query := sqlRecords
+		if kind == types.UserActionCategory {
+			query = sqlCategories
+		}
+		var recs []TrashItemDB
+		if err := dbutils.Select(ctx, storage.db, &recs, query, userID, since); err != nil {
+			return nil, errors.Wrap(err, "Get user trash error")
+		}
+		for _, rec := range recs {
+			item := types.TrashItem{
+				Kind:      kind,
+				ID:        rec.ID,
+				Category:  rec.Category,
+				Sum:       rec.Sum,
+				Records:   rec.Records,
+				Note:      rec.Note,
+				DeletedAt: rec.DeletedAt,
+			}
+			if kind == types.UserActionRecord {
+				item.Period = rec.Period
+			}
+			result = append(result, item)
+		}
+	}
+	sortTrash(result)
+	return result, nil
+}
+
+// RestoreTrashItem Восстановление записи или категории, удаленной не ранее since (в транзакции).
+// Вместе с записью восстанавливается ее категория, вместе с категорией - записи, удаленные вместе с ней.
+// Возвращает false, если объект не найден в корзине.
+func (storage *UserStorage) RestoreTrashItem(ctx context.Context, userID int64, kind string, id int64, since time.Time) (bool, error) {
+	// Запросы выборки объекта из корзины (с блокировкой от параллельного восстановления).
+	const sqlSelectRecord = `
+		SELECT r.id, r.category_id, r.deleted_at
+		FROM usermoneytransactions AS r
+			INNER JOIN users AS u
+				ON r.user_id = u.id
+		WHERE u.tg_id = $1 AND r.id = $2 AND r.deleted_at >= $3
+		FOR UPDATE OF r;`
+
+	const sqlSelectCategory = `
+		SELECT c.id, c.id AS category_id, c.deleted_at
+		FROM usercategories AS c
+			INNER JOIN users AS u
+				ON c.user_id = u.id
+		WHERE u.tg_id = $1 AND c.id = $2 AND c.deleted_at >= $3
+		FOR UPDATE OF c;`
+
+	// Запросы восстановления записей (с добавлением их сумм в помесячные итоги).
+	sqlRestoreRecord := `
+		WITH rec AS (UPDATE usermoneytransactions SET deleted_at = NULL WHERE id = $1
+			RETURNING user_id, category_id, period, sum)
+		` + sqlRollupDelta("rec", rollupAdd, "$2") + `;`
+	sqlRestoreRecords := `
+		WITH rec AS (UPDATE usermoneytransactions SET deleted_at = NULL WHERE category_id = $1 AND deleted_at = $2
+			RETURNING user_id, category_id, period, sum)
+		` + sqlRollupDelta("rec", rollupAdd, "$3") + `;`
+	const sqlRestoreCategory = `UPDATE usercategories SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL;`
+
+	var sqlSelect string
+	switch kind {
+	case types.UserActionRecord:
+		sqlSelect = sqlSelectRecord
+	case types.UserActionCategory:
+		sqlSelect = sqlSelectCategory
+	default:
+		return false, errors.New("Неизвестный тип объекта корзины.")
+	}
+
+	var isRestored bool
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			isRestored = false
+			var recs []trashObjectDB
+			if err := dbutils.Select(ctx, tx, &recs, sqlSelect, userID, id, since); err != nil {
+				return err
+			}
+			if len(recs) == 0 {
+				return nil
+			}
+			obj := recs[0]
+			if kind == types.UserActionRecord {
+				if _, err := dbutils.Exec(ctx, tx, sqlRestoreRecord, obj.ID, storage.defaultTimezone); err != nil {
+					return err
+				}
+			} else {
+				if _, err := dbutils.Exec(ctx, tx, sqlRestoreRecords, obj.CategoryID, obj.DeletedAt, storage.defaultTimezone); err != nil {
+					return err
+				}
+			}
+			if _, err := dbutils.Exec(ctx, tx, sqlRestoreCategory, obj.CategoryID); err != nil {
+				return err
+			}
+			isRestored = true
+			return nil
+		})
+	if err != nil {
+		return false, errors.Wrap(err, "Restore trash item error")
+	}
+	return isRestored, nil
+}
+
+// sortTrash Сортировка содержимого корзины (удаленные позже - первыми).
+func sortTrash(items []types.TrashItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].DeletedAt.After(items[j].DeletedAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+}
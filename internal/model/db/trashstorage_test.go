@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_UserStorage_GetUserTrash(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedAt := since.Add(time.Hour)
+	columns := []string{"id", "name", "sum", "records", "period", "note", "deleted_at"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND r.deleted_at >= $2 AND (c.deleted_at IS NULL OR c.deleted_at <> r.deleted_at);")).
+		WithArgs(15236, since).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(10, "Кино", 1000, 0, since, "премьера", deletedAt))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND c.deleted_at >= $2 GROUP BY c.id;")).
+		WithArgs(15236, since).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(5, "Такси", 3000, 2, deletedAt, "", deletedAt.Add(time.Hour)))
+
+	got, err := s.GetUserTrash(ctx, 15236, since)
+	require.NoError(t, err)
+	assert.Equal(t, []types.TrashItem{
+		{Kind: types.UserActionCategory, ID: 5, Category: "Такси", Sum: 3000, Records: 2, DeletedAt: deletedAt.Add(time.Hour)},
+		{Kind: types.UserActionRecord, ID: 10, Category: "Кино", Sum: 1000, Period: since, Note: "премьера", DeletedAt: deletedAt},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_UserStorage_RestoreTrashItem(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlxmock.Newx()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedAt := since.Add(time.Hour)
+	columns := []string{"id", "category_id", "deleted_at"}
+
+	tests := []struct {
+		name    string
+		kind    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "Запись восстанавливается вместе с категорией",
+			kind: types.UserActionRecord,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND r.id = $2 AND r.deleted_at >= $3 FOR UPDATE OF r;")).
+					WithArgs(15236, 10, since).
+					WillReturnRows(sqlxmock.NewRows(columns).AddRow(10, 5, deletedAt))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE usermoneytransactions SET deleted_at = NULL WHERE id = $1 RETURNING user_id, category_id, period, sum) INSERT INTO usermonthlyrollups")).
+					WithArgs(10, "Europe/Moscow").
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE usercategories SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL;")).
+					WithArgs(5).
+					WillReturnResult(sqlxmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "Категория восстанавливается вместе с записями",
+			kind: types.UserActionCategory,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE u.tg_id = $1 AND c.id = $2 AND c.deleted_at >= $3 FOR UPDATE OF c;")).
+					WithArgs(15236, 10, since).
+					WillReturnRows(sqlxmock.NewRows(columns).AddRow(10, 10, deletedAt))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE usermoneytransactions SET deleted_at = NULL WHERE category_id = $1 AND deleted_at = $2 RETURNING user_id, category_id, period, sum) INSERT INTO usermonthlyrollups")).
+					WithArgs(10, deletedAt, "Europe/Moscow").
+					WillReturnResult(sqlxmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE usercategories SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL;")).
+					WithArgs(10).
+					WillReturnResult(sqlxmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "Объект не найден в корзине",
+			kind: types.UserActionRecord,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE OF r;")).
+					WithArgs(15236, 10, since).
+					WillReturnRows(sqlxmock.NewRows(columns))
+				mock.ExpectCommit()
+			},
+			want: false,
+		},
+		{
+			name:    "Неизвестный тип объекта",
+			kind:    "unknown",
+			mock:    func() {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.RestoreTrashItem(ctx, 15236, tt.kind, 10, since)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Не совпало ожидание ошибки: error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
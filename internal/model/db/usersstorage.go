@@ -5,6 +5,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -25,30 +26,38 @@ type UserStorage struct {
 	db              *sqlx.DB
 	defaultCurrency string
 	defaultLimits   int64
+	defaultTimezone string
 }
 
 // NewUserStorage - Инициализация хранилища информации о пользователях.
 // db - *sqlx.DB - ссылка на подключение к БД.
 // defaultCurrency - string - валюта по умолчанию.
 // defaultLimits - int64 - бюджет по умолчанию.
-func NewUserStorage(db *sqlx.DB, defaultCurrency string, defaultLimits int64) *UserStorage {
+// defaultTimezone - string - часовой пояс по умолчанию (для пользователей, не выбравших часовой пояс).
+func NewUserStorage(db *sqlx.DB, defaultCurrency string, defaultLimits int64, defaultTimezone string) *UserStorage {
 	return &UserStorage{
 		db:              db,
 		defaultCurrency: defaultCurrency,
 		defaultLimits:   defaultLimits,
+		defaultTimezone: defaultTimezone,
 	}
 }
 
 // InsertUser Добавление пользователя в базу данных.
 func (storage *UserStorage) InsertUser(ctx context.Context, userID int64, userName string) error {
-	// Запрос на добавление данных.
-	const sqlString = `
+	return storage.exec(ctx, sqlInsertUser, userID, userName, storage.defaultCurrency, storage.defaultLimits)
+}
+
+// Запрос на добавление пользователя.
+const sqlInsertUser = `
 		INSERT INTO users (tg_id, name, currency, limits)
 			VALUES ($1, $2, $3, $4)
 			 ON CONFLICT (tg_id) DO NOTHING;`
 
+// Добавление пользователя внутри транзакции.
+func insertUser(ctx context.Context, db sqlx.ExecerContext, userID int64, userName string, currency string, limits int64) error {
 	// Выполнение запроса на добавление данных.
-	if _, err := dbutils.Exec(ctx, storage.db, sqlString, userID, userName, storage.defaultCurrency, storage.defaultLimits); err != nil {
+	if _, err := dbutils.Exec(ctx, db, sqlInsertUser, userID, userName, currency, limits); err != nil {
 		return err
 	}
 	return nil
@@ -99,21 +108,13 @@ func (storage *UserStorage) InsertUserDataRecord(ctx context.Context, userID int
 		return false, err
 	}
 
-	// Проверка, что не превышен лимит расходов.
-	isOverLimit, err := checkIfUserOverLimit(ctx, storage.db, userID, limitPeriod)
-	if err != nil {
-		return false, err
-	}
-	if isOverLimit {
-		return true, nil
-	}
-
-	// Запуск транзакции.
-	err = dbutils.RunTx(ctx, storage.db,
+	// Запуск транзакции (бюджет проверяется внутри транзакции после добавления записи).
+	var isOverLimit bool
+	err = storage.runTx(ctx,
 		// Функция, выполняемая внутри транзакции.
 		// Если функция вернет ошибку, произойдет откат транзакции.
 		func(tx *sqlx.Tx) error {
-			isOverLimit, err = insertUserDataRecordTx(ctx, tx, userID, rec, limitPeriod)
+			isOverLimit, err = insertUserDataRecordTx(ctx, tx, userID, rec, limitPeriod, storage.defaultTimezone)
 			return err
 		})
 
@@ -124,22 +125,38 @@ func (storage *UserStorage) InsertUserDataRecord(ctx context.Context, userID int
 }
 
 // GetUserDataRecord Получение информации о расходах по категориям за период.
+// Полные месяцы периода (в часовом поясе пользователя) суммируются по помесячным итогам,
+// начало периода до первого полного месяца - по записям о расходах.
 func (storage *UserStorage) GetUserDataRecord(ctx context.Context, userID int64, period time.Time) ([]types.UserDataReportRecord, error) {
-	// Отбор записей по пользователю за указанный период с группировкой по категориям.
+	// Отбор итогов и записей по пользователю за указанный период с группировкой по категориям.
 	const sqlString = `
-		SELECT c.name, SUM(r.sum)
-		FROM usermoneytransactions AS r
+		SELECT c.name, SUM(t.sum) AS sum
+		FROM (SELECT m.category_id, m.sum
+			  FROM usermonthlyrollups AS m
+					   INNER JOIN users AS u
+								  ON m.user_id = u.id
+			  WHERE u.tg_id = $1 AND m.month >= $3 AND m.count > 0
+			  UNION ALL
+			  SELECT r.category_id, r.sum
+			  FROM usermoneytransactions AS r
+					   INNER JOIN users AS u
+								  ON r.user_id = u.id
+			  WHERE u.tg_id = $1 AND r.period >= $2 AND r.period < $4 AND r.deleted_at IS NULL) AS t
 				 INNER JOIN usercategories AS c
-							ON r.category_id = c.id
-				 INNER JOIN users AS u
-							ON r.user_id = u.id
-		WHERE u.tg_id = $1 AND r.period >= $2
+							ON t.category_id = c.id
 		GROUP BY c.name
 		ORDER BY c.name;`
 
+	// Начало первого полного месяца периода в часовом поясе пользователя.
+	timezone, err := storage.GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Get user data record error")
+	}
+	fullMonths := timeutils.BeginOfFullMonth(period.In(timeutils.LoadLocation(timezone, time.UTC)))
+
 	var recs []UserDataReportRecordDB
 	// Выполнение запроса на выборку данных (запись в переменную recs).
-	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, period); err != nil {
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, period, fullMonths.Format(time.DateOnly), fullMonths); err != nil {
 		return nil, errors.Wrap(err, "Get user data record error")
 	}
 
@@ -147,7 +164,38 @@ func (storage *UserStorage) GetUserDataRecord(ctx context.Context, userID int64,
 	for ind, rec := range recs {
 		result[ind] = types.UserDataReportRecord{
 			Category: rec.Category,
-			Sum:      float64(rec.Sum),
+			Sum:      rec.Sum,
+		}
+	}
+	return result, nil
+}
+
+// GetUserMonthDataRecord Получение информации о расходах по категориям за календарный месяц
+// (month - начало месяца в часовом поясе пользователя) по помесячным итогам.
+func (storage *UserStorage) GetUserMonthDataRecord(ctx context.Context, userID int64, month time.Time) ([]types.UserDataReportRecord, error) {
+	// Отбор итогов пользователя за месяц с группировкой по категориям.
+	const sqlString = `
+		SELECT c.name, SUM(m.sum) AS sum
+		FROM usermonthlyrollups AS m
+				 INNER JOIN users AS u
+							ON m.user_id = u.id
+				 INNER JOIN usercategories AS c
+							ON m.category_id = c.id
+		WHERE u.tg_id = $1 AND m.month = $2 AND m.count > 0
+		GROUP BY c.name
+		ORDER BY c.name;`
+
+	var recs []UserDataReportRecordDB
+	// Выполнение запроса на выборку данных (запись в переменную recs).
+	if err := dbutils.Select(ctx, storage.db, &recs, sqlString, userID, month.Format(time.DateOnly)); err != nil {
+		return nil, errors.Wrap(err, "Get user month data record error")
+	}
+
+	result := make([]types.UserDataReportRecord, len(recs))
+	for ind, rec := range recs {
+		result[ind] = types.UserDataReportRecord{
+			Category: rec.Category,
+			Sum:      rec.Sum,
 		}
 	}
 	return result, nil
@@ -164,17 +212,19 @@ func (storage *UserStorage) InsertCategory(ctx context.Context, userID int64, ca
 	if len(catName) > 30 {
 		catName = string(catName[:30])
 	}
-	// Запрос на добавление данных.
+	// Запрос на добавление данных (с записью в журнал действий, если категория добавлена).
+	// Удаленная ранее категория с тем же названием восстанавливается из корзины (без удаленных записей).
 	const sqlString = `
-		INSERT INTO usercategories (user_id, name)
+		WITH cat AS (INSERT INTO usercategories (user_id, name)
 			(SELECT id, $1 FROM users WHERE users.tg_id = $2)
-		ON CONFLICT (user_id, lower(name)) DO NOTHING;`
+		ON CONFLICT (user_id, lower(name)) DO UPDATE SET deleted_at = NULL
+			WHERE usercategories.deleted_at IS NOT NULL
+		RETURNING id, user_id)
+		INSERT INTO useractions (user_id, action, object_id)
+			SELECT user_id, 'category', id FROM cat;`
 
 	// Выполнение запроса на добавление данных.
-	if _, err := dbutils.Exec(ctx, storage.db, sqlString, catName, userID); err != nil {
-		return err
-	}
-	return nil
+	return storage.exec(ctx, sqlString, catName, userID)
 }
 
 // GetUserCategory Получение списка категорий пользователя.
@@ -185,7 +235,7 @@ func (storage *UserStorage) GetUserCategory(ctx context.Context, userID int64) (
 		FROM usercategories AS c
 			INNER JOIN users AS u
 				ON c.user_id = u.id
-		WHERE u.tg_id = $1
+		WHERE u.tg_id = $1 AND c.deleted_at IS NULL
 		GROUP BY c.name
 		ORDER BY c.name;`
 
@@ -222,13 +272,62 @@ func (storage *UserStorage) SetUserCurrency(ctx context.Context, userID int64, c
 	if err != nil {
 		return err
 	}
-	// Запрос на обновление данных.
-	const sqlString = `UPDATE users SET currency = $1 WHERE tg_id = $2;`
+	// Запрос на обновление данных (с записью предыдущего значения в журнал действий).
+	const sqlString = `
+		WITH prev AS (SELECT id, currency FROM users WHERE tg_id = $2),
+			 upd AS (UPDATE users SET currency = $1 WHERE tg_id = $2)
+		INSERT INTO useractions (user_id, action, prev_value)
+			SELECT id, 'currency', currency FROM prev;`
 
 	// Выполнение запроса на обновление данных.
-	if _, err := dbutils.Exec(ctx, storage.db, sqlString, currencyName, userID); err != nil {
+	return storage.exec(ctx, sqlString, currencyName, userID)
+}
+
+// GetUserTimezone Получение часового пояса пользователя.
+// Если пользователь не выбирал часовой пояс, возвращается часовой пояс по умолчанию.
+func (storage *UserStorage) GetUserTimezone(ctx context.Context, userID int64) (string, error) {
+	// Получение часового пояса по пользователю (пустая строка, если пользователя нет).
+	const sqlString = `
+		SELECT COALESCE(MAX(timezone), '') AS timezone FROM users WHERE tg_id = $1;`
+
+	// Выполнение запроса на выборку данных (запись результата запроса в map).
+	result, err := dbutils.GetMap(ctx, storage.db, sqlString, userID)
+	if err != nil {
+		return "", errors.Wrap(err, "Get user timezone error")
+	}
+	// Приведение результата запроса к нужному типу.
+	timezone, ok := result["timezone"].(string)
+	if !ok {
+		return "", errors.New("Ошибка приведения типа результата запроса.")
+	}
+	if timezone == "" {
+		return storage.defaultTimezone, nil
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone Сохранение выбранного часового пояса пользователя.
+func (storage *UserStorage) SetUserTimezone(ctx context.Context, userID int64, timezone string, userName string) error {
+	// Проверка существования пользователя в БД.
+	_, err := storage.CheckIfUserExistAndAdd(ctx, userID, userName)
+	if err != nil {
 		return err
 	}
+	// Запрос на обновление данных.
+	const sqlString = `UPDATE users SET timezone = $1 WHERE tg_id = $2;`
+
+	// Изменение часового пояса с пересчетом помесячных итогов пользователя (границы месяцев сдвигаются).
+	err = storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			if _, err := dbutils.Exec(ctx, tx, sqlString, timezone, userID); err != nil {
+				return err
+			}
+			_, err := rebuildMonthlyRollupsTx(ctx, tx, userID, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return errors.Wrap(err, "Set user timezone error")
+	}
 	return nil
 }
 
@@ -257,109 +356,148 @@ func (storage *UserStorage) SetUserLimit(ctx context.Context, userID int64, limi
 	if err != nil {
 		return err
 	}
-	// Запрос на обновление данных.
-	const sqlString = `UPDATE users SET limits = $1 WHERE tg_id = $2;`
+	// Запрос на обновление данных (с записью предыдущего значения в журнал действий).
+	const sqlString = `
+		WITH prev AS (SELECT id, limits FROM users WHERE tg_id = $2),
+			 upd AS (UPDATE users SET limits = $1 WHERE tg_id = $2)
+		INSERT INTO useractions (user_id, action, prev_value)
+			SELECT id, 'limit', limits::text FROM prev;`
 
 	// Выполнение запроса на обновление данных.
-	if _, err := dbutils.Exec(ctx, storage.db, sqlString, limits, userID); err != nil {
-		return err
-	}
-	return nil
+	return storage.exec(ctx, sqlString, limits, userID)
 }
 
-// checkIfUserOverLimit Проверка, что текущие расходы пользователя не превзошли лимит (можно вызывать внутри транзакции).
-func checkIfUserOverLimit(ctx context.Context, db sqlx.QueryerContext, userID int64, period time.Time) (bool, error) {
-	// Проверка наличия записей о расходах.
-	isRecsExist, err := checkIfUserRecordsExistInPeriod(ctx, db, userID, period)
-	if err != nil {
-		return false, err
-	}
-	if !isRecsExist {
-		// Записей о расходах в указанном периоде нет, превышения нет.
-		return false, nil
-	}
-
-	// Запрос на проверку лимита.
+// CheckIfReceiptExist Проверка, что записи по кассовому чеку уже добавлялись пользователем.
+func (storage *UserStorage) CheckIfReceiptExist(ctx context.Context, userID int64, receipt types.Receipt) (bool, error) {
+	// Запрос на поиск чека по реквизитам.
 	const sqlString = `
-		SELECT SUM(r.sum) > u.limits AND NOT u.limits = 0 AS overlimit
-		FROM usermoneytransactions AS r
+		SELECT COUNT(r.id) AS counter
+		FROM userreceipts AS r
 			INNER JOIN users AS u
 				ON r.user_id = u.id
-		WHERE u.tg_id = $1 AND r.period >= $2 AND r.period < $3
-		GROUP BY u.limits;`
+			INNER JOIN usermoneytransactions AS t
+				ON r.transaction_id = t.id
+		WHERE u.tg_id = $1 AND r.fn = $2 AND r.fd = $3 AND r.fp = $4 AND t.deleted_at IS NULL;`
 
 	// Выполнение запроса на получение данных.
-	res, err := dbutils.GetMap(ctx, db, sqlString, userID, period, timeutils.BeginOfNextMonth(period))
+	cnt, err := dbutils.GetMap(ctx, storage.db, sqlString, userID, receipt.FN, receipt.FD, receipt.FP)
 	if err != nil {
-		return false, err
+		return false, errors.Wrap(err, "Check receipt error")
 	}
 	// Приведение результата запроса к нужному типу.
-	overlimit, ok := res["overlimit"].(bool)
+	counter, ok := cnt["counter"].(int64)
 	if !ok {
 		return false, errors.New("Ошибка приведения типа результата запроса.")
 	}
-	if overlimit {
-		return true, nil
+	return counter > 0, nil
+}
+
+// UndoLastUserAction Отмена последнего действия пользователя, совершенного не ранее указанного момента.
+// Если отменять нечего, возвращается пустое действие (Action == "").
+func (storage *UserStorage) UndoLastUserAction(ctx context.Context, userID int64, since time.Time) (types.UserAction, error) {
+	var action types.UserAction
+	// Запуск транзакции: отмена действия и удаление его из журнала.
+	err := storage.runTx(ctx,
+		func(tx *sqlx.Tx) error {
+			var err error
+			action, err = undoLastUserActionTx(ctx, tx, userID, since, storage.defaultTimezone)
+			return err
+		})
+	if err != nil {
+		return types.UserAction{}, errors.Wrap(err, "Undo user action error")
 	}
-	return false, nil
+	return action, nil
 }
 
-// checkIfUserRecordsExistInPeriod Проверка наличия записей о расходах пользователя
-// в базе данных (можно вызывать внутри транзакции).
-func checkIfUserRecordsExistInPeriod(ctx context.Context, db sqlx.QueryerContext, userID int64, period time.Time) (bool, error) {
+// checkIfUserOverLimit Проверка, что расходы пользователя за месяц, начинающийся с period (в часовом поясе пользователя),
+// не превзошли лимит (можно вызывать внутри транзакции). Расходы за месяц берутся из помесячных итогов.
+func checkIfUserOverLimit(ctx context.Context, db sqlx.QueryerContext, userID int64, period time.Time) (bool, error) {
 	// Запрос на проверку лимита.
 	const sqlString = `
-		SELECT COUNT(r.id) AS counter
+		SELECT COALESCE(SUM(m.sum), 0) > u.limits AND NOT u.limits = 0 AS overlimit
 		FROM users AS u
-			INNER JOIN usermoneytransactions AS r
-				ON r.user_id = u.id
-		WHERE u.tg_id = $1 
-		  AND r.period >= $2 AND r.period < $3
-		;`
+			LEFT JOIN usermonthlyrollups AS m
+				ON m.user_id = u.id AND m.month = $2
+		WHERE u.tg_id = $1
+		GROUP BY u.limits;`
 
 	// Выполнение запроса на получение данных.
-	cnt, err := dbutils.GetMap(ctx, db, sqlString, userID, period, timeutils.BeginOfNextMonth(period))
+	res, err := dbutils.GetMap(ctx, db, sqlString, userID, period.Format(time.DateOnly))
 	if err != nil {
 		return false, err
 	}
 	// Приведение результата запроса к нужному типу.
-	counter, ok := cnt["counter"].(int64)
+	overlimit, ok := res["overlimit"].(bool)
 	if !ok {
 		return false, errors.New("Ошибка приведения типа результата запроса.")
 	}
-	if counter == 0 {
-		return false, nil
-	}
-	return true, nil
+	return overlimit, nil
 }
 
 // insertUserDataRecordTx Функция добавления расхода, выполняемая внутри транзакции (tx).
-func insertUserDataRecordTx(ctx context.Context, tx sqlx.ExtContext, userID int64, rec types.UserDataRecord, limitPeriod time.Time) (bool, error) {
+func insertUserDataRecordTx(ctx context.Context, tx sqlx.ExtContext, userID int64, rec types.UserDataRecord, limitPeriod time.Time, defaultTimezone string) (bool, error) {
 
-	// Запрос на добаление записи с проверкой существования категории.
-	const sqlString = `
-		WITH rows AS (INSERT INTO usercategories (user_id, name)
+	// Запрос на добавление категории, если ее еще нет (удаленная категория восстанавливается из корзины).
+	// Выполняется отдельно от добавления записи: подзапросы WITH не видят строк, добавленных друг другом.
+	const sqlCategory = `
+		INSERT INTO usercategories (user_id, name)
 			(SELECT id, :category_name FROM users WHERE users.tg_id = :tg_id)
-		ON CONFLICT (user_id, lower(name)) DO NOTHING)
-		INSERT INTO usermoneytransactions (user_id, category_id, sum, period)
-			(SELECT u.id, c.id, :sum, :period
+		ON CONFLICT (user_id, lower(name)) DO UPDATE SET deleted_at = NULL
+			WHERE usercategories.deleted_at IS NOT NULL;`
+
+	// Запрос на добаление записи (с записью в журнал действий для возможности отмены и изменением помесячных итогов).
+	sqlString := `
+		WITH rec AS (INSERT INTO usermoneytransactions (user_id, category_id, sum, period, note)
+			(SELECT u.id, c.id, :sum, :period, :note
 			 FROM usercategories AS c
 					  INNER JOIN users AS u ON c.user_id = u.id
 			 WHERE u.tg_id = :tg_id AND lower(c.name) = lower(:category_name))
-		ON CONFLICT DO NOTHING;`
+		ON CONFLICT DO NOTHING
+		RETURNING id, user_id, category_id, period, sum),
+			 act AS (INSERT INTO useractions (user_id, action, object_id)
+			SELECT user_id, 'record', id FROM rec),
+			 roll AS (` + sqlRollupDelta("rec", rollupAdd, ":default_timezone") + `)
+		INSERT INTO userreceipts (user_id, transaction_id, fn, fd, fp)
+			SELECT user_id, id, :fn, :fd, :fp FROM rec WHERE :has_receipt;`
+
+	// Чек записи из корзины переходит к новой записи (восстановленная из корзины запись остается без чека),
+	// чек действующей записи повторно не добавляется (уникальный индекс).
+	const sqlReleaseReceipt = `
+		DELETE FROM userreceipts AS r
+		USING usermoneytransactions AS t, users AS u
+		WHERE r.transaction_id = t.id AND r.user_id = u.id AND u.tg_id = :tg_id AND t.deleted_at IS NOT NULL
+			AND r.fn = :fn AND r.fd = :fd AND r.fp = :fp;`
 
 	// Именованные параметры запроса.
 	args := map[string]any{
-		"tg_id":         userID,
-		"category_name": rec.Category,
-		"sum":           rec.Sum,
-		"period":        rec.Period,
-	}
-
-	// Запуск на выполнение запроса с именованными параметрами.
-	if _, err := dbutils.NamedExec(ctx, tx, sqlString, args); err != nil {
-		// Ошибка выполнения запроса (вызовет откат транзакции).
-		return false, err
+		"tg_id":            userID,
+		"category_name":    rec.Category,
+		"sum":              rec.Sum,
+		"period":           rec.Period,
+		"note":             rec.Note,
+		"has_receipt":      rec.Receipt != nil,
+		"fn":               "",
+		"fd":               "",
+		"fp":               "",
+		"default_timezone": defaultTimezone,
+	}
+	if rec.Receipt != nil {
+		// Сохранение реквизитов чека для защиты от повторного ввода.
+		args["fn"] = rec.Receipt.FN
+		args["fd"] = rec.Receipt.FD
+		args["fp"] = rec.Receipt.FP
+	}
+
+	// Запуск на выполнение запросов с именованными параметрами.
+	queries := []string{sqlCategory, sqlString}
+	if rec.Receipt != nil {
+		queries = []string{sqlCategory, sqlReleaseReceipt, sqlString}
+	}
+	for _, query := range queries {
+		if _, err := dbutils.NamedExec(ctx, tx, query, args); err != nil {
+			// Ошибка выполнения запроса (вызовет откат транзакции).
+			return false, err
+		}
 	}
 
 	// Проверка превышения (для отката транзакции в случае превышения).
@@ -376,3 +514,87 @@ func insertUserDataRecordTx(ctx context.Context, tx sqlx.ExtContext, userID int6
 	// (вызовет коммит транзакции).
 	return false, nil
 }
+
+// userActionDB Тип, принимающий структуру записи журнала действий пользователя.
+type userActionDB struct {
+	ID        int64          `db:"id"`
+	UserID    int64          `db:"user_id"`
+	Action    string         `db:"action"`
+	ObjectID  sql.NullInt64  `db:"object_id"`
+	PrevValue sql.NullString `db:"prev_value"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// undoLastUserActionTx Функция отмены последнего действия пользователя, выполняемая внутри транзакции (tx).
+func undoLastUserActionTx(ctx context.Context, tx sqlx.ExtContext, userID int64, since time.Time, defaultTimezone string) (types.UserAction, error) {
+	// Запрос на выборку последнего действия (с блокировкой от параллельной отмены).
+	const sqlSelect = `
+		SELECT a.id, a.user_id, a.action, a.object_id, a.prev_value, a.created_at
+		FROM useractions AS a
+			INNER JOIN users AS u
+				ON a.user_id = u.id
+		WHERE u.tg_id = $1 AND a.created_at >= $2
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT 1
+		FOR UPDATE OF a;`
+
+	var recs []userActionDB
+	if err := dbutils.Select(ctx, tx, &recs, sqlSelect, userID, since); err != nil {
+		return types.UserAction{}, err
+	}
+	if len(recs) == 0 {
+		// Действий для отмены нет.
+		return types.UserAction{}, nil
+	}
+	rec := recs[0]
+
+	// Запрос на отмену действия в зависимости от его типа.
+	var sqlUndo string
+	var args []any
+	// Записи и категории переносятся в корзину (записи категории - вместе с ней, с тем же временем удаления),
+	// суммы удаленных записей вычитаются из помесячных итогов.
+	switch rec.Action {
+	case types.UserActionRecord:
+		sqlUndo = `
+			WITH rec AS (UPDATE usermoneytransactions SET deleted_at = now()
+				WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+				RETURNING user_id, category_id, period, sum)
+			` + sqlRollupDelta("rec", rollupRemove, "$3") + `;`
+		args = []any{rec.ObjectID.Int64, rec.UserID, defaultTimezone}
+	case types.UserActionCategory:
+		sqlUndo = `
+			WITH cat AS (UPDATE usercategories SET deleted_at = now()
+				WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+				RETURNING id, deleted_at),
+				 rec AS (UPDATE usermoneytransactions AS r SET deleted_at = cat.deleted_at
+				FROM cat
+				WHERE r.category_id = cat.id AND r.deleted_at IS NULL
+				RETURNING r.user_id, r.category_id, r.period, r.sum)
+			` + sqlRollupDelta("rec", rollupRemove, "$3") + `;`
+		args = []any{rec.ObjectID.Int64, rec.UserID, defaultTimezone}
+	case types.UserActionLimit:
+		sqlUndo = `UPDATE users SET limits = $1::integer WHERE id = $2;`
+		args = []any{rec.PrevValue.String, rec.UserID}
+	case types.UserActionCurrency:
+		sqlUndo = `UPDATE users SET currency = $1 WHERE id = $2;`
+		args = []any{rec.PrevValue.String, rec.UserID}
+	default:
+		return types.UserAction{}, errors.New("Неизвестный тип действия.")
+	}
+	if _, err := dbutils.Exec(ctx, tx, sqlUndo, args...); err != nil {
+		return types.UserAction{}, err
+	}
+
+	// Удаление отмененного действия из журнала.
+	const sqlDelete = `DELETE FROM useractions WHERE id = $1;`
+	if _, err := dbutils.Exec(ctx, tx, sqlDelete, rec.ID); err != nil {
+		return types.UserAction{}, err
+	}
+
+	return types.UserAction{
+		Action:    rec.Action,
+		ObjectID:  rec.ObjectID.Int64,
+		PrevValue: rec.PrevValue.String,
+		CreatedAt: rec.CreatedAt,
+	}, nil
+}
@@ -16,7 +16,7 @@ func Test_UserStorage_InsertUser(t *testing.T) {
 	}
 	defer db.Close()
 
-	s := NewUserStorage(db, "RUB", 10000)
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
 
 	tests := []struct {
 		name     string
@@ -61,7 +61,7 @@ func Test_UserStorage_CheckIfUserExist(t *testing.T) {
 	}
 	defer db.Close()
 
-	s := NewUserStorage(db, "RUB", 10000)
+	s := NewUserStorage(db, "RUB", 10000, "Europe/Moscow")
 
 	tests := []struct {
 		name    string
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
 	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 )
 
@@ -49,12 +50,12 @@ func New(ctx context.Context, cbrClient CbrClient, currenciesName []string, main
 		RatesDataStorage: storage,
 	}
 	// курс базовой валюты устанавливаем в единицу.
-	exchangeRatesStorage.Rates[mainCurrency] = 1
+	exchangeRatesStorage.Rates[mainCurrency] = money.MustParseRate("1")
 	return &exchangeRatesStorage
 }
 
 // Получение курса указанной валюты.
-func (currenciesStorage *ExchangeRates) GetExchangeRate(currencyName string) (float64, error) {
+func (currenciesStorage *ExchangeRates) GetExchangeRate(currencyName string) (money.Rate, error) {
 	currenciesStorage.RLock()
 	// Попытка получить курс из локального кэша.
 	if currenciesStorage.IsLoaded {
@@ -68,13 +69,13 @@ func (currenciesStorage *ExchangeRates) GetExchangeRate(currencyName string) (fl
 	// Валюты нет в локальном кэше, попытка принудительно синхронно загрузить курсы валют из хранилища (из базы данных).
 	if err := currenciesStorage.LoadExchangeRatesFromStorage(); err != nil {
 		logger.Error("Ошибка получения курсов валют из БД", "err", err)
-		return 0, err
+		return money.Rate{}, err
 	}
 
 	// Валюты нет в хранилище (в БД), попытка принудительно синхронно загрузить курсы валют из внешнего источника.
 	if err := currenciesStorage.UpdateExchangeRates(); err != nil {
 		logger.Error("Ошибка загрузки курсов валют", "err", err)
-		return 0, err
+		return money.Rate{}, err
 	}
 
 	// Попытка получить курс из кэша после принудительного синхронного обновления курсов в кэше.
@@ -83,7 +84,7 @@ func (currenciesStorage *ExchangeRates) GetExchangeRate(currencyName string) (fl
 	if rate, ok := currenciesStorage.Rates[currencyName]; ok {
 		return rate, nil
 	}
-	return 0, errors.New("Курс валюты получить не удалось.")
+	return money.Rate{}, errors.New("Курс валюты получить не удалось.")
 }
 
 // Получение названия основной валюты.
@@ -106,7 +107,7 @@ func (currenciesStorage *ExchangeRates) ConvertSumFromCurrencyToBase(currencyNam
 	return currenciesStorage.convertSum(currencyName, sum, true)
 }
 
-// Конвертация суммы из/в базовую валюту в/из указанной.
+// Конвертация суммы из/в базовую валюту в/из указанной (с банковским округлением до минорной единицы).
 // Флаг isFrom == true означает конвертацию из указанной валюты в базовую.
 func (currenciesStorage *ExchangeRates) convertSum(currencyName string, sum int64, isFrom bool) (int64, error) {
 	if sum == 0 {
@@ -120,19 +121,16 @@ func (currenciesStorage *ExchangeRates) convertSum(currencyName string, sum int6
 		logger.Error("Ошибка получения курса валюты", "err", err)
 		return 0, err
 	}
-	if currentRate == 0 {
+	if currentRate.IsZero() {
 		logger.Error("Ошибка указания курса валюты (0)")
 		return 0, errors.New("Курс валюты некорректный.")
 	}
-	var res int64
 	if isFrom {
 		// Конвертация из указанной валюты в базовую.
-		res = int64(float64(sum) / currentRate)
-	} else {
-		// Конвертация из базовой валюты в указанную.
-		res = int64(float64(sum) * currentRate)
+		return currentRate.Divide(sum)
 	}
-	return res, nil
+	// Конвертация из базовой валюты в указанную.
+	return currentRate.Multiply(sum)
 }
 
 // Загрузка курсов валют из внешнего источника.
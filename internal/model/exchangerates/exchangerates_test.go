@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
 	mocks "github.com/ellavs/tg-bot-golang/internal/mocks/exchangerates"
 	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 )
@@ -20,13 +21,13 @@ func Test_UpdateExchangeRates_ShouldWithoutError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	cbrClient := mocks.NewMockCbrClient(ctrl)
 	// Ожидаем вызова загрузки курса валют.
-	cbrClient.EXPECT().LoadExchangeRates().Return(types.ExchangeRate{"USD": 0.0006, "CNY": 0.012}, period, nil)
+	cbrClient.EXPECT().LoadExchangeRates().Return(types.ExchangeRate{"USD": money.MustParseRate("0.0006"), "CNY": money.MustParseRate("0.012")}, period, nil)
 
 	// Имитируем наличие базы данных.
 	ctrlStorage := gomock.NewController(t)
 	ratesDataStorage := mocks.NewMockRatesDataStorage(ctrlStorage)
 	// Имитация сохранения курсов в БД.
-	ratesDataStorage.EXPECT().InsertExchangeRatesToDate(ctx, types.ExchangeRate{"USD": 0.0006, "CNY": 0.012}, period).Return(nil)
+	ratesDataStorage.EXPECT().InsertExchangeRatesToDate(ctx, types.ExchangeRate{"USD": money.MustParseRate("0.0006"), "CNY": money.MustParseRate("0.012")}, period).Return(nil)
 
 	// Запускаем тест
 	exchangeRates := New(ctx, cbrClient, []string{"USD", "CNY", "EUR", "RUB"}, "RUB", ratesDataStorage)
@@ -36,7 +37,7 @@ func Test_UpdateExchangeRates_ShouldWithoutError(t *testing.T) {
 	assert.Equal(t,
 		&ExchangeRates{
 			IsLoaded:         true,
-			Rates:            types.ExchangeRate{"USD": 0.0006, "CNY": 0.012, "RUB": 1},
+			Rates:            types.ExchangeRate{"USD": money.MustParseRate("0.0006"), "CNY": money.MustParseRate("0.012"), "RUB": money.MustParseRate("1")},
 			CurrenciesName:   []string{"USD", "CNY", "EUR", "RUB"},
 			MainCurrency:     "RUB",
 			CbrClient:        cbrClient,
@@ -51,7 +52,7 @@ func Test_UpdateExchangeRates_ShouldWithoutError(t *testing.T) {
 // Тестирование функции конвертации валют.
 func Test_ConvertSumFromBaseToCurrency_RUB_ShouldWithoutError(t *testing.T) {
 	exchangeRates := New(context.Background(), nil, []string{"USD", "CNY", "EUR", "RUB"}, "RUB", nil)
-	exchangeRates.Rates = types.ExchangeRate{"USD": 0.0006, "CNY": 0.012, "RUB": 1}
+	exchangeRates.Rates = types.ExchangeRate{"USD": money.MustParseRate("0.0006"), "CNY": money.MustParseRate("0.012"), "RUB": money.MustParseRate("1")}
 
 	var testSum int64 = 10000 // 100 рублей
 	res, err := exchangeRates.ConvertSumFromBaseToCurrency("RUB", testSum)
@@ -63,7 +64,7 @@ func Test_ConvertSumFromBaseToCurrency_RUB_ShouldWithoutError(t *testing.T) {
 // Тестирование функции конвертации валют.
 func Test_ConvertSumFromBaseToCurrency_USD_ShouldWithoutError(t *testing.T) {
 	exchangeRates := New(context.Background(), nil, []string{"USD", "CNY", "EUR", "RUB"}, "RUB", nil)
-	exchangeRates.Rates = types.ExchangeRate{"USD": 0.016, "CNY": 0.1, "RUB": 1}
+	exchangeRates.Rates = types.ExchangeRate{"USD": money.MustParseRate("0.016"), "CNY": money.MustParseRate("0.1"), "RUB": money.MustParseRate("1")}
 	exchangeRates.IsLoaded = true
 
 	var testSum int64 = 10000 // 100 рублей
@@ -72,3 +73,23 @@ func Test_ConvertSumFromBaseToCurrency_USD_ShouldWithoutError(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(160), res) // 1 доллар 16 центов
 }
+
+// Тестирование банковского округления при конвертации (без ошибок float).
+func Test_ConvertSum_ShouldRoundExactly(t *testing.T) {
+	exchangeRates := New(context.Background(), nil, []string{"USD", "RUB"}, "RUB", nil)
+	exchangeRates.Rates = types.ExchangeRate{"USD": money.MustParseRate("0.01"), "RUB": money.MustParseRate("1")}
+	exchangeRates.IsLoaded = true
+
+	// float64: 115 / 0.01 = 11499.999999999998.
+	res, err := exchangeRates.ConvertSumFromCurrencyToBase("USD", 115)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11500), res)
+
+	// 50 копеек = 0.5 цента, округляется к четному (0 центов), 150 копеек = 1.5 цента - к 2 центам.
+	res, err = exchangeRates.ConvertSumFromBaseToCurrency("USD", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), res)
+	res, err = exchangeRates.ConvertSumFromBaseToCurrency("USD", 150)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res)
+}
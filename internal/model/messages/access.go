@@ -0,0 +1,169 @@
+package messages
+
+// Доступ к боту: открытый, по списку разрешенных пользователей или по приглашениям; блокировка пользователей.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+)
+
+// Область "Константы и переменные": начало.
+
+// Режимы доступа к боту.
+const (
+	AccessOpen      = "open"      // Доступ для всех пользователей.
+	AccessAllowlist = "allowlist" // Доступ только для пользователей из списка.
+	AccessInvite    = "invite"    // Доступ для пользователей из списка и зарегистрированных по коду приглашения.
+)
+
+// inviteCodeBytes Длина кода приглашения (в байтах, в тексте - вдвое больше символов).
+const inviteCodeBytes = 6
+
+const (
+	txtAccessBlocked     = "Извините, доступ к боту для вас заблокирован."
+	txtAccessAllowlist   = "Извините, бот доступен только ограниченному кругу пользователей. Для получения доступа обратитесь к администратору."
+	txtAccessInvite      = "Извините, бот доступен только по приглашениям. Если у вас есть код приглашения, введите <code>/start &lt;код&gt;</code>."
+	txtAccessInviteError = "Код приглашения не найден или уже использован. Проверьте код или обратитесь к администратору."
+	txtAdminInvite       = "Код приглашения: <code>%v</code>\nДля регистрации пользователь должен ввести <code>/start %v</code>. Код можно использовать один раз."
+	txtAdminBlocked      = "Пользователь %v заблокирован."
+	txtAdminUnblocked    = "Пользователь %v разблокирован."
+)
+
+// Область "Константы и переменные": конец.
+
+// accessSettings Настройки доступа к боту.
+type accessSettings struct {
+	mode    string         // Режим доступа.
+	allowed map[int64]bool // Пользователи, которым доступ разрешен всегда.
+}
+
+// SetAccess Включение проверки доступа к боту в заданном режиме.
+// Пока проверка не включена, бот доступен всем пользователям.
+func (s *Model) SetAccess(mode string, allowedIDs []int64) error {
+	switch mode {
+	case AccessOpen, AccessAllowlist, AccessInvite:
+	default:
+		return errors.New("Неизвестный режим доступа: " + mode)
+	}
+	allowed := make(map[int64]bool, len(allowedIDs))
+	for _, userID := range allowedIDs {
+		allowed[userID] = true
+	}
+	s.access = &accessSettings{mode: mode, allowed: allowed}
+	return nil
+}
+
+// Проверка доступа пользователя к боту до обработки сообщения.
+// Возвращает текст отказа (пустой, если доступ разрешен). Пользователь в БД до проверки не создается,
+// в режиме приглашений он регистрируется при вводе действительного кода: "/start <код>".
+func getAccessDenial(s *Model, userID int64, userName string, text string) (string, error) {
+	if s.access == nil || s.IsAdmin(userID) {
+		return "", nil
+	}
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "getAccessDenial")
+	s.ctx = ctx
+	defer span.Finish()
+
+	access, err := s.storage.GetUserAccess(s.ctx, userID)
+	if err != nil {
+		logger.Error("Ошибка проверки доступа пользователя", "err", err)
+		return "", errors.Wrap(err, "Get user access error")
+	}
+	if access.Blocked {
+		return txtAccessBlocked, nil
+	}
+	switch {
+	case s.access.mode == AccessOpen || s.access.allowed[userID]:
+		return "", nil
+	case s.access.mode == AccessAllowlist:
+		return txtAccessAllowlist, nil
+	case access.Exists:
+		return "", nil
+	}
+	// Режим приглашений: регистрация по коду.
+	code := strings.TrimSpace(strings.TrimPrefix(text, "/start"))
+	if !strings.HasPrefix(text, "/start ") || code == "" {
+		return txtAccessInvite, nil
+	}
+	isRedeemed, err := s.storage.RedeemInviteCode(s.ctx, code, userID, userName)
+	if err != nil {
+		logger.Error("Ошибка использования кода приглашения", "err", err)
+		return "", errors.Wrap(err, "Redeem invite code error")
+	}
+	if !isRedeemed {
+		return txtAccessInviteError, nil
+	}
+	logger.Info("Пользователь зарегистрирован по приглашению", "user", userID, "code", code)
+	return "", nil
+}
+
+// Создание нового кода приглашения.
+func cmdAdminInvite(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdAdminInvite")
+	s.ctx = ctx
+	defer span.Finish()
+
+	code, err := generateInviteCode()
+	if err != nil {
+		logger.Error("Ошибка генерации кода приглашения", "err", err)
+		return true, err
+	}
+	if err := auditAdminAction(s, msg, "/admin_invite", code); err != nil {
+		return true, err
+	}
+	if err := s.storage.InsertInviteCode(s.ctx, code, msg.UserID); err != nil {
+		logger.Error("Ошибка сохранения кода приглашения", "err", err)
+		return true, errors.Wrap(err, "Insert invite code error")
+	}
+	return true, s.tgClient.SendMessage(renderf(txtAdminInvite, code, code), msg.UserID)
+}
+
+// Блокировка пользователя.
+func cmdAdminBlock(s *Model, msg Message, state UserState) (bool, error) {
+	return setUserBlocked(s, msg, "/admin_block", true)
+}
+
+// Разблокировка пользователя.
+func cmdAdminUnblock(s *Model, msg Message, state UserState) (bool, error) {
+	return setUserBlocked(s, msg, "/admin_unblock", false)
+}
+
+// Блокировка или разблокировка пользователя по ТГ-идентификатору из текста команды.
+func setUserBlocked(s *Model, msg Message, command string, blocked bool) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "setUserBlocked")
+	s.ctx = ctx
+	defer span.Finish()
+
+	args := strings.TrimSpace(strings.TrimPrefix(msg.Text, command))
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		return true, s.tgClient.SendMessage(renderf(txtAdminUserIDFormat, command), msg.UserID)
+	}
+	if err := auditAdminAction(s, msg, command, args); err != nil {
+		return true, err
+	}
+	if err := s.storage.SetUserBlocked(s.ctx, userID, blocked, msg.UserID); err != nil {
+		logger.Error("Ошибка блокировки пользователя", "err", err)
+		return true, errors.Wrap(err, "Set user blocked error")
+	}
+	answerText := txtAdminUnblocked
+	if blocked {
+		answerText = txtAdminBlocked
+	}
+	return true, s.tgClient.SendMessage(renderf(answerText, userID), msg.UserID)
+}
+
+// Генерация случайного кода приглашения.
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "Generate invite code error")
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,79 @@
+package messages
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mocks "github.com/ellavs/tg-bot-golang/internal/mocks/messages"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_OnMessage_ShouldRejectUnknownUser_WhenInviteMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	// Пользователь не зарегистрирован, код не указан - данные пользователя не сохраняются.
+	storage.EXPECT().GetUserAccess(gomock.Any(), int64(123)).Return(types.UserAccess{}, nil)
+	sender.EXPECT().SendMessage(txtAccessInvite, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	assert.NoError(t, model.SetAccess(AccessInvite, nil))
+	err := model.IncomingMessage(Message{Text: "/add_cat", UserID: 123})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnStartWithCode_ShouldRedeemInviteAndShowMenu(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	storage.EXPECT().GetUserAccess(gomock.Any(), int64(123)).Return(types.UserAccess{}, nil)
+	storage.EXPECT().RedeemInviteCode(gomock.Any(), "a1b2c3", int64(123), "test").Return(true, nil)
+	sender.EXPECT().ShowInlineButtons(renderf(txtStart, "test"), btnStart, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	assert.NoError(t, model.SetAccess(AccessInvite, nil))
+	err := model.IncomingMessage(Message{Text: "/start a1b2c3", UserID: 123, UserName: "test"})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnMessage_ShouldRejectBlockedUser_EvenIfAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	storage.EXPECT().GetUserAccess(gomock.Any(), int64(123)).Return(types.UserAccess{Exists: true, Blocked: true}, nil)
+	sender.EXPECT().SendMessage(txtAccessBlocked, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	assert.NoError(t, model.SetAccess(AccessAllowlist, []int64{123}))
+	err := model.IncomingMessage(Message{Text: "/start", UserID: 123})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnMessage_ShouldRejectExistingUser_WhenAllowlistMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	storage.EXPECT().GetUserAccess(gomock.Any(), int64(123)).Return(types.UserAccess{Exists: true}, nil)
+	sender.EXPECT().SendMessage(txtAccessAllowlist, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	assert.NoError(t, model.SetAccess(AccessAllowlist, []int64{456}))
+	err := model.IncomingMessage(Message{Text: "/start", UserID: 123})
+
+	assert.NoError(t, err)
+}
+
+func Test_SetAccess_ShouldReturnError_WhenUnknownMode(t *testing.T) {
+	model := New(context.Background(), nil, nil, nil, nil, nil)
+
+	assert.Error(t, model.SetAccess("closed", nil))
+}
@@ -0,0 +1,106 @@
+package messages
+
+// Выгрузка всех данных пользователя и удаление аккаунта по его запросу.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+const (
+	txtExportEmpty     = "Данных о вас в боте нет."
+	txtExportCaption   = "Все ваши данные в формате JSON (суммы - в копейках)."
+	txtDeleteConfirm   = "Будут безвозвратно удалены ваш профиль, категории, записи о расходах, цели, подписки и настройки. Перед удалением можно выгрузить данные командой /export_me. Удалить аккаунт?"
+	txtDeleteDone      = "Ваш аккаунт и все данные удалены. Для начала работы заново введите /start"
+	txtDeleteNotFound  = "Данных о вас в боте нет, удалять нечего."
+	txtDeleteCancel    = "Удаление отменено."
+	txtDeleteExpired   = "Запрос на удаление устарел. Для удаления аккаунта введите /delete_me"
+	deleteConfirmValue = "/delete_me yes"
+)
+
+// Кнопки подтверждения удаления аккаунта.
+var btnDeleteConfirm = []types.TgRowButtons{
+	{types.TgInlineButton{DisplayName: "Да, удалить все данные", Value: deleteConfirmValue}, types.TgInlineButton{DisplayName: "Отмена", Value: "/delete_me no"}},
+}
+
+// Ключи отчетов пользователя в кэше (совпадают с ключами периодов отчетов).
+var reportCacheKeys = []string{"w", "m", "y"}
+
+// Область "Константы и переменные": конец.
+
+// Выгрузка всех данных пользователя в файл JSON.
+func cmdExportMe(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdExportMe")
+	s.ctx = ctx
+	defer span.Finish()
+
+	export, err := s.storage.GetUserExport(s.ctx, msg.UserID)
+	if err != nil {
+		logger.Error("Ошибка выгрузки данных пользователя", "err", err)
+		return true, errors.Wrap(err, "Get user export error")
+	}
+	if export.Profile.TgID == 0 {
+		return true, s.tgClient.SendMessage(txtExportEmpty, msg.UserID)
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return true, errors.Wrap(err, "Marshal user export error")
+	}
+	fileName := fmt.Sprintf("export_%v_%v.json", msg.UserID, export.ExportedAt.Format("2006-01-02"))
+	return true, s.tgClient.SendDocument(fileName, data, txtExportCaption, msg.UserID)
+}
+
+// Запрос подтверждения удаления аккаунта.
+func cmdDeleteMe(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/delete_me"
+	return true, s.tgClient.ShowInlineButtons(txtDeleteConfirm, btnDeleteConfirm, msg.UserID)
+}
+
+// Подтверждение или отмена удаления аккаунта.
+// Удаление выполняется, только если кнопка нажата сразу после запроса подтверждения.
+func checkIfConfirmDeleteMe(s *Model, msg Message, state UserState) (bool, error) {
+	if msg.Text != deleteConfirmValue {
+		return true, s.tgClient.SendMessage(txtDeleteCancel, msg.UserID)
+	}
+	if state.Command != "/delete_me" {
+		return true, s.tgClient.SendMessage(txtDeleteExpired, msg.UserID)
+	}
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfConfirmDeleteMe")
+	s.ctx = ctx
+	defer span.Finish()
+
+	records, isDeleted, err := s.storage.DeleteUser(s.ctx, msg.UserID)
+	if err != nil {
+		logger.Error("Ошибка удаления пользователя", "err", err)
+		return true, errors.Wrap(err, "Delete user error")
+	}
+	purgeUserState(s, msg.UserID)
+	if !isDeleted {
+		return true, s.tgClient.SendMessage(txtDeleteNotFound, msg.UserID)
+	}
+	logger.Info("Аккаунт пользователя удален по его запросу", "user", msg.UserID, "records", records)
+	return true, s.tgClient.SendMessage(txtDeleteDone, msg.UserID)
+}
+
+// Удаление данных пользователя из кэша отчетов и состояния диалога.
+func purgeUserState(s *Model, userID int64) {
+	for _, key := range reportCacheKeys {
+		s.reportCache.Remove(strconv.FormatInt(userID, 10) + key)
+	}
+	delete(s.lastUserCat, userID)
+	delete(s.lastUserCommand, userID)
+	delete(s.lastUserReceipt, userID)
+	delete(s.lastUserGoal, userID)
+	delete(s.lastUserFind, userID)
+	delete(s.lastUserDate, userID)
+	delete(s.lastUserTableMode, userID)
+	delete(s.lastUserSeen, userID)
+}
@@ -0,0 +1,73 @@
+package messages
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mocks "github.com/ellavs/tg-bot-golang/internal/mocks/messages"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_OnDeleteMeConfirm_ShouldDeleteUserAndPurgeState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	cache := mocks.NewMockLRUCache(ctrl)
+	sender.EXPECT().ShowInlineButtons(txtDeleteConfirm, btnDeleteConfirm, int64(123))
+	storage.EXPECT().DeleteUser(gomock.Any(), int64(123)).Return(int64(5), true, nil)
+	cache.EXPECT().Remove("123w")
+	cache.EXPECT().Remove("123m")
+	cache.EXPECT().Remove("123y")
+	sender.EXPECT().SendMessage(txtDeleteDone, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, cache, nil)
+	model.lastUserFind[123] = types.UserDataSearchFilter{Text: "кино"}
+	assert.NoError(t, model.IncomingMessage(Message{Text: "/delete_me", UserID: 123}))
+	assert.NoError(t, model.IncomingMessage(Message{Text: deleteConfirmValue, UserID: 123, IsCallback: true}))
+
+	assert.NotContains(t, model.lastUserFind, int64(123))
+}
+
+func Test_OnDeleteMeConfirm_ShouldNotDelete_WhenConfirmationExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	// Между запросом подтверждения и нажатием кнопки была другая команда.
+	sender.EXPECT().SendMessage(txtDeleteExpired, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	err := model.IncomingMessage(Message{Text: deleteConfirmValue, UserID: 123, IsCallback: true})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnExportMe_ShouldSendJSONDocument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	export := types.UserExport{
+		BaseCurrency: "RUB",
+		Profile:      types.UserExportProfile{TgID: 123, Name: "test", Currency: "USD"},
+		Categories:   []string{"Кино"},
+		Records:      []types.UserExportRecord{{Category: "Кино", Sum: 50000, Note: "премьера"}},
+	}
+	storage.EXPECT().GetUserExport(gomock.Any(), int64(123)).Return(export, nil)
+	sender.EXPECT().SendDocument("export_123_0001-01-01.json", gomock.Any(), txtExportCaption, int64(123)).
+		DoAndReturn(func(fileName string, data []byte, caption string, userID int64) error {
+			var got types.UserExport
+			assert.NoError(t, json.Unmarshal(data, &got))
+			assert.Equal(t, export, got)
+			return nil
+		})
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	err := model.IncomingMessage(Message{Text: "/export_me", UserID: 123})
+
+	assert.NoError(t, err)
+}
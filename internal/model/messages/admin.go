@@ -0,0 +1,218 @@
+package messages
+
+// Команды администраторов бота: статистика использования, сведения о пользователе, журнал изменений
+// данных пользователя, обновление курсов валют.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// adminStatsDays Количество дней в статистике введенных записей.
+const adminStatsDays = 7
+
+// userSeenInterval Минимальный интервал между сохранениями времени обращения пользователя к боту.
+const userSeenInterval = time.Minute
+
+// adminAuditSize Количество последних изменений в журнале изменений пользователя.
+const adminAuditSize = 20
+
+const (
+	txtAdminStats         = "Пользователей: <b>%v</b>\nАктивных за сутки (DAU): <b>%v</b>\nАктивных за 30 дней (MAU): <b>%v</b>"
+	txtAdminStatsRecords  = "Записей по дням:"
+	txtAdminStatsCurrency = "Популярные валюты:"
+	txtAdminUser          = "Пользователь <b>%v</b> (%v)\nВалюта: <b>%v</b>\nБюджет: <b>%v</b>\nЧасовой пояс: <b>%v</b>\nКатегорий: <b>%v</b>\nЗаписей: <b>%v</b> на сумму <b>%v</b>\nПоследняя запись: <b>%v</b>"
+	txtAdminUserIDFormat  = "Введите ТГ-идентификатор пользователя, например: <code>%v 123456789</code>"
+	txtAdminUserNotFound  = "Пользователь %v не найден."
+	txtAdminAudit         = "Последние изменения данных пользователя %v:"
+	txtAdminAuditEmpty    = "Изменений данных пользователя %v нет."
+	txtAdminAuditLine     = "\n<code>%v</code> %v %v #%v (%v): %v → %v"
+	txtAdminRatesReloaded = "Курсы валют обновлены."
+	txtAdminRatesError    = "Не удалось обновить курсы валют: %v"
+)
+
+// Область "Константы и переменные": конец.
+
+// Отображение статистики использования бота.
+func cmdAdminStats(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdAdminStats")
+	s.ctx = ctx
+	defer span.Finish()
+
+	if err := auditAdminAction(s, msg, "/admin_stats", ""); err != nil {
+		return true, err
+	}
+	stats, err := s.storage.GetAdminStats(s.ctx, adminStatsDays)
+	if err != nil {
+		logger.Error("Ошибка получения статистики", "err", err)
+		return true, errors.Wrap(err, "Get admin stats error")
+	}
+	return true, s.tgClient.SendMessage(formatAdminStats(stats), msg.UserID)
+}
+
+// Сохранение времени обращения пользователя к боту для статистики активности (DAU/MAU)
+// не чаще раза в userSeenInterval (для зарегистрированных пользователей). Ошибка сохранения не прерывает обработку сообщения.
+func markUserSeen(s *Model, userID int64) {
+	now := time.Now()
+	if now.Sub(s.lastUserSeen[userID]) < userSeenInterval {
+		return
+	}
+	isSaved, err := s.storage.SetUserLastSeen(s.ctx, userID, now)
+	if err != nil {
+		logger.Error("Ошибка сохранения времени обращения пользователя", "err", err)
+		return
+	}
+	if isSaved {
+		s.lastUserSeen[userID] = now
+	}
+}
+
+// Отображение сведений о пользователе по ТГ-идентификатору.
+func cmdAdminUser(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdAdminUser")
+	s.ctx = ctx
+	defer span.Finish()
+
+	args := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/admin_user"))
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		return true, s.tgClient.SendMessage(renderf(txtAdminUserIDFormat, "/admin_user"), msg.UserID)
+	}
+	if err := auditAdminAction(s, msg, "/admin_user", args); err != nil {
+		return true, err
+	}
+	info, err := s.storage.GetUserInfo(s.ctx, userID)
+	if err != nil {
+		logger.Error("Ошибка получения сведений о пользователе", "err", err)
+		return true, errors.Wrap(err, "Get user info error")
+	}
+	if info.TgID == 0 {
+		return true, s.tgClient.SendMessage(renderf(txtAdminUserNotFound, userID), msg.UserID)
+	}
+	return true, s.tgClient.SendMessage(formatAdminUserInfo(info, s.currencies.GetMainCurrency()), msg.UserID)
+}
+
+// Отображение журнала изменений данных пользователя по ТГ-идентификатору.
+func cmdAdminAudit(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdAdminAudit")
+	s.ctx = ctx
+	defer span.Finish()
+
+	args := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/admin_audit"))
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		return true, s.tgClient.SendMessage(renderf(txtAdminUserIDFormat, "/admin_audit"), msg.UserID)
+	}
+	if err := auditAdminAction(s, msg, "/admin_audit", args); err != nil {
+		return true, err
+	}
+	audit, err := s.storage.GetUserAudit(s.ctx, userID, adminAuditSize)
+	if err != nil {
+		logger.Error("Ошибка получения журнала изменений", "err", err)
+		return true, errors.Wrap(err, "Get user audit error")
+	}
+	if len(audit) == 0 {
+		return true, s.tgClient.SendMessage(renderf(txtAdminAuditEmpty, userID), msg.UserID)
+	}
+	return true, s.tgClient.SendMessage(formatAdminAudit(userID, audit), msg.UserID)
+}
+
+// Принудительное обновление курсов валют из внешнего источника.
+func cmdAdminReloadRates(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdAdminReloadRates")
+	s.ctx = ctx
+	defer span.Finish()
+
+	if err := auditAdminAction(s, msg, "/admin_reload_rates", ""); err != nil {
+		return true, err
+	}
+	if err := s.currencies.UpdateExchangeRates(); err != nil {
+		logger.Error("Ошибка обновления курсов валют", "err", err)
+		return true, s.tgClient.SendMessage(renderf(txtAdminRatesError, err), msg.UserID)
+	}
+	return true, s.tgClient.SendMessage(txtAdminRatesReloaded, msg.UserID)
+}
+
+// Запись действия администратора в журнал (в БД и в лог).
+// Если записать действие не удалось, действие не выполняется.
+func auditAdminAction(s *Model, msg Message, command string, args string) error {
+	logger.Info("Действие администратора", "admin", msg.UserID, "command", command, "args", args)
+	if err := s.storage.InsertAdminAudit(s.ctx, msg.UserID, command, args); err != nil {
+		logger.Error("Ошибка записи действия администратора", "err", err)
+		return errors.Wrap(err, "Insert admin audit error")
+	}
+	return nil
+}
+
+// Формирование текста статистики использования бота.
+func formatAdminStats(stats types.AdminStats) string {
+	var res strings.Builder
+	res.WriteString(renderf(txtAdminStats, stats.UsersTotal, stats.DAU, stats.MAU))
+	if len(stats.RecordsPerDay) > 0 {
+		res.WriteString("\n\n" + txtAdminStatsRecords)
+		for _, day := range stats.RecordsPerDay {
+			res.WriteString(fmt.Sprintf("\n%v: %v", day.Day.Format("02.01.2006"), day.Count))
+		}
+	}
+	if len(stats.TopCurrencies) > 0 {
+		res.WriteString("\n\n" + txtAdminStatsCurrency)
+		for _, currency := range stats.TopCurrencies {
+			res.WriteString(renderf("\n%v: %v", currency.Currency, currency.Users))
+		}
+	}
+	return res.String()
+}
+
+// Формирование текста журнала изменений данных пользователя (значения - в формате JSON).
+func formatAdminAudit(userID int64, audit []types.AuditRecord) string {
+	var res strings.Builder
+	res.WriteString(renderf(txtAdminAudit, userID))
+	for _, rec := range audit {
+		res.WriteString(renderf(txtAdminAuditLine,
+			rec.CreatedAt.Format("02.01.2006 15:04"), rec.Entity, rec.Operation, rec.ObjectID, rec.ActorTgID,
+			formatAuditValue(rec.OldValue), formatAuditValue(rec.NewValue)))
+	}
+	return res.String()
+}
+
+// Значения из журнала изменений в формате JSON ("-" - значений нет).
+func formatAuditValue(value map[string]any) string {
+	if value == nil {
+		return "-"
+	}
+	// Значения экранируются при подстановке в шаблон.
+	var res strings.Builder
+	encoder := json.NewEncoder(&res)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(res.String(), "\n")
+}
+
+// Формирование текста сведений о пользователе (суммы - в основной валюте).
+func formatAdminUserInfo(info types.UserInfo, mainCurrency string) string {
+	timezone := info.Timezone
+	if timezone == "" {
+		timezone = "по умолчанию"
+	}
+	lastRecord := "нет"
+	if !info.LastRecordAt.IsZero() {
+		lastRecord = info.LastRecordAt.Format("02.01.2006 15:04")
+	}
+	return renderf(txtAdminUser,
+		info.TgID, info.Name, info.Currency,
+		money.New(info.Limits, mainCurrency), timezone,
+		info.Categories, info.Records, money.New(info.TotalSum, mainCurrency), lastRecord)
+}
@@ -0,0 +1,90 @@
+package messages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mocks "github.com/ellavs/tg-bot-golang/internal/mocks/messages"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_OnAdminCommand_ShouldBeUnknown_WhenUserIsNotAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	// Статистика и журнал не запрашиваются, команда считается неизвестной.
+	sender.EXPECT().SendMessage(txtUnknownCommand, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	model.SetAdmins([]int64{456})
+	err := model.IncomingMessage(Message{Text: "/admin_stats", UserID: 123})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnAdminUserCommand_ShouldAuditAndShowUserInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	currencies := mocks.NewMockExchangeRates(ctrl)
+	info := types.UserInfo{TgID: 789, Name: "ivan_petrov", Currency: "USD", Records: 2, TotalSum: 150050}
+	gomock.InOrder(
+		storage.EXPECT().InsertAdminAudit(gomock.Any(), int64(456), "/admin_user", "789"),
+		storage.EXPECT().GetUserInfo(gomock.Any(), int64(789)).Return(info, nil),
+	)
+	currencies.EXPECT().GetMainCurrency().Return("RUB")
+	sender.EXPECT().SendMessage(formatAdminUserInfo(info, "RUB"), int64(456))
+
+	model := New(context.Background(), sender, storage, currencies, nil, nil)
+	model.SetAdmins([]int64{456})
+	err := model.IncomingMessage(Message{Text: "/admin_user 789", UserID: 456})
+
+	assert.NoError(t, err)
+}
+
+func Test_formatAdminStats_ShouldListDaysAndCurrencies(t *testing.T) {
+	stats := types.AdminStats{
+		UsersTotal:    10,
+		DAU:           2,
+		MAU:           5,
+		RecordsPerDay: []types.DayCount{{Day: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), Count: 4}},
+		TopCurrencies: []types.CurrencyCount{{Currency: "RUB", Users: 8}, {Currency: "USD", Users: 2}},
+	}
+
+	assert.Equal(t,
+		"Пользователей: <b>10</b>\nАктивных за сутки (DAU): <b>2</b>\nАктивных за 30 дней (MAU): <b>5</b>"+
+			"\n\nЗаписей по дням:\n09.03.2024: 4"+
+			"\n\nПопулярные валюты:\nRUB: 8\nUSD: 2",
+		formatAdminStats(stats))
+}
+
+func Test_formatAdminUserInfo_ShouldEscapeName(t *testing.T) {
+	text := formatAdminUserInfo(types.UserInfo{TgID: 789, Name: "ivan_<petrov>"}, "RUB")
+
+	assert.Contains(t, text, "(ivan_&lt;petrov&gt;)")
+	assert.Contains(t, text, "Часовой пояс: <b>по умолчанию</b>")
+	assert.Contains(t, text, "Последняя запись: <b>нет</b>")
+}
+
+func Test_formatAdminAudit_ShouldShowValuesAsJSON(t *testing.T) {
+	audit := []types.AuditRecord{
+		{ActorTgID: 789, Entity: types.AuditEntitySettings, ObjectID: 1, Operation: types.AuditOpUpdate,
+			OldValue: map[string]any{"limits": 0}, NewValue: map[string]any{"limits": 100000},
+			CreatedAt: time.Date(2024, 3, 9, 10, 30, 0, 0, time.UTC)},
+		{ActorTgID: 789, Entity: types.AuditEntityCategory, ObjectID: 2, Operation: types.AuditOpInsert,
+			NewValue:  map[string]any{"name": "<Кино>"},
+			CreatedAt: time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)},
+	}
+
+	assert.Equal(t,
+		"Последние изменения данных пользователя 789:"+
+			"\n<code>09.03.2024 10:30</code> settings update #1 (789): {&#34;limits&#34;:0} → {&#34;limits&#34;:100000}"+
+			"\n<code>09.03.2024 10:00</code> category insert #2 (789): - → {&#34;name&#34;:&#34;&lt;Кино&gt;&#34;}",
+		formatAdminAudit(789, audit),
+	)
+}
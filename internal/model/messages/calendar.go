@@ -0,0 +1,175 @@
+package messages
+
+// Ввод расхода задним числом: относительные даты при вводе суммы и календарь для выбора даты.
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+const (
+	txtCalendarChoice = "Выберите дату расхода по категории <b>%v</b>."
+	txtCalendarDate   = "Выбрана категория <b>%v</b>, дата <b>%v</b>. Введите сумму и, при необходимости, комментарий. Для отмены введите 0. Используемая валюта: <b>%v</b>"
+	txtCalendarExpire = "Категория не выбрана. Выберите категорию заново: /add_rec"
+)
+
+// Формат месяца и дня в данных кнопок календаря.
+const (
+	calendarMonthLayout = "2006-01"
+	calendarDayLayout   = "2006-01-02"
+)
+
+// Названия месяцев для заголовка календаря.
+var calendarMonths = [...]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"}
+
+// Сокращенные названия дней недели (неделя начинается с понедельника).
+var calendarWeekdays = [...]string{"Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"}
+
+// Относительные даты расхода (количество дней назад).
+var relativeDays = map[string]int{
+	"сегодня":   0,
+	"вчера":     1,
+	"позавчера": 2,
+}
+
+// Область "Константы и переменные": конец.
+
+// Кнопка открытия календаря под сообщением о вводе суммы.
+func getCalendarOpenButtons(now time.Time) []types.TgRowButtons {
+	return []types.TgRowButtons{
+		{types.TgInlineButton{DisplayName: "📅 Другая дата", Value: "/cal " + now.Format(calendarMonthLayout)}},
+	}
+}
+
+// Нажатие кнопки открытия календаря или перехода на другой месяц.
+func checkIfChoiceCalendarMonth(s *Model, msg Message, state UserState) (bool, error) {
+	month, err := time.Parse(calendarMonthLayout, strings.TrimPrefix(msg.Text, "/cal "))
+	if err != nil {
+		return false, nil
+	}
+	if state.Category == "" {
+		return true, s.tgClient.SendMessage(txtCalendarExpire, msg.UserID)
+	}
+	// Сохранение выбранной категории на время выбора даты.
+	s.lastUserCat[msg.UserID] = state.Category
+	s.lastUserCommand[msg.UserID] = "/cat"
+	today := time.Now().In(getUserLocation(s, msg.UserID))
+	return true, s.tgClient.ShowInlineButtons(renderf(txtCalendarChoice, state.Category), getCalendarButtons(month, today), msg.UserID)
+}
+
+// Нажатие кнопки с днем в календаре.
+func checkIfChoiceCalendarDay(s *Model, msg Message, state UserState) (bool, error) {
+	loc := getUserLocation(s, msg.UserID)
+	day, err := time.ParseInLocation(calendarDayLayout, strings.TrimPrefix(msg.Text, "/cal_day "), loc)
+	if err != nil {
+		return false, nil
+	}
+	if state.Category == "" {
+		return true, s.tgClient.SendMessage(txtCalendarExpire, msg.UserID)
+	}
+	s.lastUserCat[msg.UserID] = state.Category
+	s.lastUserCommand[msg.UserID] = "/cat"
+	s.lastUserDate[msg.UserID] = day
+	answerText := renderf(txtCalendarDate, state.Category, day.Format("02.01.2006"), getUserCurrency(s, msg.UserID))
+	return true, s.tgClient.SendMessage(answerText, msg.UserID)
+}
+
+// Формирование календаря на месяц: заголовок с переходом по месяцам, дни недели и сетка дней.
+// Дни после today недоступны для выбора.
+func getCalendarButtons(month time.Time, today time.Time) []types.TgRowButtons {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, today.Location())
+	// Нажатие на неактивные ячейки повторно отображает тот же месяц.
+	currentValue := "/cal " + first.Format(calendarMonthLayout)
+	emptyButton := types.TgInlineButton{DisplayName: " ", Value: currentValue}
+
+	header := types.TgRowButtons{
+		types.TgInlineButton{DisplayName: "◀", Value: "/cal " + first.AddDate(0, -1, 0).Format(calendarMonthLayout)},
+		types.TgInlineButton{DisplayName: calendarMonths[first.Month()-1] + " " + strconv.Itoa(first.Year()), Value: currentValue},
+	}
+	if next := first.AddDate(0, 1, 0); !next.After(today) {
+		header = append(header, types.TgInlineButton{DisplayName: "▶", Value: "/cal " + next.Format(calendarMonthLayout)})
+	} else {
+		header = append(header, emptyButton)
+	}
+	buttons := []types.TgRowButtons{header}
+
+	weekdays := make(types.TgRowButtons, len(calendarWeekdays))
+	for ind, name := range calendarWeekdays {
+		weekdays[ind] = types.TgInlineButton{DisplayName: name, Value: currentValue}
+	}
+	buttons = append(buttons, weekdays)
+
+	// Пустые ячейки до первого дня месяца (понедельник - первый день недели).
+	week := types.TgRowButtons{}
+	for i := 0; i < (int(first.Weekday())+6)%7; i++ {
+		week = append(week, emptyButton)
+	}
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if day.After(today) {
+			week = append(week, types.TgInlineButton{DisplayName: "·", Value: currentValue})
+		} else {
+			week = append(week, types.TgInlineButton{DisplayName: strconv.Itoa(day.Day()), Value: "/cal_day " + day.Format(calendarDayLayout)})
+		}
+		if len(week) == len(calendarWeekdays) {
+			buttons = append(buttons, week)
+			week = types.TgRowButtons{}
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < len(calendarWeekdays) {
+			week = append(week, emptyButton)
+		}
+		buttons = append(buttons, week)
+	}
+	return buttons
+}
+
+// Разбор ввода расхода: сумма, дата (необязательно) и комментарий (необязательно).
+// Например: "1500", "1500 вчера", "1500 15.03 прививка". Если дата не указана, возвращается нулевая дата.
+func parseRecordInput(text string, now time.Time) (string, time.Time, string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", time.Time{}, "", errors.New("Не указана сумма.")
+	}
+	sumString, rest := fields[0], fields[1:]
+	var period time.Time
+	if len(rest) > 0 {
+		if date, ok := parseRecordDate(rest[0], now); ok {
+			if date.After(now) {
+				return "", time.Time{}, "", errors.New("Дата расхода в будущем.")
+			}
+			period, rest = date, rest[1:]
+		}
+	}
+	return sumString, period, strings.Join(rest, " "), nil
+}
+
+// Парсинг даты расхода: "сегодня", "вчера", "позавчера", "15.03", "15.03.2024" или "2024-03-15".
+// Дата без года относится к последнему прошедшему такому дню.
+func parseRecordDate(text string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if days, ok := relativeDays[strings.ToLower(text)]; ok {
+		return today.AddDate(0, 0, -days), true
+	}
+	for _, layout := range []string{"02.01.2006", "2.1.2006", calendarDayLayout} {
+		if date, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return date, true
+		}
+	}
+	for _, layout := range []string{"02.01", "2.1"} {
+		if date, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			date = time.Date(now.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location())
+			if date.After(today) {
+				date = date.AddDate(-1, 0, 0)
+			}
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
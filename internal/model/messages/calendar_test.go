@@ -0,0 +1,67 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseRecordInput_ShouldParseRelativeDateAndNote(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	sum, period, note, err := parseRecordInput("1500 позавчера прививка коту", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1500", sum)
+	assert.Equal(t, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), period)
+	assert.Equal(t, "прививка коту", note)
+}
+
+func Test_parseRecordInput_ShouldUsePreviousYear_WhenDayIsInFuture(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	_, period, note, err := parseRecordInput("350.50 15.03", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), period)
+	assert.Equal(t, "", note)
+}
+
+func Test_parseRecordInput_ShouldReturnZeroDate_WhenNoDate(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	sum, period, note, err := parseRecordInput("1500 такси", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1500", sum)
+	assert.True(t, period.IsZero())
+	assert.Equal(t, "такси", note)
+}
+
+func Test_parseRecordInput_ShouldReturnError_WhenDateIsInFuture(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	_, _, _, err := parseRecordInput("1500 2024-03-11", now)
+
+	assert.Error(t, err)
+}
+
+func Test_getCalendarButtons_ShouldBuildMonthGrid(t *testing.T) {
+	// Март 2024 начинается в пятницу, сегодня - 10 марта.
+	today := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	buttons := getCalendarButtons(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), today)
+
+	// Заголовок, дни недели и 5 недель.
+	assert.Len(t, buttons, 7)
+	assert.Equal(t, types.TgInlineButton{DisplayName: "◀", Value: "/cal 2024-02"}, buttons[0][0])
+	assert.Equal(t, "Март 2024", buttons[0][1].DisplayName)
+	// Переход на будущий месяц недоступен.
+	assert.Equal(t, "/cal 2024-03", buttons[0][2].Value)
+	// Первая неделя: 4 пустые ячейки, затем 1, 2, 3 марта.
+	assert.Equal(t, " ", buttons[2][3].DisplayName)
+	assert.Equal(t, types.TgInlineButton{DisplayName: "1", Value: "/cal_day 2024-03-01"}, buttons[2][4])
+	// Дни после сегодняшнего недоступны.
+	assert.Equal(t, types.TgInlineButton{DisplayName: "10", Value: "/cal_day 2024-03-10"}, buttons[3][6])
+	assert.Equal(t, "·", buttons[4][0].DisplayName)
+	for _, row := range buttons[1:] {
+		assert.Len(t, row, 7)
+	}
+}
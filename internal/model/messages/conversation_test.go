@@ -0,0 +1,308 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ellavs/tg-bot-golang/internal/cache"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/memory"
+	rates "github.com/ellavs/tg-bot-golang/internal/model/exchangerates"
+)
+
+// Тесты диалогов с ботом целиком (с хранилищами в памяти, без моков).
+
+// conversationSender Клиент, запоминающий отправленные пользователю сообщения.
+type conversationSender struct {
+	messages []string
+	buttons  [][]types.TgRowButtons
+}
+
+func (sender *conversationSender) SendMessage(text string, userID int64) error {
+	sender.messages = append(sender.messages, text)
+	sender.buttons = append(sender.buttons, nil)
+	return nil
+}
+
+func (sender *conversationSender) ShowInlineButtons(text string, buttons []types.TgRowButtons, userID int64) error {
+	sender.messages = append(sender.messages, text)
+	sender.buttons = append(sender.buttons, buttons)
+	return nil
+}
+
+func (sender *conversationSender) AnswerInlineQuery(queryID string, results []types.TgInlineResult) error {
+	return nil
+}
+
+func (sender *conversationSender) SendDocument(fileName string, data []byte, caption string, userID int64) error {
+	sender.messages = append(sender.messages, caption)
+	sender.buttons = append(sender.buttons, nil)
+	return nil
+}
+
+// conversationKafka Очередь запросов на формирование отчетов.
+type conversationKafka struct {
+	requests []string
+}
+
+func (producer *conversationKafka) SendMessage(key string, value string) (int32, int64, error) {
+	producer.requests = append(producer.requests, key+":"+value)
+	return 0, int64(len(producer.requests)), nil
+}
+
+func (producer *conversationKafka) GetTopic() string {
+	return "test"
+}
+
+// conversation Диалог одного пользователя с ботом.
+type conversation struct {
+	t       *testing.T
+	model   *Model
+	storage *memory.UserStorage
+	sender  *conversationSender
+	kafka   *conversationKafka
+	userID  int64
+}
+
+// newConversation Инициализация бота с хранилищами в памяти и курсом доллара 0.02.
+func newConversation(t *testing.T) *conversation {
+	ctx := context.Background()
+	ratesStorage := memory.NewExchangeRatesStorage([]string{"USD", "RUB"})
+	require.NoError(t, ratesStorage.InsertExchangeRatesToDate(ctx, types.ExchangeRate{"USD": money.MustParseRate("0.02"), "RUB": money.MustParseRate("1")}, time.Now()))
+	exchangeRates := rates.New(ctx, nil, []string{"USD", "RUB"}, "RUB", ratesStorage)
+	require.NoError(t, exchangeRates.LoadExchangeRatesFromStorage())
+
+	c := &conversation{
+		t:       t,
+		storage: memory.NewUserStorage("RUB", 0, "Europe/Moscow"),
+		sender:  &conversationSender{},
+		kafka:   &conversationKafka{},
+		userID:  123,
+	}
+	c.model = New(ctx, c.sender, c.storage, exchangeRates, cache.NewLRU(5), c.kafka)
+	return c
+}
+
+// say Отправка сообщения боту и получение ответа.
+func (c *conversation) say(text string) string {
+	return c.send(Message{Text: text, UserID: c.userID, UserName: "test"})
+}
+
+// press Нажатие кнопки и получение ответа.
+func (c *conversation) press(value string) string {
+	return c.send(Message{Text: value, UserID: c.userID, UserName: "test", IsCallback: true})
+}
+
+func (c *conversation) send(msg Message) string {
+	sent := len(c.sender.messages)
+	require.NoError(c.t, c.model.IncomingMessage(msg))
+	require.Len(c.t, c.sender.messages, sent+1, "ожидается один ответ на сообщение %q", msg.Text)
+	return c.sender.messages[sent]
+}
+
+// lastButtons Кнопки последнего ответа.
+func (c *conversation) lastButtons() []types.TgRowButtons {
+	return c.sender.buttons[len(c.sender.buttons)-1]
+}
+
+// report Формирование отчета по запросу из очереди (как в сервисе отчетов).
+func (c *conversation) report(key string) string {
+	require.Equal(c.t, []string{"123:" + key}, c.kafka.requests)
+	c.kafka.requests = nil
+	dt, err := c.storage.GetUserDataRecord(context.Background(), c.userID, time.Now().AddDate(0, -1, 0))
+	require.NoError(c.t, err)
+	sent := len(c.sender.messages)
+	require.NoError(c.t, c.model.SendReportToUser(dt, c.userID, key))
+	return c.sender.messages[sent]
+}
+
+func Test_Conversation_ShouldAddRecordsWithinLimitAndUndo(t *testing.T) {
+	c := newConversation(t)
+
+	// Категории уникальны без учета регистра.
+	c.say("/add_cat")
+	assert.Equal(t, txtCatSave, c.say("Кафе"))
+	c.say("/add_cat")
+	assert.Equal(t, txtCatSave, c.say("кафе"))
+	assert.Equal(t, txtCatView, c.say("/add_rec"))
+	assert.Equal(t, []types.TgRowButtons{{{DisplayName: "Кафе", Value: "/cat Кафе"}}}, c.lastButtons())
+
+	c.say("/set_limit")
+	assert.Equal(t, renderf(txtLimitSet, "2000"), c.say("2000"))
+
+	c.press("/cat Кафе")
+	assert.Equal(t, txtRecSave, c.say("1500 обед"))
+	// Запись, превышающая бюджет, не сохраняется.
+	c.press("/cat Кафе")
+	assert.Equal(t, txtRecOverLimit, c.say("600"))
+	c.press("/cat Кафе")
+	assert.Equal(t, txtRecSave, c.say("500"))
+
+	// Отчет в выбранной валюте (по курсу 0.02).
+	assert.Equal(t, renderf(txtCurrencySet, "USD"), c.press("/curr USD"))
+	assert.Equal(t, txtReportWait, c.say("/report_m"))
+	assert.Contains(t, c.report("m"), "40.00 | Кафе")
+
+	// Отмена действий в обратном порядке.
+	assert.Equal(t, renderf(txtUndoCurrency, "RUB"), c.say("/undo"))
+	assert.Equal(t, txtUndoRecord, c.say("/undo"))
+	limits, err := c.storage.GetUserLimit(context.Background(), c.userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200000), limits)
+	dt, err := c.storage.GetUserDataRecord(context.Background(), c.userID, time.Now().AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кафе", Sum: 150000}}, dt)
+}
+
+func Test_Conversation_ShouldExportAndDeleteAccount(t *testing.T) {
+	c := newConversation(t)
+
+	c.press("/cat Такси")
+	assert.Equal(t, txtRecSave, c.say("300 в аэропорт"))
+	c.say("/export_me")
+
+	export, err := c.storage.GetUserExport(context.Background(), c.userID)
+	require.NoError(t, err)
+	require.Len(t, export.Records, 1)
+	assert.Equal(t, "в аэропорт", export.Records[0].Note)
+
+	c.say("/delete_me")
+	confirm := c.lastButtons()[0][0].Value
+	c.press(confirm)
+	access, err := c.storage.GetUserAccess(context.Background(), c.userID)
+	require.NoError(t, err)
+	assert.False(t, access.Exists)
+}
+
+func Test_Conversation_ShouldRestoreUndoneRecordFromTrash(t *testing.T) {
+	c := newConversation(t)
+
+	assert.Equal(t, renderf(txtTrashEmpty, 30), c.say("/trash"))
+	c.press("/cat Кафе")
+	assert.Equal(t, txtRecSave, c.say("1500 обед"))
+	assert.Equal(t, txtUndoRecord, c.say("/undo"))
+
+	// Отмененная запись - в корзине, в отчет не попадает.
+	assert.Contains(t, c.say("/trash"), "<b>1500.00</b> Кафе - обед")
+	restore := c.lastButtons()[0][0]
+	assert.Equal(t, "Восстановить: 1500.00 Кафе", restore.DisplayName)
+	dt, err := c.storage.GetUserDataRecord(context.Background(), c.userID, time.Now().AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Empty(t, dt)
+
+	assert.Equal(t, txtTrashRestored, c.press(restore.Value))
+	assert.Equal(t, txtTrashNotFound, c.press(restore.Value))
+	dt, err = c.storage.GetUserDataRecord(context.Background(), c.userID, time.Now().AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []types.UserDataReportRecord{{Category: "Кафе", Sum: 150000}}, dt)
+
+	// Удаление и восстановление записаны в журнал изменений.
+	audit, err := c.storage.GetUserAudit(context.Background(), c.userID, 2)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, types.AuditOpRestore, audit[0].Operation)
+	assert.Equal(t, types.AuditOpDelete, audit[1].Operation)
+}
+
+func Test_Conversation_ShouldImportTableInChosenMode(t *testing.T) {
+	c := newConversation(t)
+	c.say("/set_limit")
+	c.say("2000")
+	table := "2024-03-10 1500 Кино\n2024-03-11 600 Такси\n2024-03-11 сто Такси\n\n2024-03-12 500 такси\n"
+
+	// По умолчанию таблица с ошибками не сохраняется целиком (строки нумеруются с учетом пустых).
+	assert.Contains(t, c.say("/add_tbl"), tableModeNames[types.ImportAllOrNothing])
+	assert.Equal(t, btnTableModes, c.lastButtons())
+	assert.Equal(t, txtRecTblRejected+
+		"\n1. Без ошибок, не сохранено."+
+		"\n2. Без ошибок, не сохранено."+
+		"\n3. Ошибка. Ошибка распознавания формата строки."+
+		"\n5. Без ошибок, не сохранено.", c.say(table))
+	categories, err := c.storage.GetUserCategory(context.Background(), c.userID)
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+
+	// Сохраняются строки без ошибок, бюджет проверяется по строкам таблицы.
+	c.say("/add_tbl")
+	assert.Equal(t, renderf(txtRecTblMode, tableModeNames[types.ImportBestEffort]), c.press("/add_tbl_mode best"))
+	assert.Equal(t, renderf(txtRecTblSave, 2, 4)+
+		"\n1. ОК"+
+		"\n2. Ошибка. Превышение бюджета."+
+		"\n3. Ошибка. Ошибка распознавания формата строки."+
+		"\n5. ОК", c.say(table))
+	categories, err = c.storage.GetUserCategory(context.Background(), c.userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Кино", "такси"}, categories)
+
+	// Выбранный режим запоминается, загрузка не отменяется по одной записи: отменяется предыдущее действие.
+	assert.Contains(t, c.say("/add_tbl"), tableModeNames[types.ImportBestEffort])
+	assert.Equal(t, renderf(txtUndoLimit, "без ограничений"), c.say("/undo"))
+	dt, err := c.storage.GetUserDataRecord(context.Background(), c.userID, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.UserDataReportRecord{{Category: "Кино", Sum: 150000}, {Category: "такси", Sum: 50000}}, dt)
+}
+
+func Test_Conversation_ShouldLeaveGoalInput_WhenOtherCommand(t *testing.T) {
+	c := newConversation(t)
+	c.say("/add_goal")
+	// Неверный ввод цели запрашивается повторно.
+	assert.Equal(t, txtGoalFormatError, c.say("на отпуск"))
+
+	// Другая команда выполняется, ввод цели завершается.
+	assert.NotEqual(t, txtGoalFormatError, c.say("/set_limit"))
+	c.say("2000")
+	limits, err := c.storage.GetUserLimit(context.Background(), c.userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200000), limits)
+}
+
+func Test_Conversation_ShouldLeaveGoalContribution_WhenOtherCommand(t *testing.T) {
+	c := newConversation(t)
+	ctx := context.Background()
+	require.NoError(t, c.storage.InsertGoal(ctx, c.userID, types.Goal{Name: "Отпуск", Currency: "RUB", Target: 10000000, Deadline: time.Now().AddDate(1, 0, 0)}, "test"))
+	goals, err := c.storage.GetUserGoals(ctx, c.userID)
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+	assert.Equal(t, renderf(txtGoalContribution, "RUB"), c.press(fmt.Sprintf("/goal_add %v", goals[0].ID)))
+
+	// Другая команда выполняется, ввод суммы пополнения завершается.
+	assert.Contains(t, c.say("/set_limit"), "Текущий ежемесячный бюджет")
+	c.say("2000")
+	limits, err := c.storage.GetUserLimit(ctx, c.userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200000), limits)
+	goals, err = c.storage.GetUserGoals(ctx, c.userID)
+	require.NoError(t, err)
+	assert.Zero(t, goals[0].Saved)
+}
+
+func Test_Conversation_ShouldNotCacheMonthlyDigest_AsMonthReport(t *testing.T) {
+	c := newConversation(t)
+
+	// Ежемесячный регулярный отчет - за прошлый календарный месяц.
+	sent := len(c.sender.messages)
+	require.NoError(t, c.model.SendReportToUser([]types.UserDataReportRecord{{Category: "Кафе", Sum: 150000}}, c.userID, types.ReportPreviousMonth))
+	assert.Contains(t, c.sender.messages[sent], "Отчет за <b>прошлый месяц</b>")
+
+	// Отчет за последний месяц по команде не подменяется регулярным отчетом из кэша.
+	assert.Equal(t, txtReportWait, c.say("/report_m"))
+	assert.Contains(t, c.report("m"), "Отчет за <b>последний месяц</b>")
+}
+
+func Test_Conversation_ShouldCountActiveUser_WithoutRecords(t *testing.T) {
+	c := newConversation(t)
+	c.say("/set_limit")
+	c.say("2000")
+	c.say("/help")
+
+	// Пользователь не вводил расходы, но обращался к боту.
+	stats, err := c.storage.GetAdminStats(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.DAU)
+	assert.Equal(t, int64(1), stats.MAU)
+}
@@ -0,0 +1,247 @@
+package messages
+
+// Поиск записей о расходах по тексту, сумме или дате.
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// findPageSize Количество записей на странице результатов поиска.
+const findPageSize = 10
+
+const (
+	txtFindQuery    = "Введите условия поиска: часть названия категории или комментария, сумму (<code>&gt;5000</code>, <code>&lt;=300</code>, <code>1000..5000</code>) и/или дату (<code>2024-01-15</code>, <code>2024-01</code>, <code>2024-01-01..2024-03-31</code>, <code>&gt;2024-01-01</code>). Например: <code>ветеринар &gt;1000 2024-01..2024-06</code>"
+	txtFindEmpty    = "Записи не найдены."
+	txtFindResult   = "Результаты поиска (страница %v, валюта <b>%v</b>):"
+	txtFindNotFound = "Условия поиска устарели, повторите поиск командой /find."
+)
+
+// Область "Константы и переменные": конец.
+
+// Проверка, что сообщение - команда поиска с условиями (например, "/find ветеринар").
+func isFindMessage(msg Message) bool {
+	return strings.HasPrefix(msg.Text, "/find ")
+}
+
+// Поиск по условиям из команды или запрос ввода условий поиска.
+func cmdFind(s *Model, msg Message, state UserState) (bool, error) {
+	query := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/find"))
+	if query == "" {
+		s.lastUserCommand[msg.UserID] = "/find"
+		return true, s.tgClient.SendMessage(txtFindQuery, msg.UserID)
+	}
+	return findByQuery(s, msg, query)
+}
+
+// Проверка ввода условий поиска после команды /find.
+func checkIfEnterFindQuery(s *Model, msg Message, state UserState) (bool, error) {
+	if msg.IsCallback || strings.TrimSpace(msg.Text) == "" {
+		return false, nil
+	}
+	return findByQuery(s, msg, msg.Text)
+}
+
+// Нажатие кнопки перехода на другую страницу результатов поиска.
+func checkIfChoiceFindPage(s *Model, msg Message, state UserState) (bool, error) {
+	page, err := strconv.Atoi(strings.TrimPrefix(msg.Text, "/find_page "))
+	if err != nil || page < 1 {
+		return false, nil
+	}
+	filter, ok := s.lastUserFind[msg.UserID]
+	if !ok {
+		return true, s.tgClient.SendMessage(txtFindNotFound, msg.UserID)
+	}
+	return true, sendFindPage(s, msg.UserID, filter, page)
+}
+
+// Разбор условий поиска и вывод первой страницы результатов.
+func findByQuery(s *Model, msg Message, query string) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "findByQuery")
+	s.ctx = ctx
+	defer span.Finish()
+
+	filter, err := parseFindQuery(query, getUserLocation(s, msg.UserID))
+	if err != nil {
+		return true, s.tgClient.SendMessage(escape(err.Error())+"\n"+txtFindQuery, msg.UserID)
+	}
+	// Суммы хранятся в базовой валюте.
+	if filter.SumFrom, err = convertSumFromCurrency(s, msg.UserID, filter.SumFrom); err != nil {
+		return true, errors.Wrap(err, "Ошибка конвертации валюты.")
+	}
+	if filter.SumTo, err = convertSumFromCurrency(s, msg.UserID, filter.SumTo); err != nil {
+		return true, errors.Wrap(err, "Ошибка конвертации валюты.")
+	}
+	s.lastUserFind[msg.UserID] = filter
+	return true, sendFindPage(s, msg.UserID, filter, 1)
+}
+
+// Вывод страницы результатов поиска с кнопками перехода по страницам.
+func sendFindPage(s *Model, userID int64, filter types.UserDataSearchFilter, page int) error {
+	// Запрашивается на одну запись больше, чтобы определить наличие следующей страницы.
+	recs, err := s.storage.SearchUserDataRecords(s.ctx, userID, filter, findPageSize+1, (page-1)*findPageSize)
+	if err != nil {
+		logger.Error("Ошибка поиска записей", "err", err)
+		return errors.Wrap(err, "Search user data records error")
+	}
+	if len(recs) == 0 {
+		return s.tgClient.SendMessage(txtFindEmpty, userID)
+	}
+	hasNext := len(recs) > findPageSize
+	if hasNext {
+		recs = recs[:findPageSize]
+	}
+
+	userCurrency := getUserCurrency(s, userID)
+	loc := getUserLocation(s, userID)
+	var res strings.Builder
+	res.WriteString(renderf(txtFindResult, page, userCurrency))
+	for _, rec := range recs {
+		sum, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, rec.Sum)
+		if err != nil {
+			logger.Error("Ошибка конвертации валюты", "err", err)
+			return errors.Wrap(err, "Ошибка конвертации валюты.")
+		}
+		res.WriteString(renderf("\n<code>%v</code> <b>%v</b> %v", rec.Period.In(loc).Format("02.01.2006"), money.FormatAmount(sum), rec.Category))
+		if rec.Note != "" {
+			res.WriteString(" - " + escape(rec.Note))
+		}
+	}
+
+	// Кнопки перехода по страницам.
+	var buttons types.TgRowButtons
+	if page > 1 {
+		buttons = append(buttons, types.TgInlineButton{DisplayName: "◀ Назад", Value: "/find_page " + strconv.Itoa(page-1)})
+	}
+	if hasNext {
+		buttons = append(buttons, types.TgInlineButton{DisplayName: "Вперед ▶", Value: "/find_page " + strconv.Itoa(page+1)})
+	}
+	if len(buttons) == 0 {
+		return s.tgClient.SendMessage(res.String(), userID)
+	}
+	return s.tgClient.ShowInlineButtons(res.String(), []types.TgRowButtons{buttons}, userID)
+}
+
+// Разбор условий поиска (суммы - в копейках валюты пользователя, даты - в часовом поясе пользователя).
+// Слова, не являющиеся суммой или датой, ищутся в названии категории и комментарии.
+func parseFindQuery(query string, loc *time.Location) (types.UserDataSearchFilter, error) {
+	var filter types.UserDataSearchFilter
+	var words []string
+	for _, token := range strings.Fields(query) {
+		isParsed, err := parseFindToken(token, loc, &filter)
+		if err != nil {
+			return types.UserDataSearchFilter{}, err
+		}
+		if !isParsed {
+			words = append(words, token)
+		}
+	}
+	filter.Text = strings.Join(words, " ")
+	if filter.SumTo > 0 && filter.SumFrom > filter.SumTo {
+		return types.UserDataSearchFilter{}, errors.New("Некорректный диапазон сумм.")
+	}
+	if !filter.PeriodTo.IsZero() && !filter.PeriodFrom.Before(filter.PeriodTo) {
+		return types.UserDataSearchFilter{}, errors.New("Некорректный диапазон дат.")
+	}
+	return filter, nil
+}
+
+// Разбор условия на сумму или дату: ">N", ">=N", "<N", "<=N", "N..M", "дата", "дата..дата".
+// Возвращает false, если условие не является суммой или датой.
+func parseFindToken(token string, loc *time.Location, filter *types.UserDataSearchFilter) (bool, error) {
+	// Диапазон сумм или дат.
+	if from, to, ok := strings.Cut(token, ".."); ok {
+		if sumFrom, err := parseFindSum(from); err == nil {
+			sumTo, err := parseFindSum(to)
+			if err != nil {
+				return false, errors.New("Некорректный диапазон сумм.")
+			}
+			filter.SumFrom, filter.SumTo = sumFrom, sumTo
+			return true, nil
+		}
+		periodFrom, _, err := parseFindDate(from, loc)
+		if err != nil {
+			return false, nil
+		}
+		_, periodTo, err := parseFindDate(to, loc)
+		if err != nil {
+			return false, errors.New("Некорректный диапазон дат.")
+		}
+		filter.PeriodFrom, filter.PeriodTo = periodFrom, periodTo
+		return true, nil
+	}
+
+	// Сравнение с суммой или датой.
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		value, ok := strings.CutPrefix(token, op)
+		if !ok {
+			continue
+		}
+		if sum, err := parseFindSum(value); err == nil {
+			switch op {
+			case ">=":
+				filter.SumFrom = sum
+			case ">":
+				filter.SumFrom = sum + 1
+			case "<=":
+				filter.SumTo = sum
+			case "<":
+				filter.SumTo = sum - 1
+			}
+			return true, nil
+		}
+		periodBegin, periodEnd, err := parseFindDate(value, loc)
+		if err != nil {
+			return false, errors.New("Некорректное условие " + token + ".")
+		}
+		switch op {
+		case ">=":
+			filter.PeriodFrom = periodBegin
+		case ">":
+			filter.PeriodFrom = periodEnd
+		case "<=":
+			filter.PeriodTo = periodEnd
+		case "<":
+			filter.PeriodTo = periodBegin
+		}
+		return true, nil
+	}
+
+	// Отдельная дата (день или месяц).
+	if periodBegin, periodEnd, err := parseFindDate(token, loc); err == nil {
+		filter.PeriodFrom, filter.PeriodTo = periodBegin, periodEnd
+		return true, nil
+	}
+	return false, nil
+}
+
+// Парсинг суммы условия поиска (в копейках).
+func parseFindSum(value string) (int64, error) {
+	sum, err := money.ParseAmount(value)
+	if err != nil || sum <= 0 {
+		return 0, errors.New("Некорректная сумма.")
+	}
+	return sum, nil
+}
+
+// Парсинг даты условия поиска: день (YYYY-MM-DD) или месяц (YYYY-MM).
+// Возвращает начало и конец (не включительно) периода.
+func parseFindDate(value string, loc *time.Location) (time.Time, time.Time, error) {
+	if day, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	month, err := time.ParseInLocation("2006-01", value, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Некорректная дата.")
+	}
+	return month, month.AddDate(0, 1, 0), nil
+}
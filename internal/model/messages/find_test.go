@@ -0,0 +1,54 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseFindQuery_ShouldFillTextSumAndPeriod(t *testing.T) {
+	filter, err := parseFindQuery("корм коту >5000 2024-01..2024-03", time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		types.UserDataSearchFilter{
+			Text:       "корм коту",
+			SumFrom:    500001,
+			PeriodFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			PeriodTo:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		filter,
+	)
+}
+
+func Test_parseFindQuery_ShouldParseSumRangeAndDay(t *testing.T) {
+	filter, err := parseFindQuery("1000..2500.50 2024-02-29", time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		types.UserDataSearchFilter{
+			SumFrom:    100000,
+			SumTo:      250050,
+			PeriodFrom: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			PeriodTo:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		filter,
+	)
+}
+
+func Test_parseFindQuery_ShouldParseDateComparison(t *testing.T) {
+	filter, err := parseFindQuery(">2024-01-15 <=300", time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), filter.PeriodFrom)
+	assert.Equal(t, int64(30000), filter.SumTo)
+	assert.Equal(t, "", filter.Text)
+}
+
+func Test_parseFindQuery_ShouldReturnError_WhenRangeIsReversed(t *testing.T) {
+	_, err := parseFindQuery("5000..1000", time.UTC)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,294 @@
+package messages
+
+// Цели накоплений с отслеживанием прогресса.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// goalProgressBarWidth Количество делений шкалы прогресса цели.
+const goalProgressBarWidth = 10
+
+const (
+	txtGoalsTitle          = "Цели накоплений:"
+	txtGoalsEmpty          = "Целей накоплений пока нет. Для добавления нажмите кнопку \"Новая цель\"."
+	txtGoalAdd             = "Введите цель в формате: название сумма [валюта] срок. Например:\n<code>Отпуск 150000 к июлю</code>\n<code>Ноутбук 1500 USD 2025-03-01</code>\nВалюта по умолчанию: <b>%v</b>. Для отмены введите 0."
+	txtGoalSave            = "Цель <b>%v</b> сохранена."
+	txtGoalFormatError     = "Не удалось распознать цель. Введите название, сумму, валюту (необязательно) и срок, например: <code>Отпуск 150000 к июлю</code>."
+	txtGoalCurrencyError   = "Валюта <b>%v</b> не поддерживается."
+	txtGoalContribution    = "Введите сумму пополнения цели в валюте <b>%v</b>. Для отмены введите 0."
+	txtGoalContributionSet = "Цель пополнена.\n%v"
+	txtGoalDelete          = "Цель удалена."
+	txtGoalLine            = "<b>%v</b>: %v из %v\n<code>%v</code>\n%v"
+	txtGoalMonthly         = "До %v нужно откладывать %v в месяц."
+	txtGoalDone            = "Цель достигнута!"
+	txtGoalExpired         = "Срок достижения цели (%v) истек."
+)
+
+// Строка ввода цели: название, сумма, валюта (необязательно), срок.
+var goalRegexp = regexp.MustCompile(`^(.+?)\s+(\d+(?:[.,]\d{1,2})?)(?:\s+([A-Za-z]{3}))?\s+(?:к\s+)?(\S+)$`)
+
+// Названия месяцев в дательном падеже для ввода срока вида "к июлю".
+var goalMonths = map[string]time.Month{
+	"январю": time.January, "февралю": time.February, "марту": time.March,
+	"апрелю": time.April, "маю": time.May, "июню": time.June,
+	"июлю": time.July, "августу": time.August, "сентябрю": time.September,
+	"октябрю": time.October, "ноябрю": time.November, "декабрю": time.December,
+}
+
+// Форматы ввода даты срока цели.
+var goalDateLayouts = []string{"2006-01-02", "02.01.2006", "01.2006"}
+
+// Область "Константы и переменные": конец.
+
+// Отображение списка целей с прогрессом.
+func cmdGoals(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdGoals")
+	s.ctx = ctx
+	defer span.Finish()
+
+	goals, err := s.storage.GetUserGoals(s.ctx, msg.UserID)
+	if err != nil {
+		logger.Error("Ошибка получения целей", "err", err)
+		return true, errors.Wrap(err, "Get user goals error")
+	}
+
+	buttons := []types.TgRowButtons{{types.TgInlineButton{DisplayName: "Новая цель", Value: "/add_goal"}}}
+	if len(goals) == 0 {
+		return true, s.tgClient.ShowInlineButtons(txtGoalsEmpty, buttons, msg.UserID)
+	}
+
+	var res strings.Builder
+	res.WriteString(txtGoalsTitle + "\n\n")
+	now := time.Now().In(getUserLocation(s, msg.UserID))
+	for _, goal := range goals {
+		res.WriteString(formatGoal(goal, now) + "\n\n")
+		buttons = append(buttons, types.TgRowButtons{
+			types.TgInlineButton{DisplayName: "Пополнить: " + goal.Name, Value: fmt.Sprintf("/goal_add %v", goal.ID)},
+			types.TgInlineButton{DisplayName: "Удалить", Value: fmt.Sprintf("/goal_del %v", goal.ID)},
+		})
+	}
+	return true, s.tgClient.ShowInlineButtons(res.String(), buttons, msg.UserID)
+}
+
+// Отображение сообщения о вводе новой цели.
+func cmdAddGoal(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/add_goal"
+	return true, s.tgClient.SendMessage(renderf(txtGoalAdd, getUserCurrency(s, msg.UserID)), msg.UserID)
+}
+
+// Проверка ввода новой цели и сохранение, если введено.
+func checkIfEnterNewGoal(s *Model, msg Message, state UserState) (bool, error) {
+	if msg.IsCallback || strings.HasPrefix(msg.Text, "/") {
+		// Это не ввод цели (нажатие кнопки или другая команда обрабатываются отдельно).
+		return false, nil
+	}
+	if msg.Text == "0" {
+		// Ввод цели отменен.
+		return true, nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterNewGoal")
+	s.ctx = ctx
+	defer span.Finish()
+
+	goal, err := parseGoal(msg.Text, getUserCurrency(s, msg.UserID), time.Now().In(getUserLocation(s, msg.UserID)))
+	if err != nil {
+		// Повторный запрос ввода.
+		s.lastUserCommand[msg.UserID] = "/add_goal"
+		return true, s.tgClient.SendMessage(txtGoalFormatError, msg.UserID)
+	}
+	if !isCurrencySupported(s, goal.Currency) {
+		return true, s.tgClient.SendMessage(renderf(txtGoalCurrencyError, goal.Currency), msg.UserID)
+	}
+	if err := s.storage.InsertGoal(s.ctx, msg.UserID, goal, msg.UserName); err != nil {
+		logger.Error("Ошибка сохранения цели", "err", err)
+		return true, errors.Wrap(err, "Insert goal error")
+	}
+	return true, s.tgClient.SendMessage(renderf(txtGoalSave, goal.Name), msg.UserID)
+}
+
+// Нажатие кнопки пополнения цели: запрос суммы пополнения.
+func checkIfChoiceGoalContribution(s *Model, msg Message, state UserState) (bool, error) {
+	goalID, err := strconv.ParseInt(strings.TrimPrefix(msg.Text, "/goal_add "), 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	s.lastUserGoal[msg.UserID] = goalID
+	s.lastUserCommand[msg.UserID] = "/goal_add"
+	return true, s.tgClient.SendMessage(renderf(txtGoalContribution, getUserCurrency(s, msg.UserID)), msg.UserID)
+}
+
+// Проверка ввода суммы пополнения цели и сохранение, если введено.
+func checkIfEnterGoalContribution(s *Model, msg Message, state UserState) (bool, error) {
+	goalID, ok := s.lastUserGoal[msg.UserID]
+	delete(s.lastUserGoal, msg.UserID)
+	if !ok || msg.Text == "" {
+		return false, nil
+	}
+	if msg.IsCallback || strings.HasPrefix(msg.Text, "/") {
+		// Это не ввод суммы пополнения (нажатие кнопки или другая команда обрабатываются отдельно).
+		return false, nil
+	}
+	if msg.Text == "0" {
+		// Пополнение отменено.
+		return true, nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterGoalContribution")
+	s.ctx = ctx
+	defer span.Finish()
+
+	goals, err := s.storage.GetUserGoals(s.ctx, msg.UserID)
+	if err != nil {
+		logger.Error("Ошибка получения целей", "err", err)
+		return true, errors.Wrap(err, "Get user goals error")
+	}
+	var goal *types.Goal
+	for ind := range goals {
+		if goals[ind].ID == goalID {
+			goal = &goals[ind]
+		}
+	}
+	if goal == nil {
+		return true, s.tgClient.SendMessage(txtGoalDelete, msg.UserID)
+	}
+
+	// Конвертация суммы из валюты пользователя в базовую, а затем в валюту цели.
+	sumBase, err := parseAndConvertSumFromCurrency(s, msg.UserID, msg.Text)
+	if err != nil {
+		return true, err
+	}
+	sumGoal, err := s.currencies.ConvertSumFromBaseToCurrency(goal.Currency, sumBase)
+	if err != nil {
+		logger.Error("Ошибка конвертации валюты", "err", err)
+		return true, errors.Wrap(err, "Ошибка конвертации валюты.")
+	}
+
+	updated, err := s.storage.AddGoalContribution(s.ctx, msg.UserID, goalID, sumGoal)
+	if err != nil {
+		logger.Error("Ошибка пополнения цели", "err", err)
+		return true, errors.Wrap(err, "Add goal contribution error")
+	}
+	return true, s.tgClient.SendMessage(renderf(txtGoalContributionSet, HTML(formatGoal(updated, time.Now().In(getUserLocation(s, msg.UserID))))), msg.UserID)
+}
+
+// Нажатие кнопки удаления цели.
+func checkIfChoiceGoalDelete(s *Model, msg Message, state UserState) (bool, error) {
+	goalID, err := strconv.ParseInt(strings.TrimPrefix(msg.Text, "/goal_del "), 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	if err := s.storage.DeleteGoal(s.ctx, msg.UserID, goalID); err != nil {
+		logger.Error("Ошибка удаления цели", "err", err)
+		return true, errors.Wrap(err, "Delete goal error")
+	}
+	return true, s.tgClient.SendMessage(txtGoalDelete, msg.UserID)
+}
+
+// Форматирование цели: накоплено, шкала прогресса и необходимый ежемесячный взнос.
+func formatGoal(goal types.Goal, now time.Time) string {
+	progress := float64(goal.Saved) / float64(goal.Target)
+	if progress > 1 {
+		progress = 1
+	}
+	filled := int(progress * goalProgressBarWidth)
+	bar := fmt.Sprintf("[%v%v] %v%%",
+		strings.Repeat("▓", filled), strings.Repeat("░", goalProgressBarWidth-filled), int(progress*100))
+
+	deadline := goal.Deadline.Format("02.01.2006")
+	status := ""
+	switch {
+	case goal.Saved >= goal.Target:
+		status = txtGoalDone
+	case !goal.Deadline.After(now):
+		status = renderf(txtGoalExpired, deadline)
+	default:
+		monthly := money.DivCeil(goal.Target-goal.Saved, int64(monthsLeft(now, goal.Deadline)))
+		status = renderf(txtGoalMonthly, deadline, money.New(monthly, goal.Currency))
+	}
+	return renderf(txtGoalLine, goal.Name, money.FormatAmount(goal.Saved), money.New(goal.Target, goal.Currency), bar, HTML(status))
+}
+
+// Количество месяцев (не менее одного), оставшихся до срока.
+func monthsLeft(now time.Time, deadline time.Time) int {
+	months := (deadline.Year()-now.Year())*12 + int(deadline.Month()) - int(now.Month())
+	if deadline.Day() > now.Day() {
+		months++
+	}
+	if months < 1 {
+		months = 1
+	}
+	return months
+}
+
+// Парсинг строки ввода цели, например, "Отпуск 150000 к июлю" или "Ноутбук 1500 USD 2025-03-01".
+func parseGoal(text string, defaultCurrency string, now time.Time) (types.Goal, error) {
+	matches := goalRegexp.FindStringSubmatch(strings.TrimSpace(text))
+	if len(matches) < 5 {
+		return types.Goal{}, errors.New("Неверный формат цели.")
+	}
+
+	// Парсинг суммы.
+	amount, err := money.ParseAmount(matches[2])
+	if err != nil || amount <= 0 {
+		return types.Goal{}, errors.New("Некорректная сумма.")
+	}
+
+	currency := strings.ToUpper(matches[3])
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	deadline, err := parseGoalDeadline(strings.ToLower(matches[4]), now)
+	if err != nil {
+		return types.Goal{}, err
+	}
+
+	return types.Goal{
+		Name:     strings.TrimSpace(matches[1]),
+		Currency: currency,
+		Target:   amount,
+		Deadline: deadline,
+	}, nil
+}
+
+// Парсинг срока цели: название месяца ("июлю") или дата.
+func parseGoalDeadline(text string, now time.Time) (time.Time, error) {
+	if month, ok := goalMonths[text]; ok {
+		// Ближайшее начало указанного месяца.
+		year := now.Year()
+		if month <= now.Month() {
+			year++
+		}
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	for _, layout := range goalDateLayouts {
+		if deadline, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return deadline, nil
+		}
+	}
+	return time.Time{}, errors.New("Некорректный срок цели.")
+}
+
+// Проверка, что валюта есть в списке используемых.
+func isCurrencySupported(s *Model, currency string) bool {
+	for _, name := range s.currencies.GetCurrenciesList() {
+		if name == currency {
+			return true
+		}
+	}
+	return false
+}
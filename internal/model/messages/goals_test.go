@@ -0,0 +1,79 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseGoal_ShouldFillFields_WhenMonthName(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	goal, err := parseGoal("Отпуск 150000 к июлю", "RUB", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		types.Goal{
+			Name:     "Отпуск",
+			Currency: "RUB",
+			Target:   15000000,
+			Deadline: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		goal,
+	)
+}
+
+func Test_parseGoal_ShouldUseNextYear_WhenMonthPassed(t *testing.T) {
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	goal, err := parseGoal("Отпуск на море 150000 июлю", "RUB", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Отпуск на море", goal.Name)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), goal.Deadline)
+}
+
+func Test_parseGoal_ShouldFillFields_WhenCurrencyAndDate(t *testing.T) {
+	now := time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)
+	goal, err := parseGoal("Ноутбук 1500.50 usd 2025-03-01", "RUB", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		types.Goal{
+			Name:     "Ноутбук",
+			Currency: "USD",
+			Target:   150050,
+			Deadline: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		goal,
+	)
+}
+
+func Test_parseGoal_ShouldReturnError_WhenNoDeadline(t *testing.T) {
+	_, err := parseGoal("Отпуск 150000", "RUB", time.Now())
+
+	assert.Error(t, err)
+}
+
+func Test_formatGoal_ShouldShowProgressAndMonthlyContribution(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	goal := types.Goal{
+		Name:     "Отпуск",
+		Currency: "RUB",
+		Target:   15000000,
+		Saved:    6000000,
+		Deadline: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t,
+		"<b>Отпуск</b>: 60000.00 из 150000.00 RUB\n<code>[▓▓▓▓░░░░░░] 40%</code>\nДо 01.07.2024 нужно откладывать 22500.00 RUB в месяц.",
+		formatGoal(goal, now),
+	)
+}
+
+func Test_formatGoal_ShouldShowDone_WhenTargetReached(t *testing.T) {
+	goal := types.Goal{Name: "Ноутбук", Currency: "USD", Target: 100, Saved: 150, Deadline: time.Now()}
+
+	assert.Contains(t, formatGoal(goal, time.Now()), "[▓▓▓▓▓▓▓▓▓▓] 100%")
+	assert.Contains(t, formatGoal(goal, time.Now()), txtGoalDone)
+}
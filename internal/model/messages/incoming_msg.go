@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/dbutils"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
 	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
 	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 )
@@ -18,26 +20,29 @@ import (
 // Область "Константы и переменные": начало.
 
 const (
-	txtStart            = "Привет, *%v*. Я помогаю вести учет расходов. Выберите действие."
+	txtStart            = "Привет, <b>%v</b>. Я помогаю вести учет расходов. Выберите действие."
 	txtUnknownCommand   = "К сожалению, данная команда мне неизвестна. Для начала работы введите /start"
 	txtReportError      = "Не удалось получить данные."
 	txtReportEmpty      = "За указанный период данные отсутствуют."
 	txtReportWait       = "Формирование отчета. Пожалуйста, подождите..."
 	txtCatAdd           = "Введите название категории (не более 30 символов). Для отмены введите 0."
 	txtCatView          = "Выберите категорию, а затем введите сумму."
-	txtCatChoice        = "Выбрана категория *%v*. Введите сумму (только число). Для отмены введите 0. Используемая валюта: *%v*"
+	txtCatChoice        = "Выбрана категория <b>%v</b>. Введите сумму, при необходимости дату и комментарий (например, <code>1500</code>, <code>1500 вчера прививка</code> или <code>1500 15.03</code>). Для отмены введите 0. Используемая валюта: <b>%v</b>"
 	txtCatSave          = "Категория успешно сохранена."
 	txtCatEmpty         = "Пока нет категорий, сначала добавьте хотя бы одну категорию."
 	txtRecSave          = "Запись успешно сохранена."
 	txtRecOverLimit     = "Запись не сохранена: превышен бюджет раходов в текущем месяце."
-	txtRecTbl           = "Для загрузки истории расходов введите таблицу в следующем формате (дата сумма категория):\n`YYYY-MM-DD 0.00 XXX`\nНапример: \n`2022-09-20 1500 Кино`\n`2022-07-12 350.50 Продукты, еда`\n`2022-08-30 8000 Одежда и обувь`\n`2022-09-01 60 Бензин`\n`2022-09-27 425 Такси`\n`2022-09-26 1500 Бензин`\n`2022-09-26 950 Кошка`\n`2022-09-25 50 Бензин`\nИспользуемая валюта: *%v*"
+	txtRecTbl           = "Для загрузки истории расходов введите таблицу в следующем формате (дата сумма категория):\n<code>YYYY-MM-DD 0.00 XXX</code>\nНапример: \n<code>2022-09-20 1500 Кино</code>\n<code>2022-07-12 350.50 Продукты, еда</code>\n<code>2022-08-30 8000 Одежда и обувь</code>\n<code>2022-09-01 60 Бензин</code>\n<code>2022-09-27 425 Такси</code>\n<code>2022-09-26 1500 Бензин</code>\n<code>2022-09-26 950 Кошка</code>\n<code>2022-09-25 50 Бензин</code>\nИспользуемая валюта: <b>%v</b>\nРежим сохранения: <b>%v</b> (выбирается кнопками ниже)."
+	txtRecTblMode       = "Режим сохранения таблицы: <b>%v</b>. Введите таблицу."
+	txtRecTblSave       = "Сохранено записей: %v из %v."
+	txtRecTblRejected   = "Таблица не сохранена: в строках есть ошибки. Исправьте их и введите таблицу заново или выберите режим сохранения строк без ошибок (/add_tbl)."
 	txtReportQP         = "За какой период будем смотреть отчет? Команды периодов: /report_w - неделя, /report_m - месяц, /report_y - год"
 	txtHelp             = "Я - бот, помогающий вести учет расходов. Для начала работы введите /start"
-	txtCurrencyChoice   = "В качестве основной задана валюта: *%v*. Для изменения выберите другую валюту."
-	txtCurrencySet      = "Валюта изменена на *%v*."
+	txtCurrencyChoice   = "В качестве основной задана валюта: <b>%v</b>. Для изменения выберите другую валюту."
+	txtCurrencySet      = "Валюта изменена на <b>%v</b>."
 	txtCurrencySetError = "Ошибка сохранения валюты."
-	txtLimitInfo        = "Текущий ежемесячный бюджет: *%v*. Для изменения введите число, например, 80000."
-	txtLimitSet         = "Бюджет изменен на *%v*."
+	txtLimitInfo        = "Текущий ежемесячный бюджет: <b>%v</b>. Для изменения введите число, например, 80000."
+	txtLimitSet         = "Бюджет изменен на <b>%v</b>."
 )
 
 // Команды стартовых действий.
@@ -45,7 +50,20 @@ var btnStart = []types.TgRowButtons{
 	{types.TgInlineButton{DisplayName: "Добавить категорию", Value: "/add_cat"}, types.TgInlineButton{DisplayName: "Добавить расход", Value: "/add_rec"}},
 	{types.TgInlineButton{DisplayName: "Отчет за неделю", Value: "/report_w"}, types.TgInlineButton{DisplayName: "Отчет за месяц", Value: "/report_m"}, types.TgInlineButton{DisplayName: "Отчет за год", Value: "/report_y"}},
 	{types.TgInlineButton{DisplayName: "Ввести данные за прошлый период", Value: "/add_tbl"}},
-	{types.TgInlineButton{DisplayName: "Выбрать валюту", Value: "/choice_currency"}, types.TgInlineButton{DisplayName: "Установить бюджет", Value: "/set_limit"}},
+	{types.TgInlineButton{DisplayName: "Выбрать валюту", Value: "/choice_currency"}, types.TgInlineButton{DisplayName: "Установить бюджет", Value: "/set_limit"}, types.TgInlineButton{DisplayName: "Часовой пояс", Value: "/timezone"}},
+	{types.TgInlineButton{DisplayName: "Цели накоплений", Value: "/goals"}, types.TgInlineButton{DisplayName: "Регулярные отчеты", Value: "/subscriptions"}},
+}
+
+// Кнопки выбора режима сохранения таблицы.
+var btnTableModes = []types.TgRowButtons{
+	{types.TgInlineButton{DisplayName: "Все строки или ничего", Value: "/add_tbl_mode " + types.ImportAllOrNothing}},
+	{types.TgInlineButton{DisplayName: "Только строки без ошибок", Value: "/add_tbl_mode " + types.ImportBestEffort}},
+}
+
+// Названия режимов сохранения таблицы.
+var tableModeNames = map[string]string{
+	types.ImportAllOrNothing: "все строки или ничего",
+	types.ImportBestEffort:   "только строки без ошибок",
 }
 
 var lineRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}) (\d+.?\d{0,2}) (.+)$`)
@@ -58,11 +76,14 @@ var lineRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}) (\d+.?\d{0,2}) (.+)$`)
 type MessageSender interface {
 	SendMessage(text string, userID int64) error
 	ShowInlineButtons(text string, buttons []types.TgRowButtons, userID int64) error
+	AnswerInlineQuery(queryID string, results []types.TgInlineResult) error
+	SendDocument(fileName string, data []byte, caption string, userID int64) error
 }
 
 // UserDataStorage Интерфейс для работы с хранилищем данных.
 type UserDataStorage interface {
 	InsertUserDataRecord(ctx context.Context, userID int64, rec types.UserDataRecord, userName string, limitPeriod time.Time) (bool, error)
+	InsertUserDataRecords(ctx context.Context, userID int64, recs []types.UserDataRecord, userName string, mode string) (types.UserDataImportResult, error)
 	GetUserDataRecord(ctx context.Context, userID int64, period time.Time) ([]types.UserDataReportRecord, error)
 	InsertCategory(ctx context.Context, userID int64, catName string, userName string) error
 	GetUserCategory(ctx context.Context, userID int64) ([]string, error)
@@ -70,21 +91,47 @@ type UserDataStorage interface {
 	SetUserCurrency(ctx context.Context, userID int64, currencyName string, userName string) error
 	GetUserLimit(ctx context.Context, userID int64) (int64, error)
 	SetUserLimit(ctx context.Context, userID int64, limits int64, userName string) error
+	GetUserTimezone(ctx context.Context, userID int64) (string, error)
+	SetUserTimezone(ctx context.Context, userID int64, timezone string, userName string) error
+	UndoLastUserAction(ctx context.Context, userID int64, since time.Time) (types.UserAction, error)
+	CheckIfReceiptExist(ctx context.Context, userID int64, receipt types.Receipt) (bool, error)
+	InsertGoal(ctx context.Context, userID int64, goal types.Goal, userName string) error
+	GetUserGoals(ctx context.Context, userID int64) ([]types.Goal, error)
+	AddGoalContribution(ctx context.Context, userID int64, goalID int64, sum int64) (types.Goal, error)
+	DeleteGoal(ctx context.Context, userID int64, goalID int64) error
+	GetUserSubscriptions(ctx context.Context, userID int64) ([]string, error)
+	SetUserSubscription(ctx context.Context, userID int64, kind string, enabled bool, userName string) error
+	SearchUserDataRecords(ctx context.Context, userID int64, filter types.UserDataSearchFilter, limit int, offset int) ([]types.UserDataRecord, error)
+	GetAdminStats(ctx context.Context, days int) (types.AdminStats, error)
+	SetUserLastSeen(ctx context.Context, userID int64, seenAt time.Time) (bool, error)
+	GetUserInfo(ctx context.Context, userID int64) (types.UserInfo, error)
+	InsertAdminAudit(ctx context.Context, adminID int64, command string, args string) error
+	GetUserAccess(ctx context.Context, userID int64) (types.UserAccess, error)
+	InsertInviteCode(ctx context.Context, code string, adminID int64) error
+	RedeemInviteCode(ctx context.Context, code string, userID int64, userName string) (bool, error)
+	SetUserBlocked(ctx context.Context, userID int64, blocked bool, adminID int64) error
+	GetUserExport(ctx context.Context, userID int64) (types.UserExport, error)
+	DeleteUser(ctx context.Context, userID int64) (int64, bool, error)
+	GetUserTrash(ctx context.Context, userID int64, since time.Time) ([]types.TrashItem, error)
+	RestoreTrashItem(ctx context.Context, userID int64, kind string, id int64, since time.Time) (bool, error)
+	GetUserAudit(ctx context.Context, userID int64, limit int) ([]types.AuditRecord, error)
 }
 
 // ExchangeRates Интерфейс для работы с курсами валют.
 type ExchangeRates interface {
 	ConvertSumFromBaseToCurrency(currencyName string, sum int64) (int64, error)
 	ConvertSumFromCurrencyToBase(currencyName string, sum int64) (int64, error)
-	GetExchangeRate(currencyName string) (float64, error)
+	GetExchangeRate(currencyName string) (money.Rate, error)
 	GetMainCurrency() string
 	GetCurrenciesList() []string
+	UpdateExchangeRates() error
 }
 
 // LRUCache Интерфейс для работы с кэшем отчетов.
 type LRUCache interface {
 	Add(key string, value any)
 	Get(key string) any
+	Remove(key string)
 }
 
 // kafkaProducer Интерфейс для отправки сообщений в кафку.
@@ -95,27 +142,46 @@ type kafkaProducer interface {
 
 // Model Модель бота (клиент, хранилище, последние команды пользователя)
 type Model struct {
-	ctx             context.Context
-	tgClient        MessageSender    // Клиент.
-	storage         UserDataStorage  // Хранилище пользовательской информации.
-	currencies      ExchangeRates    // Хранилише курсов валют.
-	reportCache     LRUCache         // Хранилише кэша.
-	kafkaProducer   kafkaProducer    // Кафка
-	lastUserCat     map[int64]string // Последняя выбранная пользователем категория.
-	lastUserCommand map[int64]string // Последняя выбранная пользователем команда.
+	ctx               context.Context
+	tgClient          MessageSender                        // Клиент.
+	storage           UserDataStorage                      // Хранилище пользовательской информации.
+	currencies        ExchangeRates                        // Хранилише курсов валют.
+	reportCache       LRUCache                             // Хранилише кэша.
+	kafkaProducer     kafkaProducer                        // Кафка
+	lastUserCat       map[int64]string                     // Последняя выбранная пользователем категория.
+	lastUserCommand   map[int64]string                     // Последняя выбранная пользователем команда.
+	lastUserReceipt   map[int64]types.Receipt              // Последний распознанный чек пользователя.
+	lastUserGoal      map[int64]int64                      // Последняя выбранная для пополнения цель.
+	lastUserFind      map[int64]types.UserDataSearchFilter // Последние условия поиска записей.
+	lastUserDate      map[int64]time.Time                  // Последняя выбранная в календаре дата расхода.
+	lastUserTableMode map[int64]string                     // Выбранный режим сохранения таблицы (types.ImportAllOrNothing, types.ImportBestEffort).
+	lastUserSeen      map[int64]time.Time                  // Последнее сохраненное время обращения пользователя к боту.
+	admins            map[int64]bool                       // Телеграм-идентификаторы администраторов бота.
+	access            *accessSettings                      // Настройки доступа к боту (nil - проверка не выполняется).
+	router            *Router                              // Реестр команд бота.
 }
 
 // New Генерация сущности для хранения клиента ТГ и хранилища пользователей и курсов валют.
 func New(ctx context.Context, tgClient MessageSender, storage UserDataStorage, currencies ExchangeRates, reportCache LRUCache, kafka kafkaProducer) *Model {
+	router := NewRouter()
+	registerCommands(router)
 	return &Model{
-		ctx:             ctx,
-		tgClient:        tgClient,
-		storage:         storage,
-		lastUserCat:     map[int64]string{},
-		lastUserCommand: map[int64]string{},
-		currencies:      currencies,
-		reportCache:     reportCache,
-		kafkaProducer:   kafka,
+		ctx:               ctx,
+		tgClient:          tgClient,
+		storage:           storage,
+		lastUserCat:       map[int64]string{},
+		lastUserCommand:   map[int64]string{},
+		lastUserReceipt:   map[int64]types.Receipt{},
+		lastUserGoal:      map[int64]int64{},
+		lastUserFind:      map[int64]types.UserDataSearchFilter{},
+		lastUserDate:      map[int64]time.Time{},
+		lastUserTableMode: map[int64]string{},
+		lastUserSeen:      map[int64]time.Time{},
+		admins:            map[int64]bool{},
+		currencies:        currencies,
+		reportCache:       reportCache,
+		kafkaProducer:     kafka,
+		router:            router,
 	}
 }
 
@@ -127,6 +193,7 @@ type Message struct {
 	UserDisplayName string
 	IsCallback      bool
 	CallbackMsgID   string
+	IsPhoto         bool // Сообщение - фотография (в Text - строка распознанного QR-кода).
 }
 
 func (s *Model) GetCtx() context.Context {
@@ -137,51 +204,50 @@ func (s *Model) SetCtx(ctx context.Context) {
 	s.ctx = ctx
 }
 
+// SetAdmins Установка списка администраторов бота.
+func (s *Model) SetAdmins(userIDs []int64) {
+	s.admins = make(map[int64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		s.admins[userID] = true
+	}
+}
+
+// IsAdmin Проверка, является ли пользователь администратором бота.
+func (s *Model) IsAdmin(userID int64) bool {
+	return s.admins[userID]
+}
+
 // IncomingMessage Обработка входящего сообщения.
 func (s *Model) IncomingMessage(msg Message) error {
 	span, ctx := opentracing.StartSpanFromContext(s.ctx, "IncomingMessage")
-	s.ctx = ctx
+	// Изменения данных при обработке сообщения записываются в журнал изменений от имени пользователя.
+	s.ctx = dbutils.ContextWithActor(ctx, msg.UserID)
 	defer span.Finish()
 
-	lastUserCat := s.lastUserCat[msg.UserID]
-	lastUserCommand := s.lastUserCommand[msg.UserID]
-
-	// Обнуление выбранной категории и команды.
-	s.lastUserCat[msg.UserID] = ""
-	s.lastUserCommand[msg.UserID] = ""
-
-	// Проверка ввода суммы расхода по выбранной категории и сохранение, если введено.
-	if isNeedReturn, err := checkIfEnterCategorySum(s, msg, lastUserCat); err != nil || isNeedReturn {
-		return err
-	}
-
-	// Проверка ввода новой категории и сохранение, если введено.
-	if isNeedReturn, err := checkIfEnterNewCategory(s, msg, lastUserCommand); err != nil || isNeedReturn {
-		return err
+	state := UserState{
+		Command:  s.lastUserCommand[msg.UserID],
+		Category: s.lastUserCat[msg.UserID],
+		Date:     s.lastUserDate[msg.UserID],
 	}
 
-	// Проверка ввода бюджета и сохранение, если введено.
-	if isNeedReturn, err := checkIfEnterNewLimit(s, msg, lastUserCommand); err != nil || isNeedReturn {
+	// Проверка доступа пользователя к боту.
+	denial, err := getAccessDenial(s, msg.UserID, msg.UserName, msg.Text)
+	if err != nil {
 		return err
 	}
-
-	// Проверка ввода данных в виде таблицы и сохранение, если введено.
-	if isNeedReturn, err := checkIfEnterTableData(s, msg, lastUserCommand); err != nil || isNeedReturn {
-		return err
+	if denial != "" {
+		return s.tgClient.SendMessage(denial, msg.UserID)
 	}
+	// Учет активности пользователя (после обработки, когда новый пользователь уже добавлен).
+	defer markUserSeen(s, msg.UserID)
 
-	// Проверка выбора категории для ввода расхода.
-	if isNeedReturn, err := checkIfCoiceCategory(s, msg); err != nil || isNeedReturn {
-		return err
-	}
-
-	// Проверка выбора валюты.
-	if isNeedReturn, err := checkIfCoiceCurrency(s, msg); err != nil || isNeedReturn {
-		return err
-	}
+	// Обнуление выбранной категории, даты и команды.
+	s.lastUserCat[msg.UserID] = ""
+	s.lastUserCommand[msg.UserID] = ""
+	delete(s.lastUserDate, msg.UserID)
 
-	// Распознавание стандартных команд.
-	if isNeedReturn, err := checkBotCommands(s, msg); err != nil || isNeedReturn {
+	// Поиск и вызов обработчика по реестру команд.
+	if isNeedReturn, err := s.router.Dispatch(s, msg, state); err != nil || isNeedReturn {
 		return err
 	}
 
@@ -189,6 +255,16 @@ func (s *Model) IncomingMessage(msg Message) error {
 	return s.tgClient.SendMessage(txtUnknownCommand, msg.UserID)
 }
 
+// CommandLabel Метка команды для метрик по тексту сообщения.
+func (s *Model) CommandLabel(text string) string {
+	return s.router.Label(text)
+}
+
+// BotCommands Список команд для меню бота.
+func (s *Model) BotCommands() []types.TgBotCommand {
+	return s.router.BotCommands()
+}
+
 // SendReportToUser Отправка отчета за период.
 func (s *Model) SendReportToUser(dt []types.UserDataReportRecord, userID int64, reportKey string) error {
 	span, ctx := opentracing.StartSpanFromContext(s.ctx, "SendReportToUser")
@@ -198,11 +274,13 @@ func (s *Model) SendReportToUser(dt []types.UserDataReportRecord, userID int64,
 	strReportTitle := "Отчет за "
 	switch reportKey {
 	case "w":
-		strReportTitle += "*последнюю неделю*"
+		strReportTitle += "<b>последнюю неделю</b>"
 	case "m":
-		strReportTitle += "*последний месяц*"
+		strReportTitle += "<b>последний месяц</b>"
 	case "y":
-		strReportTitle += "*последний год*"
+		strReportTitle += "<b>последний год</b>"
+	case types.ReportPreviousMonth:
+		strReportTitle += "<b>прошлый месяц</b>"
 	}
 
 	// Получение данных из БД.
@@ -211,11 +289,13 @@ func (s *Model) SendReportToUser(dt []types.UserDataReportRecord, userID int64,
 	if len(answerText) == 0 {
 		answerText = txtReportEmpty
 	} else {
-		answerText = fmt.Sprintln(strReportTitle+" ("+userCurrency+")") + answerText
+		answerText = fmt.Sprintln(strReportTitle+" ("+escape(userCurrency)+")") + answerText
+	}
+	// Сохранение значения в кэш (отчет за прошлый месяц командами не запрашивается и не кэшируется).
+	if reportKey != types.ReportPreviousMonth {
+		reportCacheKey := strconv.FormatInt(userID, 10) + reportKey
+		s.reportCache.Add(reportCacheKey, answerText)
 	}
-	// Сохранение значения в кэш.
-	reportCacheKey := strconv.Itoa(int(userID)) + reportKey
-	s.reportCache.Add(reportCacheKey, answerText)
 	err := s.tgClient.SendMessage(answerText, userID)
 	if err != nil {
 		logger.Error("Ошибка отправки сообщения в ТГ", "err", err)
@@ -228,23 +308,83 @@ func (s *Model) SendReportToUser(dt []types.UserDataReportRecord, userID int64,
 
 // Область "Служебные функции": начало.
 
+// Область "Реестр команд": начало.
+
+// registerCommands Регистрация стандартных команд бота.
+func registerCommands(r *Router) {
+	r.Register(Command{Name: "/start", Description: "Главное меню", Match: CommandWithArgs("/start"), Handler: cmdStart})
+	r.Register(Command{Name: "/help", Description: "Справка по командам", Handler: cmdHelp})
+	r.Register(Command{Name: "/add_cat", Description: "Добавить категорию", Handler: cmdAddCategory, StateHandler: checkIfEnterNewCategory})
+	r.Register(Command{Name: "/add_rec", Description: "Добавить расход", Handler: cmdAddRecord})
+	r.Register(Command{Name: "/cat", CallbackPrefix: "/cat ", CallbackHandler: checkIfCoiceCategory, StateHandler: checkIfEnterCategorySum})
+	r.Register(Command{Name: "/cal", CallbackPrefix: "/cal ", CallbackHandler: checkIfChoiceCalendarMonth})
+	r.Register(Command{Name: "/cal_day", CallbackPrefix: "/cal_day ", CallbackHandler: checkIfChoiceCalendarDay})
+	r.Register(Command{Name: "/add_tbl", Description: "Ввести данные за прошлый период", Handler: cmdAddTable, StateHandler: checkIfEnterTableData})
+	r.Register(Command{Name: "/add_tbl_mode", CallbackPrefix: "/add_tbl_mode ", CallbackHandler: checkIfChoiceTableMode})
+	r.Register(Command{Name: "/report", Description: "Выбор периода отчета", Handler: cmdReport})
+	r.Register(Command{Name: "/report_w", Description: "Отчет за неделю", Label: "report", Handler: cmdReportByPeriod})
+	r.Register(Command{Name: "/report_m", Description: "Отчет за месяц", Label: "report", Handler: cmdReportByPeriod})
+	r.Register(Command{Name: "/report_y", Description: "Отчет за год", Label: "report", Handler: cmdReportByPeriod})
+	r.Register(Command{Name: "/choice_currency", Description: "Выбрать валюту", Handler: cmdChoiceCurrency})
+	r.Register(Command{Name: "/curr", CallbackPrefix: "/curr ", CallbackHandler: checkIfCoiceCurrency})
+	r.Register(Command{Name: "/set_limit", Description: "Установить бюджет", Handler: cmdSetLimit, StateHandler: checkIfEnterNewLimit})
+	r.Register(Command{Name: "/timezone", Description: "Выбрать часовой пояс", Handler: cmdTimezone, StateHandler: checkIfChoiceTimezone})
+	r.Register(Command{Name: "/tz", CallbackPrefix: "/tz ", CallbackHandler: checkIfChoiceTimezone})
+	r.Register(Command{Name: "/undo", Description: "Отменить последнее действие", Handler: cmdUndo})
+	r.Register(Command{Name: "/trash", Description: "Корзина удаленных записей", Handler: cmdTrash})
+	r.Register(Command{Name: "/trash_restore", CallbackPrefix: "/trash_restore ", CallbackHandler: checkIfChoiceTrashRestore})
+	r.Register(Command{Name: "/goals", Description: "Цели накоплений", Handler: cmdGoals})
+	r.Register(Command{Name: "/add_goal", Description: "Добавить цель накоплений", Handler: cmdAddGoal, StateHandler: checkIfEnterNewGoal})
+	r.Register(Command{Name: "/goal_add", CallbackPrefix: "/goal_add ", CallbackHandler: checkIfChoiceGoalContribution, StateHandler: checkIfEnterGoalContribution})
+	r.Register(Command{Name: "/goal_del", CallbackPrefix: "/goal_del ", CallbackHandler: checkIfChoiceGoalDelete})
+	r.Register(Command{Name: "/subscriptions", Description: "Регулярные отчеты", Handler: cmdSubscriptions})
+	r.Register(Command{Name: "/sub", CallbackPrefix: "/sub ", CallbackHandler: checkIfChoiceSubscription})
+	r.Register(Command{Name: "/find", Description: "Поиск записей о расходах", Match: isFindMessage, Handler: cmdFind, StateHandler: checkIfEnterFindQuery})
+	r.Register(Command{Name: "/find_page", CallbackPrefix: "/find_page ", CallbackHandler: checkIfChoiceFindPage})
+	r.Register(Command{Name: "/export_me", Description: "Выгрузить все мои данные", Handler: cmdExportMe})
+	r.Register(Command{Name: "/delete_me", Description: "Удалить мой аккаунт и все данные", CallbackPrefix: "/delete_me ", Handler: cmdDeleteMe, CallbackHandler: checkIfConfirmDeleteMe})
+	r.Register(Command{Name: "/admin_stats", Description: "Статистика использования бота", AdminOnly: true, Handler: cmdAdminStats})
+	r.Register(Command{Name: "/admin_user", Description: "Сведения о пользователе", AdminOnly: true, Match: CommandWithArgs("/admin_user"), Handler: cmdAdminUser})
+	r.Register(Command{Name: "/admin_audit", Description: "Журнал изменений данных пользователя", AdminOnly: true, Match: CommandWithArgs("/admin_audit"), Handler: cmdAdminAudit})
+	r.Register(Command{Name: "/admin_reload_rates", Description: "Обновить курсы валют", AdminOnly: true, Handler: cmdAdminReloadRates})
+	r.Register(Command{Name: "/admin_invite", Description: "Создать код приглашения", AdminOnly: true, Handler: cmdAdminInvite})
+	r.Register(Command{Name: "/admin_block", Description: "Заблокировать пользователя", AdminOnly: true, Match: CommandWithArgs("/admin_block"), Handler: cmdAdminBlock})
+	r.Register(Command{Name: "/admin_unblock", Description: "Разблокировать пользователя", AdminOnly: true, Match: CommandWithArgs("/admin_unblock"), Handler: cmdAdminUnblock})
+	r.Register(Command{Name: "/receipt", Match: isReceiptMessage, Handler: cmdReceipt, StateHandler: checkIfChoiceReceiptCategory})
+}
+
+// Область "Реестр команд": конец.
+
 // Область "Распознавание входящих команд": начало.
 
 // Проверка ввода суммы расхода по выбранной категории.
-func checkIfEnterCategorySum(s *Model, msg Message, lastUserCat string) (bool, error) {
+func checkIfEnterCategorySum(s *Model, msg Message, state UserState) (bool, error) {
 	// Если выбрана категория и введена сумма, то сохранение записи о расходах.
-	if lastUserCat != "" && msg.Text != "" {
+	if state.Category != "" && msg.Text != "" && !msg.IsCallback {
 		span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterCategorySum")
 		s.ctx = ctx
 		defer span.Finish()
 
-		// Парсинг и конвертация введенной суммы.
-		catSum, err := parseAndConvertSumFromCurrency(s, msg.UserID, msg.Text)
+		// Разбор введенной суммы, даты и комментария (например, "1500 вчера прививка").
+		now := time.Now().In(getUserLocation(s, msg.UserID))
+		sumString, period, note, err := parseRecordInput(msg.Text, now)
+		if err != nil {
+			return true, s.tgClient.SendMessage(escape(err.Error()), msg.UserID)
+		}
+		if period.IsZero() {
+			// Дата, выбранная в календаре, или текущий момент.
+			period = now
+			if !state.Date.IsZero() {
+				period = state.Date
+			}
+		}
+		// Конвертация введенной суммы.
+		catSum, err := parseAndConvertSumFromCurrency(s, msg.UserID, sumString)
 		if err != nil {
 			return true, err
 		}
 		// Сохранение записи.
-		newRec := types.UserDataRecord{UserID: msg.UserID, Category: lastUserCat, Sum: catSum, Period: time.Now()}
+		newRec := types.UserDataRecord{UserID: msg.UserID, Category: state.Category, Sum: catSum, Period: period, Note: note}
 		isOverLimit, err := s.storage.InsertUserDataRecord(s.ctx, msg.UserID, newRec, msg.UserName, timeutils.BeginOfMonth(newRec.Period))
 		if err != nil {
 			if isOverLimit {
@@ -255,16 +395,16 @@ func checkIfEnterCategorySum(s *Model, msg Message, lastUserCat string) (bool, e
 				return true, errors.Wrap(err, "Insert data record error")
 			}
 		}
-		// Ответ пользователю об успешном сохранении.
-		return true, s.tgClient.SendMessage(txtRecSave, msg.UserID)
+		// Ответ пользователю об успешном сохранении (с возможностью отмены).
+		return true, s.tgClient.ShowInlineButtons(txtRecSave, btnUndo, msg.UserID)
 	}
 	// Это не ввод расхода.
 	return false, nil
 }
 
 // Проверка ввода новой категории и сохранение, если введено.
-func checkIfEnterNewCategory(s *Model, msg Message, lastUserCommand string) (bool, error) {
-	if lastUserCommand == "/add_cat" {
+func checkIfEnterNewCategory(s *Model, msg Message, state UserState) (bool, error) {
+	if state.Command == "/add_cat" {
 		span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterNewCategory")
 		s.ctx = ctx
 		defer span.Finish()
@@ -280,8 +420,8 @@ func checkIfEnterNewCategory(s *Model, msg Message, lastUserCommand string) (boo
 				logger.Error("Ошибка сохранения категории", "err", err)
 				return true, errors.Wrap(err, "Insert category error")
 			}
-			// Ответ пользователю об успешном сохранении.
-			return true, s.tgClient.SendMessage(txtCatSave, msg.UserID)
+			// Ответ пользователю об успешном сохранении (с возможностью отмены).
+			return true, s.tgClient.ShowInlineButtons(txtCatSave, btnUndo, msg.UserID)
 		}
 	}
 	// Это не ввод новой категории.
@@ -289,9 +429,9 @@ func checkIfEnterNewCategory(s *Model, msg Message, lastUserCommand string) (boo
 }
 
 // Проверка ввода бюджета и сохранение, если введено.
-func checkIfEnterNewLimit(s *Model, msg Message, lastUserCommand string) (bool, error) {
+func checkIfEnterNewLimit(s *Model, msg Message, state UserState) (bool, error) {
 	// Если выбрано добавление бюджета и введена сумма, то сохранение.
-	if lastUserCommand == "/set_limit" && msg.Text != "" {
+	if state.Command == "/set_limit" && msg.Text != "" {
 		span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterNewLimit")
 		s.ctx = ctx
 		defer span.Finish()
@@ -308,8 +448,8 @@ func checkIfEnterNewLimit(s *Model, msg Message, lastUserCommand string) (bool,
 				logger.Error("Ошибка сохранения бюджета", "err", err)
 				return true, errors.Wrap(err, "Ошибка сохранения бюджета.")
 			}
-			// Ответ пользователю об успешном сохранении.
-			return true, s.tgClient.SendMessage(fmt.Sprintf(txtLimitSet, msg.Text), msg.UserID)
+			// Ответ пользователю об успешном сохранении (с возможностью отмены).
+			return true, s.tgClient.ShowInlineButtons(renderf(txtLimitSet, msg.Text), btnUndo, msg.UserID)
 		}
 	}
 	// Это не ввод бюджета.
@@ -317,82 +457,135 @@ func checkIfEnterNewLimit(s *Model, msg Message, lastUserCommand string) (bool,
 }
 
 // Проверка ввода данных в виде таблицы и сохранение, если введено.
-func checkIfEnterTableData(s *Model, msg Message, lastUserCommand string) (bool, error) {
-	if lastUserCommand == "/add_tbl" {
-		span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterTableData")
-		s.ctx = ctx
-		defer span.Finish()
+// Все строки проверяются до сохранения, записи сохраняются одним пакетом в выбранном пользователем режиме.
+func checkIfEnterTableData(s *Model, msg Message, state UserState) (bool, error) {
+	if state.Command != "/add_tbl" || msg.IsCallback || strings.HasPrefix(msg.Text, "/") {
+		// Это не ввод таблицы (нажатие кнопки или другая команда обрабатываются отдельно).
+		return false, nil
+	}
+	if msg.Text == "0" {
+		// Ввод отменен.
+		return true, nil
+	}
 
-		// Выбрано добавление таблиных данных.
-		answerText := ""
-		if msg.Text == "0" {
-			// Ввод отменен.
-			return true, nil
-		} else {
-			// Парсинг данных.
-			lines := strings.Split(msg.Text, "\n")
-
-			for ind, line := range lines {
-				isError := false
-				txtError := ""
-				rec, err := parseLineRec(line)
-				if err != nil {
-					isError = true
-					txtError = "Ошибка распознавания формата строки."
-				} else {
-					// Сохранение данных.
-					if err := s.storage.InsertCategory(s.ctx, msg.UserID, rec.Category, msg.UserName); err != nil {
-						isError = true
-						txtError = "Ошибка добавления категории."
-					} else {
-						rec.UserID = msg.UserID
-						// Конвертация из валюты пользователя в базовую.
-						if sum, err := convertSumFromCurrency(s, msg.UserID, rec.Sum); err != nil {
-							isError = true
-							txtError = "Ошибка конвертации валюты."
-						} else {
-							rec.Sum = sum
-							// Сохранение записи.
-							if isOverLimit, err := s.storage.InsertUserDataRecord(s.ctx, msg.UserID, rec, msg.UserName, timeutils.BeginOfMonth(rec.Period)); err != nil {
-								isError = true
-								if isOverLimit {
-									txtError = "Превышение бюджета."
-								} else {
-									txtError = "Ошибка сохранения записи."
-								}
-							}
-						}
-					}
-				}
-				if isError {
-					answerText += fmt.Sprintf("%v. Ошибка. %v \n", ind+1, txtError)
-				} else {
-					answerText += fmt.Sprintf("%v. ОК\n", ind+1)
-				}
-			}
-			// Ответ пользователю об сохранении.
-			answerText = txtRecSave + "\n" + answerText
-			return true, s.tgClient.SendMessage(answerText, msg.UserID)
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfEnterTableData")
+	s.ctx = ctx
+	defer span.Finish()
+
+	// Парсинг данных (даты записей - в часовом поясе пользователя, суммы - в валюте пользователя).
+	mode := getTableMode(s, msg.UserID)
+	loc := getUserLocation(s, msg.UserID)
+	userCurrency := getUserCurrency(s, msg.UserID)
+	lines := make([]tableLine, 0)
+	var recs []types.UserDataRecord
+	var recLines []int // Номера строк таблицы для записей пакета.
+	for ind, text := range strings.Split(msg.Text, "\n") {
+		if strings.TrimSpace(text) == "" {
+			// Пустые строки пропускаются (но учитываются в номерах строк).
+			continue
+		}
+		rec, err := parseLineRec(text, loc)
+		if err != nil {
+			lines = append(lines, tableLine{num: ind + 1, err: "Ошибка распознавания формата строки."})
+			continue
+		}
+		// Конвертация из валюты пользователя в базовую.
+		sum, err := s.currencies.ConvertSumFromCurrencyToBase(userCurrency, rec.Sum)
+		if err != nil {
+			logger.Error("Ошибка конвертации валюты", "err", err)
+			lines = append(lines, tableLine{num: ind + 1, err: "Ошибка конвертации валюты."})
+			continue
+		}
+		rec.UserID = msg.UserID
+		rec.Sum = sum
+		recLines = append(recLines, len(lines))
+		recs = append(recs, rec)
+		lines = append(lines, tableLine{num: ind + 1})
+	}
+
+	// В режиме "все или ничего" таблица с ошибками не сохраняется.
+	isRejected := mode == types.ImportAllOrNothing && len(recs) < len(lines)
+	var result types.UserDataImportResult
+	if !isRejected && len(recs) > 0 {
+		var err error
+		result, err = s.storage.InsertUserDataRecords(s.ctx, msg.UserID, recs, msg.UserName, mode)
+		if err != nil {
+			logger.Error("Ошибка сохранения таблицы", "err", err)
+			return true, errors.Wrap(err, "Insert user data records error")
 		}
+		for _, ind := range result.OverLimit {
+			lines[recLines[ind]].err = "Превышение бюджета."
+		}
+		isRejected = mode == types.ImportAllOrNothing && len(result.OverLimit) > 0
 	}
-	// Это не ввод таблицы.
-	return false, nil
+
+	// Ответ пользователю о сохранении с результатом по каждой строке.
+	return true, s.tgClient.SendMessage(formatTableResult(lines, result.Inserted, isRejected), msg.UserID)
+}
+
+// tableLine Результат обработки строки таблицы.
+type tableLine struct {
+	num int    // Номер строки в тексте таблицы (с учетом пустых строк).
+	err string // Текст ошибки (пустой, если строка без ошибок).
+}
+
+// Нажатие кнопки выбора режима сохранения таблицы (ввод таблицы продолжается).
+func checkIfChoiceTableMode(s *Model, msg Message, state UserState) (bool, error) {
+	mode := strings.TrimPrefix(msg.Text, "/add_tbl_mode ")
+	if _, ok := tableModeNames[mode]; !ok {
+		return false, nil
+	}
+	s.lastUserTableMode[msg.UserID] = mode
+	s.lastUserCommand[msg.UserID] = "/add_tbl"
+	return true, s.tgClient.SendMessage(renderf(txtRecTblMode, tableModeNames[mode]), msg.UserID)
+}
+
+// Выбранный пользователем режим сохранения таблицы (по умолчанию - все строки или ничего).
+func getTableMode(s *Model, userID int64) string {
+	if mode, ok := s.lastUserTableMode[userID]; ok {
+		return mode
+	}
+	return types.ImportAllOrNothing
+}
+
+// Формирование ответа о сохранении таблицы: итог и результат по каждой строке.
+func formatTableResult(lines []tableLine, inserted int64, isRejected bool) string {
+	var res strings.Builder
+	if isRejected {
+		res.WriteString(txtRecTblRejected)
+	} else {
+		res.WriteString(renderf(txtRecTblSave, inserted, len(lines)))
+	}
+	for _, line := range lines {
+		switch {
+		case line.err != "":
+			res.WriteString(renderf("\n%v. Ошибка. %v", line.num, line.err))
+		case isRejected:
+			res.WriteString(renderf("\n%v. Без ошибок, не сохранено.", line.num))
+		default:
+			res.WriteString(renderf("\n%v. ОК", line.num))
+		}
+	}
+	return res.String()
 }
 
 // Проверка выбора категории для ввода расхода.
-func checkIfCoiceCategory(s *Model, msg Message) (bool, error) {
+func checkIfCoiceCategory(s *Model, msg Message, state UserState) (bool, error) {
 	// Распознавание нажатых кнопок выбора категорий.
 	if msg.IsCallback {
-		if strings.Contains(msg.Text, "/cat ") {
+		if strings.HasPrefix(msg.Text, "/cat ") {
 			span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfCoiceCategory")
 			s.ctx = ctx
 			defer span.Finish()
 
 			// Пользователь выбрал категорию.
 			cat := strings.Replace(msg.Text, "/cat ", "", -1)
-			answerText := fmt.Sprintf(txtCatChoice, cat, getUserCurrency(s, msg.UserID))
+			answerText := renderf(txtCatChoice, cat, getUserCurrency(s, msg.UserID))
 			s.lastUserCat[msg.UserID] = cat
-			return true, s.tgClient.SendMessage(answerText, msg.UserID)
+			s.lastUserCommand[msg.UserID] = "/cat"
+			// Кнопка выбора другой даты расхода.
+			calendar := getCalendarOpenButtons(time.Now().In(getUserLocation(s, msg.UserID)))
+			return true, s.tgClient.ShowInlineButtons(answerText, calendar, msg.UserID)
 		}
 	}
 	// Это не выбор категории.
@@ -400,22 +593,22 @@ func checkIfCoiceCategory(s *Model, msg Message) (bool, error) {
 }
 
 // Проверка выбора валюты.
-func checkIfCoiceCurrency(s *Model, msg Message) (bool, error) {
+func checkIfCoiceCurrency(s *Model, msg Message, state UserState) (bool, error) {
 	// Распознавание нажатых кнопок выбора валюты.
 	if msg.IsCallback {
-		if strings.Contains(msg.Text, "/curr ") {
+		if strings.HasPrefix(msg.Text, "/curr ") {
 			span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfCoiceCurrency")
 			s.ctx = ctx
 			defer span.Finish()
 
 			// Пользователь выбрал валюту.
 			choice := strings.Replace(msg.Text, "/curr ", "", -1)
-			answerText := fmt.Sprintf(txtCurrencySet, choice)
+			answerText := renderf(txtCurrencySet, choice)
 			// Сохранение выбранной валюты.
 			if err := s.storage.SetUserCurrency(s.ctx, msg.UserID, choice, msg.UserName); err != nil {
 				return true, s.tgClient.SendMessage(txtCurrencySetError, msg.UserID)
 			} else {
-				return true, s.tgClient.SendMessage(answerText, msg.UserID)
+				return true, s.tgClient.ShowInlineButtons(answerText, btnUndo, msg.UserID)
 			}
 		}
 	}
@@ -423,63 +616,73 @@ func checkIfCoiceCurrency(s *Model, msg Message) (bool, error) {
 	return false, nil
 }
 
-// Распознавание стандартных команд бота.
-func checkBotCommands(s *Model, msg Message) (bool, error) {
-	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkBotCommands")
-	s.ctx = ctx
-	defer span.Finish()
+// Отображение команд стартовых действий.
+func cmdStart(s *Model, msg Message, state UserState) (bool, error) {
+	displayName := msg.UserDisplayName
+	if len(displayName) == 0 {
+		displayName = msg.UserName
+	}
+	return true, s.tgClient.ShowInlineButtons(renderf(txtStart, displayName), btnStart, msg.UserID)
+}
 
-	switch msg.Text {
-	case "/start":
-		displayName := msg.UserDisplayName
-		if len(displayName) == 0 {
-			displayName = msg.UserName
-		}
-		// Отображение команд стартовых действий.
-		return true, s.tgClient.ShowInlineButtons(fmt.Sprintf(txtStart, displayName), btnStart, msg.UserID)
-	case "/report":
-		return true, s.tgClient.SendMessage(txtReportQP, msg.UserID)
-	case "/help":
-		return true, s.tgClient.SendMessage(txtHelp, msg.UserID)
-	case "/add_tbl":
-		s.lastUserCommand[msg.UserID] = "/add_tbl"
-		userCurrency := getUserCurrency(s, msg.UserID)
-		return true, s.tgClient.SendMessage(fmt.Sprintf(txtRecTbl, userCurrency), msg.UserID)
-	case "/report_w", "/report_m", "/report_y":
-		// Отображение отчета.
-		return true, s.tgClient.SendMessage(getReportByPeriod(s, msg), msg.UserID)
-	case "/add_cat":
-		// Отображение сообщения о вводе категории.
-		s.lastUserCommand[msg.UserID] = "/add_cat"
-		return true, s.tgClient.SendMessage(txtCatAdd, msg.UserID)
-	case "/add_rec":
-		s.lastUserCommand[msg.UserID] = "/add_rec"
-		// Отображение кнопок с существующими категориями для выбора.
-		if btnCat, err := getCategoryButtons(s, msg.UserID); err != nil || btnCat == nil {
-			return true, err
-		} else {
-			return true, s.tgClient.ShowInlineButtons(txtCatView, btnCat, msg.UserID)
-		}
-	case "/choice_currency":
-		// Отображение кнопок выбора валюты.
-		userCurrency := getUserCurrency(s, msg.UserID)
-		if btnCurr, err := getCurrencyButtons(s, userCurrency); err != nil {
-			return true, err
-		} else {
-			return true, s.tgClient.ShowInlineButtons(fmt.Sprintf(txtCurrencyChoice, userCurrency), btnCurr, msg.UserID)
-		}
-	case "/set_limit":
-		// Отображение сообщения о вводе бюджета.
-		s.lastUserCommand[msg.UserID] = "/set_limit"
-		answerText := fmt.Sprintf(txtLimitInfo, "без ограничений")
-		userLimit, _ := getUserLimit(s, msg.UserID)
-		if userLimit > 0 {
-			answerText = fmt.Sprintf(txtLimitInfo, userLimit/100)
-		}
-		return true, s.tgClient.SendMessage(answerText, msg.UserID)
+// Отображение справки, сформированной по реестру команд.
+func cmdHelp(s *Model, msg Message, state UserState) (bool, error) {
+	commandsHelp := escape(s.router.HelpText(s.IsAdmin(msg.UserID)))
+	return true, s.tgClient.SendMessage(txtHelp+"\n\n"+commandsHelp, msg.UserID)
+}
+
+// Отображение подсказки о командах отчетов.
+func cmdReport(s *Model, msg Message, state UserState) (bool, error) {
+	return true, s.tgClient.SendMessage(txtReportQP, msg.UserID)
+}
+
+// Отображение отчета за период.
+func cmdReportByPeriod(s *Model, msg Message, state UserState) (bool, error) {
+	return true, s.tgClient.SendMessage(getReportByPeriod(s, msg), msg.UserID)
+}
+
+// Отображение сообщения о вводе таблицы с историей расходов.
+func cmdAddTable(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/add_tbl"
+	userCurrency := getUserCurrency(s, msg.UserID)
+	return true, s.tgClient.ShowInlineButtons(renderf(txtRecTbl, userCurrency, tableModeNames[getTableMode(s, msg.UserID)]), btnTableModes, msg.UserID)
+}
+
+// Отображение сообщения о вводе категории.
+func cmdAddCategory(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/add_cat"
+	return true, s.tgClient.SendMessage(txtCatAdd, msg.UserID)
+}
+
+// Отображение кнопок с существующими категориями для выбора.
+func cmdAddRecord(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/add_rec"
+	if btnCat, err := getCategoryButtons(s, msg.UserID); err != nil || btnCat == nil {
+		return true, err
+	} else {
+		return true, s.tgClient.ShowInlineButtons(txtCatView, btnCat, msg.UserID)
 	}
-	// Команда не распознана.
-	return false, nil
+}
+
+// Отображение кнопок выбора валюты.
+func cmdChoiceCurrency(s *Model, msg Message, state UserState) (bool, error) {
+	userCurrency := getUserCurrency(s, msg.UserID)
+	if btnCurr, err := getCurrencyButtons(s, userCurrency); err != nil {
+		return true, err
+	} else {
+		return true, s.tgClient.ShowInlineButtons(renderf(txtCurrencyChoice, userCurrency), btnCurr, msg.UserID)
+	}
+}
+
+// Отображение сообщения о вводе бюджета.
+func cmdSetLimit(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/set_limit"
+	answerText := renderf(txtLimitInfo, "без ограничений")
+	userLimit, _ := getUserLimit(s, msg.UserID)
+	if userLimit > 0 {
+		answerText = renderf(txtLimitInfo, money.FormatAmount(userLimit))
+	}
+	return true, s.tgClient.SendMessage(answerText, msg.UserID)
 }
 
 // Область "Распознавание входящих команд": конец.
@@ -496,7 +699,7 @@ func getReportByPeriod(s *Model, msg Message) string {
 	reportKey := strings.Replace(msg.Text, "/report_", "", -1)
 
 	// Ключ для поиска в кэше.
-	reportCacheKey := strconv.Itoa(int(msg.UserID)) + reportKey
+	reportCacheKey := strconv.FormatInt(msg.UserID, 10) + reportKey
 	// Попытка получить значение из кэша.
 	cacheValue := s.reportCache.Get(reportCacheKey)
 	if cacheValue != nil {
@@ -509,7 +712,7 @@ func getReportByPeriod(s *Model, msg Message) string {
 	}
 
 	// Отправка запроса на формирование отчета в кафку.
-	p, o, err := s.kafkaProducer.SendMessage(strconv.Itoa(int(msg.UserID)), reportKey)
+	p, o, err := s.kafkaProducer.SendMessage(strconv.FormatInt(msg.UserID, 10), reportKey)
 	if err != nil {
 		logger.Error("Ошибка отправки сообщения в кафку", "err", err)
 		answerText = txtReportError
@@ -523,32 +726,39 @@ func getReportByPeriod(s *Model, msg Message) string {
 
 // Форматирование массива данных в строку для вывода отчета.
 func formatReport(s *Model, recs []types.UserDataReportRecord, userCurrency string) string {
-	var res strings.Builder
-	totalSum := 0.0
 	for ind, rec := range recs {
 		// Конвертация сумм в валюту пользователя.
-		sumCurrency, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, int64(rec.Sum))
+		sumCurrency, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, rec.Sum)
 		if err != nil {
 			logger.Error("Ошибка конвертации валюты", "err", err)
 			return "Ошибка конвертации валюты"
 		}
-		recs[ind].Sum = float64(sumCurrency) / 100
-		totalSum += float64(sumCurrency) / 100
+		recs[ind].Sum = sumCurrency
 	}
-	maxSumStr := fmt.Sprintf("%.2f", totalSum)
+	return formatReportTable(recs)
+}
 
-	res.WriteString(fmt.Sprintf("`%*s | %v`", len(maxSumStr)+1, "Сумма", "Категория") + "\n")
-	res.WriteString(fmt.Sprintf("`%v`", strings.Repeat("-", len(maxSumStr)+15)) + "\n")
+// Форматирование таблицы отчета (суммы в минорных единицах валюты пользователя).
+func formatReportTable(recs []types.UserDataReportRecord) string {
+	var totalSum int64
+	for _, rec := range recs {
+		totalSum += rec.Sum
+	}
+	maxSumStr := money.FormatAmount(totalSum)
 
+	lines := []string{
+		fmt.Sprintf("%*s | %v", len(maxSumStr)+1, "Сумма", "Категория"),
+		strings.Repeat("-", len(maxSumStr)+15),
+	}
 	for _, rec := range recs {
 		// Форматирование категории и числа до нужной ширины.
-		res.WriteString(fmt.Sprintf("`%*.2f | %v`", len(maxSumStr)+1, rec.Sum, rec.Category) + "\n")
+		lines = append(lines, fmt.Sprintf("%*s | %v", len(maxSumStr)+1, money.FormatAmount(rec.Sum), rec.Category))
 	}
 	if len(recs) > 0 {
-		res.WriteString(fmt.Sprintf("`%v`", strings.Repeat("-", len(maxSumStr)+15)) + "\n")
-		res.WriteString(fmt.Sprintf("`%*.2f | %v`", len(maxSumStr)+1, totalSum, "ИТОГО") + "\n")
+		lines = append(lines, strings.Repeat("-", len(maxSumStr)+15))
+		lines = append(lines, fmt.Sprintf("%*s | %v", len(maxSumStr)+1, maxSumStr, "ИТОГО"))
 	}
-	return res.String()
+	return string(pre(lines)) + "\n"
 }
 
 // Область "Формирование отчета": конец.
@@ -602,6 +812,16 @@ func getUserCurrency(s *Model, userID int64) string {
 	return userCurrency
 }
 
+// Получение часового пояса пользователя.
+// Если часовой пояс не удалось получить, используется часовой пояс сервера.
+func getUserLocation(s *Model, userID int64) *time.Location {
+	timezone, err := s.storage.GetUserTimezone(s.ctx, userID)
+	if err != nil {
+		logger.Error("Ошибка получения часового пояса", "err", err)
+	}
+	return timeutils.LoadLocation(timezone, time.Local)
+}
+
 // Получение бюджета пользователя.
 func getUserLimit(s *Model, userID int64) (int64, error) {
 	userLimit, err := s.storage.GetUserLimit(s.ctx, userID)
@@ -616,8 +836,8 @@ func getUserLimit(s *Model, userID int64) (int64, error) {
 
 // Область "Другие функции": начало.
 
-// Парсинг строки данных.
-func parseLineRec(line string) (types.UserDataRecord, error) {
+// Парсинг строки данных (дата - в часовом поясе пользователя).
+func parseLineRec(line string, loc *time.Location) (types.UserDataRecord, error) {
 	matches := lineRegexp.FindStringSubmatch(line)
 	if len(matches) < 4 {
 		return types.UserDataRecord{}, errors.New("Неверный формат строки.")
@@ -627,21 +847,21 @@ func parseLineRec(line string) (types.UserDataRecord, error) {
 	amountStr := matches[2]
 	category := matches[3]
 
-	// Парсинг числа.
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	// Парсинг суммы (без потери копеек).
+	amount, err := money.ParseAmount(amountStr)
 	if err != nil {
 		return types.UserDataRecord{}, errors.Wrap(err, "Некорректная сумма.")
 	}
 
 	// Парсинг даты.
-	period, err := time.Parse("2006-01-02", periodStr)
+	period, err := time.ParseInLocation("2006-01-02", periodStr, loc)
 	if err != nil {
 		return types.UserDataRecord{}, errors.Wrap(err, "Некорректная дата.")
 	}
 
 	return types.UserDataRecord{
 		Category: category,
-		Sum:      int64(amount * 100),
+		Sum:      amount,
 		Period:   period,
 	}, nil
 }
@@ -659,13 +879,11 @@ func convertSumFromCurrency(s *Model, userID int64, sum int64) (int64, error) {
 
 // Парсинг вводимого пользователем числа и конвертация суммы в базовую валюту.
 func parseAndConvertSumFromCurrency(s *Model, userID int64, sumString string) (int64, error) {
-	// Парсинг числа.
-	sum, err := strconv.ParseFloat(sumString, 64)
+	// Парсинг числа в разменные денежные единицы (1/100, для рублей - копейки, для долларов - центы и т.п.)
+	nominalAmount, err := money.ParseAmount(sumString)
 	if err != nil {
 		return 0, errors.Wrap(err, "Error parse sum")
 	}
-	// Сумма в разменных денежных единицах (1/100, для рублей - копейки, для долларов - центы и т.п.)
-	nominalAmount := int64(sum * 100)
 	// Конвертация из валюты пользователя в базовую валюту.
 	if nominalAmount, err = convertSumFromCurrency(s, userID, nominalAmount); err != nil {
 		return 0, errors.Wrap(err, "Ошибка конвертации валюты.")
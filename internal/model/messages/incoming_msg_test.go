@@ -2,7 +2,6 @@ package messages
 
 import (
 	"context"
-	"fmt"
 	"testing"
 	"time"
 
@@ -12,14 +11,21 @@ import (
 	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
 )
 
+// expectUserSeen Ожидание сохранения времени обращения пользователя к боту (для статистики активности).
+func expectUserSeen(storage *mocks.MockUserDataStorage) {
+	storage.EXPECT().SetUserLastSeen(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+}
+
 func Test_OnStartCommand_ShouldAnswerWithIntroMessage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
 	// Ожидаем ответ в виде сообщения c именем пользователя и кнопок меню.
-	sender.EXPECT().ShowInlineButtons(fmt.Sprintf(txtStart, "Test"), btnStart, int64(123))
+	sender.EXPECT().ShowInlineButtons(renderf(txtStart, "Test"), btnStart, int64(123))
 
 	// Запускаем тест модели - команда старт
-	model := New(context.Background(), sender, nil, nil, nil, nil)
+	model := New(context.Background(), sender, storage, nil, nil, nil)
 	err := model.IncomingMessage(Message{
 		Text:            "/start",
 		UserID:          123,
@@ -33,10 +39,12 @@ func Test_OnStartCommand_ShouldAnswerWithIntroMessage(t *testing.T) {
 func Test_OnUnknownCommand_ShouldAnswerWithHelpMessage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
 	// Ожидаем ответ, что такая команда неизвестна.
 	sender.EXPECT().SendMessage(txtUnknownCommand, int64(123))
 
-	model := New(context.Background(), sender, nil, nil, nil, nil)
+	model := New(context.Background(), sender, storage, nil, nil, nil)
 	err := model.IncomingMessage(Message{
 		Text:   "some test text",
 		UserID: 123,
@@ -47,7 +55,7 @@ func Test_OnUnknownCommand_ShouldAnswerWithHelpMessage(t *testing.T) {
 
 func Test_parseLineRec_ShouldFillFields(t *testing.T) {
 	line := "2022-09-20 1500 Кино"
-	userDataRec, err := parseLineRec(line)
+	userDataRec, err := parseLineRec(line, time.UTC)
 
 	assert.NoError(t, err)
 	assert.Equal(t,
@@ -62,7 +70,7 @@ func Test_parseLineRec_ShouldFillFields(t *testing.T) {
 
 func Test_parseLineRec_ShouldFillFields_WhenSumIsFloat(t *testing.T) {
 	line := "2022-07-12 350.50 Продукты, еда"
-	userDataRec, err := parseLineRec(line)
+	userDataRec, err := parseLineRec(line, time.UTC)
 
 	assert.NoError(t, err)
 	assert.Equal(t,
@@ -75,16 +83,64 @@ func Test_parseLineRec_ShouldFillFields_WhenSumIsFloat(t *testing.T) {
 	)
 }
 
+func Test_parseLineRec_ShouldKeepKopecks(t *testing.T) {
+	// При разборе через float64 сумма 0.29 превращалась в 28 копеек.
+	userDataRec, err := parseLineRec("2022-07-12 0.29 Кофе", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(29), userDataRec.Sum)
+
+	_, err = parseLineRec("2022-07-12 0.299 Кофе", time.UTC)
+	assert.Error(t, err)
+}
+
 func Test_parseLineRec_ShouldReturnError_WhenNoSum(t *testing.T) {
 	line := "2022-04-10 Кошка"
-	_, err := parseLineRec(line)
+	_, err := parseLineRec(line, time.UTC)
 
 	assert.Error(t, err)
 }
 
 func Test_parseLineRec_ShouldReturnError_WhenBadDate(t *testing.T) {
 	line := "2022-22-05 150 Еда"
-	_, err := parseLineRec(line)
+	_, err := parseLineRec(line, time.UTC)
 
 	assert.Error(t, err)
 }
+
+func Test_OnUndoCommand_ShouldRevertLastRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	// Ожидаем отмену последнего действия и сообщение об отмене записи.
+	storage.EXPECT().UndoLastUserAction(gomock.Any(), int64(123), gomock.Any()).
+		Return(types.UserAction{Action: types.UserActionRecord, ObjectID: 15}, nil)
+	sender.EXPECT().SendMessage(txtUndoRecord, int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	err := model.IncomingMessage(Message{
+		Text:       "/undo",
+		UserID:     123,
+		IsCallback: true,
+	})
+
+	assert.NoError(t, err)
+}
+
+func Test_OnUndoCommand_ShouldAnswerWhenNothingToUndo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	// Действий для отмены нет.
+	storage.EXPECT().UndoLastUserAction(gomock.Any(), int64(123), gomock.Any()).Return(types.UserAction{}, nil)
+	sender.EXPECT().SendMessage(renderf(txtUndoEmpty, undoWindow.Minutes()), int64(123))
+
+	model := New(context.Background(), sender, storage, nil, nil, nil)
+	err := model.IncomingMessage(Message{
+		Text:   "/undo",
+		UserID: 123,
+	})
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,179 @@
+package messages
+
+// Инлайн-режим: быстрая статистика расходов по запросу "@bot месяц" из любого чата.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// inlineTopCount Количество категорий в результате "Топ категорий".
+const inlineTopCount = 3
+
+const (
+	txtInlineTotalTitle = "Расходы за %v"
+	txtInlineTotal      = "Расходы за <b>%v</b>: <b>%v</b>"
+	txtInlineLimit      = "\nБюджет: <b>%v</b> (израсходовано %.0f%%)"
+	txtInlineTopTitle   = "Топ категорий за %v"
+	txtInlineTop        = "Топ категорий за <b>%v</b>:"
+	txtInlineCardTitle  = "Отчет за %v"
+	txtInlineEmpty      = "За %v расходов нет."
+)
+
+// inlinePeriod Период статистики инлайн-режима.
+type inlinePeriod struct {
+	Key   string                    // Ключ отчета (как в командах /report_w, /report_m, /report_y).
+	Name  string                    // Название периода для вывода.
+	Begin func(time.Time) time.Time // Начало периода.
+}
+
+// Периоды статистики по ключевым словам запроса (по умолчанию - текущий месяц).
+var inlinePeriods = map[string]inlinePeriod{
+	"неделя": {Key: "w", Name: "неделю", Begin: func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+	"месяц":  {Key: "m", Name: "текущий месяц", Begin: timeutils.BeginOfMonth},
+	"год":    {Key: "y", Name: "год", Begin: func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+}
+
+// Область "Константы и переменные": конец.
+
+// InlineQuery Структура инлайн-запроса для обработки.
+type InlineQuery struct {
+	ID       string
+	Query    string
+	UserID   int64
+	UserName string
+}
+
+// IncomingInlineQuery Обработка инлайн-запроса: ответ статистикой расходов за период.
+func (s *Model) IncomingInlineQuery(query InlineQuery) error {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "IncomingInlineQuery")
+	s.ctx = ctx
+	defer span.Finish()
+
+	// Пользователям без доступа к боту статистика не отображается.
+	denial, err := getAccessDenial(s, query.UserID, query.UserName, "")
+	if err != nil {
+		return err
+	}
+	if denial != "" {
+		return s.tgClient.AnswerInlineQuery(query.ID, nil)
+	}
+	markUserSeen(s, query.UserID)
+
+	results, err := getInlineResults(s, query.UserID, parseInlinePeriod(query.Query), time.Now().In(getUserLocation(s, query.UserID)))
+	if err != nil {
+		logger.Error("Ошибка формирования ответа на инлайн-запрос", "err", err)
+		return err
+	}
+	return s.tgClient.AnswerInlineQuery(query.ID, results)
+}
+
+// Определение периода по тексту инлайн-запроса.
+func parseInlinePeriod(query string) inlinePeriod {
+	query = strings.ToLower(strings.TrimSpace(query))
+	for word, period := range inlinePeriods {
+		if query != "" && strings.HasPrefix(word, query) {
+			return period
+		}
+	}
+	return inlinePeriods["месяц"]
+}
+
+// Формирование результатов инлайн-запроса: итог с бюджетом, топ категорий и карточка отчета.
+func getInlineResults(s *Model, userID int64, period inlinePeriod, now time.Time) ([]types.TgInlineResult, error) {
+	recs, err := s.storage.GetUserDataRecord(s.ctx, userID, period.Begin(now))
+	if err != nil {
+		return nil, errors.Wrap(err, "Get user data record error")
+	}
+	userCurrency := getUserCurrency(s, userID)
+	// Конвертация сумм в валюту пользователя.
+	var totalSum int64
+	for ind, rec := range recs {
+		sumCurrency, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, rec.Sum)
+		if err != nil {
+			return nil, errors.Wrap(err, "Ошибка конвертации валюты.")
+		}
+		recs[ind].Sum = sumCurrency
+		totalSum += sumCurrency
+	}
+	if len(recs) == 0 {
+		text := renderf(txtInlineEmpty, period.Name)
+		return []types.TgInlineResult{
+			{ID: "total_" + period.Key, Title: fmt.Sprintf(txtInlineTotalTitle, period.Name), Description: text, Text: text},
+		}, nil
+	}
+
+	// Итог за период (для месяца - в сравнении с бюджетом).
+	totalText := renderf(txtInlineTotal, period.Name, money.New(totalSum, userCurrency))
+	if period.Key == "m" {
+		totalText += getInlineLimitText(s, userID, userCurrency, totalSum)
+	}
+
+	// Топ категорий по сумме расходов.
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Sum > recs[j].Sum })
+	var topText strings.Builder
+	topText.WriteString(renderf(txtInlineTop, period.Name))
+	for ind, rec := range recs {
+		if ind == inlineTopCount {
+			break
+		}
+		topText.WriteString(renderf("\n%v. %v - <b>%v</b> (%.0f%%)", ind+1, rec.Category, money.New(rec.Sum, userCurrency), percent(rec.Sum, totalSum)))
+	}
+
+	return []types.TgInlineResult{
+		{ID: "total_" + period.Key, Title: fmt.Sprintf(txtInlineTotalTitle, period.Name), Description: money.New(totalSum, userCurrency).String(), Text: totalText},
+		{ID: "top_" + period.Key, Title: fmt.Sprintf(txtInlineTopTitle, period.Name), Description: recs[0].Category, Text: topText.String()},
+		{ID: "card_" + period.Key, Title: fmt.Sprintf(txtInlineCardTitle, period.Name), Description: "Таблица расходов по категориям", Text: getInlineReportCard(s, userID, period, recs, userCurrency)},
+	}, nil
+}
+
+// Строка сравнения расходов с бюджетом пользователя (пустая, если бюджет не задан).
+func getInlineLimitText(s *Model, userID int64, userCurrency string, totalSum int64) string {
+	userLimit, err := getUserLimit(s, userID)
+	if err != nil || userLimit <= 0 {
+		return ""
+	}
+	limitCurrency, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, userLimit)
+	if err != nil {
+		logger.Error("Ошибка конвертации валюты", "err", err)
+		return ""
+	}
+	return renderf(txtInlineLimit, money.New(limitCurrency, userCurrency), percent(totalSum, limitCurrency))
+}
+
+// Доля суммы от общей суммы в процентах (для отображения).
+func percent(sum int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(sum) / float64(total) * 100
+}
+
+// Карточка отчета: отчет из кэша (сформированный командой /report_*) или по данным хранилища.
+func getInlineReportCard(s *Model, userID int64, period inlinePeriod, recs []types.UserDataReportRecord, userCurrency string) string {
+	// Месячный отчет в кэше формируется за последние 30 дней, а не за текущий месяц.
+	if period.Key != "m" {
+		reportCacheKey := strconv.FormatInt(userID, 10) + period.Key
+		if answerText, ok := s.reportCache.Get(reportCacheKey).(string); ok {
+			return answerText
+		}
+	}
+	// Суммы уже сконвертированы в валюту пользователя, сортировка - по категориям, как в отчете.
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Category < recs[j].Category })
+	var res strings.Builder
+	res.WriteString(renderf("Отчет за <b>%v</b> (%v)\n", period.Name, userCurrency))
+	res.WriteString(formatReportTable(recs))
+	return res.String()
+}
@@ -0,0 +1,45 @@
+package messages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mocks "github.com/ellavs/tg-bot-golang/internal/mocks/messages"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseInlinePeriod_ShouldMatchByPrefix(t *testing.T) {
+	assert.Equal(t, "w", parseInlinePeriod("Нед").Key)
+	assert.Equal(t, "y", parseInlinePeriod(" год ").Key)
+	assert.Equal(t, "m", parseInlinePeriod("месяц").Key)
+	assert.Equal(t, "m", parseInlinePeriod("").Key)
+	assert.Equal(t, "m", parseInlinePeriod("что-то").Key)
+}
+
+func Test_OnInlineQuery_ShouldAnswerWithTotalTopAndCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sender := mocks.NewMockMessageSender(ctrl)
+	storage := mocks.NewMockUserDataStorage(ctrl)
+	expectUserSeen(storage)
+	currencies := mocks.NewMockExchangeRates(ctrl)
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	storage.EXPECT().GetUserDataRecord(gomock.Any(), int64(123), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).Return(
+		[]types.UserDataReportRecord{{Category: "Кино", Sum: 10000}, {Category: "Такси", Sum: 30000}}, nil)
+	storage.EXPECT().GetUserCurrency(gomock.Any(), int64(123)).Return("RUB", nil)
+	storage.EXPECT().GetUserLimit(gomock.Any(), int64(123)).Return(int64(80000), nil)
+	currencies.EXPECT().ConvertSumFromBaseToCurrency("RUB", gomock.Any()).DoAndReturn(
+		func(currencyName string, sum int64) (int64, error) { return sum, nil }).AnyTimes()
+
+	model := New(context.Background(), sender, storage, currencies, nil, nil)
+	results, err := getInlineResults(model, 123, parseInlinePeriod("месяц"), now)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "Расходы за <b>текущий месяц</b>: <b>400.00 RUB</b>\nБюджет: <b>800.00 RUB</b> (израсходовано 50%)", results[0].Text)
+	assert.Equal(t, "Топ категорий за <b>текущий месяц</b>:\n1. Такси - <b>300.00 RUB</b> (75%)\n2. Кино - <b>100.00 RUB</b> (25%)", results[1].Text)
+	assert.Contains(t, results[2].Text, "Кино")
+}
@@ -0,0 +1,156 @@
+package messages
+
+// Добавление расхода по кассовому чеку (строка из QR-кода или фотография QR-кода).
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// receiptCurrency Валюта кассовых чеков.
+const receiptCurrency = "RUB"
+
+// receiptOperationIncome Признак расчета "приход" (покупка).
+const receiptOperationIncome = "1"
+
+const (
+	txtReceiptChoice = "Чек от <b>%v</b> на сумму <b>%v</b>. Выберите категорию расхода."
+	txtReceiptError  = "Не удалось распознать чек. Отправьте строку из QR-кода чека (например, <code>t=20240101T1230&amp;s=1234.00&amp;fn=...&amp;i=...&amp;fp=...&amp;n=1</code>) или фотографию QR-кода."
+	txtReceiptExist  = "Расход по этому чеку уже был добавлен ранее."
+)
+
+// Строка из QR-кода чека (распознается по параметру t с датой и временем покупки).
+var receiptRegexp = regexp.MustCompile(`^\S*(?:^|&)t=\d{8}T\d{4}\S*$`)
+
+// Форматы даты и времени покупки в QR-коде чека.
+var receiptTimeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// Область "Константы и переменные": конец.
+
+// Проверка, что сообщение содержит чек (строку из QR-кода или фотографию).
+func isReceiptMessage(msg Message) bool {
+	return msg.IsPhoto || receiptRegexp.MatchString(strings.TrimSpace(msg.Text))
+}
+
+// Распознавание чека и отображение кнопок выбора категории.
+func cmdReceipt(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdReceipt")
+	s.ctx = ctx
+	defer span.Finish()
+
+	receipt, err := parseReceipt(msg.Text, getUserLocation(s, msg.UserID))
+	if err != nil {
+		logger.Info("Чек не распознан", "err", err)
+		return true, s.tgClient.SendMessage(txtReceiptError, msg.UserID)
+	}
+	// Проверка, что чек не вводился ранее.
+	isExist, err := s.storage.CheckIfReceiptExist(s.ctx, msg.UserID, receipt)
+	if err != nil {
+		logger.Error("Ошибка проверки чека", "err", err)
+		return true, errors.Wrap(err, "Check receipt error")
+	}
+	if isExist {
+		return true, s.tgClient.SendMessage(txtReceiptExist, msg.UserID)
+	}
+	// Отображение кнопок с существующими категориями для выбора.
+	btnCat, err := getCategoryButtons(s, msg.UserID)
+	if err != nil || btnCat == nil {
+		return true, err
+	}
+	s.lastUserReceipt[msg.UserID] = receipt
+	s.lastUserCommand[msg.UserID] = "/receipt"
+	answerText := renderf(txtReceiptChoice, receipt.Period.Format("02.01.2006 15:04"), money.New(receipt.Sum, receiptCurrency))
+	return true, s.tgClient.ShowInlineButtons(answerText, btnCat, msg.UserID)
+}
+
+// Проверка выбора категории для расхода по чеку и сохранение записи.
+func checkIfChoiceReceiptCategory(s *Model, msg Message, state UserState) (bool, error) {
+	receipt, ok := s.lastUserReceipt[msg.UserID]
+	delete(s.lastUserReceipt, msg.UserID)
+	if !ok || !msg.IsCallback || !strings.HasPrefix(msg.Text, "/cat ") {
+		// Выбор категории для чека отменен.
+		return false, nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfChoiceReceiptCategory")
+	s.ctx = ctx
+	defer span.Finish()
+
+	// Конвертация суммы чека в базовую валюту.
+	sum, err := s.currencies.ConvertSumFromCurrencyToBase(receiptCurrency, receipt.Sum)
+	if err != nil {
+		logger.Error("Ошибка конвертации валюты", "err", err)
+		return true, errors.Wrap(err, "Ошибка конвертации валюты.")
+	}
+	// Сохранение записи вместе с реквизитами чека.
+	newRec := types.UserDataRecord{
+		UserID:   msg.UserID,
+		Category: strings.TrimPrefix(msg.Text, "/cat "),
+		Sum:      sum,
+		Period:   receipt.Period,
+		Receipt:  &receipt,
+	}
+	isOverLimit, err := s.storage.InsertUserDataRecord(s.ctx, msg.UserID, newRec, msg.UserName, timeutils.BeginOfMonth(newRec.Period))
+	if err != nil {
+		if isOverLimit {
+			return true, s.tgClient.SendMessage(txtRecOverLimit, msg.UserID)
+		}
+		logger.Error("Ошибка сохранения записи по чеку", "err", err)
+		return true, errors.Wrap(err, "Insert receipt record error")
+	}
+	// Ответ пользователю об успешном сохранении (с возможностью отмены).
+	return true, s.tgClient.ShowInlineButtons(txtRecSave, btnUndo, msg.UserID)
+}
+
+// Парсинг строки из QR-кода кассового чека (время покупки - в часовом поясе пользователя).
+// Например: t=20240101T1230&s=1234.00&fn=9289000100408074&i=12345&fp=3522207165&n=1
+func parseReceipt(text string, loc *time.Location) (types.Receipt, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(text))
+	if err != nil {
+		return types.Receipt{}, errors.Wrap(err, "Некорректная строка чека.")
+	}
+	for _, key := range []string{"t", "s", "fn", "i", "fp"} {
+		if values.Get(key) == "" {
+			return types.Receipt{}, errors.New("В строке чека отсутствует параметр " + key + ".")
+		}
+	}
+	if n := values.Get("n"); n != "" && n != receiptOperationIncome {
+		return types.Receipt{}, errors.New("Чек не является чеком покупки.")
+	}
+
+	// Парсинг даты и времени покупки.
+	var period time.Time
+	for _, layout := range receiptTimeLayouts {
+		if period, err = time.ParseInLocation(layout, values.Get("t"), loc); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return types.Receipt{}, errors.Wrap(err, "Некорректная дата чека.")
+	}
+
+	// Парсинг суммы (в рублях с копейками).
+	amount, err := money.ParseAmount(values.Get("s"))
+	if err != nil || amount <= 0 {
+		return types.Receipt{}, errors.New("Некорректная сумма чека.")
+	}
+
+	return types.Receipt{
+		Period: period,
+		Sum:    amount,
+		FN:     values.Get("fn"),
+		FD:     values.Get("i"),
+		FP:     values.Get("fp"),
+	}, nil
+}
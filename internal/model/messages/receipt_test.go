@@ -0,0 +1,52 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseReceipt_ShouldFillFields(t *testing.T) {
+	receipt, err := parseReceipt("t=20240101T1230&s=1234.00&fn=9289000100408074&i=12345&fp=3522207165&n=1", time.Local)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		types.Receipt{
+			Period: time.Date(2024, 1, 1, 12, 30, 0, 0, time.Local),
+			Sum:    123400,
+			FN:     "9289000100408074",
+			FD:     "12345",
+			FP:     "3522207165",
+		},
+		receipt,
+	)
+}
+
+func Test_parseReceipt_ShouldFillFields_WhenTimeWithSeconds(t *testing.T) {
+	receipt, err := parseReceipt("t=20221016T153045&s=350.55&fn=1&i=2&fp=3&n=1", time.Local)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 10, 16, 15, 30, 45, 0, time.Local), receipt.Period)
+	assert.Equal(t, int64(35055), receipt.Sum)
+}
+
+func Test_parseReceipt_ShouldReturnError_WhenNoFiscalSign(t *testing.T) {
+	_, err := parseReceipt("t=20240101T1230&s=1234.00&fn=9289000100408074&i=12345&n=1", time.Local)
+
+	assert.Error(t, err)
+}
+
+func Test_parseReceipt_ShouldReturnError_WhenRefund(t *testing.T) {
+	_, err := parseReceipt("t=20240101T1230&s=1234.00&fn=9289000100408074&i=12345&fp=3522207165&n=2", time.Local)
+
+	assert.Error(t, err)
+}
+
+func Test_isReceiptMessage_ShouldRecognizeReceiptString(t *testing.T) {
+	assert.True(t, isReceiptMessage(Message{Text: "t=20240101T1230&s=1234.00&fn=1&i=2&fp=3&n=1"}))
+	assert.True(t, isReceiptMessage(Message{Text: "s=1234.00&t=20240101T1230&fn=1&i=2&fp=3&n=1"}))
+	assert.True(t, isReceiptMessage(Message{IsPhoto: true}))
+	assert.False(t, isReceiptMessage(Message{Text: "2022-09-20 1500 Кино"}))
+}
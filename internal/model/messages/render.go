@@ -0,0 +1,57 @@
+package messages
+
+// Формирование текста сообщений с разметкой HTML и экранированием пользовательских данных.
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HTML Фрагмент текста с разметкой HTML, не требующий экранирования при подстановке в шаблон.
+type HTML string
+
+// renderf Подстановка значений в шаблон с разметкой HTML (аналог fmt.Sprintf).
+// Строки, ошибки и значения с методом String экранируются, фрагменты типа HTML подставляются как есть.
+func renderf(template string, args ...any) string {
+	escaped := make([]any, len(args))
+	for ind, arg := range args {
+		switch value := arg.(type) {
+		case HTML:
+			escaped[ind] = string(value)
+		case string:
+			escaped[ind] = html.EscapeString(value)
+		case error:
+			escaped[ind] = html.EscapeString(value.Error())
+		case fmt.Stringer:
+			escaped[ind] = html.EscapeString(value.String())
+		default:
+			escaped[ind] = arg
+		}
+	}
+	return fmt.Sprintf(template, escaped...)
+}
+
+// escape Экранирование текста для вставки в сообщение с разметкой HTML.
+func escape(text string) string {
+	return html.EscapeString(text)
+}
+
+// bold Выделение текста полужирным шрифтом.
+func bold(text string) HTML {
+	return HTML("<b>" + escape(text) + "</b>")
+}
+
+// code Выделение текста моноширинным шрифтом.
+func code(text string) HTML {
+	return HTML("<code>" + escape(text) + "</code>")
+}
+
+// pre Многострочный моноширинный блок (например, таблица отчета).
+func pre(lines []string) HTML {
+	escaped := make([]string, len(lines))
+	for ind, line := range lines {
+		escaped[ind] = escape(line)
+	}
+	return HTML("<pre>" + strings.Join(escaped, "\n") + "</pre>")
+}
@@ -0,0 +1,122 @@
+package messages
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Пользовательский ввод, ломающий разметку без экранирования.
+const hostileInput = "Кафе_бар *1* `x` <b>&amp; [ссылка](http://a.b)"
+
+// Разрешенные теги и сущности разметки HTML телеграма, которые используются в сообщениях бота.
+var (
+	allowedTagRegexp    = regexp.MustCompile(`</?(b|code|pre)>`)
+	allowedEntityRegexp = regexp.MustCompile(`&(amp|lt|gt|quot|#39);`)
+)
+
+// assertValidHTML Проверка, что текст содержит только разрешенные парные теги и корректно экранирован.
+func assertValidHTML(t *testing.T, text string) {
+	t.Helper()
+	for _, tag := range []string{"b", "code", "pre"} {
+		assert.Equal(t, strings.Count(text, "<"+tag+">"), strings.Count(text, "</"+tag+">"), text)
+	}
+	rest := allowedEntityRegexp.ReplaceAllString(allowedTagRegexp.ReplaceAllString(text, ""), "")
+	assert.NotContains(t, rest, "<", text)
+	assert.NotContains(t, rest, ">", text)
+	assert.NotContains(t, rest, "&", text)
+}
+
+func Test_renderf_ShouldEscapeUserInput(t *testing.T) {
+	assert.Equal(t, "Выбрана <b>a&lt;b&gt; &amp; c</b>",
+		renderf("Выбрана <b>%v</b>", "a<b> & c"))
+	assert.Equal(t, "Ошибка: x &lt; 0", renderf("Ошибка: %v", errors.New("x < 0")))
+	assert.Equal(t, "<b>a</b> &lt;i&gt;", renderf("%v %v", HTML("<b>a</b>"), "<i>"))
+	assert.Equal(t, "Сумма: 1.50", renderf("Сумма: %.2f", 1.5))
+	assert.Equal(t, "<b>a&amp;b</b> <code>&lt;x&gt;</code>", renderf("%v %v", bold("a&b"), code("<x>")))
+	assert.Equal(t, HTML("<pre>1 | a&lt;b\n2 | c</pre>"), pre([]string{"1 | a<b", "2 | c"}))
+}
+
+func Test_renderf_ShouldProduceValidHTML_WhenTemplatesGetHostileInput(t *testing.T) {
+	templates := loadTemplates(t)
+	assert.NotEmpty(t, templates)
+	for name, template := range templates {
+		var args []any
+		for _, verb := range formatVerbRegexp.FindAllString(template, -1) {
+			switch verb[len(verb)-1] {
+			case 'v', 's':
+				args = append(args, hostileInput)
+			case 'f':
+				args = append(args, 1.5)
+			case 'd':
+				args = append(args, 1)
+			}
+		}
+		text := renderf(template, args...)
+		assert.NotContains(t, text, "%!", name)
+		assertValidHTML(t, text)
+		if strings.Contains(template, "%v") {
+			assert.Contains(t, text, "Кафе_бар *1* `x` &lt;b&gt;&amp;amp;", name)
+		}
+	}
+}
+
+// Глагол форматирования в шаблоне сообщения.
+var formatVerbRegexp = regexp.MustCompile(`%[-+# 0-9.]*[a-z%]`)
+
+// loadTemplates Все шаблоны сообщений (константы txt*) из исходных файлов пакета.
+func loadTemplates(t *testing.T) map[string]string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	assert.NoError(t, err)
+	templates := make(map[string]string)
+	for _, file := range pkgs["messages"].Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for ind, name := range valueSpec.Names {
+					lit, ok := valueSpec.Values[ind].(*ast.BasicLit)
+					if !strings.HasPrefix(name.Name, "txt") || !ok || lit.Kind != token.STRING {
+						continue
+					}
+					value, err := strconv.Unquote(lit.Value)
+					assert.NoError(t, err)
+					templates[name.Name] = value
+				}
+			}
+		}
+	}
+	return templates
+}
+
+func Test_formatReportTable_ShouldEscapeCategories(t *testing.T) {
+	text := formatReportTable([]types.UserDataReportRecord{{Category: hostileInput, Sum: 10000}})
+
+	assertValidHTML(t, text)
+	assert.True(t, strings.HasPrefix(text, "<pre>"), text)
+	assert.Contains(t, text, "100.00 | Кафе_бар *1* `x` &lt;b&gt;&amp;amp;")
+}
+
+func Test_formatGoal_ShouldEscapeGoalName(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	text := formatGoal(types.Goal{Name: hostileInput, Target: 100, Saved: 100, Currency: "RUB"}, now)
+
+	assertValidHTML(t, text)
+	assert.True(t, strings.HasPrefix(text, "<b>Кафе_бар *1* `x` &lt;b&gt;&amp;amp;"), text)
+}
@@ -0,0 +1,178 @@
+package messages
+
+// Маршрутизация входящих сообщений по зарегистрированным командам бота.
+
+import (
+	"strings"
+	"time"
+
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// labelNone Метка метрик для сообщений, не относящихся ни к одной команде.
+const labelNone = "none"
+
+// UserState Состояние диалога с пользователем на момент получения сообщения.
+type UserState struct {
+	Command  string    // Последняя выбранная пользователем команда.
+	Category string    // Последняя выбранная пользователем категория.
+	Date     time.Time // Последняя выбранная пользователем дата расхода (нулевая - не выбрана).
+}
+
+// CommandFunc Функция обработки команды, нажатия кнопки или ввода данных после команды.
+// Возвращает признак, что сообщение обработано (дальнейший поиск обработчика не требуется).
+type CommandFunc func(s *Model, msg Message, state UserState) (bool, error)
+
+// MatchFunc Функция распознавания сообщения по его содержимому.
+type MatchFunc func(msg Message) bool
+
+// Command Описание команды бота.
+type Command struct {
+	Name            string      // Команда, например, "/add_cat".
+	Aliases         []string    // Дополнительные варианты написания команды.
+	Description     string      // Описание для справки и меню бота (пустое - команда не отображается).
+	CallbackPrefix  string      // Префикс данных кнопки, например, "/cat ".
+	Match           MatchFunc   // Распознавание сообщения, не являющегося командой (например, строки из QR-кода чека).
+	Label           string      // Метка для метрик (по умолчанию - имя команды без "/").
+	AdminOnly       bool        // Команда доступна только администраторам (не отображается в меню бота).
+	Handler         CommandFunc // Обработка команды и ее алиасов.
+	CallbackHandler CommandFunc // Обработка нажатия кнопки с префиксом CallbackPrefix.
+	StateHandler    CommandFunc // Обработка ввода пользователя, если последней была выбрана эта команда.
+}
+
+// Router Реестр команд бота.
+type Router struct {
+	commands []*Command          // Команды в порядке регистрации.
+	byName   map[string]*Command // Команды по имени и алиасам.
+}
+
+// NewRouter Создание пустого реестра команд.
+func NewRouter() *Router {
+	return &Router{
+		byName: map[string]*Command{},
+	}
+}
+
+// Register Регистрация команды в реестре.
+// Повторная регистрация команды с тем же именем заменяет предыдущую.
+func (r *Router) Register(cmd Command) {
+	if cmd.Label == "" {
+		cmd.Label = strings.TrimPrefix(cmd.Name, "/")
+	}
+	c, ok := r.byName[cmd.Name]
+	if ok {
+		*c = cmd
+	} else {
+		c = &cmd
+		r.commands = append(r.commands, c)
+	}
+	r.byName[cmd.Name] = c
+	for _, alias := range cmd.Aliases {
+		r.byName[alias] = c
+	}
+}
+
+// Dispatch Поиск и вызов обработчика сообщения.
+// Порядок: ввод данных после команды, нажатие кнопки, команда, распознавание по содержимому.
+func (r *Router) Dispatch(s *Model, msg Message, state UserState) (bool, error) {
+	// Ввод данных после выбранной ранее команды.
+	if state.Command != "" {
+		if cmd, ok := r.byName[state.Command]; ok && cmd.StateHandler != nil && isAllowed(s, cmd, msg) {
+			if isHandled, err := cmd.StateHandler(s, msg, state); err != nil || isHandled {
+				return true, err
+			}
+		}
+	}
+	// Нажатие кнопки.
+	if msg.IsCallback {
+		if cmd := r.findByCallback(msg.Text); cmd != nil && cmd.CallbackHandler != nil && isAllowed(s, cmd, msg) {
+			if isHandled, err := cmd.CallbackHandler(s, msg, state); err != nil || isHandled {
+				return true, err
+			}
+		}
+	}
+	// Команда.
+	if cmd, ok := r.byName[msg.Text]; ok && cmd.Handler != nil && isAllowed(s, cmd, msg) {
+		return cmd.Handler(s, msg, state)
+	}
+	// Сообщение, распознаваемое по содержимому.
+	if cmd := r.findByMatch(msg); cmd != nil && cmd.Handler != nil && isAllowed(s, cmd, msg) {
+		return cmd.Handler(s, msg, state)
+	}
+	return false, nil
+}
+
+// Label Определение метки метрик по тексту сообщения.
+func (r *Router) Label(text string) string {
+	if cmd := r.findByCallback(text); cmd != nil {
+		return cmd.Label
+	}
+	if cmd, ok := r.byName[strings.TrimSpace(text)]; ok {
+		return cmd.Label
+	}
+	if cmd := r.findByMatch(Message{Text: text}); cmd != nil {
+		return cmd.Label
+	}
+	return labelNone
+}
+
+// HelpText Формирование справки по командам, имеющим описание.
+// Команды администраторов включаются в справку только при withAdmin.
+func (r *Router) HelpText(withAdmin bool) string {
+	var res strings.Builder
+	for _, cmd := range r.commands {
+		if cmd.Description == "" || (cmd.AdminOnly && !withAdmin) {
+			continue
+		}
+		res.WriteString(cmd.Name + " - " + cmd.Description + "\n")
+	}
+	return res.String()
+}
+
+// BotCommands Список команд для меню бота в телеграме.
+func (r *Router) BotCommands() []types.TgBotCommand {
+	var res []types.TgBotCommand
+	for _, cmd := range r.commands {
+		if cmd.Description == "" || cmd.AdminOnly {
+			continue
+		}
+		res = append(res, types.TgBotCommand{
+			Command:     strings.TrimPrefix(cmd.Name, "/"),
+			Description: cmd.Description,
+		})
+	}
+	return res
+}
+
+// CommandWithArgs Распознавание команды с параметрами, например, "/admin_user 123".
+func CommandWithArgs(command string) MatchFunc {
+	return func(msg Message) bool {
+		return !msg.IsCallback && strings.HasPrefix(msg.Text, command+" ")
+	}
+}
+
+// isAllowed Проверка доступа пользователя к команде.
+// Команды администраторов для остальных пользователей считаются неизвестными.
+func isAllowed(s *Model, cmd *Command, msg Message) bool {
+	return !cmd.AdminOnly || (s != nil && s.IsAdmin(msg.UserID))
+}
+
+// findByCallback Поиск команды по префиксу данных кнопки.
+func (r *Router) findByCallback(text string) *Command {
+	for _, cmd := range r.commands {
+		if cmd.CallbackPrefix != "" && strings.HasPrefix(text, cmd.CallbackPrefix) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// findByMatch Поиск команды, распознающей сообщение по содержимому.
+func (r *Router) findByMatch(msg Message) *Command {
+	for _, cmd := range r.commands {
+		if cmd.Match != nil && cmd.Match(msg) {
+			return cmd
+		}
+	}
+	return nil
+}
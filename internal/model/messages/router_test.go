@@ -0,0 +1,102 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func newTestRouter(calls *[]string) *Router {
+	handler := func(name string) CommandFunc {
+		return func(s *Model, msg Message, state UserState) (bool, error) {
+			*calls = append(*calls, name)
+			return true, nil
+		}
+	}
+	r := NewRouter()
+	r.Register(Command{Name: "/start", Aliases: []string{"/menu"}, Description: "Главное меню", Handler: handler("start")})
+	r.Register(Command{Name: "/add_cat", Description: "Добавить категорию", Handler: handler("add_cat"), StateHandler: handler("add_cat state")})
+	r.Register(Command{Name: "/cat", CallbackPrefix: "/cat ", CallbackHandler: handler("cat callback")})
+	r.Register(Command{Name: "/report_w", Label: "report", Handler: handler("report_w")})
+	return r
+}
+
+func Test_Router_Dispatch_ShouldCallHandlerByNameAndAlias(t *testing.T) {
+	var calls []string
+	r := newTestRouter(&calls)
+
+	isHandled, err := r.Dispatch(nil, Message{Text: "/start"}, UserState{})
+	assert.NoError(t, err)
+	assert.True(t, isHandled)
+	isHandled, err = r.Dispatch(nil, Message{Text: "/menu"}, UserState{})
+	assert.NoError(t, err)
+	assert.True(t, isHandled)
+
+	assert.Equal(t, []string{"start", "start"}, calls)
+}
+
+func Test_Router_Dispatch_ShouldPreferStateHandler(t *testing.T) {
+	var calls []string
+	r := newTestRouter(&calls)
+
+	isHandled, err := r.Dispatch(nil, Message{Text: "/start"}, UserState{Command: "/add_cat"})
+
+	assert.NoError(t, err)
+	assert.True(t, isHandled)
+	assert.Equal(t, []string{"add_cat state"}, calls)
+}
+
+func Test_Router_Dispatch_ShouldCallCallbackHandlerOnlyForCallbacks(t *testing.T) {
+	var calls []string
+	r := newTestRouter(&calls)
+
+	isHandled, err := r.Dispatch(nil, Message{Text: "/cat Кино"}, UserState{})
+	assert.NoError(t, err)
+	assert.False(t, isHandled)
+
+	isHandled, err = r.Dispatch(nil, Message{Text: "/cat Кино", IsCallback: true}, UserState{})
+	assert.NoError(t, err)
+	assert.True(t, isHandled)
+	assert.Equal(t, []string{"cat callback"}, calls)
+}
+
+func Test_Router_Label_ShouldBeDerivedFromCommands(t *testing.T) {
+	r := newTestRouter(&[]string{})
+
+	assert.Equal(t, "start", r.Label("/menu"))
+	assert.Equal(t, "cat", r.Label("/cat Кино"))
+	assert.Equal(t, "report", r.Label("/report_w"))
+	assert.Equal(t, "none", r.Label("150.50"))
+}
+
+func Test_Router_BotCommands_ShouldSkipCommandsWithoutDescription(t *testing.T) {
+	r := newTestRouter(&[]string{})
+
+	assert.Equal(t,
+		[]types.TgBotCommand{
+			{Command: "start", Description: "Главное меню"},
+			{Command: "add_cat", Description: "Добавить категорию"},
+		},
+		r.BotCommands(),
+	)
+	assert.Equal(t, "/start - Главное меню\n/add_cat - Добавить категорию\n", r.HelpText(false))
+}
+
+func Test_Router_ShouldHideAdminCommands(t *testing.T) {
+	var calls []string
+	r := newTestRouter(&calls)
+	r.Register(Command{Name: "/admin_stats", Description: "Статистика", AdminOnly: true, Handler: func(s *Model, msg Message, state UserState) (bool, error) {
+		calls = append(calls, "admin_stats")
+		return true, nil
+	}})
+
+	isHandled, err := r.Dispatch(nil, Message{Text: "/admin_stats"}, UserState{})
+	assert.NoError(t, err)
+	assert.False(t, isHandled)
+	assert.Empty(t, calls)
+
+	assert.Len(t, r.BotCommands(), 2)
+	assert.NotContains(t, r.HelpText(false), "/admin_stats")
+	assert.Contains(t, r.HelpText(true), "/admin_stats - Статистика")
+}
@@ -0,0 +1,92 @@
+package messages
+
+// Настройка подписок на регулярные отчеты.
+
+import (
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+const (
+	txtSubscriptions      = "Регулярные отчеты приходят автоматически утром по расписанию. Нажмите на отчет, чтобы включить или отключить его."
+	txtSubscriptionsError = "Ошибка сохранения подписки."
+)
+
+// Виды подписок и их названия (в порядке отображения).
+var subscriptionKinds = []struct {
+	Kind string
+	Name string
+}{
+	{Kind: types.SubscriptionWeekly, Name: "Еженедельный отчет (по понедельникам)"},
+	{Kind: types.SubscriptionMonthly, Name: "Ежемесячный отчет (1-го числа)"},
+}
+
+// Область "Константы и переменные": конец.
+
+// Отображение настроек подписок на регулярные отчеты.
+func cmdSubscriptions(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdSubscriptions")
+	s.ctx = ctx
+	defer span.Finish()
+
+	buttons, err := getSubscriptionButtons(s, msg.UserID)
+	if err != nil {
+		return true, err
+	}
+	return true, s.tgClient.ShowInlineButtons(txtSubscriptions, buttons, msg.UserID)
+}
+
+// Нажатие кнопки включения или отключения подписки.
+func checkIfChoiceSubscription(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfChoiceSubscription")
+	s.ctx = ctx
+	defer span.Finish()
+
+	kind := strings.TrimPrefix(msg.Text, "/sub ")
+	userKinds, err := s.storage.GetUserSubscriptions(s.ctx, msg.UserID)
+	if err != nil {
+		logger.Error("Ошибка получения подписок", "err", err)
+		return true, errors.Wrap(err, "Get user subscriptions error")
+	}
+	// Переключение подписки.
+	if err := s.storage.SetUserSubscription(s.ctx, msg.UserID, kind, !containsString(userKinds, kind), msg.UserName); err != nil {
+		logger.Error("Ошибка сохранения подписки", "err", err)
+		return true, s.tgClient.SendMessage(txtSubscriptionsError, msg.UserID)
+	}
+	// Отображение обновленных настроек.
+	return cmdSubscriptions(s, msg, state)
+}
+
+// Получение кнопок подписок с отметкой включенных.
+func getSubscriptionButtons(s *Model, userID int64) ([]types.TgRowButtons, error) {
+	userKinds, err := s.storage.GetUserSubscriptions(s.ctx, userID)
+	if err != nil {
+		logger.Error("Ошибка получения подписок", "err", err)
+		return nil, errors.Wrap(err, "Get user subscriptions error")
+	}
+	buttons := make([]types.TgRowButtons, len(subscriptionKinds))
+	for ind, sub := range subscriptionKinds {
+		mark := "☐ "
+		if containsString(userKinds, sub.Kind) {
+			mark = "✅ "
+		}
+		buttons[ind] = types.TgRowButtons{types.TgInlineButton{DisplayName: mark + sub.Name, Value: "/sub " + sub.Kind}}
+	}
+	return buttons, nil
+}
+
+// Проверка наличия строки в списке.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package messages
+
+// Выбор часового пояса пользователя.
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+const (
+	txtTimezoneInfo  = "Текущий часовой пояс: <b>%v</b> (время: %v). Выберите город или введите часовой пояс, например, <code>Asia/Vladivostok</code> или <code>UTC+10</code>."
+	txtTimezoneSet   = "Часовой пояс изменен на <b>%v</b> (время: %v)."
+	txtTimezoneError = "Часовой пояс не распознан. Введите, например, <code>Europe/Moscow</code> или <code>UTC+3</code>."
+)
+
+// Кнопки выбора часового пояса (основные часовые пояса России).
+var btnTimezones = []types.TgRowButtons{
+	{types.TgInlineButton{DisplayName: "Калининград", Value: "/tz Europe/Kaliningrad"}, types.TgInlineButton{DisplayName: "Москва", Value: "/tz Europe/Moscow"}, types.TgInlineButton{DisplayName: "Самара", Value: "/tz Europe/Samara"}},
+	{types.TgInlineButton{DisplayName: "Екатеринбург", Value: "/tz Asia/Yekaterinburg"}, types.TgInlineButton{DisplayName: "Омск", Value: "/tz Asia/Omsk"}, types.TgInlineButton{DisplayName: "Новосибирск", Value: "/tz Asia/Novosibirsk"}},
+	{types.TgInlineButton{DisplayName: "Красноярск", Value: "/tz Asia/Krasnoyarsk"}, types.TgInlineButton{DisplayName: "Иркутск", Value: "/tz Asia/Irkutsk"}, types.TgInlineButton{DisplayName: "Якутск", Value: "/tz Asia/Yakutsk"}},
+	{types.TgInlineButton{DisplayName: "Владивосток", Value: "/tz Asia/Vladivostok"}, types.TgInlineButton{DisplayName: "Магадан", Value: "/tz Asia/Magadan"}, types.TgInlineButton{DisplayName: "Камчатка", Value: "/tz Asia/Kamchatka"}},
+}
+
+// Смещение относительно UTC (например, "UTC+10", "+3", "GMT-5").
+var utcOffsetRegexp = regexp.MustCompile(`^(?i:UTC|GMT)?([+-])(\d{1,2})$`)
+
+// Область "Константы и переменные": конец.
+
+// Отображение текущего часового пояса и кнопок выбора.
+func cmdTimezone(s *Model, msg Message, state UserState) (bool, error) {
+	s.lastUserCommand[msg.UserID] = "/timezone"
+	loc := getUserLocation(s, msg.UserID)
+	answerText := renderf(txtTimezoneInfo, loc.String(), time.Now().In(loc).Format("15:04"))
+	return true, s.tgClient.ShowInlineButtons(answerText, btnTimezones, msg.UserID)
+}
+
+// Проверка выбора или ввода часового пояса и сохранение.
+func checkIfChoiceTimezone(s *Model, msg Message, state UserState) (bool, error) {
+	if (msg.IsCallback && !strings.HasPrefix(msg.Text, "/tz ")) || (!msg.IsCallback && strings.HasPrefix(msg.Text, "/")) {
+		// Выбрана другая команда.
+		return false, nil
+	}
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfChoiceTimezone")
+	s.ctx = ctx
+	defer span.Finish()
+
+	timezone, err := parseTimezone(strings.TrimPrefix(msg.Text, "/tz "))
+	if err != nil {
+		return true, s.tgClient.SendMessage(txtTimezoneError, msg.UserID)
+	}
+	if err := s.storage.SetUserTimezone(s.ctx, msg.UserID, timezone, msg.UserName); err != nil {
+		logger.Error("Ошибка сохранения часового пояса", "err", err)
+		return true, errors.Wrap(err, "Set user timezone error")
+	}
+	loc, _ := time.LoadLocation(timezone)
+	return true, s.tgClient.SendMessage(renderf(txtTimezoneSet, timezone, time.Now().In(loc).Format("15:04")), msg.UserID)
+}
+
+// Парсинг часового пояса: название IANA или смещение относительно UTC.
+// Смещение сохраняется как часовой пояс Etc/GMT (с обратным знаком по стандарту IANA).
+func parseTimezone(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if matches := utcOffsetRegexp.FindStringSubmatch(text); matches != nil {
+		hours, _ := strconv.Atoi(matches[2])
+		if hours == 0 {
+			return "UTC", nil
+		}
+		sign := "-"
+		if matches[1] == "-" {
+			sign = "+"
+		}
+		// Существуют пояса от Etc/GMT-14 до Etc/GMT+12.
+		text = "Etc/GMT" + sign + strconv.Itoa(hours)
+	}
+	if text == "" || strings.EqualFold(text, "local") {
+		return "", errors.New("Не указан часовой пояс.")
+	}
+	loc, err := time.LoadLocation(text)
+	if err != nil {
+		return "", errors.Wrap(err, "Неизвестный часовой пояс.")
+	}
+	return loc.String(), nil
+}
@@ -0,0 +1,29 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseTimezone_ShouldAcceptNameAndOffset(t *testing.T) {
+	tests := map[string]string{
+		"Asia/Vladivostok": "Asia/Vladivostok",
+		"UTC+10":           "Etc/GMT-10",
+		"gmt-5":            "Etc/GMT+5",
+		"+3":               "Etc/GMT-3",
+		"UTC+0":            "UTC",
+	}
+	for text, want := range tests {
+		timezone, err := parseTimezone(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, want, timezone, text)
+	}
+}
+
+func Test_parseTimezone_ShouldReturnError_WhenUnknown(t *testing.T) {
+	for _, text := range []string{"", "Local", "Марс/Олимп", "UTC+20", "UTC-13"} {
+		_, err := parseTimezone(text)
+		assert.Error(t, err, text)
+	}
+}
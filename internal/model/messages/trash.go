@@ -0,0 +1,116 @@
+package messages
+
+// Корзина: просмотр удаленных записей и категорий и их восстановление.
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// trashWindow Период, в течение которого удаленные записи и категории можно восстановить.
+const trashWindow = 30 * 24 * time.Hour
+
+// trashListSize Количество элементов корзины в сообщении (новые - первыми).
+const trashListSize = 20
+
+const (
+	txtTrashEmpty    = "Корзина пуста. Удаленные записи и категории можно восстановить в течение %v дней."
+	txtTrashTitle    = "Корзина (валюта <b>%v</b>). Удаленные записи и категории можно восстановить в течение %v дней:"
+	txtTrashRecord   = "\n<code>%v</code> <b>%v</b> %v"
+	txtTrashCategory = "\nКатегория <b>%v</b>: записей %v на сумму <b>%v</b>"
+	txtTrashRestored = "Восстановлено из корзины."
+	txtTrashNotFound = "Не найдено в корзине: уже восстановлено или истек срок хранения."
+)
+
+// Область "Константы и переменные": конец.
+
+// Отображение содержимого корзины с кнопками восстановления.
+func cmdTrash(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdTrash")
+	s.ctx = ctx
+	defer span.Finish()
+
+	items, err := s.storage.GetUserTrash(s.ctx, msg.UserID, time.Now().Add(-trashWindow))
+	if err != nil {
+		logger.Error("Ошибка получения корзины", "err", err)
+		return true, errors.Wrap(err, "Get user trash error")
+	}
+	days := int(trashWindow.Hours() / 24)
+	if len(items) == 0 {
+		return true, s.tgClient.SendMessage(renderf(txtTrashEmpty, days), msg.UserID)
+	}
+	if len(items) > trashListSize {
+		items = items[:trashListSize]
+	}
+
+	userCurrency := getUserCurrency(s, msg.UserID)
+	loc := getUserLocation(s, msg.UserID)
+	var res strings.Builder
+	res.WriteString(renderf(txtTrashTitle, userCurrency, days))
+	var buttons []types.TgRowButtons
+	for _, item := range items {
+		sum, err := s.currencies.ConvertSumFromBaseToCurrency(userCurrency, item.Sum)
+		if err != nil {
+			logger.Error("Ошибка конвертации валюты", "err", err)
+			return true, errors.Wrap(err, "Ошибка конвертации валюты.")
+		}
+		name := item.Category
+		if item.Kind == types.UserActionCategory {
+			res.WriteString(renderf(txtTrashCategory, item.Category, item.Records, money.FormatAmount(sum)))
+		} else {
+			res.WriteString(renderf(txtTrashRecord, item.Period.In(loc).Format("02.01.2006"), money.FormatAmount(sum), item.Category))
+			if item.Note != "" {
+				res.WriteString(" - " + escape(item.Note))
+			}
+			name = money.FormatAmount(sum) + " " + item.Category
+		}
+		buttons = append(buttons, types.TgRowButtons{
+			types.TgInlineButton{DisplayName: "Восстановить: " + name, Value: "/trash_restore " + item.Kind + " " + strconv.FormatInt(item.ID, 10)},
+		})
+	}
+	return true, s.tgClient.ShowInlineButtons(res.String(), buttons, msg.UserID)
+}
+
+// Нажатие кнопки восстановления записи или категории из корзины.
+func checkIfChoiceTrashRestore(s *Model, msg Message, state UserState) (bool, error) {
+	kind, id, ok := parseTrashRestore(msg.Text)
+	if !ok {
+		return false, nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "checkIfChoiceTrashRestore")
+	s.ctx = ctx
+	defer span.Finish()
+
+	isRestored, err := s.storage.RestoreTrashItem(s.ctx, msg.UserID, kind, id, time.Now().Add(-trashWindow))
+	if err != nil {
+		logger.Error("Ошибка восстановления из корзины", "err", err)
+		return true, errors.Wrap(err, "Restore trash item error")
+	}
+	if !isRestored {
+		return true, s.tgClient.SendMessage(txtTrashNotFound, msg.UserID)
+	}
+	return true, s.tgClient.SendMessage(txtTrashRestored, msg.UserID)
+}
+
+// Разбор команды восстановления вида "/trash_restore record 10".
+func parseTrashRestore(text string) (string, int64, bool) {
+	fields := strings.Fields(strings.TrimPrefix(text, "/trash_restore "))
+	if len(fields) != 2 || (fields[0] != types.UserActionRecord && fields[0] != types.UserActionCategory) {
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[0], id, true
+}
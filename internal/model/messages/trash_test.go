@@ -0,0 +1,23 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+func Test_parseTrashRestore_ShouldParseKindAndID(t *testing.T) {
+	kind, id, ok := parseTrashRestore("/trash_restore category 15")
+
+	assert.True(t, ok)
+	assert.Equal(t, types.UserActionCategory, kind)
+	assert.Equal(t, int64(15), id)
+}
+
+func Test_parseTrashRestore_ShouldFail_WhenKindOrIDInvalid(t *testing.T) {
+	for _, text := range []string{"/trash_restore limit 15", "/trash_restore record x", "/trash_restore record", "/trash_restore record 1 2"} {
+		_, _, ok := parseTrashRestore(text)
+		assert.False(t, ok, text)
+	}
+}
@@ -0,0 +1,65 @@
+package messages
+
+// Отмена последнего действия пользователя.
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// Область "Константы и переменные": начало.
+
+// undoWindow Период, в течение которого действие пользователя можно отменить.
+const undoWindow = 10 * time.Minute
+
+const (
+	txtUndoEmpty    = "Нет действий для отмены. Отменить можно только действия за последние %v минут."
+	txtUndoError    = "Не удалось отменить действие."
+	txtUndoRecord   = "Последняя запись о расходах отменена и перемещена в корзину (/trash)."
+	txtUndoCategory = "Добавление категории отменено, категория перемещена в корзину (/trash)."
+	txtUndoLimit    = "Бюджет возвращен к прежнему значению: <b>%v</b>."
+	txtUndoCurrency = "Валюта возвращена к прежнему значению: <b>%v</b>."
+)
+
+// Кнопка отмены действия под сообщением о сохранении.
+var btnUndo = []types.TgRowButtons{
+	{types.TgInlineButton{DisplayName: "Отменить", Value: "/undo"}},
+}
+
+// Область "Константы и переменные": конец.
+
+// Отмена последнего действия пользователя (по команде или нажатию кнопки "Отменить").
+func cmdUndo(s *Model, msg Message, state UserState) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(s.ctx, "cmdUndo")
+	s.ctx = ctx
+	defer span.Finish()
+
+	action, err := s.storage.UndoLastUserAction(s.ctx, msg.UserID, time.Now().Add(-undoWindow))
+	if err != nil {
+		logger.Error("Ошибка отмены действия", "err", err)
+		return true, s.tgClient.SendMessage(txtUndoError, msg.UserID)
+	}
+
+	answerText := ""
+	switch action.Action {
+	case types.UserActionRecord:
+		answerText = txtUndoRecord
+	case types.UserActionCategory:
+		answerText = txtUndoCategory
+	case types.UserActionLimit:
+		answerText = renderf(txtUndoLimit, "без ограничений")
+		if limit, err := strconv.ParseInt(action.PrevValue, 10, 64); err == nil && limit > 0 {
+			answerText = renderf(txtUndoLimit, money.FormatAmount(limit))
+		}
+	case types.UserActionCurrency:
+		answerText = renderf(txtUndoCurrency, action.PrevValue)
+	default:
+		answerText = renderf(txtUndoEmpty, undoWindow.Minutes())
+	}
+	return true, s.tgClient.SendMessage(answerText, msg.UserID)
+}
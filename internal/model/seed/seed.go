@@ -0,0 +1,287 @@
+// Package seed Генерация демонстрационных данных (пользователи, категории, расходы и курсы валют)
+// для демонстраций и нагрузочного тестирования. Данные воспроизводимы: при одинаковых параметрах
+// генерируются одинаковые данные.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ellavs/tg-bot-golang/internal/helpers/money"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// DemoUserIDFrom Телеграм-идентификатор первого демонстрационного пользователя (далее - по порядку).
+const DemoUserIDFrom = 2_000_000_000
+
+// Options Параметры генерации данных.
+type Options struct {
+	Users        int       // Количество пользователей.
+	Categories   int       // Количество категорий у пользователя (не больше количества шаблонов категорий).
+	Months       int       // Количество месяцев истории расходов (включая текущий).
+	Seed         int64     // Начальное значение генератора случайных чисел.
+	Now          time.Time // Текущее время (расходы и курсы позже него не генерируются).
+	MainCurrency string    // Основная валюта (в ней хранятся суммы расходов).
+	Currencies   []string  // Используемые валюты (курсы генерируются для всех, кроме основной).
+}
+
+// User Демонстрационный пользователь.
+type User struct {
+	TgID       int64                  // Телеграм-идентификатор.
+	Name       string                 // Имя пользователя.
+	Limit      int64                  // Ежемесячный бюджет (в копейках).
+	Categories []string               // Категории расходов.
+	Records    []types.UserDataRecord // Расходы (в порядке возрастания даты).
+}
+
+// DayRates Курсы валют на дату.
+type DayRates struct {
+	Period time.Time          // Дата курсов.
+	Rates  types.ExchangeRate // Курсы валют к основной валюте.
+}
+
+// Data Сгенерированные данные.
+type Data struct {
+	Users []User     // Пользователи с категориями и расходами.
+	Rates []DayRates // Курсы валют по дням.
+}
+
+// Stats Количество добавленных данных.
+type Stats struct {
+	Users      int // Пользователей.
+	Categories int // Категорий.
+	Records    int // Расходов.
+	RateDays   int // Дней с курсами валют.
+}
+
+// Storage Хранилище данных пользователей.
+type Storage interface {
+	InsertCategory(ctx context.Context, userID int64, catName string, userName string) error
+	InsertUserDataRecord(ctx context.Context, userID int64, rec types.UserDataRecord, userName string, limitPeriod time.Time) (bool, error)
+	SetUserLimit(ctx context.Context, userID int64, limits int64, userName string) error
+}
+
+// RatesStorage Хранилище курсов валют.
+type RatesStorage interface {
+	InsertExchangeRatesToDate(ctx context.Context, rates types.ExchangeRate, period time.Time) error
+}
+
+// categoryTemplate Шаблон категории расходов.
+type categoryTemplate struct {
+	Name     string      // Название категории.
+	PerMonth float64     // Среднее количество расходов в месяц.
+	Monthly  bool        // Ежемесячный платеж: ровно один расход в месяц, сезонный множитель применяется к сумме.
+	Amount   int64       // Типичная сумма расхода (в копейках).
+	Season   [12]float64 // Сезонные множители (январь - декабрь) количества расходов (для ежемесячных платежей - суммы).
+}
+
+// categoryTemplates Шаблоны категорий в порядке популярности (пользователь получает первые Options.Categories).
+var categoryTemplates = []categoryTemplate{
+	{Name: "Продукты", PerMonth: 12, Amount: 180000, Season: [12]float64{1.1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1.05, 1.4}},
+	{Name: "Транспорт", PerMonth: 10, Amount: 35000, Season: [12]float64{1.1, 1.1, 1, 1, 0.9, 0.8, 0.7, 0.8, 1, 1.1, 1.1, 1.1}},
+	{Name: "Кафе", PerMonth: 5, Amount: 90000, Season: [12]float64{0.8, 0.9, 1, 1, 1.2, 1.3, 1.3, 1.3, 1, 0.9, 0.9, 1.4}},
+	{Name: "Коммунальные услуги", Monthly: true, Amount: 650000, Season: [12]float64{1.4, 1.4, 1.3, 1.1, 0.9, 0.8, 0.8, 0.8, 0.9, 1.1, 1.3, 1.4}},
+	{Name: "Интернет", Monthly: true, Amount: 95000, Season: [12]float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	{Name: "Бензин", PerMonth: 3, Amount: 250000, Season: [12]float64{0.8, 0.8, 0.9, 1, 1.2, 1.4, 1.5, 1.4, 1.1, 0.9, 0.8, 0.9}},
+	{Name: "Здоровье", PerMonth: 1.5, Amount: 220000, Season: [12]float64{1.3, 1.4, 1.2, 1, 0.8, 0.7, 0.7, 0.7, 0.9, 1.2, 1.3, 1.2}},
+	{Name: "Одежда", PerMonth: 1, Amount: 450000, Season: [12]float64{0.7, 0.6, 0.9, 1.4, 1.1, 0.9, 0.8, 1, 1.6, 1.3, 1, 1.1}},
+	{Name: "Подарки", PerMonth: 0.5, Amount: 300000, Season: [12]float64{0.5, 1.6, 2, 0.6, 0.6, 0.6, 0.6, 0.6, 0.6, 0.6, 0.8, 4}},
+	{Name: "Отдых", PerMonth: 0.4, Amount: 2500000, Season: [12]float64{0.6, 0.3, 0.3, 0.5, 1.2, 2.5, 3, 2.5, 1, 0.4, 0.3, 1.2}},
+}
+
+// baseRates Начальные курсы валют к рублю (количество единиц валюты за рубль).
+var baseRates = map[string]float64{
+	"RUB": 1,
+	"USD": 0.015858969,
+	"EUR": 0.0160078,
+	"CNY": 0.11505467,
+}
+
+// rateVolatility Среднеквадратичное дневное изменение курса.
+const rateVolatility = 0.004
+
+// MaxCategories Максимальное количество категорий у пользователя.
+func MaxCategories() int {
+	return len(categoryTemplates)
+}
+
+// Generate Генерация данных по параметрам.
+func Generate(opts Options) (Data, error) {
+	if opts.Users <= 0 || opts.Months <= 0 {
+		return Data{}, errors.New("Количество пользователей и месяцев должно быть больше нуля.")
+	}
+	if opts.Categories <= 0 || opts.Categories > len(categoryTemplates) {
+		return Data{}, errors.Errorf("Количество категорий должно быть от 1 до %v.", len(categoryTemplates))
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	firstMonth := time.Date(opts.Now.Year(), opts.Now.Month(), 1, 0, 0, 0, 0, opts.Now.Location()).AddDate(0, 1-opts.Months, 0)
+
+	rates, err := generateRates(rng, opts, firstMonth)
+	if err != nil {
+		return Data{}, err
+	}
+	users := make([]User, opts.Users)
+	for ind := range users {
+		users[ind] = generateUser(rng, opts, firstMonth, ind)
+	}
+	return Data{Users: users, Rates: rates}, nil
+}
+
+// Run Генерация данных и добавление их в хранилища.
+func Run(ctx context.Context, storage Storage, ratesStorage RatesStorage, opts Options) (Stats, error) {
+	data, err := Generate(opts)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var stats Stats
+	for _, day := range data.Rates {
+		if err := ratesStorage.InsertExchangeRatesToDate(ctx, day.Rates, day.Period); err != nil {
+			return stats, errors.Wrap(err, "Insert demo exchange rates error")
+		}
+		stats.RateDays++
+	}
+
+	limitPeriod := time.Date(opts.Now.Year(), opts.Now.Month(), 1, 0, 0, 0, 0, opts.Now.Location())
+	for _, user := range data.Users {
+		// Бюджет снимается на время добавления расходов (при повторном запуске он уже установлен).
+		if err := storage.SetUserLimit(ctx, user.TgID, 0, user.Name); err != nil {
+			return stats, errors.Wrap(err, "Reset demo user limit error")
+		}
+		for _, category := range user.Categories {
+			if err := storage.InsertCategory(ctx, user.TgID, category, user.Name); err != nil {
+				return stats, errors.Wrap(err, "Insert demo category error")
+			}
+			stats.Categories++
+		}
+		for _, rec := range user.Records {
+			if _, err := storage.InsertUserDataRecord(ctx, user.TgID, rec, user.Name, limitPeriod); err != nil {
+				return stats, errors.Wrap(err, "Insert demo record error")
+			}
+			stats.Records++
+		}
+		if err := storage.SetUserLimit(ctx, user.TgID, user.Limit, user.Name); err != nil {
+			return stats, errors.Wrap(err, "Set demo user limit error")
+		}
+		stats.Users++
+		logger.Debug("Добавлен демонстрационный пользователь", "user", user.TgID, "records", len(user.Records))
+	}
+	return stats, nil
+}
+
+// generateUser Генерация пользователя с категориями и расходами по месяцам.
+func generateUser(rng *rand.Rand, opts Options, firstMonth time.Time, ind int) User {
+	user := User{
+		TgID: DemoUserIDFrom + int64(ind),
+		Name: fmt.Sprintf("demo_%d", ind+1),
+	}
+	// Уровень расходов пользователя (от 0.6 до 1.8 от типичного).
+	scale := 0.6 + rng.Float64()*1.2
+
+	var total int64
+	for _, tmpl := range categoryTemplates[:opts.Categories] {
+		user.Categories = append(user.Categories, tmpl.Name)
+		for month := firstMonth; !month.After(opts.Now); month = month.AddDate(0, 1, 0) {
+			season := tmpl.Season[month.Month()-1]
+			count := 1
+			if !tmpl.Monthly {
+				count = poisson(rng, tmpl.PerMonth*season)
+			}
+			for n := 0; n < count; n++ {
+				amountScale := scale * (0.6 + rng.Float64()*0.8)
+				if tmpl.Monthly {
+					// Ежемесячный платеж: сумма меняется по сезону и незначительно от месяца к месяцу.
+					amountScale = scale * season * (0.95 + rng.Float64()*0.1)
+				}
+				period := randomTimeInMonth(rng, month)
+				if period.After(opts.Now) {
+					continue
+				}
+				sum := roundAmount(float64(tmpl.Amount) * amountScale)
+				user.Records = append(user.Records, types.UserDataRecord{Category: tmpl.Name, Sum: sum, Period: period})
+				total += sum
+			}
+		}
+	}
+	sort.SliceStable(user.Records, func(i, j int) bool { return user.Records[i].Period.Before(user.Records[j].Period) })
+
+	// Бюджет - средние расходы в месяц с запасом 10%, округленные до тысячи рублей.
+	const thousand = 100000
+	user.Limit = (total*11/10/int64(opts.Months)/thousand + 1) * thousand
+	return user
+}
+
+// generateRates Генерация дневных курсов валют (случайное блуждание от начальных курсов).
+func generateRates(rng *rand.Rand, opts Options, firstMonth time.Time) ([]DayRates, error) {
+	mainRate, ok := baseRates[opts.MainCurrency]
+	if !ok {
+		return nil, errors.Errorf("Нет демонстрационного курса основной валюты %v.", opts.MainCurrency)
+	}
+	current := map[string]float64{}
+	for _, name := range opts.Currencies {
+		if name == opts.MainCurrency {
+			continue
+		}
+		rate, ok := baseRates[name]
+		if !ok {
+			return nil, errors.Errorf("Нет демонстрационного курса валюты %v.", name)
+		}
+		current[name] = rate / mainRate
+	}
+	if len(current) == 0 {
+		return nil, nil
+	}
+
+	var days []DayRates
+	for day := firstMonth; !day.After(opts.Now); day = day.AddDate(0, 0, 1) {
+		rates := types.ExchangeRate{}
+		// Валюты перебираются в порядке списка, чтобы данные не зависели от порядка обхода map.
+		for _, name := range opts.Currencies {
+			rate, ok := current[name]
+			if !ok {
+				continue
+			}
+			rate *= 1 + rng.NormFloat64()*rateVolatility
+			current[name] = rate
+			parsed, err := money.ParseRate(strconv.FormatFloat(rate, 'f', 8, 64))
+			if err != nil {
+				return nil, err
+			}
+			rates[name] = parsed
+		}
+		days = append(days, DayRates{Period: day, Rates: rates})
+	}
+	return days, nil
+}
+
+// poisson Случайное количество событий с распределением Пуассона со средним lambda.
+func poisson(rng *rand.Rand, lambda float64) int {
+	limit, product, count := math.Exp(-lambda), rng.Float64(), 0
+	for product > limit {
+		product *= rng.Float64()
+		count++
+	}
+	return count
+}
+
+// randomTimeInMonth Случайное время в течение месяца (с 8 до 22 часов).
+func randomTimeInMonth(rng *rand.Rand, month time.Time) time.Time {
+	days := month.AddDate(0, 1, -1).Day()
+	return month.AddDate(0, 0, rng.Intn(days)).
+		Add(time.Duration(8+rng.Intn(14))*time.Hour + time.Duration(rng.Intn(60))*time.Minute)
+}
+
+// roundAmount Округление суммы до рубля (копейки остаются только у небольших сумм, как в чеках магазинов).
+func roundAmount(amount float64) int64 {
+	if amount < 100000 {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount/100)) * 100
+}
@@ -0,0 +1,140 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ellavs/tg-bot-golang/internal/model/db/memory"
+)
+
+func testOptions() Options {
+	return Options{
+		Users:        20,
+		Categories:   MaxCategories(),
+		Months:       12,
+		Seed:         7,
+		Now:          time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC),
+		MainCurrency: "RUB",
+		Currencies:   []string{"USD", "CNY", "EUR", "RUB"},
+	}
+}
+
+func Test_Generate_ShouldBeReproducible(t *testing.T) {
+	first, err := Generate(testOptions())
+	require.NoError(t, err)
+	second, err := Generate(testOptions())
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	opts := testOptions()
+	opts.Seed++
+	other, err := Generate(opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Users[0].Records, other.Users[0].Records)
+}
+
+func Test_Generate_ShouldKeepRecordsWithinPeriod(t *testing.T) {
+	opts := testOptions()
+	data, err := Generate(opts)
+	require.NoError(t, err)
+
+	firstMonth := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	require.Len(t, data.Users, opts.Users)
+	for ind, user := range data.Users {
+		assert.Equal(t, int64(DemoUserIDFrom+ind), user.TgID)
+		assert.Len(t, user.Categories, opts.Categories)
+		assert.Greater(t, user.Limit, int64(0))
+		require.NotEmpty(t, user.Records)
+		for _, rec := range user.Records {
+			assert.Contains(t, user.Categories, rec.Category)
+			assert.Greater(t, rec.Sum, int64(0))
+			assert.False(t, rec.Period.Before(firstMonth), rec.Period)
+			assert.False(t, rec.Period.After(opts.Now), rec.Period)
+		}
+	}
+
+	// Курсы на каждый день периода, кроме основной валюты.
+	require.Len(t, data.Rates, int(opts.Now.Sub(firstMonth).Hours()/24)+1)
+	for _, day := range data.Rates {
+		assert.Len(t, day.Rates, 3)
+		assert.NotContains(t, day.Rates, "RUB")
+	}
+}
+
+func Test_Generate_ShouldFollowSeasonality(t *testing.T) {
+	opts := testOptions()
+	opts.Users = 200
+	data, err := Generate(opts)
+	require.NoError(t, err)
+
+	// Расходы на отдых летом больше, чем зимой, расходы на коммунальные услуги - наоборот.
+	sums := map[string]map[time.Month]int64{}
+	for _, user := range data.Users {
+		for _, rec := range user.Records {
+			if sums[rec.Category] == nil {
+				sums[rec.Category] = map[time.Month]int64{}
+			}
+			sums[rec.Category][rec.Period.Month()] += rec.Sum
+		}
+	}
+	assert.Greater(t, sums["Отдых"][time.July], 2*sums["Отдых"][time.February])
+	assert.Greater(t, sums["Коммунальные услуги"][time.January], sums["Коммунальные услуги"][time.July])
+	assert.Greater(t, sums["Подарки"][time.December], 2*sums["Подарки"][time.October])
+}
+
+func Test_Generate_ShouldReturnError_WhenInvalidOptions(t *testing.T) {
+	for _, change := range []func(opts *Options){
+		func(opts *Options) { opts.Users = 0 },
+		func(opts *Options) { opts.Months = 0 },
+		func(opts *Options) { opts.Categories = MaxCategories() + 1 },
+		func(opts *Options) { opts.Currencies = []string{"GBP", "RUB"} },
+	} {
+		opts := testOptions()
+		change(&opts)
+		_, err := Generate(opts)
+		assert.Error(t, err)
+	}
+}
+
+func Test_Run_ShouldFillStorages(t *testing.T) {
+	ctx := context.Background()
+	opts := testOptions()
+	opts.Users = 3
+	opts.Categories = 4
+	data, err := Generate(opts)
+	require.NoError(t, err)
+
+	storage := memory.NewUserStorage("RUB", 0, "Europe/Moscow")
+	ratesStorage := memory.NewExchangeRatesStorage(opts.Currencies)
+	stats, err := Run(ctx, storage, ratesStorage, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Users: 3, Categories: 12, Records: stats.Records, RateDays: len(data.Rates)}, stats)
+
+	for _, user := range data.Users {
+		categories, err := storage.GetUserCategory(ctx, user.TgID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, user.Categories, categories)
+		limit, err := storage.GetUserLimit(ctx, user.TgID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Limit, limit)
+
+		var total int64
+		for _, rec := range user.Records {
+			total += rec.Sum
+		}
+		report, err := storage.GetUserDataRecord(ctx, user.TgID, time.Time{})
+		require.NoError(t, err)
+		var reportTotal int64
+		for _, rec := range report {
+			reportTotal += rec.Sum
+		}
+		assert.Equal(t, total, reportTotal)
+	}
+
+	last, err := ratesStorage.GetLastExchangeRates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, data.Rates[len(data.Rates)-1].Rates, last)
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/ellavs/tg-bot-golang/internal/clients/tg"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	"github.com/ellavs/tg-bot-golang/internal/model/messages"
+)
+
+const txtThrottled = "Слишком много запросов. Пожалуйста, повторите через %v сек."
+
+// Классы команд по меткам реестра команд бота (остальные команды - ClassDefault).
+var commandClasses = map[string]string{
+	"report": ClassReport,
+}
+
+// DroppedUpdates Количество отклоненных из-за ограничения частоты сообщений.
+var DroppedUpdates = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "tg",
+		Subsystem: "messages",
+		Name:      "dropped_total",
+	},
+	[]string{"class"},
+)
+
+// Middleware Функция ограничения частоты запросов пользователей.
+// Отклоненные сообщения не обрабатываются, о первом из них подряд пользователю отправляется уведомление.
+// На нажатие кнопки отвечается и при отклонении (иначе на кнопке продолжает отображаться ожидание).
+func Middleware(limiter *Limiter) func(next tg.HandlerFunc) tg.HandlerFunc {
+	return func(next tg.HandlerFunc) tg.HandlerFunc {
+		return tg.HandlerFunc(func(tgUpdate tgbotapi.Update, c *tg.Client, msgModel *messages.Model) {
+			userID, class := getUpdateClass(tgUpdate, msgModel)
+			res := limiter.Allow(userID, class, time.Now())
+			if res.Allowed {
+				next.RunFunc(tgUpdate, c, msgModel)
+				return
+			}
+			DroppedUpdates.WithLabelValues(class).Inc()
+			logger.Info("Сообщение отклонено из-за ограничения частоты запросов", "user", userID, "class", class)
+			seconds := int(math.Ceil(res.RetryAfter.Seconds()))
+			if tgUpdate.CallbackQuery != nil {
+				if err := c.AnswerCallback(tgUpdate.CallbackQuery.ID, fmt.Sprintf(txtThrottled, seconds)); err != nil {
+					logger.Error("Ошибка ответа на нажатие кнопки:", "err", err)
+				}
+			}
+			// На инлайн-запросы уведомление не отправляется (пользователь может не иметь чата с ботом).
+			if res.Notify && class != ClassInline {
+				if err := c.SendMessage(fmt.Sprintf(txtThrottled, seconds), userID); err != nil {
+					logger.Error("Ошибка отправки уведомления об ограничении:", "err", err)
+				}
+			}
+		})
+	}
+}
+
+// Определение пользователя и класса команды по входящему сообщению.
+func getUpdateClass(tgUpdate tgbotapi.Update, msgModel *messages.Model) (int64, string) {
+	var userID int64
+	var text string
+	switch {
+	case tgUpdate.Message != nil:
+		userID, text = tgUpdate.Message.From.ID, tgUpdate.Message.Text
+	case tgUpdate.CallbackQuery != nil:
+		userID, text = tgUpdate.CallbackQuery.From.ID, tgUpdate.CallbackQuery.Data
+	case tgUpdate.InlineQuery != nil:
+		return tgUpdate.InlineQuery.From.ID, ClassInline
+	}
+	if class, ok := commandClasses[msgModel.CommandLabel(text)]; ok {
+		return userID, class
+	}
+	return userID, ClassDefault
+}
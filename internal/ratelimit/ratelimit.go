@@ -0,0 +1,114 @@
+// Package ratelimit - ограничение частоты запросов пользователей (алгоритм token bucket).
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Классы команд с отдельными ограничениями.
+const (
+	ClassDefault = "default" // Все команды, не относящиеся к другим классам.
+	ClassReport  = "report"  // Отчеты (запросы к БД с агрегацией и сообщения в кафку).
+	ClassInline  = "inline"  // Инлайн-запросы.
+)
+
+// cleanupPeriod Периодичность удаления неиспользуемых счетчиков пользователей.
+const cleanupPeriod = 10 * time.Minute
+
+// Limit Ограничение частоты запросов одного класса.
+type Limit struct {
+	PerMinute float64 // Скорость пополнения (запросов в минуту), 0 - без ограничений.
+	Burst     int     // Емкость (количество запросов подряд).
+}
+
+// Result Результат проверки запроса.
+type Result struct {
+	Allowed    bool          // Запрос разрешен.
+	Notify     bool          // Первый отклоненный запрос подряд (пользователю нужно сообщить об ограничении).
+	RetryAfter time.Duration // Через сколько будет разрешен следующий запрос (для отклоненных запросов).
+}
+
+// bucketKey Счетчик запросов пользователя по классу команд.
+type bucketKey struct {
+	userID int64
+	class  string
+}
+
+// bucket Состояние счетчика запросов.
+type bucket struct {
+	tokens   float64   // Доступное количество запросов.
+	updated  time.Time // Время последнего пересчета.
+	notified bool      // Пользователю уже сообщено об ограничении.
+}
+
+// Limiter Ограничение частоты запросов пользователей по классам команд.
+type Limiter struct {
+	mutex       *sync.Mutex
+	limits      map[string]Limit
+	buckets     map[bucketKey]*bucket
+	lastCleanup time.Time
+}
+
+// NewLimiter Создание ограничителя с заданными ограничениями по классам команд.
+// Классы без ограничений (или с PerMinute == 0) не ограничиваются.
+func NewLimiter(limits map[string]Limit) *Limiter {
+	return &Limiter{
+		mutex:   new(sync.Mutex),
+		limits:  limits,
+		buckets: make(map[bucketKey]*bucket),
+	}
+}
+
+// Allow Проверка и учет запроса пользователя.
+func (l *Limiter) Allow(userID int64, class string, now time.Time) Result {
+	limit, ok := l.limits[class]
+	if !ok || limit.PerMinute <= 0 || limit.Burst <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.cleanup(now)
+
+	key := bucketKey{userID: userID, class: class}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	// Пополнение запросов за прошедшее время.
+	perSecond := limit.PerMinute / 60
+	b.tokens += now.Sub(b.updated).Seconds() * perSecond
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.notified = false
+		return Result{Allowed: true}
+	}
+	res := Result{
+		Notify:     !b.notified,
+		RetryAfter: time.Duration((1 - b.tokens) / perSecond * float64(time.Second)),
+	}
+	b.notified = true
+	return res
+}
+
+// cleanup Удаление счетчиков, которые полностью восстановились (эквивалентны новым).
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupPeriod {
+		return
+	}
+	l.lastCleanup = now
+	for key, b := range l.buckets {
+		limit := l.limits[key.class]
+		if b.tokens+now.Sub(b.updated).Minutes()*limit.PerMinute >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Limiter_Allow_ShouldDropAfterBurstAndRefill(t *testing.T) {
+	limiter := NewLimiter(map[string]Limit{ClassReport: {PerMinute: 6, Burst: 2}})
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	assert.True(t, limiter.Allow(123, ClassReport, now).Allowed)
+	assert.True(t, limiter.Allow(123, ClassReport, now).Allowed)
+
+	// Уведомление только о первом отклоненном запросе подряд.
+	res := limiter.Allow(123, ClassReport, now)
+	assert.Equal(t, Result{Notify: true, RetryAfter: 10 * time.Second}, res)
+	res = limiter.Allow(123, ClassReport, now.Add(5*time.Second))
+	assert.False(t, res.Allowed)
+	assert.False(t, res.Notify)
+
+	// Другой пользователь ограничивается отдельно.
+	assert.True(t, limiter.Allow(456, ClassReport, now).Allowed)
+
+	// Через 10 секунд доступен один запрос.
+	assert.True(t, limiter.Allow(123, ClassReport, now.Add(10*time.Second)).Allowed)
+	assert.False(t, limiter.Allow(123, ClassReport, now.Add(10*time.Second)).Allowed)
+}
+
+func Test_Limiter_Allow_ShouldNotLimitUnknownOrDisabledClass(t *testing.T) {
+	limiter := NewLimiter(map[string]Limit{ClassDefault: {PerMinute: 0, Burst: 1}})
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.Allow(123, ClassDefault, now).Allowed)
+		assert.True(t, limiter.Allow(123, ClassInline, now).Allowed)
+	}
+}
+
+func Test_Limiter_Allow_ShouldCleanupRefilledBuckets(t *testing.T) {
+	limiter := NewLimiter(map[string]Limit{ClassDefault: {PerMinute: 60, Burst: 5}})
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	limiter.Allow(123, ClassDefault, now)
+	limiter.Allow(456, ClassDefault, now.Add(cleanupPeriod))
+
+	assert.Len(t, limiter.buckets, 1)
+}
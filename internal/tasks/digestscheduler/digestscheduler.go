@@ -0,0 +1,111 @@
+package digestscheduler
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ellavs/tg-bot-golang/internal/helpers/timeutils"
+	"github.com/ellavs/tg-bot-golang/internal/logger"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+// SubscriptionStorage Интерфейс для работы с хранилищем подписок на регулярные отчеты.
+type SubscriptionStorage interface {
+	GetSubscriptions(ctx context.Context) ([]types.Subscription, error)
+	MarkSubscriptionSent(ctx context.Context, subscriptionID int64, dueAt time.Time, sentAt time.Time) (bool, error)
+}
+
+// kafkaProducer Интерфейс для отправки запросов на формирование отчета в кафку.
+type kafkaProducer interface {
+	SendMessage(key string, value string) (partition int32, offset int64, err error)
+}
+
+// Schedule Расписание регулярных отчетов.
+type Schedule struct {
+	Location *time.Location // Часовой пояс расписания (если у подписки не задан часовой пояс пользователя).
+	Hour     int            // Час отправки отчетов.
+}
+
+// DigestScheduler Процедура периодической проверки подписок и отправки запросов
+// на формирование регулярных отчетов в кафку (в тот же топик, что и запросы пользователей).
+func DigestScheduler(ctx context.Context, storage SubscriptionStorage, producer kafkaProducer, schedule Schedule, checkPeriod time.Duration) {
+	// Создаем таймер на указанную периодичность.
+	ticker := time.NewTicker(checkPeriod)
+	// Запускаем горутину, проверяющую подписки по таймеру.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				// Завершение горутины.
+				return
+			case <-ticker.C:
+				if err := sendDueDigests(ctx, storage, producer, schedule, time.Now()); err != nil {
+					logger.Error("Ошибка отправки регулярных отчетов:", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// sendDueDigests Отправка запросов на формирование отчетов по подпискам, срок которых наступил.
+func sendDueDigests(ctx context.Context, storage SubscriptionStorage, producer kafkaProducer, schedule Schedule, now time.Time) error {
+	subscriptions, err := storage.GetSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subscriptions {
+		// Расписание в часовом поясе пользователя.
+		userSchedule := Schedule{Location: timeutils.LoadLocation(sub.Timezone, schedule.Location), Hour: schedule.Hour}
+		dueAt := LastDueTime(sub.Kind, now, userSchedule)
+		if dueAt.IsZero() || !sub.LastSentAt.Before(dueAt) {
+			// Отчет за текущий срок уже отправлен.
+			continue
+		}
+		// Отметка об отправке (защита от повторной отправки несколькими экземплярами бота).
+		isMarked, err := storage.MarkSubscriptionSent(ctx, sub.ID, dueAt, now)
+		if err != nil {
+			logger.Error("Ошибка отметки об отправке отчета", "err", err, "subscriptionID", sub.ID)
+			continue
+		}
+		if !isMarked {
+			continue
+		}
+		logger.Info("Отправка регулярного отчета", "userID", sub.UserID, "kind", sub.Kind)
+		if _, _, err := producer.SendMessage(strconv.FormatInt(sub.UserID, 10), ReportKey(sub.Kind)); err != nil {
+			logger.Error("Ошибка отправки сообщения в кафку", "err", err)
+		}
+	}
+	return nil
+}
+
+// ReportKey Ключ отчета для регулярного отчета вида kind: ежемесячный отчет строится за прошлый календарный месяц
+// (а не за последние 30 дней, как /report_m), еженедельный - за последние 7 дней.
+func ReportKey(kind string) string {
+	if kind == types.SubscriptionMonthly {
+		return types.ReportPreviousMonth
+	}
+	return kind
+}
+
+// LastDueTime Последний (не позднее now) срок отправки отчета по расписанию:
+// для еженедельного отчета - понедельник, для ежемесячного - 1-е число месяца.
+func LastDueTime(kind string, now time.Time, schedule Schedule) time.Time {
+	local := now.In(schedule.Location)
+	switch kind {
+	case types.SubscriptionWeekly:
+		daysSinceMonday := (int(local.Weekday()) + 6) % 7
+		due := time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, schedule.Hour, 0, 0, 0, schedule.Location)
+		if due.After(local) {
+			due = due.AddDate(0, 0, -7)
+		}
+		return due
+	case types.SubscriptionMonthly:
+		due := time.Date(local.Year(), local.Month(), 1, schedule.Hour, 0, 0, 0, schedule.Location)
+		if due.After(local) {
+			due = due.AddDate(0, -1, 0)
+		}
+		return due
+	}
+	return time.Time{}
+}
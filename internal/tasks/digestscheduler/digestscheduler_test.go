@@ -0,0 +1,100 @@
+package digestscheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/ellavs/tg-bot-golang/internal/model/bottypes"
+)
+
+var testSchedule = Schedule{Location: time.FixedZone("UTC+10", 10*60*60), Hour: 9}
+
+func Test_LastDueTime_Weekly(t *testing.T) {
+	// Среда 08.03.2023 12:00 по времени расписания -> понедельник 06.03.2023 09:00.
+	now := time.Date(2023, 3, 8, 12, 0, 0, 0, testSchedule.Location)
+	assert.True(t, time.Date(2023, 3, 6, 9, 0, 0, 0, testSchedule.Location).Equal(LastDueTime(types.SubscriptionWeekly, now, testSchedule)))
+
+	// Понедельник 06.03.2023 08:00 (до времени отправки) -> предыдущий понедельник.
+	now = time.Date(2023, 3, 6, 8, 0, 0, 0, testSchedule.Location)
+	assert.True(t, time.Date(2023, 2, 27, 9, 0, 0, 0, testSchedule.Location).Equal(LastDueTime(types.SubscriptionWeekly, now, testSchedule)))
+}
+
+func Test_LastDueTime_Monthly_ShouldUseScheduleTimezone(t *testing.T) {
+	// 31.12.2022 23:30 UTC = 01.01.2023 09:30 по времени расписания (UTC+10).
+	now := time.Date(2022, 12, 31, 23, 30, 0, 0, time.UTC)
+	assert.True(t, time.Date(2023, 1, 1, 9, 0, 0, 0, testSchedule.Location).Equal(LastDueTime(types.SubscriptionMonthly, now, testSchedule)))
+}
+
+type testStorage struct {
+	subscriptions []types.Subscription
+	marked        []int64
+}
+
+func (s *testStorage) GetSubscriptions(ctx context.Context) ([]types.Subscription, error) {
+	return s.subscriptions, nil
+}
+
+func (s *testStorage) MarkSubscriptionSent(ctx context.Context, subscriptionID int64, dueAt time.Time, sentAt time.Time) (bool, error) {
+	s.marked = append(s.marked, subscriptionID)
+	return true, nil
+}
+
+type testProducer struct {
+	messages []string
+}
+
+func (p *testProducer) SendMessage(key string, value string) (int32, int64, error) {
+	p.messages = append(p.messages, key+":"+value)
+	return 0, 0, nil
+}
+
+func Test_sendDueDigests_ShouldSendOnlyDueSubscriptions(t *testing.T) {
+	now := time.Date(2023, 3, 6, 10, 0, 0, 0, testSchedule.Location)
+	storage := &testStorage{subscriptions: []types.Subscription{
+		// Еженедельный отчет еще не отправлялся на этой неделе.
+		{ID: 1, UserID: 123, Kind: types.SubscriptionWeekly, LastSentAt: now.AddDate(0, 0, -7)},
+		// Еженедельный отчет уже отправлен.
+		{ID: 2, UserID: 456, Kind: types.SubscriptionWeekly, LastSentAt: now.Add(-30 * time.Minute)},
+		// Ежемесячный отчет отправлен 1-го числа.
+		{ID: 3, UserID: 123, Kind: types.SubscriptionMonthly, LastSentAt: time.Date(2023, 3, 1, 9, 5, 0, 0, testSchedule.Location)},
+	}}
+	producer := &testProducer{}
+
+	err := sendDueDigests(context.Background(), storage, producer, testSchedule, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, storage.marked)
+	assert.Equal(t, []string{"123:w"}, producer.messages)
+}
+
+func Test_sendDueDigests_ShouldRequestPreviousMonthReport(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, testSchedule.Location)
+	storage := &testStorage{subscriptions: []types.Subscription{
+		{ID: 1, UserID: 123, Kind: types.SubscriptionMonthly, LastSentAt: time.Date(2023, 2, 1, 9, 5, 0, 0, testSchedule.Location)},
+	}}
+	producer := &testProducer{}
+
+	err := sendDueDigests(context.Background(), storage, producer, testSchedule, now)
+
+	// Ежемесячный отчет строится за прошлый календарный месяц, а не за последние 30 дней (как /report_m).
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"123:" + types.ReportPreviousMonth}, producer.messages)
+}
+
+func Test_sendDueDigests_ShouldUseUserTimezone(t *testing.T) {
+	// Понедельник 06.03.2023 09:30 во Владивостоке (UTC+10) = 05.03.2023 23:30 UTC.
+	now := time.Date(2023, 3, 5, 23, 30, 0, 0, time.UTC)
+	storage := &testStorage{subscriptions: []types.Subscription{
+		{ID: 1, UserID: 123, Kind: types.SubscriptionWeekly, LastSentAt: now.AddDate(0, 0, -7), Timezone: "Asia/Vladivostok"},
+		// В часовом поясе по умолчанию (UTC) понедельник еще не наступил.
+		{ID: 2, UserID: 456, Kind: types.SubscriptionWeekly, LastSentAt: now.AddDate(0, 0, -6)},
+	}}
+	producer := &testProducer{}
+
+	err := sendDueDigests(context.Background(), storage, producer, Schedule{Location: time.UTC, Hour: 9}, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"123:w"}, producer.messages)
+}
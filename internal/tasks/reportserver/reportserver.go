@@ -30,7 +30,7 @@ func (s *server) PutReport(ctx context.Context, in *api.ReportRequest) (*api.Rep
 
 	itemsReport := make([]types.UserDataReportRecord, len(items))
 	for ind, r := range items {
-		itemsReport[ind] = types.UserDataReportRecord{Category: r.Category, Sum: float64(r.Sum)}
+		itemsReport[ind] = types.UserDataReportRecord{Category: r.Category, Sum: r.Sum}
 	}
 
 	// Отправка полученного отчета пользователю в телеграм.
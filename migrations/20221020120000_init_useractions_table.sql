@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists useractions
+(
+    id         integer generated by default as identity primary key,
+    user_id    integer     not null references users (id) on delete cascade,
+    action     text        not null
+        constraint useractions_action_check
+            check (action in ('record', 'category', 'limit', 'currency')),
+    object_id  integer,              -- (идентификатор добавленной записи или категории)
+    prev_value text,                 -- (предыдущее значение бюджета или валюты)
+    created_at timestamptz not null default now()
+    );
+
+comment on table useractions is 'Журнал действий пользователей для отмены последнего действия';
+
+-- Индекс по пользователю и времени действия для поиска последнего действия.
+create index if not exists useractions_user_id_created_at
+    on useractions (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index useractions_user_id_created_at;
+DROP TABLE IF EXISTS "useractions";
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists userreceipts
+(
+    id             integer generated by default as identity primary key,
+    user_id        integer not null references users (id) on delete cascade,
+    transaction_id integer not null references usermoneytransactions (id) on delete cascade,
+    fn             text    not null, -- (номер фискального накопителя)
+    fd             text    not null, -- (номер фискального документа)
+    fp             text    not null  -- (фискальный признак документа)
+    );
+
+comment on table userreceipts is 'Кассовые чеки, по которым добавлены записи о расходах';
+
+-- Индекс по пользователю и реквизитам чека для защиты от повторного ввода.
+create unique index if not exists userreceipts_user_id_fn_fd_fp
+    on userreceipts (user_id, fn, fd, fp);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index userreceipts_user_id_fn_fd_fp;
+DROP TABLE IF EXISTS "userreceipts";
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists usergoals
+(
+    id         integer generated by default as identity primary key,
+    user_id    integer     not null references users (id) on delete cascade,
+    name       text        not null
+        constraint usergoals_name_check
+            check (name <> ''::text),
+    currency   text        not null,
+    target     bigint      not null -- (целевая сумма в копейках валюты цели)
+        constraint usergoals_target_check
+            check (target > 0),
+    saved      bigint      not null default 0, -- (накопленная сумма в копейках валюты цели)
+    deadline   timestamptz not null,
+    created_at timestamptz not null default now()
+    );
+
+comment on table usergoals is 'Цели накоплений пользователей';
+
+-- Индекс по пользователю для быстрого получения списка целей.
+create index if not exists usergoals_user_id
+    on usergoals (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index usergoals_user_id;
+DROP TABLE IF EXISTS "usergoals";
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists usersubscriptions
+(
+    id           integer generated by default as identity primary key,
+    user_id      integer     not null references users (id) on delete cascade,
+    kind         text        not null -- (w - еженедельный отчет, m - ежемесячный отчет)
+        constraint usersubscriptions_kind_check
+            check (kind in ('w', 'm')),
+    last_sent_at timestamptz not null default now()
+    );
+
+comment on table usersubscriptions is 'Подписки пользователей на регулярные отчеты';
+
+-- Индекс по пользователю и виду подписки (не более одной подписки каждого вида).
+create unique index if not exists usersubscriptions_user_id_kind
+    on usersubscriptions (user_id, kind);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index usersubscriptions_user_id_kind;
+DROP TABLE IF EXISTS "usersubscriptions";
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table usermoneytransactions
+    add column if not exists note text not null default '';
+
+comment on column usermoneytransactions.note is 'Комментарий к записи о расходах';
+
+-- Расширение для индексов поиска по подстроке (ILIKE '%...%').
+create extension if not exists pg_trgm;
+
+-- Индекс для поиска записей по подстроке комментария.
+create index if not exists usermoneytransactions_note_trgm
+    on usermoneytransactions using gin (note gin_trgm_ops);
+
+-- Индекс по пользователю и сумме для поиска записей по диапазону сумм.
+create index if not exists usermoneytransactions_user_id_sum
+    on usermoneytransactions (user_id, sum);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index usermoneytransactions_user_id_sum;
+drop index usermoneytransactions_note_trgm;
+alter table usermoneytransactions
+    drop column if exists note;
+-- +goose StatementEnd
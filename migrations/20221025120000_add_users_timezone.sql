@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column if not exists timezone text not null default '';
+
+comment on column users.timezone is 'Часовой пояс пользователя (IANA, пустая строка - часовой пояс по умолчанию из конфигурации)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users
+    drop column if exists timezone;
+-- +goose StatementEnd
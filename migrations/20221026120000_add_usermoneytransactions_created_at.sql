@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table usermoneytransactions
+    add column if not exists created_at timestamptz;
+
+-- Для существующих записей время ввода неизвестно, используется дата расхода.
+update usermoneytransactions set created_at = period where created_at is null;
+
+alter table usermoneytransactions
+    alter column created_at set default now(),
+    alter column created_at set not null;
+
+comment on column usermoneytransactions.created_at is 'Время ввода записи (для статистики использования)';
+
+-- Индекс по времени ввода для статистики активности пользователей.
+create index if not exists usermoneytransactions_created_at
+    on usermoneytransactions (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index usermoneytransactions_created_at;
+alter table usermoneytransactions
+    drop column if exists created_at;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists adminaudit
+(
+    id          integer generated by default as identity primary key,
+    admin_tg_id bigint      not null, -- (ТГ-идентификатор администратора)
+    command     text        not null,
+    args        text        not null default '',
+    created_at  timestamptz not null default now()
+    );
+
+comment on table adminaudit is 'Журнал действий администраторов бота';
+
+-- Индекс по времени действия для просмотра журнала.
+create index if not exists adminaudit_created_at
+    on adminaudit (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index adminaudit_created_at;
+DROP TABLE IF EXISTS "adminaudit";
+-- +goose StatementEnd
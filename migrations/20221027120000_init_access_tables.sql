@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists invitecodes
+(
+    id          integer generated by default as identity primary key,
+    code        text        not null,
+    created_by  bigint      not null, -- (ТГ-идентификатор администратора)
+    created_at  timestamptz not null default now(),
+    used_by     bigint,               -- (ТГ-идентификатор пользователя, использовавшего код)
+    used_at     timestamptz
+    );
+
+comment on table invitecodes is 'Коды приглашений для доступа к боту';
+
+-- Индекс по коду приглашения (коды уникальны).
+create unique index if not exists invitecodes_code
+    on invitecodes (code);
+
+create table if not exists blockedusers
+(
+    tg_id       bigint      not null primary key,
+    blocked_by  bigint      not null, -- (ТГ-идентификатор администратора)
+    created_at  timestamptz not null default now()
+    );
+
+comment on table blockedusers is 'Заблокированные администраторами пользователи';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "blockedusers";
+drop index invitecodes_code;
+DROP TABLE IF EXISTS "invitecodes";
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists userdeletions
+(
+    id         integer generated by default as identity primary key,
+    tg_id      bigint      not null, -- (ТГ-идентификатор удаленного пользователя)
+    records    integer     not null default 0, -- (количество удаленных записей о расходах)
+    deleted_at timestamptz not null default now()
+    );
+
+comment on table userdeletions is 'Журнал удаления аккаунтов пользователей по их запросу';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "userdeletions";
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Курсы хранятся в десятичном виде без погрешностей float.
+alter table exchangerates
+    alter column rate type numeric(24, 12) using round(rate::numeric, 12);
+
+comment on column exchangerates.rate is 'Курс валюты (количество единиц валюты за единицу основной валюты)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table exchangerates
+    alter column rate type float using rate::float;
+-- +goose StatementEnd
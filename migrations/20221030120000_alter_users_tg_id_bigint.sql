@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ТГ-идентификаторы пользователей и чатов могут превышать 2^31 (идентификаторы групп - отрицательные 52-битные),
+-- поэтому, как и в остальных таблицах, хранятся в bigint.
+alter table users
+    alter column tg_id type bigint;
+
+comment on column users.tg_id is 'ТГ-идентификатор пользователя';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users
+    alter column tg_id type integer;
+-- +goose StatementEnd
@@ -0,0 +1,179 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Мягкое удаление: удаленные записи и категории помечаются временем удаления и попадают в корзину.
+alter table usercategories
+    add column if not exists deleted_at timestamptz;
+alter table usermoneytransactions
+    add column if not exists deleted_at timestamptz;
+
+comment on column usercategories.deleted_at is 'Время удаления категории (null - категория не удалена)';
+comment on column usermoneytransactions.deleted_at is 'Время удаления записи (null - запись не удалена)';
+
+-- Записи о расходах больше не удаляются каскадно вместе с категорией
+-- (категория удаляется из базы только вместе с пользователем).
+alter table usermoneytransactions
+    drop constraint if exists usermoneytransactions_category_id_fkey,
+    add constraint usermoneytransactions_category_id_fkey
+        foreign key (category_id) references usercategories (id);
+
+-- Индекс для выборки корзины пользователя.
+create index if not exists usermoneytransactions_user_id_deleted_at
+    on usermoneytransactions (user_id, deleted_at)
+    where deleted_at is not null;
+
+create table if not exists useraudit
+(
+    id          bigint generated by default as identity primary key,
+    user_id     integer     not null, -- (владелец измененных данных; без внешнего ключа, чтобы журнал не изменялся каскадно)
+    actor_tg_id bigint,               -- (ТГ-идентификатор пользователя, совершившего изменение)
+    entity      text        not null
+        constraint useraudit_entity_check
+            check (entity in ('record', 'category', 'settings')),
+    object_id   integer     not null, -- (идентификатор записи, категории или пользователя)
+    operation   text        not null
+        constraint useraudit_operation_check
+            check (operation in ('insert', 'update', 'delete', 'restore')),
+    old_value   jsonb,                -- (значения до изменения)
+    new_value   jsonb,                -- (значения после изменения)
+    created_at  timestamptz not null default now()
+    );
+
+comment on table useraudit is 'Журнал изменений записей о расходах, категорий и настроек пользователей (только добавление)';
+
+-- Индекс по пользователю и времени изменения для просмотра журнала.
+create index if not exists useraudit_user_id_created_at
+    on useraudit (user_id, created_at);
+
+-- Запись изменения строки usermoneytransactions, usercategories или users в журнал.
+create or replace function useraudit_log() returns trigger
+    language plpgsql as
+$$
+declare
+    v_old       jsonb;
+    v_new       jsonb;
+    v_row       jsonb;
+    v_entity    text;
+    v_owner_id  integer;
+    v_operation text := lower(TG_OP);
+begin
+    if TG_OP <> 'INSERT' then
+        v_old := to_jsonb(OLD);
+    end if;
+    if TG_OP <> 'DELETE' then
+        v_new := to_jsonb(NEW);
+    end if;
+    v_row := coalesce(v_new, v_old);
+
+    if TG_TABLE_NAME = 'users' then
+        -- Настройки пользователя.
+        v_entity := 'settings';
+        v_owner_id := (v_row ->> 'id')::integer;
+        v_old := v_old - 'id' - 'tg_id' - 'name';
+        v_new := v_new - 'id' - 'tg_id' - 'name';
+    else
+        v_entity := case TG_TABLE_NAME when 'usercategories' then 'category' else 'record' end;
+        v_owner_id := (v_row ->> 'user_id')::integer;
+        v_old := v_old - 'id' - 'user_id' - 'created_at';
+        v_new := v_new - 'id' - 'user_id' - 'created_at';
+        -- Перенос в корзину и восстановление из нее.
+        if TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is null and v_new ->> 'deleted_at' is not null then
+            v_operation := 'delete';
+        elsif TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is not null and v_new ->> 'deleted_at' is null then
+            v_operation := 'restore';
+        end if;
+    end if;
+
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (v_owner_id, (select u.tg_id from users as u where u.id = v_owner_id), v_entity,
+            (v_row ->> 'id')::integer, v_operation, v_old, v_new);
+    return null;
+end;
+$$;
+
+create trigger usermoneytransactions_audit
+    after insert or delete
+    on usermoneytransactions
+    for each row
+execute function useraudit_log();
+
+create trigger usermoneytransactions_audit_update
+    after update
+    on usermoneytransactions
+    for each row
+    when (old is distinct from new)
+execute function useraudit_log();
+
+create trigger usercategories_audit
+    after insert or delete
+    on usercategories
+    for each row
+execute function useraudit_log();
+
+create trigger usercategories_audit_update
+    after update
+    on usercategories
+    for each row
+    when (old is distinct from new)
+execute function useraudit_log();
+
+create trigger users_audit
+    after insert
+    on users
+    for each row
+execute function useraudit_log();
+
+create trigger users_audit_update
+    after update
+    on users
+    for each row
+    when ((old.currency, old.limits, old.timezone) is distinct from (new.currency, new.limits, new.timezone))
+execute function useraudit_log();
+
+-- Журнал не изменяется, записи удаляются только вместе с данными удаленного пользователя.
+create or replace function useraudit_protect() returns trigger
+    language plpgsql as
+$$
+begin
+    if TG_OP = 'DELETE' and not exists (select 1 from users as u where u.id = OLD.user_id) then
+        return OLD;
+    end if;
+    raise exception 'useraudit is append-only';
+end;
+$$;
+
+create trigger useraudit_protect
+    before update or delete
+    on useraudit
+    for each row
+execute function useraudit_protect();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists useraudit_protect on useraudit;
+drop trigger if exists users_audit_update on users;
+drop trigger if exists users_audit on users;
+drop trigger if exists usercategories_audit_update on usercategories;
+drop trigger if exists usercategories_audit on usercategories;
+drop trigger if exists usermoneytransactions_audit_update on usermoneytransactions;
+drop trigger if exists usermoneytransactions_audit on usermoneytransactions;
+drop function if exists useraudit_protect();
+drop function if exists useraudit_log();
+DROP TABLE IF EXISTS "useraudit";
+
+-- Удаленные записи и категории при откате удаляются окончательно.
+delete from usermoneytransactions where deleted_at is not null;
+delete from usermoneytransactions
+where category_id in (select id from usercategories where deleted_at is not null);
+delete from usercategories where deleted_at is not null;
+
+drop index if exists usermoneytransactions_user_id_deleted_at;
+alter table usermoneytransactions
+    drop constraint if exists usermoneytransactions_category_id_fkey,
+    add constraint usermoneytransactions_category_id_fkey
+        foreign key (category_id) references usercategories (id) on delete cascade;
+alter table usermoneytransactions
+    drop column if exists deleted_at;
+alter table usercategories
+    drop column if exists deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists usermonthlyrollups
+(
+    user_id     integer not null references users (id) on delete cascade,
+    category_id integer not null references usercategories (id) on delete cascade,
+    month       date    not null, -- (первый день месяца в часовом поясе пользователя)
+    sum         bigint  not null, -- (сумма в копейках)
+    count       integer not null, -- (количество записей)
+    primary key (user_id, month, category_id)
+    );
+
+comment on table usermonthlyrollups is 'Помесячные итоги расходов пользователей по категориям (без удаленных записей)';
+
+-- Заполнение итогов по уже добавленным записям. Для пользователей, не выбравших часовой пояс, используется
+-- часовой пояс Europe/Moscow. При применении миграции приложением итоги в той же транзакции пересчитываются
+-- с часовым поясом по умолчанию из конфигурации (см. db.AddMigrationHooks); после применения утилитой goose
+-- при другом часовом поясе в конфигурации итоги нужно пересчитать командой "bot rollups rebuild".
+insert into usermonthlyrollups (user_id, category_id, month, sum, count)
+select r.user_id,
+       r.category_id,
+       date_trunc('month', r.period at time zone coalesce(nullif(u.timezone, ''), 'Europe/Moscow'))::date,
+       sum(r.sum),
+       count(*)
+from usermoneytransactions as r
+         inner join users as u
+                    on r.user_id = u.id
+where r.deleted_at is null
+group by 1, 2, 3;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "usermonthlyrollups";
+-- +goose StatementEnd
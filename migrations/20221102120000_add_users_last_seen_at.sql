@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column if not exists last_seen_at timestamptz;
+
+comment on column users.last_seen_at is 'Время последнего обращения пользователя к боту (для статистики активности DAU/MAU)';
+
+-- Начальное значение - время ввода последней записи о расходах (ранее активность считалась по записям).
+update users as u
+set last_seen_at = r.created_at
+from (select user_id, max(created_at) as created_at
+      from usermoneytransactions
+      group by user_id) as r
+where r.user_id = u.id;
+
+create index if not exists users_last_seen_at
+    on users (last_seen_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists users_last_seen_at;
+alter table users
+    drop column if exists last_seen_at;
+-- +goose StatementEnd
@@ -0,0 +1,98 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Пользователь, совершивший изменение, передается триггерам журнала изменений через настройку транзакции
+-- app.actor_tg_id (set_config('app.actor_tg_id', ..., true)). Для изменений без пользователя (начальные данные,
+-- команды запуска) поле actor_tg_id остается пустым. Время последнего обращения к боту в журнал не записывается.
+create or replace function useraudit_log() returns trigger
+    language plpgsql as
+$$
+declare
+    v_old       jsonb;
+    v_new       jsonb;
+    v_row       jsonb;
+    v_entity    text;
+    v_owner_id  integer;
+    v_operation text := lower(TG_OP);
+begin
+    if TG_OP <> 'INSERT' then
+        v_old := to_jsonb(OLD);
+    end if;
+    if TG_OP <> 'DELETE' then
+        v_new := to_jsonb(NEW);
+    end if;
+    v_row := coalesce(v_new, v_old);
+
+    if TG_TABLE_NAME = 'users' then
+        -- Настройки пользователя.
+        v_entity := 'settings';
+        v_owner_id := (v_row ->> 'id')::integer;
+        v_old := v_old - 'id' - 'tg_id' - 'name' - 'last_seen_at';
+        v_new := v_new - 'id' - 'tg_id' - 'name' - 'last_seen_at';
+    else
+        v_entity := case TG_TABLE_NAME when 'usercategories' then 'category' else 'record' end;
+        v_owner_id := (v_row ->> 'user_id')::integer;
+        v_old := v_old - 'id' - 'user_id' - 'created_at';
+        v_new := v_new - 'id' - 'user_id' - 'created_at';
+        -- Перенос в корзину и восстановление из нее.
+        if TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is null and v_new ->> 'deleted_at' is not null then
+            v_operation := 'delete';
+        elsif TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is not null and v_new ->> 'deleted_at' is null then
+            v_operation := 'restore';
+        end if;
+    end if;
+
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (v_owner_id, nullif(current_setting('app.actor_tg_id', true), '')::bigint, v_entity,
+            (v_row ->> 'id')::integer, v_operation, v_old, v_new);
+    return null;
+end;
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+create or replace function useraudit_log() returns trigger
+    language plpgsql as
+$$
+declare
+    v_old       jsonb;
+    v_new       jsonb;
+    v_row       jsonb;
+    v_entity    text;
+    v_owner_id  integer;
+    v_operation text := lower(TG_OP);
+begin
+    if TG_OP <> 'INSERT' then
+        v_old := to_jsonb(OLD);
+    end if;
+    if TG_OP <> 'DELETE' then
+        v_new := to_jsonb(NEW);
+    end if;
+    v_row := coalesce(v_new, v_old);
+
+    if TG_TABLE_NAME = 'users' then
+        -- Настройки пользователя.
+        v_entity := 'settings';
+        v_owner_id := (v_row ->> 'id')::integer;
+        v_old := v_old - 'id' - 'tg_id' - 'name';
+        v_new := v_new - 'id' - 'tg_id' - 'name';
+    else
+        v_entity := case TG_TABLE_NAME when 'usercategories' then 'category' else 'record' end;
+        v_owner_id := (v_row ->> 'user_id')::integer;
+        v_old := v_old - 'id' - 'user_id' - 'created_at';
+        v_new := v_new - 'id' - 'user_id' - 'created_at';
+        -- Перенос в корзину и восстановление из нее.
+        if TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is null and v_new ->> 'deleted_at' is not null then
+            v_operation := 'delete';
+        elsif TG_OP = 'UPDATE' and v_old ->> 'deleted_at' is not null and v_new ->> 'deleted_at' is null then
+            v_operation := 'restore';
+        end if;
+    end if;
+
+    insert into useraudit (user_id, actor_tg_id, entity, object_id, operation, old_value, new_value)
+    values (v_owner_id, (select u.tg_id from users as u where u.id = v_owner_id), v_entity,
+            (v_row ->> 'id')::integer, v_operation, v_old, v_new);
+    return null;
+end;
+$$;
+-- +goose StatementEnd
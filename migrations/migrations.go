@@ -0,0 +1,9 @@
+// Package migrations Файлы миграций базы данных PostgreSQL (в формате goose), встроенные в приложение.
+package migrations
+
+import "embed"
+
+// FS Файлы миграций (применяются командой "migrate", см. пакет internal/model/db/migrator).
+//
+//go:embed *.sql
+var FS embed.FS